@@ -12,8 +12,12 @@ import (
 	"github.com/tmc/langchaingo/tools"
 )
 
-// agentScratchpad "agent_scratchpad" for the agent to put its thoughts in.
-const agentScratchpad = "agent_scratchpad"
+const (
+	// agentScratchpad "agent_scratchpad" for the agent to put its thoughts in.
+	agentScratchpad = "agent_scratchpad"
+	// toolArgKey is the name of the single string argument tools are given.
+	toolArgKey = "__arg1"
+)
 
 // OpenAIFunctionsAgent is an Agent driven by OpenAIs function powered API.
 type OpenAIFunctionsAgent struct {
@@ -48,18 +52,21 @@ func NewOpenAIFunctionsAgent(llm llms.Model, tools []tools.Tool, opts ...Creatio
 	}
 }
 
-func (o *OpenAIFunctionsAgent) functions() []llms.FunctionDefinition {
-	res := make([]llms.FunctionDefinition, 0)
+func (o *OpenAIFunctionsAgent) tools() []llms.Tool {
+	res := make([]llms.Tool, 0)
 	for _, tool := range o.Tools {
-		res = append(res, llms.FunctionDefinition{
-			Name:        tool.Name(),
-			Description: tool.Description(),
-			Parameters: map[string]any{
-				"properties": map[string]any{
-					"__arg1": map[string]string{"title": "__arg1", "type": "string"},
+		res = append(res, llms.Tool{
+			Type: llms.ToolTypeFunction,
+			Function: &llms.FunctionDefinition{
+				Name:        tool.Name(),
+				Description: tool.Description(),
+				Parameters: map[string]any{
+					"properties": map[string]any{
+						toolArgKey: map[string]string{"title": toolArgKey, "type": "string"},
+					},
+					"required": []string{toolArgKey},
+					"type":     "object",
 				},
-				"required": []string{"__arg1"},
-				"type":     "object",
 			},
 		})
 	}
@@ -94,18 +101,11 @@ func (o *OpenAIFunctionsAgent) Plan(
 
 	mcList := make([]llms.MessageContent, len(prompt.Messages()))
 	for i, msg := range prompt.Messages() {
-		role := msg.GetType()
-		text := msg.GetContent()
-
-		mc := llms.MessageContent{
-			Role:  role,
-			Parts: []llms.ContentPart{llms.TextContent{Text: text}},
-		}
-		mcList[i] = mc
+		mcList[i] = messageContentFromChatMessage(msg)
 	}
 
 	result, err := o.LLM.GenerateContent(ctx, mcList,
-		llms.WithFunctions(o.functions()), llms.WithStreamingFunc(stream))
+		llms.WithTools(o.tools()), llms.WithStreamingFunc(stream))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -151,22 +151,98 @@ func (o *OpenAIFunctionsAgent) constructScratchPad(steps []schema.AgentStep) []s
 
 	messages := make([]schema.ChatMessage, 0)
 	for _, step := range steps {
-		messages = append(messages, schema.FunctionChatMessage{
-			Name:    step.Action.Tool,
-			Content: step.Observation,
-		})
+		// Each step is sent back as the tool call that caused it followed by
+		// the tool's result.
+		args, err := json.Marshal(map[string]string{toolArgKey: step.Action.ToolInput})
+		if err != nil {
+			args = []byte("{}")
+		}
+		functionCall := &schema.FunctionCall{Name: step.Action.Tool, Arguments: string(args)}
+
+		// Legacy function calls have no ID.
+		if step.Action.ToolID == "" {
+			messages = append(messages,
+				schema.AIChatMessage{FunctionCall: functionCall},
+				schema.FunctionChatMessage{
+					Name:    step.Action.Tool,
+					Content: step.Observation,
+				},
+			)
+			continue
+		}
+
+		messages = append(messages,
+			schema.AIChatMessage{
+				ToolCalls: []schema.ToolCall{{
+					ID:           step.Action.ToolID,
+					Type:         llms.ToolTypeFunction,
+					FunctionCall: functionCall,
+				}},
+			},
+			schema.ToolChatMessage{
+				ID:      step.Action.ToolID,
+				Name:    step.Action.Tool,
+				Content: step.Observation,
+			},
+		)
 	}
 
 	return messages
 }
 
+// messageContentFromChatMessage converts a chat message of the prompt to a
+// MessageContent, keeping function and tool calls and their results as
+// dedicated parts.
+func messageContentFromChatMessage(msg schema.ChatMessage) llms.MessageContent {
+	switch m := msg.(type) {
+	case schema.AIChatMessage:
+		if len(m.ToolCalls) == 0 && m.FunctionCall == nil {
+			break
+		}
+		parts := make([]llms.ContentPart, 0, len(m.ToolCalls)+2)
+		if m.Content != "" {
+			parts = append(parts, llms.TextContent{Text: m.Content})
+		}
+		if m.FunctionCall != nil {
+			parts = append(parts, llms.ToolCall{Type: llms.ToolTypeFunction, FunctionCall: m.FunctionCall})
+		}
+		for _, tc := range m.ToolCalls {
+			parts = append(parts, llms.ToolCall{
+				ID:           tc.ID,
+				Type:         tc.Type,
+				FunctionCall: tc.FunctionCall,
+			})
+		}
+		return llms.MessageContent{Role: m.GetType(), Parts: parts}
+	case schema.FunctionChatMessage:
+		return llms.MessageContent{
+			Role:  m.GetType(),
+			Parts: []llms.ContentPart{llms.ToolCallResponse{Name: m.Name, Content: m.Content}},
+		}
+	case schema.ToolChatMessage:
+		return llms.MessageContent{
+			Role: m.GetType(),
+			Parts: []llms.ContentPart{llms.ToolCallResponse{
+				ToolCallID: m.ID,
+				Name:       m.Name,
+				Content:    m.Content,
+			}},
+		}
+	}
+
+	return llms.MessageContent{
+		Role:  msg.GetType(),
+		Parts: []llms.ContentPart{llms.TextContent{Text: msg.GetContent()}},
+	}
+}
+
 func (o *OpenAIFunctionsAgent) ParseOutput(contentResp *llms.ContentResponse) (
 	[]schema.AgentAction, *schema.AgentFinish, error,
 ) {
 	choice := contentResp.Choices[0]
 
 	// finish
-	if choice.FuncCall == nil {
+	if choice.FuncCall == nil && len(choice.ToolCalls) == 0 {
 		return nil, &schema.AgentFinish{
 			ReturnValues: map[string]any{
 				"output": choice.Content,
@@ -175,18 +251,40 @@ func (o *OpenAIFunctionsAgent) ParseOutput(contentResp *llms.ContentResponse) (
 		}, nil
 	}
 
-	// action
-	functionCall := choice.FuncCall
+	// actions
+	if len(choice.ToolCalls) > 0 {
+		actions := make([]schema.AgentAction, 0, len(choice.ToolCalls))
+		for _, toolCall := range choice.ToolCalls {
+			action, err := parseFunctionCall(toolCall.FunctionCall, choice.Content)
+			if err != nil {
+				return nil, nil, err
+			}
+			action.ToolID = toolCall.ID
+			actions = append(actions, action)
+		}
+		return actions, nil, nil
+	}
+
+	action, err := parseFunctionCall(choice.FuncCall, choice.Content)
+	if err != nil {
+		return nil, nil, err
+	}
+	return []schema.AgentAction{action}, nil, nil
+}
+
+// parseFunctionCall converts a function call requested by the model to an
+// agent action.
+func parseFunctionCall(functionCall *schema.FunctionCall, content string) (schema.AgentAction, error) {
 	functionName := functionCall.Name
 	toolInputStr := functionCall.Arguments
 	toolInputMap := make(map[string]any, 0)
 	err := json.Unmarshal([]byte(toolInputStr), &toolInputMap)
 	if err != nil {
-		return nil, nil, err
+		return schema.AgentAction{}, err
 	}
 
 	toolInput := toolInputStr
-	if arg1, ok := toolInputMap["__arg1"]; ok {
+	if arg1, ok := toolInputMap[toolArgKey]; ok {
 		toolInputCheck, ok := arg1.(string)
 		if ok {
 			toolInput = toolInputCheck
@@ -194,15 +292,13 @@ func (o *OpenAIFunctionsAgent) ParseOutput(contentResp *llms.ContentResponse) (
 	}
 
 	contentMsg := "\n"
-	if choice.Content != "" {
-		contentMsg = fmt.Sprintf("responded: %s\n", choice.Content)
+	if content != "" {
+		contentMsg = fmt.Sprintf("responded: %s\n", content)
 	}
 
-	return []schema.AgentAction{
-		{
-			Tool:      functionName,
-			ToolInput: toolInput,
-			Log:       fmt.Sprintf("Invoking: %s with %s \n %s \n", functionName, toolInputStr, contentMsg),
-		},
-	}, nil, nil
+	return schema.AgentAction{
+		Tool:      functionName,
+		ToolInput: toolInput,
+		Log:       fmt.Sprintf("Invoking: %s with %s \n %s \n", functionName, toolInputStr, contentMsg),
+	}, nil
 }
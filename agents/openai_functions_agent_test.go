@@ -0,0 +1,113 @@
+package agents_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/agents"
+	"github.com/tmc/langchaingo/chains"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// toolCallingModel is a model that asks for a fixed set of tool calls in its
+// first turn and answers in its second.
+type toolCallingModel struct {
+	toolCalls []llms.ToolCall
+	calls     [][]llms.MessageContent
+	options   []llms.CallOptions
+}
+
+func (m *toolCallingModel) GenerateContent(
+	_ context.Context,
+	messages []llms.MessageContent,
+	options ...llms.CallOption,
+) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	m.calls = append(m.calls, messages)
+	m.options = append(m.options, opts)
+
+	if len(m.calls) == 1 {
+		return &llms.ContentResponse{Choices: []*llms.ContentChoice{{ToolCalls: m.toolCalls}}}, nil
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: "done"}}}, nil
+}
+
+func (m *toolCallingModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+type echoTool struct{}
+
+var _ tools.Tool = echoTool{}
+
+func (echoTool) Name() string        { return "echo" }
+func (echoTool) Description() string { return "Echoes its input" }
+func (echoTool) Call(_ context.Context, input string) (string, error) {
+	return "echo: " + input, nil
+}
+
+func TestOpenAIFunctionsAgentParallelToolCalls(t *testing.T) {
+	t.Parallel()
+
+	llm := &toolCallingModel{toolCalls: []llms.ToolCall{
+		{ID: "call_1", Type: "function", FunctionCall: &schema.FunctionCall{Name: "echo", Arguments: `{"__arg1":"a"}`}},
+		{ID: "call_2", Type: "function", FunctionCall: &schema.FunctionCall{Name: "echo", Arguments: `{"__arg1":"b"}`}},
+	}}
+	agent := agents.NewOpenAIFunctionsAgent(llm, []tools.Tool{echoTool{}})
+	executor := agents.NewExecutor(agent, []tools.Tool{echoTool{}}, agents.WithReturnIntermediateSteps())
+
+	out, err := chains.Call(context.Background(), executor, map[string]any{"input": "echo a and b"})
+	require.NoError(t, err)
+	require.Equal(t, "done", out["output"])
+
+	steps, ok := out["intermediateSteps"].([]schema.AgentStep)
+	require.True(t, ok)
+	require.Len(t, steps, 2)
+	require.Equal(t, "call_1", steps[0].Action.ToolID)
+	require.Equal(t, "echo: a", steps[0].Observation)
+	require.Equal(t, "call_2", steps[1].Action.ToolID)
+	require.Equal(t, "echo: b", steps[1].Observation)
+
+	require.Len(t, llm.options[0].Tools, 1)
+	require.Equal(t, "echo", llm.options[0].Tools[0].Function.Name)
+
+	// The second call carries each tool call followed by its result.
+	second := llm.calls[1]
+	require.Len(t, second, 6)
+	require.Equal(t, schema.ChatMessageTypeAI, second[2].Role)
+	require.Equal(t, "call_1", second[2].Parts[0].(llms.ToolCall).ID)
+	require.Equal(t, schema.ChatMessageTypeTool, second[3].Role)
+	require.Equal(t, llms.ToolCallResponse{ToolCallID: "call_1", Name: "echo", Content: "echo: a"}, second[3].Parts[0])
+	require.Equal(t, llms.ToolCallResponse{ToolCallID: "call_2", Name: "echo", Content: "echo: b"}, second[5].Parts[0])
+}
+
+func TestOpenAIFunctionsAgentLegacyFunctionCall(t *testing.T) {
+	t.Parallel()
+
+	llm := &toolCallingModel{toolCalls: []llms.ToolCall{
+		{Type: "function", FunctionCall: &schema.FunctionCall{Name: "echo", Arguments: `{"__arg1":"a"}`}},
+	}}
+	agent := agents.NewOpenAIFunctionsAgent(llm, []tools.Tool{echoTool{}})
+	executor := agents.NewExecutor(agent, []tools.Tool{echoTool{}})
+
+	out, err := chains.Call(context.Background(), executor, map[string]any{"input": "echo a"})
+	require.NoError(t, err)
+	require.Equal(t, "done", out["output"])
+
+	// The second call carries the function call followed by its result.
+	second := llm.calls[1]
+	require.Len(t, second, 4)
+	require.Equal(t, schema.ChatMessageTypeAI, second[2].Role)
+	require.Equal(t, llms.ToolCall{
+		Type:         "function",
+		FunctionCall: &schema.FunctionCall{Name: "echo", Arguments: `{"__arg1":"a"}`},
+	}, second[2].Parts[0])
+	require.Equal(t, schema.ChatMessageTypeFunction, second[3].Role)
+	require.Equal(t, llms.ToolCallResponse{Name: "echo", Content: "echo: a"}, second[3].Parts[0])
+}
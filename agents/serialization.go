@@ -0,0 +1,227 @@
+package agents
+
+import (
+	"fmt"
+
+	"github.com/tmc/langchaingo/chains"
+	"github.com/tmc/langchaingo/prompts"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// ChainTypeExecutor is the type of agent executors in chain configs.
+const ChainTypeExecutor = "agent_executor"
+
+// RegisterChainTypes registers the agent executor type in a registry of chain
+// types, so that executors can be saved and loaded with chains.Serializer.
+//
+// The config of an executor has its ExecutorConfig in its params. The agent is
+// built from the model of the config and its optional prompt, or else
+// described by its chain. The tool_names and tool_descriptions partials of a
+// saved prompt are left out, and filled in again from the tools of the config
+// when it is loaded.
+func RegisterChainTypes(registry *chains.Registry) {
+	registry.Register(ChainTypeExecutor, loadExecutor, saveExecutor)
+}
+
+// ExecutorConfig is the serializable description of the settings of an agent
+// executor, kept in the params of its chain config.
+type ExecutorConfig struct {
+	// Agent is the type of the agent.
+	Agent string `json:"agent" yaml:"agent"`
+	// Tools are the names of the tools of the executor in the resources.
+	Tools                   []string `json:"tools,omitempty" yaml:"tools,omitempty"`
+	MaxIterations           int      `json:"max_iterations,omitempty" yaml:"max_iterations,omitempty"`
+	ReturnIntermediateSteps bool     `json:"return_intermediate_steps,omitempty" yaml:"return_intermediate_steps,omitempty"` //nolint:lll
+}
+
+func loadExecutor(s *chains.Serializer, config *chains.ChainConfig) (chains.Chain, error) { //nolint:ireturn
+	var params ExecutorConfig
+	if err := config.DecodeParams(&params); err != nil {
+		return nil, err
+	}
+	agentTools, err := s.Tools(params.Tools)
+	if err != nil {
+		return nil, err
+	}
+	agent, err := loadAgent(s, config, AgentType(params.Agent), agentTools)
+	if err != nil {
+		return nil, err
+	}
+	memory, err := s.LoadMemory(config.Memory)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []CreationOption{WithMemory(memory)}
+	if params.MaxIterations > 0 {
+		opts = append(opts, WithMaxIterations(params.MaxIterations))
+	}
+	if params.ReturnIntermediateSteps {
+		opts = append(opts, WithReturnIntermediateSteps())
+	}
+	return NewExecutor(agent, agentTools, opts...), nil
+}
+
+func loadAgent(s *chains.Serializer, config *chains.ChainConfig, agentType AgentType, agentTools []tools.Tool) (Agent, error) { //nolint:ireturn,lll
+	outputKey := config.OutputKey
+	if outputKey == "" {
+		outputKey = _defaultOutputKey
+	}
+
+	if config.Chain != nil {
+		chain, err := s.LoadChain(config.Chain)
+		if err != nil {
+			return nil, err
+		}
+		switch agentType {
+		case ZeroShotReactDescription:
+			return &OneShotZeroAgent{Chain: chain, Tools: agentTools, OutputKey: outputKey}, nil
+		case ConversationalReactDescription:
+			return &ConversationalAgent{Chain: chain, Tools: agentTools, OutputKey: outputKey}, nil
+		default:
+			return nil, fmt.Errorf("%w: %q with a chain", ErrUnknownAgentType, agentType)
+		}
+	}
+
+	llm, err := s.Model(config.LLM)
+	if err != nil {
+		return nil, err
+	}
+	opts := []CreationOption{WithOutputKey(outputKey)}
+	var prompt prompts.FormatPrompter
+	if config.Prompt != nil {
+		if prompt, err = prompts.LoadPrompt(config.Prompt); err != nil {
+			return nil, err
+		}
+		if template, ok := prompt.(prompts.PromptTemplate); ok {
+			opts = append(opts, WithPrompt(withToolPartials(template, agentTools)))
+		} else if agentType != OpenAIFunctions {
+			return nil, fmt.Errorf("%w: the prompt of a %s agent must be a prompt template", ErrInvalidOptions, agentType)
+		}
+	}
+
+	switch agentType {
+	case ZeroShotReactDescription:
+		return NewOneShotAgent(llm, agentTools, opts...), nil
+	case ConversationalReactDescription:
+		return NewConversationalAgent(llm, agentTools, opts...), nil
+	case OpenAIFunctions:
+		agent := NewOpenAIFunctionsAgent(llm, agentTools, opts...)
+		if prompt != nil {
+			agent.Prompt = prompt
+		}
+		return agent, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownAgentType, agentType)
+	}
+}
+
+func saveExecutor(s *chains.Serializer, chain chains.Chain) (*chains.ChainConfig, error) {
+	var e Executor
+	switch chain := chain.(type) {
+	case Executor:
+		e = chain
+	case *Executor:
+		e = *chain
+	default:
+		return nil, nil
+	}
+
+	if e.ErrorHandler != nil {
+		return nil, fmt.Errorf("%w: executor has a parser error handler", chains.ErrChainNotSerializable)
+	}
+	config, agentType, err := saveAgent(s, e.Agent)
+	if err != nil {
+		return nil, err
+	}
+	params := ExecutorConfig{
+		Agent:                   string(agentType),
+		MaxIterations:           e.MaxIterations,
+		ReturnIntermediateSteps: e.ReturnIntermediateSteps,
+	}
+	for _, tool := range e.Tools {
+		params.Tools = append(params.Tools, tool.Name())
+	}
+	if err := config.EncodeParams(params); err != nil {
+		return nil, err
+	}
+	if config.Memory, err = s.SaveMemory(e.Memory); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func saveAgent(s *chains.Serializer, agent Agent) (*chains.ChainConfig, AgentType, error) {
+	var (
+		config    = &chains.ChainConfig{}
+		agentType AgentType
+		chain     chains.Chain
+	)
+	switch a := agent.(type) {
+	case *OneShotZeroAgent:
+		agentType, config.OutputKey, chain = ZeroShotReactDescription, a.OutputKey, a.Chain
+	case *ConversationalAgent:
+		agentType, config.OutputKey, chain = ConversationalReactDescription, a.OutputKey, a.Chain
+	case *OpenAIFunctionsAgent:
+		var err error
+		if config.LLM, err = s.ModelName(a.LLM); err != nil {
+			return nil, "", err
+		}
+		if config.Prompt, err = prompts.SavePrompt(a.Prompt); err != nil {
+			return nil, "", err
+		}
+		config.OutputKey = a.OutputKey
+		return config, OpenAIFunctions, nil
+	default:
+		return nil, "", fmt.Errorf("%w: unsupported agent type %T", chains.ErrChainNotSerializable, agent)
+	}
+
+	var err error
+	// An agent made by NewOneShotAgent or NewConversationalAgent is saved as
+	// its model and prompt, without the tools baked into the prompt.
+	if llmChain, ok := chain.(*chains.LLMChain); ok {
+		if template, ok := llmChain.Prompt.(prompts.PromptTemplate); ok {
+			if config.LLM, err = s.ModelName(llmChain.LLM); err != nil {
+				return nil, "", err
+			}
+			if config.Prompt, err = prompts.SavePrompt(withoutToolPartials(template)); err != nil {
+				return nil, "", err
+			}
+			return config, agentType, nil
+		}
+	}
+	if config.Chain, err = s.SaveChain(chain); err != nil {
+		return nil, "", err
+	}
+	return config, agentType, nil
+}
+
+// withToolPartials returns the prompt with the tool_names and
+// tool_descriptions partials of the tools.
+func withToolPartials(prompt prompts.PromptTemplate, agentTools []tools.Tool) prompts.PromptTemplate {
+	partials := make(map[string]any, len(prompt.PartialVariables)+2)
+	for key, value := range prompt.PartialVariables {
+		partials[key] = value
+	}
+	partials["tool_names"] = toolNames(agentTools)
+	partials["tool_descriptions"] = toolDescriptions(agentTools)
+	prompt.PartialVariables = partials
+	return prompt
+}
+
+// withoutToolPartials returns the prompt without the tool_names and
+// tool_descriptions partials.
+func withoutToolPartials(prompt prompts.PromptTemplate) prompts.PromptTemplate {
+	var partials map[string]any
+	for key, value := range prompt.PartialVariables {
+		if key == "tool_names" || key == "tool_descriptions" {
+			continue
+		}
+		if partials == nil {
+			partials = make(map[string]any, len(prompt.PartialVariables))
+		}
+		partials[key] = value
+	}
+	prompt.PartialVariables = partials
+	return prompt
+}
@@ -0,0 +1,119 @@
+package agents_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/agents"
+	"github.com/tmc/langchaingo/chains"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/memory"
+	"github.com/tmc/langchaingo/prompts"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/tools"
+	"gopkg.in/yaml.v3"
+)
+
+func newTestSerializer(llm llms.Model) *chains.Serializer {
+	registry := chains.NewRegistry()
+	agents.RegisterChainTypes(registry)
+	return chains.NewSerializer(registry, chains.Resources{
+		Models: map[string]llms.Model{"gpt": llm},
+		Tools:  []tools.Tool{tools.Calculator{}, echoTool{}},
+	})
+}
+
+func TestSerializeExecutor(t *testing.T) {
+	t.Parallel()
+
+	llm := &toolCallingModel{}
+	executor, err := agents.Initialize(llm, []tools.Tool{echoTool{}}, agents.ConversationalReactDescription,
+		agents.WithMaxIterations(3), agents.WithMemory(memory.NewConversationBuffer()))
+	require.NoError(t, err)
+	s := newTestSerializer(llm)
+
+	config, err := s.SaveChain(executor)
+	require.NoError(t, err)
+	assert.Equal(t, agents.ChainTypeExecutor, config.Type)
+	var params agents.ExecutorConfig
+	require.NoError(t, config.DecodeParams(&params))
+	assert.Equal(t, agents.ExecutorConfig{
+		Agent:         string(agents.ConversationalReactDescription),
+		Tools:         []string{"echo"},
+		MaxIterations: 3,
+	}, params)
+	assert.Equal(t, chains.MemoryTypeBuffer, config.Memory.Type)
+	assert.Nil(t, config.Chain)
+	assert.Equal(t, "gpt", config.LLM)
+	assert.Equal(t, map[string]string{"history": ""}, config.Prompt.PartialVariables)
+
+	data, err := yaml.Marshal(config)
+	require.NoError(t, err)
+	parsed, err := chains.ParseChainConfig(data)
+	require.NoError(t, err)
+	loaded, err := s.LoadChain(parsed)
+	require.NoError(t, err)
+	assert.Equal(t, executor, loaded)
+
+	// The tools of the config fill in the tool partials of the saved prompt.
+	params.Tools = []string{"echo", "calculator"}
+	require.NoError(t, parsed.EncodeParams(params))
+	loaded, err = s.LoadChain(parsed)
+	require.NoError(t, err)
+	agent, ok := loaded.(agents.Executor).Agent.(*agents.ConversationalAgent)
+	require.True(t, ok)
+	prompt, ok := agent.Chain.(*chains.LLMChain).Prompt.(prompts.PromptTemplate)
+	require.True(t, ok)
+	assert.Equal(t, "echo, calculator", prompt.PartialVariables["tool_names"])
+
+	_, err = s.SaveChain(agents.NewExecutor(executor.Agent, nil,
+		agents.WithParserErrorHandler(agents.NewParserErrorHandler(nil))))
+	require.ErrorIs(t, err, chains.ErrChainNotSerializable)
+}
+
+func TestLoadExecutor(t *testing.T) {
+	t.Parallel()
+
+	llm := &toolCallingModel{toolCalls: []llms.ToolCall{
+		{ID: "call_1", Type: "function", FunctionCall: &schema.FunctionCall{Name: "echo", Arguments: `{"__arg1":"a"}`}},
+	}}
+	config, err := chains.ParseChainConfig([]byte(`
+type: agent_executor
+llm: gpt
+params:
+  agent: openAIFunctions
+  tools: [echo]
+  return_intermediate_steps: true
+`))
+	require.NoError(t, err)
+	s := newTestSerializer(llm)
+	chain, err := s.LoadChain(config)
+	require.NoError(t, err)
+
+	executor, ok := chain.(agents.Executor)
+	require.True(t, ok)
+	require.IsType(t, &agents.OpenAIFunctionsAgent{}, executor.Agent)
+	assert.Equal(t, 5, executor.MaxIterations)
+
+	result, err := chains.Call(context.Background(), executor, map[string]any{"input": "say a"})
+	require.NoError(t, err)
+	assert.Equal(t, "done", result["output"])
+	steps, ok := result["intermediateSteps"].([]schema.AgentStep)
+	require.True(t, ok)
+	require.Len(t, steps, 1)
+	assert.Equal(t, "echo: a", steps[0].Observation)
+
+	config.Params["tools"] = []any{"search"}
+	_, err = s.LoadChain(config)
+	require.ErrorIs(t, err, chains.ErrMissingResource)
+
+	require.NoError(t, config.EncodeParams(agents.ExecutorConfig{Agent: "planAndExecute"}))
+	_, err = s.LoadChain(config)
+	require.ErrorIs(t, err, agents.ErrUnknownAgentType)
+
+	config.Params["max_iteration"] = 3
+	_, err = s.LoadChain(config)
+	require.ErrorContains(t, err, "max_iteration")
+}
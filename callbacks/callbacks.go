@@ -17,6 +17,13 @@ type Handler interface {
 	HandleLLMGenerateContentStart(ctx context.Context, ms []llms.MessageContent)
 	HandleLLMGenerateContentEnd(ctx context.Context, res *llms.ContentResponse)
 	HandleLLMError(ctx context.Context, err error)
+	HandleLLMRoute(ctx context.Context, backend string)
+	HandleImageGenerationStart(ctx context.Context, prompt string)
+	HandleImageGenerationEnd(ctx context.Context, images []llms.GeneratedImage)
+	HandleTranscriptionStart(ctx context.Context, audio llms.BinaryContent)
+	HandleTranscriptionEnd(ctx context.Context, transcription *llms.Transcription)
+	HandleSpeechStart(ctx context.Context, text string)
+	HandleSpeechEnd(ctx context.Context, speech *llms.BinaryContent)
 	HandleChainStart(ctx context.Context, inputs map[string]any)
 	HandleChainEnd(ctx context.Context, outputs map[string]any)
 	HandleChainError(ctx context.Context, err error)
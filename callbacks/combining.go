@@ -104,6 +104,48 @@ func (l CombiningHandler) HandleLLMError(ctx context.Context, err error) {
 	}
 }
 
+func (l CombiningHandler) HandleLLMRoute(ctx context.Context, backend string) {
+	for _, handle := range l.Callbacks {
+		handle.HandleLLMRoute(ctx, backend)
+	}
+}
+
+func (l CombiningHandler) HandleImageGenerationStart(ctx context.Context, prompt string) {
+	for _, handle := range l.Callbacks {
+		handle.HandleImageGenerationStart(ctx, prompt)
+	}
+}
+
+func (l CombiningHandler) HandleImageGenerationEnd(ctx context.Context, images []llms.GeneratedImage) {
+	for _, handle := range l.Callbacks {
+		handle.HandleImageGenerationEnd(ctx, images)
+	}
+}
+
+func (l CombiningHandler) HandleTranscriptionStart(ctx context.Context, audio llms.BinaryContent) {
+	for _, handle := range l.Callbacks {
+		handle.HandleTranscriptionStart(ctx, audio)
+	}
+}
+
+func (l CombiningHandler) HandleTranscriptionEnd(ctx context.Context, transcription *llms.Transcription) {
+	for _, handle := range l.Callbacks {
+		handle.HandleTranscriptionEnd(ctx, transcription)
+	}
+}
+
+func (l CombiningHandler) HandleSpeechStart(ctx context.Context, text string) {
+	for _, handle := range l.Callbacks {
+		handle.HandleSpeechStart(ctx, text)
+	}
+}
+
+func (l CombiningHandler) HandleSpeechEnd(ctx context.Context, speech *llms.BinaryContent) {
+	for _, handle := range l.Callbacks {
+		handle.HandleSpeechEnd(ctx, speech)
+	}
+}
+
 func (l CombiningHandler) HandleToolError(ctx context.Context, err error) {
 	for _, handle := range l.Callbacks {
 		handle.HandleToolError(ctx, err)
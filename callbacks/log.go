@@ -49,6 +49,10 @@ func (l LogHandler) HandleLLMGenerateContentEnd(_ context.Context, res *llms.Con
 			fmt.Println("FuncCall: ", c.FuncCall.Name, c.FuncCall.Arguments)
 		}
 	}
+	if res.Usage != nil {
+		fmt.Printf("Usage: %d prompt + %d completion = %d tokens\n",
+			res.Usage.PromptTokens, res.Usage.CompletionTokens, res.Usage.TotalTokens)
+	}
 }
 
 func (l LogHandler) HandleStreamingFunc(_ context.Context, chunk []byte) {
@@ -67,6 +71,34 @@ func (l LogHandler) HandleLLMError(_ context.Context, err error) {
 	fmt.Println("Exiting LLM with error:", err)
 }
 
+func (l LogHandler) HandleLLMRoute(_ context.Context, backend string) {
+	fmt.Println("LLM call served by backend:", backend)
+}
+
+func (l LogHandler) HandleImageGenerationStart(_ context.Context, prompt string) {
+	fmt.Println("Generating images with prompt:", prompt)
+}
+
+func (l LogHandler) HandleImageGenerationEnd(_ context.Context, images []llms.GeneratedImage) {
+	fmt.Println("Generated images:", len(images))
+}
+
+func (l LogHandler) HandleTranscriptionStart(_ context.Context, audio llms.BinaryContent) {
+	fmt.Printf("Transcribing %d bytes of %s\n", len(audio.Data), audio.MIMEType)
+}
+
+func (l LogHandler) HandleTranscriptionEnd(_ context.Context, transcription *llms.Transcription) {
+	fmt.Println("Transcribed:", transcription.Text)
+}
+
+func (l LogHandler) HandleSpeechStart(_ context.Context, text string) {
+	fmt.Println("Synthesizing speech of:", text)
+}
+
+func (l LogHandler) HandleSpeechEnd(_ context.Context, speech *llms.BinaryContent) {
+	fmt.Printf("Synthesized %d bytes of %s\n", len(speech.Data), speech.MIMEType)
+}
+
 func (l LogHandler) HandleChainStart(_ context.Context, inputs map[string]any) {
 	fmt.Println("Entering chain with inputs:", formatChainValues(inputs))
 }
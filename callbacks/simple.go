@@ -17,6 +17,13 @@ func (SimpleHandler) HandleLLMStart(context.Context, []string)
 func (SimpleHandler) HandleLLMGenerateContentStart(context.Context, []llms.MessageContent) {}
 func (SimpleHandler) HandleLLMGenerateContentEnd(context.Context, *llms.ContentResponse)   {}
 func (SimpleHandler) HandleLLMError(context.Context, error)                                {}
+func (SimpleHandler) HandleLLMRoute(context.Context, string)                               {}
+func (SimpleHandler) HandleImageGenerationStart(context.Context, string)                   {}
+func (SimpleHandler) HandleImageGenerationEnd(context.Context, []llms.GeneratedImage)      {}
+func (SimpleHandler) HandleTranscriptionStart(context.Context, llms.BinaryContent)         {}
+func (SimpleHandler) HandleTranscriptionEnd(context.Context, *llms.Transcription)          {}
+func (SimpleHandler) HandleSpeechStart(context.Context, string)                            {}
+func (SimpleHandler) HandleSpeechEnd(context.Context, *llms.BinaryContent)                 {}
 func (SimpleHandler) HandleChainStart(context.Context, map[string]any)                     {}
 func (SimpleHandler) HandleChainEnd(context.Context, map[string]any)                       {}
 func (SimpleHandler) HandleChainError(context.Context, error)                              {}
@@ -0,0 +1,158 @@
+package callbacks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// RunUsage is the token usage of a chain or agent run, and its estimated cost.
+type RunUsage struct {
+	llms.Usage
+	// Calls is the number of LLM calls made during the run.
+	Calls int `json:"calls"`
+	// Cost is the estimated cost of the run.
+	Cost float64 `json:"cost"`
+}
+
+// UsageHandler is a callback handler that sums the token usage reported by
+// LLM calls, and estimates its cost. Usage is attributed to the outermost
+// chain run in progress, so the handler should be shared by the chain and its
+// LLMs, and used for one run at a time.
+type UsageHandler struct {
+	SimpleHandler
+
+	// Price is the price used to estimate costs of the models that aren't in
+	// the registry, or whose responses don't name them.
+	Price llms.Price
+	// Registry has the prices of models, looked up by the model name of
+	// responses. The DefaultModelRegistry is used if nil.
+	Registry *llms.ModelRegistry
+
+	mu      sync.Mutex
+	depth   int
+	current RunUsage
+	runs    []RunUsage
+	total   RunUsage
+}
+
+var _ Handler = &UsageHandler{}
+
+// NewUsageHandler creates a new UsageHandler that estimates costs at the
+// prices of the models in the DefaultModelRegistry, or at the given price for
+// other models.
+func NewUsageHandler(price llms.Price) *UsageHandler {
+	return &UsageHandler{Price: price}
+}
+
+// HandleLLMGenerateContentEnd adds the usage of the response, if any.
+func (h *UsageHandler) HandleLLMGenerateContentEnd(_ context.Context, res *llms.ContentResponse) {
+	if res == nil || res.Usage == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	price := h.price(res.Model)
+	h.total.add(res.Usage, price)
+	if h.depth > 0 {
+		h.current.add(res.Usage, price)
+	}
+}
+
+// price returns the price of the named model.
+func (h *UsageHandler) price(model string) llms.Price {
+	if model == "" {
+		return h.Price
+	}
+	registry := h.Registry
+	if registry == nil {
+		registry = llms.DefaultModelRegistry
+	}
+	if info, ok := registry.Lookup("", model); ok {
+		return info.Price
+	}
+	return h.Price
+}
+
+// HandleChainStart starts a run, unless one is already in progress.
+func (h *UsageHandler) HandleChainStart(context.Context, map[string]any) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.depth == 0 {
+		h.current = RunUsage{}
+	}
+	h.depth++
+}
+
+// HandleChainEnd ends the run in progress when the outermost chain ends.
+func (h *UsageHandler) HandleChainEnd(context.Context, map[string]any) {
+	h.endChain()
+}
+
+// HandleChainError ends the run in progress when the outermost chain fails.
+func (h *UsageHandler) HandleChainError(context.Context, error) {
+	h.endChain()
+}
+
+func (h *UsageHandler) endChain() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.depth == 0 {
+		return
+	}
+	h.depth--
+	if h.depth == 0 {
+		h.runs = append(h.runs, h.current)
+	}
+}
+
+// Runs returns the usage of each completed run, in order.
+func (h *UsageHandler) Runs() []RunUsage {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return append([]RunUsage(nil), h.runs...)
+}
+
+// LastRun returns the usage of the most recently completed run, and false if
+// no run has completed.
+func (h *UsageHandler) LastRun() (RunUsage, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.runs) == 0 {
+		return RunUsage{}, false
+	}
+	return h.runs[len(h.runs)-1], true
+}
+
+// Total returns the usage of all LLM calls seen by the handler, whether they
+// were part of a run or not.
+func (h *UsageHandler) Total() RunUsage {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.total
+}
+
+// Reset forgets all the usage seen by the handler.
+func (h *UsageHandler) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.depth = 0
+	h.current = RunUsage{}
+	h.runs = nil
+	h.total = RunUsage{}
+}
+
+func (r *RunUsage) add(u *llms.Usage, price llms.Price) {
+	r.Usage.Add(u)
+	r.Calls++
+	r.Cost += u.Cost(price)
+}
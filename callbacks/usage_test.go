@@ -0,0 +1,69 @@
+package callbacks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestUsageHandler(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	h := NewUsageHandler(llms.Price{Prompt: 1, Completion: 2})
+	resp := &llms.ContentResponse{Usage: llms.NewUsage(1_000_000, 500_000)}
+
+	// A call outside of any run only counts toward the total.
+	h.HandleLLMGenerateContentEnd(ctx, resp)
+	_, ok := h.LastRun()
+	require.False(t, ok)
+
+	// Nested chains are part of the outermost run.
+	h.HandleChainStart(ctx, nil)
+	h.HandleLLMGenerateContentEnd(ctx, resp)
+	h.HandleChainStart(ctx, nil)
+	h.HandleLLMGenerateContentEnd(ctx, resp)
+	h.HandleLLMGenerateContentEnd(ctx, &llms.ContentResponse{})
+	h.HandleChainEnd(ctx, nil)
+	h.HandleChainEnd(ctx, nil)
+
+	h.HandleChainStart(ctx, nil)
+	h.HandleLLMGenerateContentEnd(ctx, resp)
+	h.HandleChainError(ctx, context.Canceled)
+
+	runs := h.Runs()
+	require.Len(t, runs, 2)
+	assert.Equal(t, RunUsage{
+		Usage: llms.Usage{PromptTokens: 2_000_000, CompletionTokens: 1_000_000, TotalTokens: 3_000_000},
+		Calls: 2,
+		Cost:  4,
+	}, runs[0])
+	assert.Equal(t, 1, runs[1].Calls)
+	assert.InDelta(t, 2.0, runs[1].Cost, 1e-9)
+
+	total := h.Total()
+	assert.Equal(t, 4, total.Calls)
+	assert.Equal(t, 6_000_000, total.TotalTokens)
+	assert.InDelta(t, 8.0, total.Cost, 1e-9)
+
+	h.Reset()
+	assert.Empty(t, h.Runs())
+	assert.Equal(t, RunUsage{}, h.Total())
+}
+
+func TestUsageHandlerPrices(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	h := NewUsageHandler(llms.Price{Prompt: 1})
+	h.Registry = llms.NewModelRegistry(llms.ModelInfo{Name: "big", Price: llms.Price{Prompt: 10, Completion: 20}})
+
+	// Models are priced by the name in their responses.
+	h.HandleLLMGenerateContentEnd(ctx, &llms.ContentResponse{Usage: llms.NewUsage(1_000_000, 0), Model: "big-2024"})
+	h.HandleLLMGenerateContentEnd(ctx, &llms.ContentResponse{Usage: llms.NewUsage(1_000_000, 0), Model: "small"})
+	h.HandleLLMGenerateContentEnd(ctx, &llms.ContentResponse{Usage: llms.NewUsage(1_000_000, 0)})
+	assert.InDelta(t, 12.0, h.Total().Cost, 1e-9)
+}
@@ -61,7 +61,7 @@ func (a APIChain) Call(ctx context.Context, values map[string]any, opts ...Chain
 	reqChainTmp := 0.0
 	opts = append(opts, WithTemperature(reqChainTmp))
 
-	tmpOutput, err := Call(ctx, a.RequestChain, values, opts...)
+	tmpOutput, err := Call(IntermediateStep(ctx), a.RequestChain, values, opts...)
 	if err != nil {
 		return nil, err
 	}
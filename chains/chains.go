@@ -47,7 +47,7 @@ func Call(ctx context.Context, c Chain, inputValues map[string]any, options ...C
 		callbacksHandler.HandleChainStart(ctx, inputValues)
 	}
 
-	outputValues, err := callChain(ctx, c, fullValues, options...)
+	outputValues, err := callChain(withChainPath(ctx, c), c, fullValues, options...)
 	if err != nil {
 		if callbacksHandler != nil {
 			callbacksHandler.HandleChainError(ctx, err)
@@ -629,7 +629,7 @@ func (c *Constitutional) processCritiquesAndRevisions(ctx context.Context, respo
 ) ([]Pair, error) {
 	critiquesAndRevisions := make([]Pair, 0, len(c.constitutionalPrinciples))
 	for _, constitutionalPrincipal := range c.constitutionalPrinciples {
-		rawCritique, err := c.critiqueChain.Call(ctx, map[string]any{
+		rawCritique, err := c.critiqueChain.Call(IntermediateStep(ctx), map[string]any{
 			"inputPrompt":     inputPrompt,
 			"outputFromModel": response,
 			"critiqueRequest": constitutionalPrincipal.critiqueRequest,
@@ -166,7 +166,7 @@ func (c ConversationalRetrievalQA) getQuestion(
 	}
 
 	results, err := Call(
-		ctx,
+		IntermediateStep(ctx),
 		c.CondenseQuestionChain,
 		map[string]any{
 			"chat_history": chatHistoryStr,
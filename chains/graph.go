@@ -0,0 +1,395 @@
+package chains
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tmc/langchaingo/internal/util"
+	"github.com/tmc/langchaingo/memory"
+	"github.com/tmc/langchaingo/schema"
+)
+
+const _defaultGraphMaxIterations = 10
+
+// ErrGraphMaxIterations is returned when a node of a graph runs more times
+// than the maximum number of iterations, usually because of a loop whose exit
+// condition is never met.
+var ErrGraphMaxIterations = errors.New("graph exceeded the maximum number of iterations")
+
+// Condition reports whether an edge of a graph is followed, given the values
+// known when the node it starts from has run: the inputs of the graph and the
+// outputs of the nodes run so far.
+type Condition func(values map[string]any) bool
+
+// OutputEquals returns a condition that holds when the value of key equals
+// value.
+func OutputEquals(key string, value any) Condition {
+	return func(values map[string]any) bool {
+		v, ok := values[key]
+		return ok && reflect.DeepEqual(v, value)
+	}
+}
+
+// GraphNode is a node of a graph. A node either runs a chain, or runs several
+// branches concurrently with the same inputs and merges their outputs.
+type GraphNode struct {
+	Name string
+	// Chain is the chain run by the node.
+	Chain Chain
+	// Branches are the chains run concurrently by the node. Their output keys
+	// must not overlap.
+	Branches []Chain
+}
+
+// GraphEdge is an edge of a graph. After a node runs, its outgoing edges are
+// checked in the order they were declared and the first one whose condition
+// holds is followed. An edge without a condition is always followed. The graph
+// ends when no edge is followed.
+type GraphEdge struct {
+	From      string
+	To        string
+	Condition Condition
+}
+
+// Graph is a chain that runs a graph of chains. It starts from its first node
+// and follows the edges whose conditions hold, so nodes can be skipped, run
+// concurrently or run again in a loop. Each node gets the inputs of the graph
+// and the outputs of the nodes run before it. The chunks streamed by nodes
+// without any of the output keys of the graph aren't part of its final output.
+type Graph struct {
+	nodes         map[string]GraphNode
+	edges         map[string][]GraphEdge
+	entry         string
+	inputKeys     []string
+	outputKeys    []string
+	maxIterations int
+	memory        schema.Memory
+}
+
+var _ Chain = (*Graph)(nil)
+
+// GraphOption is an option for a graph.
+type GraphOption func(*Graph)
+
+// WithGraphMemory sets the memory of a graph.
+func WithGraphMemory(memory schema.Memory) GraphOption {
+	return func(g *Graph) {
+		g.memory = memory
+	}
+}
+
+// WithGraphMaxIterations sets how many times a single node can run during a
+// call of the graph, 10 by default.
+func WithGraphMaxIterations(n int) GraphOption {
+	return func(g *Graph) {
+		g.maxIterations = n
+	}
+}
+
+// NewGraph creates a graph from its nodes, starting from the first one, and
+// its edges. The graph is validated so that every node gets its input keys
+// whatever the path taken to it, and the output keys are known wherever the
+// graph can end.
+func NewGraph(nodes []GraphNode, edges []GraphEdge, inputKeys []string, outputKeys []string, opts ...GraphOption) (*Graph, error) { //nolint:lll
+	g := &Graph{
+		nodes:         make(map[string]GraphNode, len(nodes)),
+		edges:         make(map[string][]GraphEdge),
+		inputKeys:     inputKeys,
+		outputKeys:    outputKeys,
+		maxIterations: _defaultGraphMaxIterations,
+		memory:        memory.NewSimple(),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("%w: graph has no nodes", ErrChainInitialization)
+	}
+	g.entry = nodes[0].Name
+	for _, node := range nodes {
+		if err := validateGraphNode(node); err != nil {
+			return nil, err
+		}
+		if _, ok := g.nodes[node.Name]; ok {
+			return nil, fmt.Errorf("%w: duplicate node %s", ErrChainInitialization, node.Name)
+		}
+		g.nodes[node.Name] = node
+	}
+	for _, edge := range edges {
+		for _, name := range []string{edge.From, edge.To} {
+			if _, ok := g.nodes[name]; !ok {
+				return nil, fmt.Errorf("%w: edge from %s to %s has unknown node %s",
+					ErrChainInitialization, edge.From, edge.To, name)
+			}
+		}
+		g.edges[edge.From] = append(g.edges[edge.From], edge)
+	}
+
+	if err := g.validateKeys(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func validateGraphNode(node GraphNode) error {
+	if node.Name == "" {
+		return fmt.Errorf("%w: graph node has no name", ErrChainInitialization)
+	}
+	if (node.Chain == nil) == (len(node.Branches) == 0) {
+		return fmt.Errorf("%w: node %s must have either a chain or branches", ErrChainInitialization, node.Name)
+	}
+	seen := make(map[string]struct{})
+	for _, branch := range node.Branches {
+		overlappingKeys := util.Intersection(branch.GetOutputKeys(), seen)
+		if len(overlappingKeys) > 0 {
+			return fmt.Errorf("%w: branches of node %s have overlapping output keys: %v",
+				ErrChainInitialization, node.Name, strings.Join(overlappingKeys, delimiter))
+		}
+		for _, key := range branch.GetOutputKeys() {
+			seen[key] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// validateKeys checks the keys of the graph. The keys known at a node are the
+// ones set on every path from the entry to the node, which are found by
+// propagating the known keys along the edges until they no longer change.
+func (g *Graph) validateKeys() error { //nolint:cyclop
+	inputKeys := util.ToSet(g.inputKeys)
+
+	// Make sure memory keys don't collide with input keys
+	memoryKeys := g.memory.MemoryVariables(context.Background())
+	overlappingKeys := util.Intersection(memoryKeys, inputKeys)
+	if len(overlappingKeys) > 0 {
+		return fmt.Errorf(
+			"%w: input keys [%v] also exist in the memory keys: [%v] - please use input keys and memory keys that don't overlap",
+			ErrChainInitialization, strings.Join(overlappingKeys, delimiter), strings.Join(memoryKeys, delimiter),
+		)
+	}
+	for _, key := range memoryKeys {
+		inputKeys[key] = struct{}{}
+	}
+
+	known := map[string]map[string]struct{}{g.entry: inputKeys}
+	queue := []string{g.entry}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		keys := union(known[name], g.nodeOutputKeys(g.nodes[name]))
+		for _, edge := range g.edges[name] {
+			previous, ok := known[edge.To]
+			if !ok {
+				known[edge.To] = keys
+				queue = append(queue, edge.To)
+				continue
+			}
+			if edge.To == g.entry {
+				continue
+			}
+			next := util.ToSet(util.Intersection(util.ListKeys(previous), keys))
+			if len(next) < len(previous) {
+				known[edge.To] = next
+				queue = append(queue, edge.To)
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(g.nodes) {
+		node := g.nodes[name]
+		keys, ok := known[name]
+		if !ok {
+			return fmt.Errorf("%w: node %s can't be reached", ErrChainInitialization, name)
+		}
+
+		// Check that the chains of the node have input keys that are known on
+		// every path to it
+		for _, c := range append([]Chain{node.Chain}, node.Branches...) {
+			if c == nil {
+				continue
+			}
+			missingKeys := util.Difference(c.GetInputKeys(), keys)
+			if len(missingKeys) > 0 {
+				return fmt.Errorf(
+					"%w: node %s is missing required input keys: [%v], only had: [%v]",
+					ErrChainInitialization, name, strings.Join(missingKeys, delimiter),
+					strings.Join(sortedKeys(keys), delimiter),
+				)
+			}
+		}
+
+		// Check that the node does not overwrite input or memory keys
+		overlappingKeys := util.Intersection(g.nodeOutputKeys(node), inputKeys)
+		if len(overlappingKeys) > 0 {
+			return fmt.Errorf("%w: node %s has output keys that are input keys: %v",
+				ErrChainInitialization, name, strings.Join(overlappingKeys, delimiter))
+		}
+
+		// Check that outputKeys are known wherever the graph can end
+		if !g.canEnd(name) {
+			continue
+		}
+		missingKeys := util.Difference(g.outputKeys, union(keys, g.nodeOutputKeys(node)))
+		if len(missingKeys) > 0 {
+			return fmt.Errorf("%w: output keys [%v] are not known when the graph ends at node %s",
+				ErrChainInitialization, strings.Join(missingKeys, delimiter), name)
+		}
+	}
+
+	return nil
+}
+
+// canEnd reports whether the graph can end after running a node, which is when
+// none of its outgoing edges is always followed.
+func (g *Graph) canEnd(name string) bool {
+	for _, edge := range g.edges[name] {
+		if edge.Condition == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *Graph) nodeOutputKeys(node GraphNode) []string {
+	if node.Chain != nil {
+		return node.Chain.GetOutputKeys()
+	}
+	var keys []string
+	for _, branch := range node.Branches {
+		keys = append(keys, branch.GetOutputKeys()...)
+	}
+	return keys
+}
+
+func union(set map[string]struct{}, list []string) map[string]struct{} {
+	result := make(map[string]struct{}, len(set)+len(list))
+	for key := range set {
+		result[key] = struct{}{}
+	}
+	for _, key := range list {
+		result[key] = struct{}{}
+	}
+	return result
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := util.ListKeys(m)
+	sort.Strings(keys)
+	return keys
+}
+
+// Call runs the nodes of the graph and returns the values of its output keys.
+// This method should not be called directly. Use rather the Call, Run or
+// Predict functions that handles the memory and other aspects of the chain.
+func (g *Graph) Call(ctx context.Context, inputs map[string]any, options ...ChainCallOption) (map[string]any, error) { //nolint:lll
+	values := make(map[string]any, len(inputs))
+	for key, value := range inputs {
+		values[key] = value
+	}
+
+	iterations := make(map[string]int)
+	for name := g.entry; name != ""; name = g.next(name, values) {
+		iterations[name]++
+		if iterations[name] > g.maxIterations {
+			return nil, fmt.Errorf("%w: node %s ran %d times", ErrGraphMaxIterations, name, g.maxIterations)
+		}
+
+		outputs, err := g.runNode(ctx, g.nodes[name], values, options...)
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %w", name, err)
+		}
+		for key, value := range outputs {
+			values[key] = value
+		}
+	}
+
+	outputs := make(map[string]any, len(g.outputKeys))
+	for _, key := range g.outputKeys {
+		outputs[key] = values[key]
+	}
+	return outputs, nil
+}
+
+// next returns the node the graph goes to after running a node, or an empty
+// string when the graph ends.
+func (g *Graph) next(name string, values map[string]any) string {
+	for _, edge := range g.edges[name] {
+		if edge.Condition == nil || edge.Condition(values) {
+			return edge.To
+		}
+	}
+	return ""
+}
+
+func (g *Graph) runNode(ctx context.Context, node GraphNode, values map[string]any, options ...ChainCallOption) (map[string]any, error) { //nolint:lll
+	if node.Chain != nil {
+		return Call(intermediateUnless(ctx, node.Chain, g.outputKeys), node.Chain, copyValues(values), options...)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]map[string]any, len(node.Branches))
+	errs := make([]error, len(node.Branches))
+	var wg sync.WaitGroup
+	for i, branch := range node.Branches {
+		wg.Add(1)
+		go func(i int, branch Chain) {
+			defer wg.Done()
+			results[i], errs[i] = Call(intermediateUnless(ctx, branch, g.outputKeys), branch, copyValues(values), options...)
+			if errs[i] != nil {
+				cancel()
+			}
+		}(i, branch)
+	}
+	wg.Wait()
+
+	// Report the error of the failing branch rather than the cancellation of
+	// the others.
+	var err error
+	for _, e := range errs {
+		if e != nil && (err == nil || errors.Is(err, context.Canceled)) {
+			err = e
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := make(map[string]any)
+	for i, branch := range node.Branches {
+		for _, key := range branch.GetOutputKeys() {
+			outputs[key] = results[i][key]
+		}
+	}
+	return outputs, nil
+}
+
+func copyValues(values map[string]any) map[string]any {
+	c := make(map[string]any, len(values))
+	for key, value := range values {
+		c[key] = value
+	}
+	return c
+}
+
+// GetMemory gets the memory of the chain.
+func (g *Graph) GetMemory() schema.Memory {
+	return g.memory
+}
+
+// GetInputKeys returns the input keys the chain expects.
+func (g *Graph) GetInputKeys() []string {
+	return g.inputKeys
+}
+
+// GetOutputKeys returns the output keys the chain returns.
+func (g *Graph) GetOutputKeys() []string {
+	return g.outputKeys
+}
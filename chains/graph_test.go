@@ -0,0 +1,275 @@
+package chains
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/memory"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// transformNode returns a transform chain setting output to f applied to the
+// string value of input.
+func transformNode(input, output string, f func(string) any) Transform {
+	return NewTransform(
+		func(_ context.Context, values map[string]any, _ ...ChainCallOption) (map[string]any, error) {
+			s, _ := values[input].(string)
+			return map[string]any{output: f(s)}, nil
+		},
+		[]string{input},
+		[]string{output},
+	)
+}
+
+func TestGraphRouting(t *testing.T) {
+	t.Parallel()
+
+	classify := transformNode("question", "topic", func(s string) any {
+		if strings.Contains(s, "+") {
+			return "math"
+		}
+		return "other"
+	})
+	graph, err := NewGraph(
+		[]GraphNode{
+			{Name: "classify", Chain: classify},
+			{Name: "math", Chain: transformNode("question", "answer", func(string) any { return "42" })},
+			{Name: "search", Chain: NewRetrieverChain(testRetriever{}, "question", "docs")},
+			{Name: "answer", Chain: transformNode("question", "answer", func(string) any { return "from docs" })},
+		},
+		[]GraphEdge{
+			{From: "classify", To: "math", Condition: OutputEquals("topic", "math")},
+			{From: "classify", To: "search"},
+			{From: "search", To: "answer"},
+		},
+		[]string{"question"},
+		[]string{"answer"},
+	)
+	require.NoError(t, err)
+
+	out, err := Call(context.Background(), graph, map[string]any{"question": "40 + 2"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"answer": "42"}, out)
+
+	answer, err := Run(context.Background(), graph, "what is foo?")
+	require.NoError(t, err)
+	assert.Equal(t, "from docs", answer)
+}
+
+func TestGraphParallel(t *testing.T) {
+	t.Parallel()
+
+	var running, maxRunning atomic.Int32
+	branch := func(output string) Chain {
+		return NewTransform(
+			func(_ context.Context, values map[string]any, _ ...ChainCallOption) (map[string]any, error) {
+				n := running.Add(1)
+				defer running.Add(-1)
+				for m := maxRunning.Load(); n > m && !maxRunning.CompareAndSwap(m, n); m = maxRunning.Load() {
+				}
+				time.Sleep(10 * time.Millisecond)
+				return map[string]any{output: values["input"].(string) + " " + output}, nil
+			},
+			[]string{"input"},
+			[]string{output},
+		)
+	}
+	join := NewTransform(
+		func(_ context.Context, values map[string]any, _ ...ChainCallOption) (map[string]any, error) {
+			return map[string]any{"output": values["a"].(string) + ", " + values["b"].(string)}, nil
+		},
+		[]string{"a", "b"},
+		[]string{"output"},
+	)
+
+	graph, err := NewGraph(
+		[]GraphNode{
+			{Name: "fanout", Branches: []Chain{branch("a"), branch("b")}},
+			{Name: "join", Chain: join},
+		},
+		[]GraphEdge{{From: "fanout", To: "join"}},
+		[]string{"input"},
+		[]string{"output"},
+	)
+	require.NoError(t, err)
+
+	out, err := Run(context.Background(), graph, "x")
+	require.NoError(t, err)
+	assert.Equal(t, "x a, x b", out)
+	assert.Equal(t, int32(2), maxRunning.Load())
+}
+
+func TestGraphParallelError(t *testing.T) {
+	t.Parallel()
+
+	failing := NewTransform(
+		func(context.Context, map[string]any, ...ChainCallOption) (map[string]any, error) {
+			return nil, errDummy
+		},
+		[]string{"input"},
+		[]string{"a"},
+	)
+	slow := NewTransform(
+		func(ctx context.Context, _ map[string]any, _ ...ChainCallOption) (map[string]any, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+		[]string{"input"},
+		[]string{"b"},
+	)
+
+	graph, err := NewGraph(
+		[]GraphNode{{Name: "fanout", Branches: []Chain{slow, failing}}},
+		nil,
+		[]string{"input"},
+		[]string{"a", "b"},
+	)
+	require.NoError(t, err)
+
+	_, err = Call(context.Background(), graph, map[string]any{"input": "x"})
+	require.ErrorIs(t, err, errDummy)
+}
+
+func TestGraphLoop(t *testing.T) {
+	t.Parallel()
+
+	graph, err := NewGraph(
+		[]GraphNode{
+			{Name: "draft", Chain: transformNode("input", "text", func(s string) any { return s })},
+			{Name: "refine", Chain: transformNode("text", "text", func(s string) any { return s + "!" })},
+			{Name: "review", Chain: transformNode("text", "done", func(s string) any { return strings.Count(s, "!") >= 3 })},
+		},
+		[]GraphEdge{
+			{From: "draft", To: "refine"},
+			{From: "refine", To: "review"},
+			{From: "review", To: "refine", Condition: OutputEquals("done", false)},
+		},
+		[]string{"input"},
+		[]string{"text"},
+	)
+	require.NoError(t, err)
+
+	out, err := Run(context.Background(), graph, "hi")
+	require.NoError(t, err)
+	assert.Equal(t, "hi!!!", out)
+
+	graph, err = NewGraph(
+		[]GraphNode{
+			{Name: "refine", Chain: transformNode("input", "text", func(s string) any { return s })},
+		},
+		[]GraphEdge{{From: "refine", To: "refine"}},
+		[]string{"input"},
+		[]string{"text"},
+		WithGraphMaxIterations(3),
+	)
+	require.NoError(t, err)
+	_, err = Run(context.Background(), graph, "hi")
+	require.ErrorIs(t, err, ErrGraphMaxIterations)
+}
+
+func TestGraphValidation(t *testing.T) {
+	t.Parallel()
+
+	upper := transformNode("input", "upper", func(s string) any { return strings.ToUpper(s) })
+	lower := transformNode("input", "lower", func(s string) any { return strings.ToLower(s) })
+	both := NewTransform(nil, []string{"upper", "lower"}, []string{"output"})
+
+	testCases := []struct {
+		name       string
+		nodes      []GraphNode
+		edges      []GraphEdge
+		outputKeys []string
+		opts       []GraphOption
+		errMsg     string
+	}{
+		{
+			name:   "no nodes",
+			errMsg: "graph has no nodes",
+		},
+		{
+			name:   "duplicate node",
+			nodes:  []GraphNode{{Name: "a", Chain: upper}, {Name: "a", Chain: lower}},
+			errMsg: "duplicate node a",
+		},
+		{
+			name:   "chain and branches",
+			nodes:  []GraphNode{{Name: "a", Chain: upper, Branches: []Chain{lower}}},
+			errMsg: "node a must have either a chain or branches",
+		},
+		{
+			name:   "overlapping branches",
+			nodes:  []GraphNode{{Name: "a", Branches: []Chain{upper, upper}}},
+			errMsg: "branches of node a have overlapping output keys: upper",
+		},
+		{
+			name:   "unknown node",
+			nodes:  []GraphNode{{Name: "a", Chain: upper}},
+			edges:  []GraphEdge{{From: "a", To: "b"}},
+			errMsg: "edge from a to b has unknown node b",
+		},
+		{
+			name:   "unreachable node",
+			nodes:  []GraphNode{{Name: "a", Chain: upper}, {Name: "b", Chain: lower}},
+			errMsg: "node b can't be reached",
+		},
+		{
+			name: "input key missing on a path",
+			nodes: []GraphNode{
+				{Name: "a", Chain: upper}, {Name: "b", Chain: lower}, {Name: "c", Chain: both},
+			},
+			edges: []GraphEdge{
+				{From: "a", To: "b", Condition: OutputEquals("upper", "X")},
+				{From: "a", To: "c"},
+				{From: "b", To: "c"},
+			},
+			errMsg: "node c is missing required input keys: [lower], only had: [input,upper]",
+		},
+		{
+			name:   "overwritten input key",
+			nodes:  []GraphNode{{Name: "a", Chain: transformNode("input", "input", func(s string) any { return s })}},
+			errMsg: "node a has output keys that are input keys: input",
+		},
+		{
+			name:   "memory key overlap",
+			nodes:  []GraphNode{{Name: "a", Chain: upper}},
+			opts:   []GraphOption{WithGraphMemory(memory.NewConversationBuffer(memory.WithMemoryKey("input")))},
+			errMsg: "input keys [input] also exist in the memory keys",
+		},
+		{
+			name:       "output key missing where the graph can end",
+			nodes:      []GraphNode{{Name: "a", Chain: upper}, {Name: "b", Chain: lower}},
+			edges:      []GraphEdge{{From: "a", To: "b", Condition: OutputEquals("upper", "X")}},
+			outputKeys: []string{"lower"},
+			errMsg:     "output keys [lower] are not known when the graph ends at node a",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := NewGraph(tc.nodes, tc.edges, []string{"input"}, tc.outputKeys, tc.opts...)
+			require.ErrorIs(t, err, ErrChainInitialization)
+			assert.Contains(t, err.Error(), tc.errMsg)
+		})
+	}
+}
+
+func TestRetrieverChain(t *testing.T) {
+	t.Parallel()
+
+	c := NewRetrieverChain(testRetriever{}, "query", "docs")
+	out, err := Call(context.Background(), c, map[string]any{"query": "foo"})
+	require.NoError(t, err)
+	docs, ok := out["docs"].([]schema.Document)
+	require.True(t, ok)
+	assert.Len(t, docs, 2)
+
+	_, err = Call(context.Background(), c, map[string]any{"query": 1})
+	require.ErrorIs(t, err, ErrInputValuesWrongType)
+}
@@ -40,7 +40,8 @@ func (c LLMMathChain) Call(ctx context.Context, values map[string]any, options .
 	if !ok {
 		return nil, fmt.Errorf("%w: %w", ErrInvalidInputValues, ErrInputValuesWrongType)
 	}
-	output, err := Call(ctx, c.LLMChain, map[string]any{
+	// The LLM writes an expression, whose value is the answer.
+	output, err := Call(IntermediateStep(ctx), c.LLMChain, map[string]any{
 		"question": question,
 	}, options...)
 	if err != nil {
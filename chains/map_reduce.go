@@ -64,7 +64,7 @@ func (c MapReduceDocuments) Call(ctx context.Context, values map[string]any, opt
 	}
 
 	// Execute the chain with each of the documents asynchronously.
-	mapResults, err := Apply(ctx, c.LLMChain, c.getApplyInputs(values, docs), c.MaxNumberOfConcurrent, options...)
+	mapResults, err := Apply(IntermediateStep(ctx), c.LLMChain, c.getApplyInputs(values, docs), c.MaxNumberOfConcurrent, options...)
 	if err != nil {
 		return nil, err
 	}
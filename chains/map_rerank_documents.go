@@ -80,7 +80,7 @@ func (c MapRerankDocuments) Call(ctx context.Context, values map[string]any, opt
 	}
 
 	applyInputs := c.getApplyInputs(values, docs)
-	mapResults, err := Apply(ctx, c.LLMChain, applyInputs, c.MaxConcurrentWorkers, options...)
+	mapResults, err := Apply(IntermediateStep(ctx), c.LLMChain, applyInputs, c.MaxConcurrentWorkers, options...)
 	if err != nil {
 		return nil, err
 	}
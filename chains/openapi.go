@@ -0,0 +1,476 @@
+package chains
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/memory"
+	"github.com/tmc/langchaingo/prompts"
+	"github.com/tmc/langchaingo/schema"
+)
+
+const (
+	_openAPIDefaultMaxResponseLength = 4000
+	_openAPIDefaultMaxRequests       = 5
+	// _openAPIMaxResponseBytes is the maximum size of a response read from the
+	// API.
+	_openAPIMaxResponseBytes = 1 << 20
+	// _openAPIMaxRedirects is the maximum number of redirects followed, as by
+	// the default policy of http.Client.
+	_openAPIMaxRedirects = 10
+	// _openAPISummaryInputFactor bounds the length of the responses given to
+	// the summary chain, as a multiple of the maximum response length.
+	_openAPISummaryInputFactor = 4
+)
+
+//nolint:lll
+const _openAPISystemPrompt = `You answer questions using the %s API. Call the functions to send requests to the API, then answer the question from their responses.`
+
+//nolint:lll
+const _openAPISummaryPrompt = `Summarize the following API response, keeping the information relevant to the question. Only use information from the response.
+
+Question: {{.input}}
+
+API response:
+{{.api_response}}
+
+Summary:`
+
+var (
+	// ErrRequestNotAllowed is returned when the model makes a request to a URL
+	// that isn't in the allow-list of an OpenAPI chain.
+	ErrRequestNotAllowed = errors.New("request not allowed")
+	// ErrMaxRequests is returned when the model of an OpenAPI chain keeps
+	// making requests without answering.
+	ErrMaxRequests = errors.New("maximum number of requests reached")
+)
+
+// OpenAPIChain is a chain answering questions using an API described by an
+// OpenAPI spec. The operations of the API are given to the model as functions,
+// and the requests are built from the arguments of the function calls. Only
+// the URLs in the allow-list of the chain can be requested, and large
+// responses are truncated or summarized before they are given to the model.
+type OpenAPIChain struct {
+	llm     llms.Model
+	spec    *OpenAPISpec
+	request HTTPRequest
+
+	operations        map[string]OpenAPIOperation
+	tools             []llms.Tool
+	headers           map[string]string
+	allowList         []string
+	allowedPrefixes   []*url.URL
+	maxResponseLength int
+	maxRequests       int
+	summaryChain      *LLMChain
+	memory            schema.Memory
+}
+
+var _ Chain = (*OpenAPIChain)(nil)
+
+// OpenAPIChainOption is an option for an OpenAPI chain.
+type OpenAPIChainOption func(*OpenAPIChain)
+
+// WithOpenAPIAllowList sets the URLs the chain can request, as URL prefixes
+// such as "https://api.example.com/v1" or "api.example.com". By default, only
+// the URLs under the server URL of the spec are allowed.
+func WithOpenAPIAllowList(prefixes ...string) OpenAPIChainOption {
+	return func(c *OpenAPIChain) {
+		c.allowList = prefixes
+	}
+}
+
+// WithOpenAPIHeaders sets headers sent with every request, such as
+// credentials.
+func WithOpenAPIHeaders(headers map[string]string) OpenAPIChainOption {
+	return func(c *OpenAPIChain) {
+		c.headers = headers
+	}
+}
+
+// WithOpenAPIMaxResponseLength sets the maximum length of the responses given
+// to the model, 4000 characters by default.
+func WithOpenAPIMaxResponseLength(n int) OpenAPIChainOption {
+	return func(c *OpenAPIChain) {
+		c.maxResponseLength = n
+	}
+}
+
+// WithOpenAPISummarizeResponses summarizes the responses longer than the
+// maximum response length with the model, instead of truncating them.
+func WithOpenAPISummarizeResponses() OpenAPIChainOption {
+	return func(c *OpenAPIChain) {
+		c.summaryChain = NewLLMChain(c.llm, prompts.NewPromptTemplate(_openAPISummaryPrompt,
+			[]string{"input", "api_response"}))
+	}
+}
+
+// WithOpenAPIMaxRequests sets the maximum number of requests the model can make
+// to answer a question, 5 by default.
+func WithOpenAPIMaxRequests(n int) OpenAPIChainOption {
+	return func(c *OpenAPIChain) {
+		c.maxRequests = n
+	}
+}
+
+// NewOpenAPIChain creates a new OpenAPI chain using the operations of spec. The
+// server URL of the spec must be absolute.
+//
+// Redirects are checked against the allow-list when request is an
+// *http.Client, which is copied for the chain. Other HTTPRequest
+// implementations should not follow redirects: responses from URLs outside
+// the allow-list are rejected, but only after they were requested.
+func NewOpenAPIChain(llm llms.Model, spec *OpenAPISpec, request HTTPRequest, opts ...OpenAPIChainOption) (*OpenAPIChain, error) { //nolint:lll
+	server, err := url.Parse(spec.ServerURL)
+	if err != nil || !server.IsAbs() {
+		return nil, fmt.Errorf("%w: server URL %q is not absolute", ErrChainInitialization, spec.ServerURL)
+	}
+
+	c := &OpenAPIChain{
+		llm:               llm,
+		spec:              spec,
+		request:           request,
+		operations:        make(map[string]OpenAPIOperation, len(spec.Operations)),
+		allowList:         []string{server.String()},
+		maxResponseLength: _openAPIDefaultMaxResponseLength,
+		maxRequests:       _openAPIDefaultMaxRequests,
+		memory:            memory.NewSimple(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if len(spec.Operations) == 0 {
+		return nil, fmt.Errorf("%w: OpenAPI spec has no operations", ErrChainInitialization)
+	}
+	for _, prefix := range c.allowList {
+		u, err := parseURLPrefix(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("%w: allow-list: %w", ErrChainInitialization, err)
+		}
+		c.allowedPrefixes = append(c.allowedPrefixes, u)
+	}
+	if client, ok := request.(*http.Client); ok {
+		c.request = c.checkRedirects(client)
+	}
+	for _, op := range spec.Operations {
+		c.operations[op.Name] = op
+		c.tools = append(c.tools, llms.Tool{Type: llms.ToolTypeFunction, Function: op.functionDefinition()})
+	}
+	return c, nil
+}
+
+// Call answers the question in the "input" key, letting the model call the
+// operations of the API until it answers. The answer is returned in the
+// "answer" key.
+func (c *OpenAPIChain) Call(ctx context.Context, values map[string]any, options ...ChainCallOption) (map[string]any, error) { //nolint:lll
+	question, ok := values["input"].(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInputValues, ErrInputValuesWrongType)
+	}
+
+	system := fmt.Sprintf(_openAPISystemPrompt, c.spec.Title)
+	if c.spec.Description != "" {
+		system += "\n\n" + c.spec.Description
+	}
+	messages := []llms.MessageContent{
+		llms.TextParts(schema.ChatMessageTypeSystem, system),
+		llms.TextParts(schema.ChatMessageTypeHuman, question),
+	}
+	callOptions := append(getLLMCallOptions(ctx, "answer", options...), llms.WithTools(c.tools))
+
+	for requests := 0; ; {
+		resp, err := c.llm.GenerateContent(ctx, messages, callOptions...)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Choices) == 0 {
+			return nil, ErrInvalidOutputValues
+		}
+		choice := resp.Choices[0]
+		if len(choice.ToolCalls) == 0 {
+			return map[string]any{"answer": choice.Content}, nil
+		}
+
+		requests += len(choice.ToolCalls)
+		if requests > c.maxRequests {
+			return nil, fmt.Errorf("%w: %d", ErrMaxRequests, c.maxRequests)
+		}
+
+		call := llms.MessageContent{Role: schema.ChatMessageTypeAI}
+		if choice.Content != "" {
+			call.Parts = append(call.Parts, llms.TextContent{Text: choice.Content})
+		}
+		for _, tc := range choice.ToolCalls {
+			call.Parts = append(call.Parts, tc)
+		}
+		messages = append(messages, call)
+
+		for _, tc := range choice.ToolCalls {
+			result, err := c.runOperation(ctx, question, tc)
+			if errors.Is(err, ErrRequestNotAllowed) {
+				return nil, err
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if err != nil {
+				// Let the model fix its call.
+				result = "Error: " + err.Error()
+			}
+			response := llms.ToolCallResponse{ToolCallID: tc.ID, Content: result}
+			if tc.FunctionCall != nil {
+				response.Name = tc.FunctionCall.Name
+			}
+			messages = append(messages, llms.MessageContent{
+				Role:  schema.ChatMessageTypeTool,
+				Parts: []llms.ContentPart{response},
+			})
+		}
+	}
+}
+
+// runOperation sends the request of a function call, and returns the response
+// given to the model.
+func (c *OpenAPIChain) runOperation(ctx context.Context, question string, tc llms.ToolCall) (string, error) {
+	if tc.FunctionCall == nil {
+		return "", fmt.Errorf("tool call %s has no function call", tc.ID) //nolint:goerr113
+	}
+	op, ok := c.operations[tc.FunctionCall.Name]
+	if !ok {
+		return "", fmt.Errorf("unknown function %s", tc.FunctionCall.Name) //nolint:goerr113
+	}
+
+	req, err := c.newRequest(ctx, op, tc.FunctionCall.Arguments)
+	if err != nil {
+		return "", err
+	}
+	if !c.allowed(req.URL) {
+		return "", fmt.Errorf("%w: %s %s", ErrRequestNotAllowed, req.Method, req.URL.Redacted())
+	}
+
+	resp, err := c.request.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.Request != nil && resp.Request.URL != nil && !c.allowed(resp.Request.URL) {
+		return "", fmt.Errorf("%w: redirected to %s", ErrRequestNotAllowed, resp.Request.URL.Redacted())
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, _openAPIMaxResponseBytes))
+	if err != nil {
+		return "", err
+	}
+
+	result := string(body)
+	if resp.StatusCode >= http.StatusBadRequest {
+		result = fmt.Sprintf("HTTP status %s: %s", resp.Status, result)
+	}
+	return c.shorten(ctx, question, result)
+}
+
+// checkRedirects returns a copy of client that only follows redirects to the
+// URLs of the allow-list.
+func (c *OpenAPIChain) checkRedirects(client *http.Client) *http.Client {
+	checked := *client
+	checkRedirect := client.CheckRedirect
+	checked.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !c.allowed(req.URL) {
+			return fmt.Errorf("%w: redirected to %s", ErrRequestNotAllowed, req.URL.Redacted())
+		}
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+		if len(via) >= _openAPIMaxRedirects {
+			return fmt.Errorf("stopped after %d redirects", _openAPIMaxRedirects) //nolint:goerr113
+		}
+		return nil
+	}
+	return &checked
+}
+
+// newRequest builds the request of an operation from the JSON arguments of a
+// function call.
+func (c *OpenAPIChain) newRequest(ctx context.Context, op OpenAPIOperation, arguments string) (*http.Request, error) { //nolint:cyclop,lll
+	args := make(map[string]any)
+	if strings.TrimSpace(arguments) != "" {
+		dec := json.NewDecoder(strings.NewReader(arguments))
+		dec.UseNumber()
+		if err := dec.Decode(&args); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+
+	urlPath := op.Path
+	query := url.Values{}
+	header := http.Header{}
+	for _, p := range op.Parameters {
+		value, ok := args[p.Name]
+		delete(args, p.Name)
+		if !ok || value == nil {
+			if p.Required {
+				return nil, fmt.Errorf("missing required argument %s", p.Name) //nolint:goerr113
+			}
+			continue
+		}
+		switch p.In {
+		case "path":
+			s := argumentString(value)
+			if s == "" || s == "." || s == ".." {
+				return nil, fmt.Errorf("invalid value %q for path argument %s", s, p.Name) //nolint:goerr113
+			}
+			urlPath = strings.ReplaceAll(urlPath, "{"+p.Name+"}", url.PathEscape(s))
+		case "query":
+			if list, ok := value.([]any); ok {
+				for _, item := range list {
+					query.Add(p.Name, argumentString(item))
+				}
+				continue
+			}
+			query.Set(p.Name, argumentString(value))
+		case "header":
+			header.Set(p.Name, argumentString(value))
+		}
+	}
+
+	var body io.Reader
+	if value, ok := args["body"]; ok && op.RequestBody != nil {
+		delete(args, "body")
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	} else if op.RequestBodyRequired {
+		return nil, fmt.Errorf("missing required argument body") //nolint:goerr113
+	}
+	if len(args) > 0 {
+		return nil, fmt.Errorf("unknown arguments: %s", strings.Join(sortedKeys(args), ", ")) //nolint:goerr113
+	}
+
+	u, err := url.Parse(strings.TrimSuffix(c.spec.ServerURL, "/") + urlPath)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, op.Method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+// argumentString returns an argument as a string, with objects and arrays
+// encoded as JSON.
+func argumentString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return fmt.Sprint(v)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// parseURLPrefix parses a prefix of the allow-list, which may have no scheme.
+func parseURLPrefix(prefix string) (*url.URL, error) {
+	if !strings.Contains(prefix, "://") {
+		prefix = "//" + prefix
+	}
+	u, err := url.Parse(prefix)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("URL prefix %q has no host", prefix) //nolint:goerr113
+	}
+	return u, nil
+}
+
+// allowed reports whether a URL is under one of the prefixes of the
+// allow-list.
+func (c *OpenAPIChain) allowed(u *url.URL) bool {
+	urlPath := path.Clean("/" + u.Path)
+	for _, prefix := range c.allowedPrefixes {
+		if !strings.EqualFold(prefix.Host, u.Host) {
+			continue
+		}
+		if prefix.Scheme != "" && !strings.EqualFold(prefix.Scheme, u.Scheme) {
+			continue
+		}
+		prefixPath := strings.TrimSuffix(prefix.Path, "/")
+		if prefixPath == "" || urlPath == prefixPath || strings.HasPrefix(urlPath, prefixPath+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// shorten returns a response shortened to the maximum response length, by
+// summarizing it if the chain summarizes responses or by truncating it.
+func (c *OpenAPIChain) shorten(ctx context.Context, question, response string) (string, error) {
+	if c.maxResponseLength <= 0 || len(response) <= c.maxResponseLength {
+		return response, nil
+	}
+	if c.summaryChain == nil {
+		return truncate(response, c.maxResponseLength) + "\n[response truncated]", nil
+	}
+
+	summary, err := Predict(IntermediateStep(ctx), c.summaryChain, map[string]any{
+		"input":        question,
+		"api_response": truncate(response, c.maxResponseLength*_openAPISummaryInputFactor),
+	})
+	if err != nil {
+		return "", err
+	}
+	return truncate(summary, c.maxResponseLength), nil
+}
+
+// truncate returns the first n bytes of s, without splitting a character.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// GetMemory returns the memory of the chain.
+func (c *OpenAPIChain) GetMemory() schema.Memory {
+	return c.memory
+}
+
+// GetInputKeys returns the input keys of the chain.
+func (c *OpenAPIChain) GetInputKeys() []string {
+	return []string{"input"}
+}
+
+// GetOutputKeys returns the output keys of the chain.
+func (c *OpenAPIChain) GetOutputKeys() []string {
+	return []string{"answer"}
+}
@@ -0,0 +1,336 @@
+package chains
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+	"gopkg.in/yaml.v3"
+)
+
+// _openAPIMaxRefDepth is the maximum depth of nested references resolved in a
+// schema, which bounds recursive schemas.
+const _openAPIMaxRefDepth = 8
+
+var (
+	// ErrInvalidOpenAPISpec is returned when an OpenAPI spec can't be parsed.
+	ErrInvalidOpenAPISpec = errors.New("invalid OpenAPI spec")
+	// ErrUnsupportedOpenAPIVersion is returned for specs that aren't OpenAPI 3.
+	ErrUnsupportedOpenAPIVersion = errors.New("unsupported OpenAPI version")
+)
+
+//nolint:gochecknoglobals
+var (
+	_openAPIMethods          = []string{"get", "put", "post", "delete", "patch", "head", "options"}
+	_openAPIInvalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+)
+
+// OpenAPISpec is the server and operations of an OpenAPI 3 spec.
+type OpenAPISpec struct {
+	Title       string
+	Description string
+	// ServerURL is the URL of the first server of the spec, which the paths of
+	// the operations are relative to.
+	ServerURL  string
+	Operations []OpenAPIOperation
+}
+
+// OpenAPIOperation is an operation of an OpenAPI spec.
+type OpenAPIOperation struct {
+	// Name is the operation ID, or a name made of the method and path of the
+	// operation when it has none.
+	Name        string
+	Method      string
+	Path        string
+	Description string
+	Parameters  []OpenAPIParameter
+	// RequestBody is the JSON schema of the JSON request body, if any.
+	RequestBody map[string]any
+	// RequestBodyRequired reports whether the request body is required.
+	RequestBodyRequired bool
+}
+
+// OpenAPIParameter is a parameter of an operation.
+type OpenAPIParameter struct {
+	Name string
+	// In is the location of the parameter: path, query or header.
+	In          string
+	Description string
+	Required    bool
+	// Schema is the JSON schema of the parameter.
+	Schema map[string]any
+}
+
+// LoadOpenAPISpec fetches and parses an OpenAPI 3 spec, in JSON or YAML. A
+// relative server URL is resolved against the URL of the spec.
+func LoadOpenAPISpec(ctx context.Context, request HTTPRequest, specURL string) (*OpenAPISpec, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, specURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := request.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch OpenAPI spec: unexpected status %s", resp.Status) //nolint:goerr113
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := ParseOpenAPISpec(data)
+	if err != nil {
+		return nil, err
+	}
+	base, err := url.Parse(specURL)
+	if err != nil {
+		return nil, err
+	}
+	server, err := base.Parse(spec.ServerURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: server URL: %w", ErrInvalidOpenAPISpec, err)
+	}
+	spec.ServerURL = server.String()
+	return spec, nil
+}
+
+// ParseOpenAPISpec parses an OpenAPI 3 spec, in JSON or YAML. The references
+// to components are resolved.
+func ParseOpenAPISpec(data []byte) (*OpenAPISpec, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidOpenAPISpec, err)
+	}
+	version, _ := doc["openapi"].(string)
+	if !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedOpenAPIVersion, version)
+	}
+
+	info := mapValue(doc, "info")
+	spec := &OpenAPISpec{
+		Title:       stringValue(info, "title"),
+		Description: stringValue(info, "description"),
+		ServerURL:   "/",
+	}
+	if servers, ok := doc["servers"].([]any); ok && len(servers) > 0 {
+		if server, ok := servers[0].(map[string]any); ok {
+			spec.ServerURL = stringValue(server, "url")
+		}
+	}
+
+	paths := mapValue(doc, "paths")
+	names := make(map[string]string)
+	for _, path := range sortedKeys(paths) {
+		item, _ := resolveRef(doc, paths[path], 0).(map[string]any)
+		pathParams := openAPIParameters(doc, item["parameters"])
+		for _, method := range _openAPIMethods {
+			op, ok := resolveRef(doc, item[method], 0).(map[string]any)
+			if !ok {
+				continue
+			}
+			operation, err := openAPIOperation(doc, method, path, op, pathParams)
+			if err != nil {
+				return nil, err
+			}
+			if other, ok := names[operation.Name]; ok {
+				return nil, fmt.Errorf("%w: operations %s and %s %s have the same name %s",
+					ErrInvalidOpenAPISpec, other, strings.ToUpper(method), path, operation.Name)
+			}
+			names[operation.Name] = strings.ToUpper(method) + " " + path
+			spec.Operations = append(spec.Operations, operation)
+		}
+	}
+	return spec, nil
+}
+
+func openAPIOperation(doc map[string]any, method, path string, op map[string]any, pathParams []OpenAPIParameter) (OpenAPIOperation, error) { //nolint:lll
+	operation := OpenAPIOperation{
+		Name:        openAPIOperationName(method, path, stringValue(op, "operationId")),
+		Method:      strings.ToUpper(method),
+		Path:        path,
+		Description: stringValue(op, "summary"),
+	}
+	if description := stringValue(op, "description"); description != "" {
+		if operation.Description != "" {
+			operation.Description += "\n"
+		}
+		operation.Description += description
+	}
+
+	// Parameters of the operation override the ones of its path.
+	params := openAPIParameters(doc, op["parameters"])
+	for _, p := range pathParams {
+		overridden := false
+		for _, q := range params {
+			overridden = overridden || (p.Name == q.Name && p.In == q.In)
+		}
+		if !overridden {
+			params = append(params, p)
+		}
+	}
+	for _, p := range params {
+		if p.In == "cookie" {
+			continue
+		}
+		if p.Name == "body" {
+			return operation, fmt.Errorf("%w: operation %s has a parameter named body", ErrInvalidOpenAPISpec, operation.Name)
+		}
+		operation.Parameters = append(operation.Parameters, p)
+	}
+
+	if body, ok := resolveRef(doc, op["requestBody"], 0).(map[string]any); ok {
+		content := mapValue(body, "content")
+		if media := mapValue(content, "application/json"); media != nil {
+			schema, _ := resolveRef(doc, media["schema"], 0).(map[string]any)
+			if schema == nil {
+				schema = map[string]any{"type": "object"}
+			}
+			operation.RequestBody = schema
+			operation.RequestBodyRequired, _ = body["required"].(bool)
+		}
+	}
+	return operation, nil
+}
+
+func openAPIParameters(doc map[string]any, value any) []OpenAPIParameter {
+	list, _ := value.([]any)
+	params := make([]OpenAPIParameter, 0, len(list))
+	for _, item := range list {
+		p, ok := resolveRef(doc, item, 0).(map[string]any)
+		if !ok {
+			continue
+		}
+		param := OpenAPIParameter{
+			Name:        stringValue(p, "name"),
+			In:          stringValue(p, "in"),
+			Description: stringValue(p, "description"),
+			Schema:      mapValue(p, "schema"),
+		}
+		param.Required, _ = p["required"].(bool)
+		if param.In == "path" {
+			param.Required = true
+		}
+		if param.Schema == nil {
+			param.Schema = map[string]any{"type": "string"}
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// openAPIOperationName returns the name of an operation as a function name,
+// made of letters, digits, underscores and dashes.
+func openAPIOperationName(method, path, operationID string) string {
+	name := operationID
+	if name == "" {
+		name = method + "_" + path
+	}
+	return strings.Trim(_openAPIInvalidNameChars.ReplaceAllString(name, "_"), "_")
+}
+
+// resolveRef returns value with the local references it contains replaced by
+// the values they refer to.
+func resolveRef(doc map[string]any, value any, depth int) any {
+	switch v := value.(type) {
+	case map[string]any:
+		if ref, ok := v["$ref"].(string); ok {
+			if depth >= _openAPIMaxRefDepth {
+				return map[string]any{"type": "object"}
+			}
+			return resolveRef(doc, lookupRef(doc, ref), depth+1)
+		}
+		resolved := make(map[string]any, len(v))
+		for key, item := range v {
+			resolved[key] = resolveRef(doc, item, depth)
+		}
+		return resolved
+	case []any:
+		resolved := make([]any, len(v))
+		for i, item := range v {
+			resolved[i] = resolveRef(doc, item, depth)
+		}
+		return resolved
+	default:
+		return value
+	}
+}
+
+// lookupRef returns the value a local reference such as
+// "#/components/schemas/Pet" refers to, or nil.
+func lookupRef(doc map[string]any, ref string) any {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil
+	}
+	var value any = doc
+	for _, part := range strings.Split(ref[2:], "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = m[part]
+	}
+	return value
+}
+
+func mapValue(m map[string]any, key string) map[string]any {
+	v, _ := m[key].(map[string]any)
+	return v
+}
+
+func stringValue(m map[string]any, key string) string {
+	v, _ := m[key].(string)
+	return v
+}
+
+// functionDefinition returns the definition of the function the model calls to
+// run the operation. Its arguments are the parameters of the operation, and
+// the request body in the "body" argument.
+func (o OpenAPIOperation) functionDefinition() *llms.FunctionDefinition {
+	properties := make(map[string]any, len(o.Parameters)+1)
+	required := make([]string, 0)
+	for _, p := range o.Parameters {
+		schema := make(map[string]any, len(p.Schema)+1)
+		for key, value := range p.Schema {
+			schema[key] = value
+		}
+		if p.Description != "" {
+			schema["description"] = p.Description
+		}
+		properties[p.Name] = schema
+		if p.Required {
+			required = append(required, p.Name)
+		}
+	}
+	if o.RequestBody != nil {
+		properties["body"] = o.RequestBody
+		if o.RequestBodyRequired {
+			required = append(required, "body")
+		}
+	}
+	sort.Strings(required)
+
+	description := o.Description
+	if description == "" {
+		description = o.Method + " " + o.Path
+	}
+	return &llms.FunctionDefinition{
+		Name:        o.Name,
+		Description: description,
+		Parameters: map[string]any{
+			"type":       "object",
+			"properties": properties,
+			"required":   required,
+		},
+	}
+}
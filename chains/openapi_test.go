@@ -0,0 +1,337 @@
+package chains
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+const _testPetStoreSpec = `openapi: 3.0.0
+info:
+  title: Pet Store
+  description: A store selling pets.
+servers:
+  - url: /api
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      summary: List the pets.
+      parameters:
+        - name: limit
+          in: query
+          schema:
+            type: integer
+        - name: tag
+          in: query
+          schema:
+            type: array
+            items:
+              type: string
+    post:
+      operationId: createPet
+      summary: Create a pet.
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+  /pets/{petId}:
+    parameters:
+      - $ref: '#/components/parameters/PetID'
+    get:
+      operationId: showPet
+      summary: Show a pet.
+components:
+  parameters:
+    PetID:
+      name: petId
+      in: path
+      description: The ID of the pet.
+      schema:
+        type: string
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string
+        tag:
+          type: string
+`
+
+// petStore serves the pet store spec and API, and records the requests.
+type petStore struct {
+	mu       sync.Mutex
+	requests []string
+	bodies   []string
+	// redirect, when set, is the URL API requests are redirected to.
+	redirect string
+}
+
+func (s *petStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/openapi.yaml" {
+		_, _ = w.Write([]byte(_testPetStoreSpec))
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.RequestURI())
+	s.bodies = append(s.bodies, string(body))
+	s.mu.Unlock()
+
+	switch {
+	case s.redirect != "":
+		http.Redirect(w, r, s.redirect, http.StatusFound)
+	case r.Method == http.MethodGet && r.URL.Path == "/api/pets":
+		_, _ = w.Write([]byte(`[{"name":"Rex","tag":"dog"},{"name":"Tom","tag":"cat"}]` + strings.Repeat(" ", 100)))
+	case r.Method == http.MethodGet && r.URL.Path == "/api/pets/1":
+		_, _ = w.Write([]byte(`{"name":"Rex","tag":"dog"}`))
+	case r.Method == http.MethodPost && r.URL.Path == "/api/pets":
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"2"}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`not found`))
+	}
+}
+
+// toolModel calls the functions in calls, one per turn, then answers with the
+// responses of the calls. The prompts of other calls are answered with
+// "summary".
+type toolModel struct {
+	calls    []llms.ToolCall
+	messages []llms.MessageContent
+}
+
+func (m *toolModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+func (m *toolModel) GenerateContent(_ context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) { //nolint:lll
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if len(opts.Tools) == 0 {
+		return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: "summary"}}}, nil
+	}
+
+	m.messages = messages
+	if len(m.calls) > 0 {
+		call := m.calls[0]
+		m.calls = m.calls[1:]
+		return &llms.ContentResponse{Choices: []*llms.ContentChoice{{ToolCalls: []llms.ToolCall{call}}}}, nil
+	}
+	var responses []string
+	for _, mc := range messages {
+		for _, part := range mc.Parts {
+			if r, ok := part.(llms.ToolCallResponse); ok {
+				responses = append(responses, r.Content)
+			}
+		}
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: strings.Join(responses, "\n")}}}, nil
+}
+
+func functionCall(name, arguments string) llms.ToolCall {
+	return llms.ToolCall{
+		ID:           name,
+		Type:         llms.ToolTypeFunction,
+		FunctionCall: &schema.FunctionCall{Name: name, Arguments: arguments},
+	}
+}
+
+func newPetStore(t *testing.T) (*petStore, *httptest.Server, *OpenAPISpec) {
+	t.Helper()
+
+	store := &petStore{}
+	srv := httptest.NewServer(store)
+	t.Cleanup(srv.Close)
+	spec, err := LoadOpenAPISpec(context.Background(), srv.Client(), srv.URL+"/openapi.yaml")
+	require.NoError(t, err)
+	return store, srv, spec
+}
+
+func TestParseOpenAPISpec(t *testing.T) {
+	t.Parallel()
+
+	_, srv, spec := newPetStore(t)
+	assert.Equal(t, "Pet Store", spec.Title)
+	assert.Equal(t, srv.URL+"/api", spec.ServerURL)
+	require.Len(t, spec.Operations, 3)
+
+	names := make([]string, 0, len(spec.Operations))
+	for _, op := range spec.Operations {
+		names = append(names, op.Method+" "+op.Path+" "+op.Name)
+	}
+	assert.Equal(t, []string{"GET /pets listPets", "POST /pets createPet", "GET /pets/{petId} showPet"}, names)
+
+	fn := spec.Operations[2].functionDefinition()
+	assert.Equal(t, "showPet", fn.Name)
+	assert.Equal(t, "Show a pet.", fn.Description)
+	assert.Equal(t, map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"petId": map[string]any{"type": "string", "description": "The ID of the pet."},
+		},
+		"required": []string{"petId"},
+	}, fn.Parameters)
+
+	body := spec.Operations[1].functionDefinition().Parameters.(map[string]any)["properties"].(map[string]any)["body"]
+	assert.Equal(t, []any{"name"}, body.(map[string]any)["required"])
+
+	_, err := ParseOpenAPISpec([]byte(`swagger: "2.0"`))
+	require.ErrorIs(t, err, ErrUnsupportedOpenAPIVersion)
+}
+
+func TestOpenAPIChain(t *testing.T) {
+	t.Parallel()
+
+	store, _, spec := newPetStore(t)
+	llm := &toolModel{calls: []llms.ToolCall{
+		functionCall("showPet", `{"petId": "1"}`),
+		functionCall("listPets", `{"limit": 2, "tag": ["dog", "cat"]}`),
+		functionCall("createPet", `{"body": {"name": "Kitty", "tag": "cat"}}`),
+		functionCall("showPet", `{"petId": ".."}`),
+		functionCall("showPet", `{"id": "1"}`),
+	}}
+	chain, err := NewOpenAPIChain(llm, spec, http.DefaultClient,
+		WithOpenAPIHeaders(map[string]string{"Authorization": "Bearer token"}),
+		WithOpenAPIMaxResponseLength(60))
+	require.NoError(t, err)
+
+	answer, err := Run(context.Background(), chain, "Which pets are there?")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"GET /api/pets/1",
+		"GET /api/pets?limit=2&tag=dog&tag=cat",
+		"POST /api/pets",
+	}, store.requests)
+	assert.JSONEq(t, `{"name":"Kitty","tag":"cat"}`, store.bodies[2])
+
+	responses := strings.Split(answer, "\n")
+	require.Len(t, responses, 6)
+	assert.Equal(t, `{"name":"Rex","tag":"dog"}`, responses[0])
+	assert.Equal(t, `[{"name":"Rex","tag":"dog"},{"name":"Tom","tag":"cat"}]`+strings.Repeat(" ", 5), responses[1])
+	assert.Equal(t, "[response truncated]", responses[2])
+	assert.Equal(t, `{"id":"2"}`, responses[3])
+	assert.Equal(t, `Error: invalid value ".." for path argument petId`, responses[4])
+	assert.Equal(t, "Error: missing required argument petId", responses[5])
+	assert.Equal(t, schema.ChatMessageTypeSystem, llm.messages[0].Role)
+}
+
+func TestOpenAPIChainSummarizeResponses(t *testing.T) {
+	t.Parallel()
+
+	_, _, spec := newPetStore(t)
+	llm := &toolModel{calls: []llms.ToolCall{functionCall("listPets", `{}`)}}
+	chain, err := NewOpenAPIChain(llm, spec, http.DefaultClient,
+		WithOpenAPIMaxResponseLength(60), WithOpenAPISummarizeResponses())
+	require.NoError(t, err)
+
+	answer, err := Run(context.Background(), chain, "Which pets are there?")
+	require.NoError(t, err)
+	assert.Equal(t, "summary", answer)
+}
+
+func TestOpenAPIChainAllowList(t *testing.T) {
+	t.Parallel()
+
+	store, srv, spec := newPetStore(t)
+	newChain := func(calls []llms.ToolCall, allowList ...string) *OpenAPIChain {
+		chain, err := NewOpenAPIChain(&toolModel{calls: calls}, spec, http.DefaultClient,
+			WithOpenAPIAllowList(allowList...))
+		require.NoError(t, err)
+		return chain
+	}
+
+	_, err := Run(context.Background(),
+		newChain([]llms.ToolCall{functionCall("showPet", `{"petId": "1"}`)}, "api.example.com"),
+		"Who is pet 1?")
+	require.ErrorIs(t, err, ErrRequestNotAllowed)
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	_, err = Run(context.Background(),
+		newChain([]llms.ToolCall{functionCall("showPet", `{"petId": "12"}`)}, host+"/api/pets/1"),
+		"Who is pet 12?")
+	require.ErrorIs(t, err, ErrRequestNotAllowed)
+
+	_, err = Run(context.Background(),
+		newChain([]llms.ToolCall{functionCall("showPet", `{"petId": "1"}`)}, host+"/api/pets/1"),
+		"Who is pet 1?")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GET /api/pets/1"}, store.requests)
+
+	_, err = NewOpenAPIChain(&toolModel{}, spec, http.DefaultClient, WithOpenAPIAllowList("/api"))
+	require.ErrorIs(t, err, ErrChainInitialization)
+}
+
+// followingClient is an HTTPRequest following redirects without checking them.
+type followingClient struct{}
+
+func (followingClient) Do(req *http.Request) (*http.Response, error) {
+	return http.DefaultClient.Do(req)
+}
+
+func TestOpenAPIChainRedirect(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		_, _ = w.Write([]byte(`{"secret":"internal"}`))
+	}))
+	t.Cleanup(other.Close)
+
+	store, _, spec := newPetStore(t)
+	store.redirect = other.URL + "/api/pets/1"
+	calls := []llms.ToolCall{functionCall("showPet", `{"petId": "1"}`)}
+
+	chain, err := NewOpenAPIChain(&toolModel{calls: calls}, spec, http.DefaultClient)
+	require.NoError(t, err)
+	_, err = Run(context.Background(), chain, "Who is pet 1?")
+	require.ErrorIs(t, err, ErrRequestNotAllowed)
+	assert.Zero(t, hits.Load())
+	assert.Nil(t, http.DefaultClient.CheckRedirect)
+
+	// Responses to redirects followed by other clients are rejected.
+	chain, err = NewOpenAPIChain(&toolModel{calls: calls}, spec, followingClient{})
+	require.NoError(t, err)
+	_, err = Run(context.Background(), chain, "Who is pet 1?")
+	require.ErrorIs(t, err, ErrRequestNotAllowed)
+	assert.Equal(t, int32(1), hits.Load())
+}
+
+func TestOpenAPIChainMaxRequests(t *testing.T) {
+	t.Parallel()
+
+	_, _, spec := newPetStore(t)
+	calls := make([]llms.ToolCall, 0, 3)
+	for i := 0; i < 3; i++ {
+		calls = append(calls, functionCall("showPet", fmt.Sprintf(`{"petId": "%d"}`, i)))
+	}
+	chain, err := NewOpenAPIChain(&toolModel{calls: calls}, spec, http.DefaultClient, WithOpenAPIMaxRequests(2))
+	require.NoError(t, err)
+
+	_, err = Run(context.Background(), chain, "Who are the pets?")
+	require.ErrorIs(t, err, ErrMaxRequests)
+
+	_, err = json.Marshal(spec)
+	require.NoError(t, err)
+}
@@ -22,6 +22,12 @@ type chainCallOption struct {
 	// StreamingFunc is a function to be called for each chunk of a streaming response.
 	// Return an error to stop streaming earl.
 	StreamingFunc func(ctx context.Context, chunk []byte) error
+	// StreamingEventFunc is a function to be called for each chunk of a streaming
+	// response, tagged with the chain it was streamed from.
+	StreamingEventFunc func(ctx context.Context, event StreamEvent) error
+	// FinalOutputOnly streams only the chunks of the final output of the chain,
+	// and not the ones of its intermediate steps.
+	FinalOutputOnly bool
 	// TopK is the number of tokens to consider for top-k sampling in an LLM call.
 	TopK int
 	// TopP is the cumulative probability for top-p sampling in an LLM call.
@@ -73,6 +79,24 @@ func WithStreamingFunc(streamingFunc func(ctx context.Context, chunk []byte) err
 	}
 }
 
+// WithStreamingEventFunc is an option for LLM.Call that allows streaming
+// responses as events telling which chain and output key each chunk is from.
+func WithStreamingEventFunc(streamingEventFunc func(ctx context.Context, event StreamEvent) error) ChainCallOption {
+	return func(o *chainCallOption) {
+		o.StreamingEventFunc = streamingEventFunc
+	}
+}
+
+// WithFinalOutputOnly is an option for chains streaming responses, so that only
+// the chunks of the final output are streamed. The chunks of intermediate
+// steps, such as the questions condensed by a conversational retrieval chain,
+// aren't streamed.
+func WithFinalOutputOnly() ChainCallOption {
+	return func(o *chainCallOption) {
+		o.FinalOutputOnly = true
+	}
+}
+
 // WithTopK will add an option to use top-k sampling for LLM.Call.
 func WithTopK(topK int) ChainCallOption {
 	return func(o *chainCallOption) {
@@ -129,24 +153,18 @@ func WithCallback(callbackHandler callbacks.Handler) ChainCallOption {
 	}
 }
 
-func getLLMCallOptions(options ...ChainCallOption) []llms.CallOption {
+func getLLMCallOptions(ctx context.Context, outputKey string, options ...ChainCallOption) []llms.CallOption {
 	opts := &chainCallOption{}
 	for _, option := range options {
 		option(opts)
 	}
-	if opts.StreamingFunc == nil && opts.CallbackHandler != nil {
-		opts.StreamingFunc = func(ctx context.Context, chunk []byte) error {
-			opts.CallbackHandler.HandleStreamingFunc(ctx, chunk)
-			return nil
-		}
-	}
 
 	chainCallOption := []llms.CallOption{
 		llms.WithModel(opts.Model),
 		llms.WithMaxTokens(opts.MaxTokens),
 		llms.WithTemperature(opts.Temperature),
 		llms.WithStopWords(opts.StopWords),
-		llms.WithStreamingFunc(opts.StreamingFunc),
+		llms.WithStreamingFunc(opts.streamingFunc(ctx, outputKey)),
 		llms.WithTopK(opts.TopK),
 		llms.WithTopP(opts.TopP),
 		llms.WithSeed(opts.Seed),
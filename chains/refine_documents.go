@@ -81,7 +81,7 @@ func (c RefineDocuments) Call(ctx context.Context, values map[string]any, option
 	if err != nil {
 		return nil, err
 	}
-	response, err := Predict(ctx, c.LLMChain, initialInputs, options...)
+	response, err := Predict(c.stepContext(ctx, 0, len(docs)), c.LLMChain, initialInputs, options...)
 	if err != nil {
 		return nil, err
 	}
@@ -92,7 +92,7 @@ func (c RefineDocuments) Call(ctx context.Context, values map[string]any, option
 		if err != nil {
 			return nil, err
 		}
-		response, err = Predict(ctx, c.RefineLLMChain, refineInputs, options...)
+		response, err = Predict(c.stepContext(ctx, i, len(docs)), c.RefineLLMChain, refineInputs, options...)
 		if err != nil {
 			return nil, err
 		}
@@ -103,6 +103,15 @@ func (c RefineDocuments) Call(ctx context.Context, values map[string]any, option
 	}, nil
 }
 
+// stepContext returns the context for the step combining the i-th document,
+// which is an intermediate step unless it is the last one.
+func (c RefineDocuments) stepContext(ctx context.Context, i, numDocs int) context.Context {
+	if i < numDocs-1 {
+		return IntermediateStep(ctx)
+	}
+	return ctx
+}
+
 func (c RefineDocuments) constructInitialInputs(doc schema.Document, rest map[string]any) (map[string]any, error) {
 	return c.getBaseInputs(doc, rest)
 }
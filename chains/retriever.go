@@ -0,0 +1,63 @@
+package chains
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tmc/langchaingo/memory"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// RetrieverChain is a chain that gets the documents relevant to a query from a
+// retriever.
+type RetrieverChain struct {
+	Memory    schema.Memory
+	Retriever schema.Retriever
+	// The input key to get the query from.
+	InputKey string
+	// The output key to return the documents in.
+	OutputKey string
+}
+
+var _ Chain = RetrieverChain{}
+
+// NewRetrieverChain creates a new retriever chain getting the query from
+// inputKey and returning the documents in outputKey.
+func NewRetrieverChain(retriever schema.Retriever, inputKey, outputKey string) RetrieverChain {
+	return RetrieverChain{
+		Memory:    memory.NewSimple(),
+		Retriever: retriever,
+		InputKey:  inputKey,
+		OutputKey: outputKey,
+	}
+}
+
+// Call returns the documents relevant to the query.
+func (c RetrieverChain) Call(ctx context.Context, inputs map[string]any, _ ...ChainCallOption) (map[string]any, error) { //nolint:lll
+	query, ok := inputs[c.InputKey].(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInputValues, ErrInputValuesWrongType)
+	}
+
+	docs, err := c.Retriever.GetRelevantDocuments(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]any{c.OutputKey: docs}, nil
+}
+
+// GetMemory gets the memory of the chain.
+func (c RetrieverChain) GetMemory() schema.Memory {
+	return c.Memory
+}
+
+// GetInputKeys returns the input keys the chain expects.
+func (c RetrieverChain) GetInputKeys() []string {
+	return []string{c.InputKey}
+}
+
+// GetOutputKeys returns the output keys the chain returns.
+func (c RetrieverChain) GetOutputKeys() []string {
+	return []string{c.OutputKey}
+}
@@ -101,7 +101,7 @@ func (c *SequentialChain) Call(ctx context.Context, inputs map[string]any, optio
 	var outputs map[string]any
 	var err error
 	for _, chain := range c.chains {
-		outputs, err = Call(ctx, chain, inputs, options...)
+		outputs, err = Call(intermediateUnless(ctx, chain, c.outputKeys), chain, inputs, options...)
 		if err != nil {
 			return nil, err
 		}
@@ -176,9 +176,13 @@ func validateSimpleSeq(chains []Chain) error {
 // Use the Run function that handles the memory and other aspects of the chain.
 func (c *SimpleSequentialChain) Call(ctx context.Context, inputs map[string]any, options ...ChainCallOption) (map[string]any, error) { //nolint:lll
 	input := inputs[input]
-	for _, chain := range c.chains {
+	for i, chain := range c.chains {
+		stepCtx := ctx
+		if i < len(c.chains)-1 {
+			stepCtx = IntermediateStep(ctx)
+		}
 		var err error
-		input, err = Run(ctx, chain, input, options...)
+		input, err = Run(stepCtx, chain, input, options...)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,569 @@
+package chains
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/memory"
+	"github.com/tmc/langchaingo/outputparser"
+	"github.com/tmc/langchaingo/prompts"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/tools"
+	"gopkg.in/yaml.v3"
+)
+
+// The types of the chains registered by NewRegistry.
+const (
+	ChainTypeLLM            = "llm_chain"
+	ChainTypeStuffDocuments = "stuff_documents_chain"
+	ChainTypeRetrievalQA    = "retrieval_qa"
+	ChainTypeSequential     = "sequential_chain"
+)
+
+// The types of the memories in a MemoryConfig.
+const (
+	MemoryTypeSimple       = "simple"
+	MemoryTypeBuffer       = "buffer"
+	MemoryTypeWindowBuffer = "window_buffer"
+	MemoryTypeTokenBuffer  = "token_buffer"
+)
+
+var (
+	// ErrChainNotSerializable is returned when saving a chain of a type that
+	// isn't registered, or that has parts that can't be serialized.
+	ErrChainNotSerializable = errors.New("chain is not serializable")
+	// ErrUnknownChainType is returned when loading a chain or a memory of an
+	// unknown type.
+	ErrUnknownChainType = errors.New("unknown chain type")
+	// ErrMissingResource is returned when a config refers to a model, a
+	// retriever or a tool that isn't in the resources, or when saving a chain
+	// using one that isn't.
+	ErrMissingResource = errors.New("missing resource")
+)
+
+// ChainConfig is the serializable description of a chain. The fields used
+// depend on the type of the chain. Models, retrievers and tools are referred
+// to by name, and are injected from the resources when loading the chain.
+type ChainConfig struct {
+	// Type is the type of the chain in the registry.
+	Type string `json:"type" yaml:"type"`
+
+	// LLM is the name of the model of the chain in the resources.
+	LLM    string                `json:"llm,omitempty" yaml:"llm,omitempty"`
+	Prompt *prompts.PromptConfig `json:"prompt,omitempty" yaml:"prompt,omitempty"`
+	Memory *MemoryConfig         `json:"memory,omitempty" yaml:"memory,omitempty"`
+
+	InputKey   string   `json:"input_key,omitempty" yaml:"input_key,omitempty"`
+	OutputKey  string   `json:"output_key,omitempty" yaml:"output_key,omitempty"`
+	InputKeys  []string `json:"input_keys,omitempty" yaml:"input_keys,omitempty"`
+	OutputKeys []string `json:"output_keys,omitempty" yaml:"output_keys,omitempty"`
+
+	DocumentVariableName string `json:"document_variable_name,omitempty" yaml:"document_variable_name,omitempty"`
+	Separator            string `json:"separator,omitempty" yaml:"separator,omitempty"`
+
+	// Retriever is the name of the retriever of the chain in the resources.
+	Retriever             string `json:"retriever,omitempty" yaml:"retriever,omitempty"`
+	ReturnSourceDocuments bool   `json:"return_source_documents,omitempty" yaml:"return_source_documents,omitempty"`
+
+	// Chain is the sub-chain of a chain wrapping a single chain, such as the
+	// LLM chain of a stuff documents chain.
+	Chain *ChainConfig `json:"chain,omitempty" yaml:"chain,omitempty"`
+	// Chains are the sub-chains of a chain running several chains.
+	Chains []*ChainConfig `json:"chains,omitempty" yaml:"chains,omitempty"`
+
+	// Params are the settings of other chain types, such as the types
+	// registered by other packages. They are read and written with
+	// DecodeParams and EncodeParams.
+	Params map[string]any `json:"params,omitempty" yaml:"params,omitempty"`
+}
+
+// DecodeParams decodes the params of the config into v, a pointer to a struct
+// with yaml tags. Unknown params are an error.
+func (c *ChainConfig) DecodeParams(v any) error {
+	if len(c.Params) == 0 {
+		return nil
+	}
+	data, err := yaml.Marshal(c.Params)
+	if err != nil {
+		return err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("%s params: %w", c.Type, err)
+	}
+	return nil
+}
+
+// EncodeParams sets the params of the config to the fields of v, a struct
+// with yaml tags.
+func (c *ChainConfig) EncodeParams(v any) error {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var params map[string]any
+	if err := yaml.Unmarshal(data, &params); err != nil {
+		return err
+	}
+	if len(params) == 0 {
+		params = nil
+	}
+	c.Params = params
+	return nil
+}
+
+// MemoryConfig is the serializable description of the memory of a chain. The
+// chat history isn't part of it: loaded memories start with an empty history.
+type MemoryConfig struct {
+	// Type is the type of the memory: "simple", "buffer", "window_buffer" or
+	// "token_buffer".
+	Type           string `json:"type" yaml:"type"`
+	MemoryKey      string `json:"memory_key,omitempty" yaml:"memory_key,omitempty"`
+	InputKey       string `json:"input_key,omitempty" yaml:"input_key,omitempty"`
+	OutputKey      string `json:"output_key,omitempty" yaml:"output_key,omitempty"`
+	HumanPrefix    string `json:"human_prefix,omitempty" yaml:"human_prefix,omitempty"`
+	AIPrefix       string `json:"ai_prefix,omitempty" yaml:"ai_prefix,omitempty"`
+	ReturnMessages bool   `json:"return_messages,omitempty" yaml:"return_messages,omitempty"`
+	// WindowSize is the number of exchanges kept by a window buffer.
+	WindowSize int `json:"window_size,omitempty" yaml:"window_size,omitempty"`
+	// LLM, ModelName and MaxTokenLimit are the settings of a token buffer.
+	LLM           string `json:"llm,omitempty" yaml:"llm,omitempty"`
+	ModelName     string `json:"model_name,omitempty" yaml:"model_name,omitempty"`
+	MaxTokenLimit int    `json:"max_token_limit,omitempty" yaml:"max_token_limit,omitempty"`
+}
+
+// ParseChainConfig parses a chain config in JSON or YAML. Unknown fields are
+// an error.
+func ParseChainConfig(data []byte) (*ChainConfig, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	var config ChainConfig
+	if err := decoder.Decode(&config); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("empty chain config") //nolint:goerr113
+		}
+		return nil, err
+	}
+	return &config, nil
+}
+
+// ChainLoader builds a chain of a registered type from its config.
+type ChainLoader func(s *Serializer, config *ChainConfig) (Chain, error)
+
+// ChainSaver returns the config of a chain of a registered type, or nil if
+// the chain isn't of the type.
+type ChainSaver func(s *Serializer, chain Chain) (*ChainConfig, error)
+
+type registeredChain struct {
+	load ChainLoader
+	save ChainSaver
+}
+
+// Registry maps the types of chains in configs to the functions loading and
+// saving them.
+type Registry struct {
+	types map[string]registeredChain
+	order []string
+}
+
+// NewRegistry creates a registry of the LLMChain, StuffDocuments, RetrievalQA
+// and SequentialChain types.
+func NewRegistry() *Registry {
+	r := &Registry{types: make(map[string]registeredChain)}
+	r.Register(ChainTypeLLM, loadLLMChain, saveLLMChain)
+	r.Register(ChainTypeStuffDocuments, loadStuffDocuments, saveStuffDocuments)
+	r.Register(ChainTypeRetrievalQA, loadRetrievalQA, saveRetrievalQA)
+	r.Register(ChainTypeSequential, loadSequentialChain, saveSequentialChain)
+	return r
+}
+
+// Register registers a chain type, replacing the type of the same name if
+// any. When saving a chain, the savers are tried in the order the types were
+// registered.
+func (r *Registry) Register(chainType string, load ChainLoader, save ChainSaver) {
+	if _, ok := r.types[chainType]; !ok {
+		r.order = append(r.order, chainType)
+	}
+	r.types[chainType] = registeredChain{load: load, save: save}
+}
+
+// Resources are the instances configs refer to, which are injected when
+// loading chains. Models and retrievers are referred to by their keys, and
+// tools by their names.
+type Resources struct {
+	Models     map[string]llms.Model
+	Retrievers map[string]schema.Retriever
+	Tools      []tools.Tool
+}
+
+// Serializer loads chains from configs and saves chains to configs, with a
+// registry of chain types and the resources the configs refer to. Callbacks
+// handlers aren't saved.
+type Serializer struct {
+	registry  *Registry
+	resources Resources
+}
+
+// NewSerializer creates a serializer of the chain types of a registry,
+// injecting the resources in loaded chains.
+func NewSerializer(registry *Registry, resources Resources) *Serializer {
+	return &Serializer{registry: registry, resources: resources}
+}
+
+// LoadChain builds the chain described by a config.
+func (s *Serializer) LoadChain(config *ChainConfig) (Chain, error) { //nolint:ireturn
+	if config == nil {
+		return nil, fmt.Errorf("%w: missing chain config", ErrUnknownChainType)
+	}
+	t, ok := s.registry.types[config.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownChainType, config.Type)
+	}
+	return t.load(s, config)
+}
+
+// SaveChain returns the config of a chain of a registered type.
+func (s *Serializer) SaveChain(chain Chain) (*ChainConfig, error) {
+	for _, name := range s.registry.order {
+		config, err := s.registry.types[name].save(s, chain)
+		if err != nil {
+			return nil, err
+		}
+		if config != nil {
+			config.Type = name
+			return config, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unregistered chain type %T", ErrChainNotSerializable, chain)
+}
+
+// Model returns the model of the resources with a name.
+func (s *Serializer) Model(name string) (llms.Model, error) { //nolint:ireturn
+	model, ok := s.resources.Models[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: model %q", ErrMissingResource, name)
+	}
+	return model, nil
+}
+
+// ModelName returns the name of a model in the resources.
+func (s *Serializer) ModelName(model llms.Model) (string, error) {
+	for _, name := range sortedKeys(s.resources.Models) {
+		if sameInstance(s.resources.Models[name], model) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("%w: model %T", ErrMissingResource, model)
+}
+
+// Retriever returns the retriever of the resources with a name.
+func (s *Serializer) Retriever(name string) (schema.Retriever, error) { //nolint:ireturn
+	retriever, ok := s.resources.Retrievers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: retriever %q", ErrMissingResource, name)
+	}
+	return retriever, nil
+}
+
+// RetrieverName returns the name of a retriever in the resources.
+func (s *Serializer) RetrieverName(retriever schema.Retriever) (string, error) {
+	for _, name := range sortedKeys(s.resources.Retrievers) {
+		if sameInstance(s.resources.Retrievers[name], retriever) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("%w: retriever %T", ErrMissingResource, retriever)
+}
+
+// Tools returns the tools of the resources with names.
+func (s *Serializer) Tools(names []string) ([]tools.Tool, error) {
+	loaded := make([]tools.Tool, 0, len(names))
+	for _, name := range names {
+		i := 0
+		for i < len(s.resources.Tools) && s.resources.Tools[i].Name() != name {
+			i++
+		}
+		if i == len(s.resources.Tools) {
+			return nil, fmt.Errorf("%w: tool %q", ErrMissingResource, name)
+		}
+		loaded = append(loaded, s.resources.Tools[i])
+	}
+	return loaded, nil
+}
+
+// sameInstance reports whether two resources are the same instance, without
+// panicking on values of types that aren't comparable.
+func sameInstance(a, b any) bool {
+	t := reflect.TypeOf(a)
+	return t == reflect.TypeOf(b) && t != nil && t.Comparable() && a == b
+}
+
+// LoadMemory builds the memory described by a config. A nil config is a
+// simple memory.
+func (s *Serializer) LoadMemory(config *MemoryConfig) (schema.Memory, error) { //nolint:ireturn
+	if config == nil || config.Type == MemoryTypeSimple {
+		return memory.NewSimple(), nil
+	}
+
+	options := []memory.ConversationBufferOption{
+		memory.WithInputKey(config.InputKey),
+		memory.WithOutputKey(config.OutputKey),
+		memory.WithReturnMessages(config.ReturnMessages),
+	}
+	if config.MemoryKey != "" {
+		options = append(options, memory.WithMemoryKey(config.MemoryKey))
+	}
+	if config.HumanPrefix != "" {
+		options = append(options, memory.WithHumanPrefix(config.HumanPrefix))
+	}
+	if config.AIPrefix != "" {
+		options = append(options, memory.WithAIPrefix(config.AIPrefix))
+	}
+
+	switch config.Type {
+	case MemoryTypeBuffer:
+		return memory.NewConversationBuffer(options...), nil
+	case MemoryTypeWindowBuffer:
+		return memory.NewConversationWindowBuffer(config.WindowSize, options...), nil
+	case MemoryTypeTokenBuffer:
+		llm, err := s.Model(config.LLM)
+		if err != nil {
+			return nil, err
+		}
+		m := memory.NewConversationTokenBuffer(llm, config.MaxTokenLimit, options...)
+		m.ModelName = config.ModelName
+		return m, nil
+	default:
+		return nil, fmt.Errorf("%w: memory type %q", ErrUnknownChainType, config.Type)
+	}
+}
+
+// SaveMemory returns the config of a memory. A simple memory has no config.
+func (s *Serializer) SaveMemory(m schema.Memory) (*MemoryConfig, error) {
+	switch m := m.(type) {
+	case nil, memory.Simple, *memory.Simple:
+		return nil, nil
+	case *memory.ConversationBuffer:
+		return bufferConfig(MemoryTypeBuffer, m), nil
+	case *memory.ConversationWindowBuffer:
+		config := bufferConfig(MemoryTypeWindowBuffer, &m.ConversationBuffer)
+		config.WindowSize = m.ConversationWindowSize
+		return config, nil
+	case *memory.ConversationTokenBuffer:
+		if m.Tokenizer != nil {
+			return nil, fmt.Errorf("%w: token buffer has a tokenizer", ErrChainNotSerializable)
+		}
+		llm, err := s.ModelName(m.LLM)
+		if err != nil {
+			return nil, err
+		}
+		config := bufferConfig(MemoryTypeTokenBuffer, &m.ConversationBuffer)
+		config.LLM = llm
+		config.ModelName = m.ModelName
+		config.MaxTokenLimit = m.MaxTokenLimit
+		return config, nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported memory type %T", ErrChainNotSerializable, m)
+	}
+}
+
+func bufferConfig(memoryType string, m *memory.ConversationBuffer) *MemoryConfig {
+	return &MemoryConfig{
+		Type:           memoryType,
+		MemoryKey:      m.MemoryKey,
+		InputKey:       m.InputKey,
+		OutputKey:      m.OutputKey,
+		HumanPrefix:    m.HumanPrefix,
+		AIPrefix:       m.AIPrefix,
+		ReturnMessages: m.ReturnMessages,
+	}
+}
+
+func loadLLMChain(s *Serializer, config *ChainConfig) (Chain, error) { //nolint:ireturn
+	llm, err := s.Model(config.LLM)
+	if err != nil {
+		return nil, err
+	}
+	if config.Prompt == nil {
+		return nil, fmt.Errorf("%w: LLM chain has no prompt", ErrChainInitialization)
+	}
+	prompt, err := prompts.LoadPrompt(config.Prompt)
+	if err != nil {
+		return nil, err
+	}
+	chain := NewLLMChain(llm, prompt)
+	if config.OutputKey != "" {
+		chain.OutputKey = config.OutputKey
+	}
+	if chain.Memory, err = s.LoadMemory(config.Memory); err != nil {
+		return nil, err
+	}
+	return chain, nil
+}
+
+func saveLLMChain(s *Serializer, chain Chain) (*ChainConfig, error) {
+	var c *LLMChain
+	switch chain := chain.(type) {
+	case *LLMChain:
+		c = chain
+	case LLMChain:
+		c = &chain
+	default:
+		return nil, nil
+	}
+
+	switch c.OutputParser.(type) {
+	case nil, outputparser.Simple:
+	default:
+		return nil, fmt.Errorf("%w: LLM chain has an output parser %T", ErrChainNotSerializable, c.OutputParser)
+	}
+	llm, err := s.ModelName(c.LLM)
+	if err != nil {
+		return nil, err
+	}
+	prompt, err := prompts.SavePrompt(c.Prompt)
+	if err != nil {
+		return nil, err
+	}
+	memoryConfig, err := s.SaveMemory(c.Memory)
+	if err != nil {
+		return nil, err
+	}
+	return &ChainConfig{LLM: llm, Prompt: prompt, OutputKey: c.OutputKey, Memory: memoryConfig}, nil
+}
+
+func loadStuffDocuments(s *Serializer, config *ChainConfig) (Chain, error) { //nolint:ireturn
+	sub, err := s.LoadChain(config.Chain)
+	if err != nil {
+		return nil, err
+	}
+	llmChain, ok := sub.(*LLMChain)
+	if !ok {
+		return nil, fmt.Errorf("%w: the chain of a stuff documents chain must be an LLM chain, got %T",
+			ErrChainInitialization, sub)
+	}
+	chain := NewStuffDocuments(llmChain)
+	if config.InputKey != "" {
+		chain.InputKey = config.InputKey
+	}
+	if config.DocumentVariableName != "" {
+		chain.DocumentVariableName = config.DocumentVariableName
+	}
+	if config.Separator != "" {
+		chain.Separator = config.Separator
+	}
+	return chain, nil
+}
+
+func saveStuffDocuments(s *Serializer, chain Chain) (*ChainConfig, error) {
+	var c StuffDocuments
+	switch chain := chain.(type) {
+	case StuffDocuments:
+		c = chain
+	case *StuffDocuments:
+		c = *chain
+	default:
+		return nil, nil
+	}
+
+	sub, err := s.SaveChain(c.LLMChain)
+	if err != nil {
+		return nil, err
+	}
+	return &ChainConfig{
+		Chain:                sub,
+		InputKey:             c.InputKey,
+		DocumentVariableName: c.DocumentVariableName,
+		Separator:            c.Separator,
+	}, nil
+}
+
+func loadRetrievalQA(s *Serializer, config *ChainConfig) (Chain, error) { //nolint:ireturn
+	retriever, err := s.Retriever(config.Retriever)
+	if err != nil {
+		return nil, err
+	}
+	combineDocumentsChain, err := s.LoadChain(config.Chain)
+	if err != nil {
+		return nil, err
+	}
+	chain := NewRetrievalQA(combineDocumentsChain, retriever)
+	if config.InputKey != "" {
+		chain.InputKey = config.InputKey
+	}
+	chain.ReturnSourceDocuments = config.ReturnSourceDocuments
+	return chain, nil
+}
+
+func saveRetrievalQA(s *Serializer, chain Chain) (*ChainConfig, error) {
+	var c RetrievalQA
+	switch chain := chain.(type) {
+	case RetrievalQA:
+		c = chain
+	case *RetrievalQA:
+		c = *chain
+	default:
+		return nil, nil
+	}
+
+	retriever, err := s.RetrieverName(c.Retriever)
+	if err != nil {
+		return nil, err
+	}
+	sub, err := s.SaveChain(c.CombineDocumentsChain)
+	if err != nil {
+		return nil, err
+	}
+	return &ChainConfig{
+		Retriever:             retriever,
+		Chain:                 sub,
+		InputKey:              c.InputKey,
+		ReturnSourceDocuments: c.ReturnSourceDocuments,
+	}, nil
+}
+
+func loadSequentialChain(s *Serializer, config *ChainConfig) (Chain, error) { //nolint:ireturn
+	chains := make([]Chain, 0, len(config.Chains))
+	for _, sub := range config.Chains {
+		chain, err := s.LoadChain(sub)
+		if err != nil {
+			return nil, err
+		}
+		chains = append(chains, chain)
+	}
+	m, err := s.LoadMemory(config.Memory)
+	if err != nil {
+		return nil, err
+	}
+	return NewSequentialChain(chains, config.InputKeys, config.OutputKeys, WithSeqChainMemory(m))
+}
+
+func saveSequentialChain(s *Serializer, chain Chain) (*ChainConfig, error) {
+	c, ok := chain.(*SequentialChain)
+	if !ok {
+		return nil, nil
+	}
+
+	config := &ChainConfig{
+		InputKeys:  c.inputKeys,
+		OutputKeys: c.outputKeys,
+		Chains:     make([]*ChainConfig, 0, len(c.chains)),
+	}
+	for _, chain := range c.chains {
+		sub, err := s.SaveChain(chain)
+		if err != nil {
+			return nil, err
+		}
+		config.Chains = append(config.Chains, sub)
+	}
+	var err error
+	if config.Memory, err = s.SaveMemory(c.memory); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
@@ -0,0 +1,177 @@
+package chains
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/memory"
+	"github.com/tmc/langchaingo/prompts"
+	"github.com/tmc/langchaingo/schema"
+	"gopkg.in/yaml.v3"
+)
+
+const _testSequentialConfig = `
+type: sequential_chain
+input_keys: [topic]
+output_keys: [text]
+chains:
+  - type: llm_chain
+    llm: echo
+    output_key: draft
+    prompt:
+      template: "Write about {{.topic}}{{.punctuation}}"
+      input_variables: [topic]
+      partial_variables:
+        punctuation: "!"
+  - type: llm_chain
+    llm: echo
+    prompt:
+      type: chat
+      messages:
+        - type: system
+          template: You polish texts.
+        - type: human
+          template: "Polish: {{.draft}}"
+          input_variables: [draft]
+    memory:
+      type: window_buffer
+      window_size: 3
+      input_key: draft
+`
+
+func TestLoadChain(t *testing.T) {
+	t.Parallel()
+
+	config, err := ParseChainConfig([]byte(_testSequentialConfig))
+	require.NoError(t, err)
+	s := NewSerializer(NewRegistry(), Resources{Models: map[string]llms.Model{"echo": &testLanguageModel{}}})
+	chain, err := s.LoadChain(config)
+	require.NoError(t, err)
+
+	result, err := Call(context.Background(), chain, map[string]any{"topic": "cats"})
+	require.NoError(t, err)
+	assert.Equal(t, "System: You polish texts.\nHuman: Polish: Write about cats!", result["text"])
+
+	second := chain.(*SequentialChain).chains[1].(*LLMChain)
+	require.IsType(t, &memory.ConversationWindowBuffer{}, second.Memory)
+	assert.Equal(t, 3, second.Memory.(*memory.ConversationWindowBuffer).ConversationWindowSize)
+
+	// Saving the loaded chain gives back the config, with the defaults.
+	saved, err := s.SaveChain(chain)
+	require.NoError(t, err)
+	assert.Equal(t, "draft", saved.Chains[0].OutputKey)
+	assert.Equal(t, prompts.TemplateFormatGoTemplate, saved.Chains[0].Prompt.TemplateFormat)
+	assert.Equal(t, "text", saved.Chains[1].OutputKey)
+	assert.Equal(t, &MemoryConfig{
+		Type: MemoryTypeWindowBuffer, MemoryKey: "history", InputKey: "draft",
+		HumanPrefix: "Human", AIPrefix: "AI", WindowSize: 3,
+	}, saved.Chains[1].Memory)
+
+	data, err := yaml.Marshal(saved)
+	require.NoError(t, err)
+	reloaded, err := ParseChainConfig(data)
+	require.NoError(t, err)
+	assert.Equal(t, saved, reloaded)
+}
+
+func TestSaveChain(t *testing.T) {
+	t.Parallel()
+
+	llm := &testLanguageModel{expResult: "foo is 34"}
+	qa := NewRetrievalQA(LoadStuffQA(llm), testRetriever{})
+	qa.ReturnSourceDocuments = true
+	resources := Resources{
+		Models:     map[string]llms.Model{"other": &testLanguageModel{}, "qa": llm},
+		Retrievers: map[string]schema.Retriever{"docs": testRetriever{}},
+	}
+	s := NewSerializer(NewRegistry(), resources)
+
+	config, err := s.SaveChain(qa)
+	require.NoError(t, err)
+	assert.Equal(t, ChainTypeRetrievalQA, config.Type)
+	assert.Equal(t, "docs", config.Retriever)
+	assert.Equal(t, ChainTypeStuffDocuments, config.Chain.Type)
+	assert.Equal(t, "context", config.Chain.DocumentVariableName)
+	assert.Equal(t, ChainTypeLLM, config.Chain.Chain.Type)
+	assert.Equal(t, "qa", config.Chain.Chain.LLM)
+
+	data, err := json.Marshal(config)
+	require.NoError(t, err)
+	parsed, err := ParseChainConfig(data)
+	require.NoError(t, err)
+	loaded, err := s.LoadChain(parsed)
+	require.NoError(t, err)
+	assert.Equal(t, qa, loaded)
+
+	result, err := Call(context.Background(), loaded, map[string]any{"query": "what is foo?"})
+	require.NoError(t, err)
+	assert.Equal(t, "foo is 34", result["text"])
+	assert.Len(t, result["source_documents"], 2)
+}
+
+func TestSerializationErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := ParseChainConfig([]byte("type: llm_chain\nllm_name: echo\n"))
+	require.ErrorContains(t, err, "field llm_name not found")
+
+	s := NewSerializer(NewRegistry(), Resources{})
+	_, err = s.LoadChain(&ChainConfig{Type: "llm_math"})
+	require.ErrorIs(t, err, ErrUnknownChainType)
+
+	_, err = s.LoadChain(&ChainConfig{Type: ChainTypeLLM, LLM: "echo", Prompt: &prompts.PromptConfig{Template: "x"}})
+	require.ErrorIs(t, err, ErrMissingResource)
+
+	llm := &testLanguageModel{}
+	_, err = s.SaveChain(NewLLMChain(llm, prompts.NewPromptTemplate("x", nil)))
+	require.ErrorIs(t, err, ErrMissingResource)
+
+	s = NewSerializer(NewRegistry(), Resources{Models: map[string]llms.Model{"echo": llm}})
+	_, err = s.SaveChain(NewLLMMathChain(llm))
+	require.ErrorIs(t, err, ErrChainNotSerializable)
+
+	prompt := prompts.NewPromptTemplate("{{.date}}", nil)
+	prompt.PartialVariables = map[string]any{"date": func() string { return "today" }}
+	_, err = s.SaveChain(NewLLMChain(llm, prompt))
+	require.ErrorIs(t, err, prompts.ErrPromptNotSerializable)
+}
+
+// keyedChain is a chain of a type registered by the tests, with a setting
+// saved in the params of its config.
+type keyedChain struct {
+	Transform
+	key string
+}
+
+func TestRegisterChainType(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	registry.Register("keyed",
+		func(_ *Serializer, config *ChainConfig) (Chain, error) {
+			key, _ := config.Params["key"].(string)
+			return keyedChain{key: key}, nil
+		},
+		func(_ *Serializer, chain Chain) (*ChainConfig, error) {
+			c, ok := chain.(keyedChain)
+			if !ok {
+				return nil, nil
+			}
+			return &ChainConfig{Params: map[string]any{"key": c.key}}, nil
+		})
+	s := NewSerializer(registry, Resources{})
+
+	config, err := ParseChainConfig([]byte("type: keyed\nparams:\n  key: text\n"))
+	require.NoError(t, err)
+	chain, err := s.LoadChain(config)
+	require.NoError(t, err)
+	assert.Equal(t, keyedChain{key: "text"}, chain)
+
+	saved, err := s.SaveChain(chain)
+	require.NoError(t, err)
+	assert.Equal(t, config, saved)
+}
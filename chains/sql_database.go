@@ -2,8 +2,10 @@ package chains
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/memory"
@@ -38,6 +40,15 @@ const (
 	_sqlChainDefaultInputKeyQuery      = "query"
 	_sqlChainDefaultInputKeyTableNames = "table_names_to_use"
 	_sqlChainDefaultOutputKey          = "result"
+	_sqlChainSQLQueryOutputKey         = "sql_query"
+
+	_sqlChainDefaultQueryTimeout      = 30 * time.Second
+	_sqlChainDefaultMaxRepairAttempts = 2
+)
+
+const (
+	_sqlQueryPrefix  = "\nSQLQuery:"  //nolint:gosec
+	_sqlResultPrefix = "\nSQLResult:" //nolint:gosec
 )
 
 // SQLDatabaseChain is a chain used for interacting with SQL Database.
@@ -46,6 +57,17 @@ type SQLDatabaseChain struct {
 	TopK      int
 	Database  *sqldatabase.SQLDatabase
 	OutputKey string
+
+	// SafeMode only runs queries that are a single SELECT statement, limited
+	// to TopK rows, in a read-only transaction. Queries failing to parse or
+	// run are sent back to the LLM to be fixed, and the query that ran is
+	// returned in the "sql_query" output key.
+	SafeMode bool
+	// QueryTimeout is the timeout of queries run in safe mode.
+	QueryTimeout time.Duration
+	// MaxRepairAttempts is the number of times the LLM is asked to fix a
+	// failing query in safe mode.
+	MaxRepairAttempts int
 }
 
 // NewSQLDatabaseChain creates a new SQLDatabaseChain.
@@ -59,6 +81,9 @@ func NewSQLDatabaseChain(llm llms.Model, topK int, database *sqldatabase.SQLData
 		TopK:      topK,
 		Database:  database,
 		OutputKey: _sqlChainDefaultOutputKey,
+
+		QueryTimeout:      _sqlChainDefaultQueryTimeout,
+		MaxRepairAttempts: _sqlChainDefaultMaxRepairAttempts,
 	}
 }
 
@@ -72,6 +97,7 @@ func NewSQLDatabaseChain(llm llms.Model, topK int, database *sqldatabase.SQLData
 // Outputs
 //
 //	"result" : with the result of the query.
+//	"sql_query" (in safe mode): the query that was run.
 //
 //nolint:all
 func (s SQLDatabaseChain) Call(ctx context.Context, inputs map[string]any, options ...ChainCallOption) (map[string]any, error) {
@@ -95,34 +121,38 @@ func (s SQLDatabaseChain) Call(ctx context.Context, inputs map[string]any, optio
 		return nil, err
 	}
 
-	const (
-		queryPrefixWith = "\nSQLQuery:"  //nolint:gosec
-		stopWord        = "\nSQLResult:" //nolint:gosec
-	)
 	llmInputs := map[string]any{
-		"input":      query + queryPrefixWith,
+		"input":      query + _sqlQueryPrefix,
 		"top_k":      s.TopK,
 		"dialect":    s.Database.Dialect(),
 		"table_info": tableInfos,
 	}
 
-	// Predict sql query
-	opt := append(options, WithStopWords([]string{stopWord})) //nolint:cyclop
-	out, err := Predict(ctx, s.LLMChain, llmInputs, opt...)
-	if err != nil {
-		return nil, err
-	}
-	sqlQuery := strings.TrimSpace(out)
+	opt := append(options, WithStopWords([]string{_sqlResultPrefix})) //nolint:cyclop
+	var sqlQuery, queryResult string
+	if s.SafeMode {
+		sqlQuery, queryResult, err = s.safeQuery(ctx, query, llmInputs, opt)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// Predict sql query
+		out, err := Predict(IntermediateStep(ctx), s.LLMChain, llmInputs, opt...)
+		if err != nil {
+			return nil, err
+		}
+		sqlQuery = strings.TrimSpace(out)
 
-	// Execute sql query
-	queryResult, err := s.Database.Query(ctx, sqlQuery)
-	if err != nil {
-		return nil, err
+		// Execute sql query
+		queryResult, err = s.Database.Query(ctx, sqlQuery)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Generate answer
-	llmInputs["input"] = query + queryPrefixWith + sqlQuery + stopWord + queryResult
-	out, err = Predict(ctx, s.LLMChain, llmInputs, options...)
+	llmInputs["input"] = query + _sqlQueryPrefix + sqlQuery + _sqlResultPrefix + queryResult
+	out, err := Predict(ctx, s.LLMChain, llmInputs, options...)
 	if err != nil {
 		return nil, err
 	}
@@ -134,7 +164,87 @@ func (s SQLDatabaseChain) Call(ctx context.Context, inputs map[string]any, optio
 		out = strings.TrimSpace(strs[1])
 	}
 
-	return map[string]any{s.OutputKey: out}, nil
+	outputs := map[string]any{s.OutputKey: out}
+	if s.SafeMode {
+		outputs[_sqlChainSQLQueryOutputKey] = sqlQuery
+	}
+	return outputs, nil
+}
+
+// safeQuery predicts a query and runs it in safe mode. When the query fails to
+// parse or run, the LLM is given the error to fix the query, up to
+// MaxRepairAttempts times. Queries that aren't read-only are rejected.
+func (s SQLDatabaseChain) safeQuery(ctx context.Context, question string, llmInputs map[string]any, options []ChainCallOption) (string, string, error) { //nolint:lll
+	inputs := make(map[string]any, len(llmInputs))
+	for key, value := range llmInputs {
+		inputs[key] = value
+	}
+
+	for attempt := 0; ; attempt++ {
+		out, err := Predict(IntermediateStep(ctx), s.LLMChain, inputs, options...)
+		if err != nil {
+			return "", "", err
+		}
+		sqlQuery := extractSQL(out)
+
+		limitedQuery, result, err := s.runSafeQuery(ctx, sqlQuery)
+		if err == nil {
+			return limitedQuery, result, nil
+		}
+		if errors.Is(err, sqldatabase.ErrUnsafeQuery) || errors.Is(err, sqldatabase.ErrReadOnlyNotSupported) ||
+			ctx.Err() != nil || attempt >= s.MaxRepairAttempts {
+			return "", "", fmt.Errorf("query %q: %w", sqlQuery, err)
+		}
+
+		inputs["input"] = question + _sqlQueryPrefix + sqlQuery +
+			"\nSQLError: " + err.Error() +
+			"\nThe SQLQuery above failed, write a corrected one." + _sqlQueryPrefix
+	}
+}
+
+// extractSQL returns the query predicted by the LLM, which may be wrapped in a
+// markdown code block.
+func extractSQL(text string) string {
+	text = strings.TrimSpace(text)
+	_, block, ok := strings.Cut(text, "```")
+	if !ok {
+		return text
+	}
+	// Skip the language of the code block, if any.
+	if i := strings.IndexByte(block, '\n'); i >= 0 {
+		lang := strings.TrimSpace(block[:i])
+		if !strings.ContainsAny(lang, " \t(") && !strings.EqualFold(lang, "SELECT") && !strings.EqualFold(lang, "WITH") {
+			block = block[i+1:]
+		}
+	}
+	block, _, _ = strings.Cut(block, "```")
+	return strings.TrimSpace(block)
+}
+
+// runSafeQuery checks that a query is read-only, limits it to TopK rows and
+// runs it in a read-only transaction. It returns the query that was run and
+// its result.
+func (s SQLDatabaseChain) runSafeQuery(ctx context.Context, sqlQuery string) (string, string, error) {
+	var err error
+	if s.TopK > 0 {
+		sqlQuery, err = sqldatabase.LimitReadOnlyQuery(sqlQuery, s.TopK)
+	} else {
+		err = sqldatabase.CheckReadOnlyQuery(sqlQuery)
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	if s.QueryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.QueryTimeout)
+		defer cancel()
+	}
+	result, err := s.Database.QueryReadOnly(ctx, sqlQuery)
+	if err != nil {
+		return "", "", err
+	}
+	return sqlQuery, result, nil
 }
 
 func (s SQLDatabaseChain) GetMemory() schema.Memory { //nolint:ireturn
@@ -146,5 +256,8 @@ func (s SQLDatabaseChain) GetInputKeys() []string {
 }
 
 func (s SQLDatabaseChain) GetOutputKeys() []string {
+	if s.SafeMode {
+		return []string{s.OutputKey, _sqlChainSQLQueryOutputKey}
+	}
 	return []string{s.OutputKey}
 }
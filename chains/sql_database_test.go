@@ -3,12 +3,16 @@ package chains
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/llms/openai"
 	"github.com/tmc/langchaingo/tools/sqldatabase"
 	"github.com/tmc/langchaingo/tools/sqldatabase/mysql"
+	"github.com/tmc/langchaingo/tools/sqldatabase/sqlite3"
 )
 
 func TestSQLDatabaseChain_Call(t *testing.T) {
@@ -45,3 +49,87 @@ func TestSQLDatabaseChain_Call(t *testing.T) {
 
 	t.Log(ret)
 }
+
+// scriptedModel returns its responses in order, and records the prompts.
+type scriptedModel struct {
+	responses []string
+	prompts   []string
+}
+
+func (m *scriptedModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+func (m *scriptedModel) GenerateContent(_ context.Context, mc []llms.MessageContent, _ ...llms.CallOption) (*llms.ContentResponse, error) { //nolint:lll
+	m.prompts = append(m.prompts, mc[0].Parts[0].(llms.TextContent).Text)
+	response := m.responses[0]
+	m.responses = m.responses[1:]
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: response}}}, nil
+}
+
+func newTestSQLite(t *testing.T) *sqldatabase.SQLDatabase {
+	t.Helper()
+
+	engine, err := sqlite3.NewSQLite3(filepath.Join(t.TempDir(), "test.sqlite"))
+	require.NoError(t, err)
+	for _, query := range []string{
+		"CREATE TABLE users (name text)",
+		"INSERT INTO users VALUES ('alice'), ('bob'), ('carol')",
+	} {
+		_, _, err = engine.Query(context.Background(), query)
+		require.NoError(t, err)
+	}
+	db, err := sqldatabase.NewSQLDatabase(engine, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestSQLDatabaseChainSafeMode(t *testing.T) {
+	t.Parallel()
+
+	llm := &scriptedModel{responses: []string{
+		"SELECT name FROM users WHERE",
+		"```sql\nSELECT name FROM users ORDER BY name\n```",
+		"Answer: alice and bob",
+	}}
+	chain := NewSQLDatabaseChain(llm, 2, newTestSQLite(t))
+	chain.SafeMode = true
+
+	result, err := Call(context.Background(), chain, map[string]any{"query": "Who are the users?"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"result":    "alice and bob",
+		"sql_query": "SELECT name FROM users ORDER BY name LIMIT 2",
+	}, result)
+
+	require.Len(t, llm.prompts, 3)
+	assert.Contains(t, llm.prompts[1], "SQLQuery:SELECT name FROM users WHERE\nSQLError: near")
+	assert.Contains(t, llm.prompts[2], "SQLResult:name\nalice\nbob\n")
+	assert.Contains(t, llm.prompts[0], "CREATE TABLE users")
+}
+
+func TestSQLDatabaseChainSafeModeErrors(t *testing.T) {
+	t.Parallel()
+
+	db := newTestSQLite(t)
+
+	// Writes are rejected without asking the LLM to repair them.
+	llm := &scriptedModel{responses: []string{"DELETE FROM users"}}
+	chain := NewSQLDatabaseChain(llm, 2, db)
+	chain.SafeMode = true
+	_, err := Call(context.Background(), chain, map[string]any{"query": "Delete the users"})
+	require.ErrorIs(t, err, sqldatabase.ErrUnsafeQuery)
+	count, err := db.Query(context.Background(), "SELECT count(*) AS n FROM users")
+	require.NoError(t, err)
+	assert.Equal(t, "n\n3\n", count)
+
+	// Queries are repaired a limited number of times.
+	llm = &scriptedModel{responses: []string{"SELECT x FROM users", "SELECT y FROM users"}}
+	chain = NewSQLDatabaseChain(llm, 2, db)
+	chain.SafeMode = true
+	chain.MaxRepairAttempts = 1
+	_, err = Call(context.Background(), chain, map[string]any{"query": "Who are the users?"})
+	require.ErrorContains(t, err, "no such column: y")
+	assert.Len(t, llm.prompts, 2)
+}
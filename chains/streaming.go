@@ -0,0 +1,107 @@
+package chains
+
+import (
+	"context"
+	"reflect"
+)
+
+// StreamEvent is a chunk of the output of an LLM streamed while running a
+// chain.
+type StreamEvent struct {
+	// Path is the names of the chains the chunk was streamed from, from the
+	// chain given to Call to the chain calling the LLM.
+	Path []string
+	// OutputKey is the output key of the chain calling the LLM.
+	OutputKey string
+	// Final reports whether the chunk is part of the final output of the
+	// outermost chain, rather than of an intermediate step such as a condensed
+	// question or the map step of a map reduce chain.
+	Final bool
+	Chunk []byte
+}
+
+// streamStep is the position of a chain call in the chains being run.
+type streamStep struct {
+	path         []string
+	intermediate bool
+}
+
+type streamStepKey struct{}
+
+func getStreamStep(ctx context.Context) streamStep {
+	step, _ := ctx.Value(streamStepKey{}).(streamStep)
+	return step
+}
+
+// withChainPath returns a context for calling c from the chain being run.
+func withChainPath(ctx context.Context, c Chain) context.Context {
+	step := getStreamStep(ctx)
+	path := make([]string, len(step.path), len(step.path)+1)
+	copy(path, step.path)
+	step.path = append(path, chainName(c))
+	return context.WithValue(ctx, streamStepKey{}, step)
+}
+
+// IntermediateStep returns a context for calling a chain whose output is an
+// intermediate step of the chain being run, and not part of its final output.
+// The chunks streamed by the chain and the chains it calls aren't final.
+func IntermediateStep(ctx context.Context) context.Context {
+	step := getStreamStep(ctx)
+	step.intermediate = true
+	return context.WithValue(ctx, streamStepKey{}, step)
+}
+
+// intermediateUnless returns a context for calling a chain that is an
+// intermediate step, unless it has one of outputKeys.
+func intermediateUnless(ctx context.Context, c Chain, outputKeys []string) context.Context {
+	for _, key := range c.GetOutputKeys() {
+		for _, outputKey := range outputKeys {
+			if key == outputKey {
+				return ctx
+			}
+		}
+	}
+	return IntermediateStep(ctx)
+}
+
+func chainName(c Chain) string {
+	t := reflect.TypeOf(c)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// streamingFunc returns the function streaming the chunks of an LLM called by
+// a chain returning its output in outputKey, or nil if nothing is streamed.
+func (o *chainCallOption) streamingFunc(ctx context.Context, outputKey string) func(context.Context, []byte) error {
+	streamingFunc := o.StreamingFunc
+	if streamingFunc == nil && o.CallbackHandler != nil {
+		streamingFunc = func(ctx context.Context, chunk []byte) error {
+			o.CallbackHandler.HandleStreamingFunc(ctx, chunk)
+			return nil
+		}
+	}
+	step := getStreamStep(ctx)
+	if (streamingFunc == nil && o.StreamingEventFunc == nil) || (o.FinalOutputOnly && step.intermediate) {
+		return nil
+	}
+
+	return func(ctx context.Context, chunk []byte) error {
+		if o.StreamingEventFunc != nil {
+			err := o.StreamingEventFunc(ctx, StreamEvent{
+				Path:      step.path,
+				OutputKey: outputKey,
+				Final:     !step.intermediate,
+				Chunk:     chunk,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if streamingFunc != nil {
+			return streamingFunc(ctx, chunk)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,123 @@
+package chains
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/prompts"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// streamingModel streams its result word by word.
+type streamingModel struct {
+	result string
+}
+
+func (m streamingModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+func (m streamingModel) GenerateContent(ctx context.Context, _ []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) { //nolint:lll
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.StreamingFunc != nil {
+		for _, word := range strings.SplitAfter(m.result, " ") {
+			if err := opts.StreamingFunc(ctx, []byte(word)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: m.result}}}, nil
+}
+
+// eventRecorder records the streamed events.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []StreamEvent
+}
+
+func (r *eventRecorder) record(_ context.Context, event StreamEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *eventRecorder) text(final bool) string {
+	var sb strings.Builder
+	for _, event := range r.events {
+		if event.Final == final {
+			sb.Write(event.Chunk)
+		}
+	}
+	return sb.String()
+}
+
+func TestStreamingEvents(t *testing.T) {
+	t.Parallel()
+
+	qa := NewRetrievalQA(LoadStuffQA(streamingModel{result: "foo is 34"}), testRetriever{})
+	recorder := &eventRecorder{}
+	answer, err := Run(context.Background(), qa, "what is foo?", WithStreamingEventFunc(recorder.record))
+	require.NoError(t, err)
+
+	assert.Equal(t, "foo is 34", answer)
+	assert.Equal(t, answer, recorder.text(true))
+	require.Len(t, recorder.events, 3)
+	assert.Equal(t, []string{"RetrievalQA", "StuffDocuments", "LLMChain"}, recorder.events[0].Path)
+	assert.Equal(t, "text", recorder.events[0].OutputKey)
+}
+
+func TestStreamingFinalOutputOnly(t *testing.T) {
+	t.Parallel()
+
+	draft := NewLLMChain(streamingModel{result: "a rough draft"}, prompts.NewPromptTemplate("{{.topic}}", []string{"topic"}))
+	draft.OutputKey = "draft"
+	polish := NewLLMChain(streamingModel{result: "the final text"}, prompts.NewPromptTemplate("{{.draft}}", []string{"draft"}))
+	polish.OutputKey = "text"
+	seq, err := NewSequentialChain([]Chain{draft, polish}, []string{"topic"}, []string{"text"})
+	require.NoError(t, err)
+
+	recorder := &eventRecorder{}
+	_, err = Call(context.Background(), seq, map[string]any{"topic": "x"}, WithStreamingEventFunc(recorder.record))
+	require.NoError(t, err)
+	assert.Equal(t, "a rough draft", recorder.text(false))
+	assert.Equal(t, "the final text", recorder.text(true))
+	assert.Equal(t, []string{"SequentialChain", "LLMChain"}, recorder.events[0].Path)
+	assert.Equal(t, "draft", recorder.events[0].OutputKey)
+
+	var streamed strings.Builder
+	_, err = Call(context.Background(), seq, map[string]any{"topic": "x"},
+		WithFinalOutputOnly(),
+		WithStreamingFunc(func(_ context.Context, chunk []byte) error {
+			streamed.Write(chunk)
+			return nil
+		}))
+	require.NoError(t, err)
+	assert.Equal(t, "the final text", streamed.String())
+}
+
+func TestStreamingMapReduce(t *testing.T) {
+	t.Parallel()
+
+	mapChain := NewLLMChain(streamingModel{result: "summary"}, prompts.NewPromptTemplate("{{.context}}", []string{"context"}))
+	reduceChain := NewStuffDocuments(NewLLMChain(streamingModel{result: "all summaries"},
+		prompts.NewPromptTemplate("{{.context}}", []string{"context"})))
+	c := NewMapReduceDocuments(mapChain, reduceChain)
+
+	recorder := &eventRecorder{}
+	docs := []schema.Document{{PageContent: "foo"}, {PageContent: "bar"}}
+	_, err := Call(context.Background(), c, map[string]any{"input_documents": docs},
+		WithStreamingEventFunc(recorder.record), WithFinalOutputOnly())
+	require.NoError(t, err)
+	require.Len(t, recorder.events, 2)
+	assert.Equal(t, "all summaries", recorder.text(true))
+	assert.Equal(t, []string{"MapReduceDocuments", "StuffDocuments", "LLMChain"}, recorder.events[0].Path)
+}
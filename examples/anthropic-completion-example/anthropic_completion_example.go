@@ -11,12 +11,12 @@ import (
 
 func main() {
 	llm, err := anthropic.New()
-	// note: You would include anthropic.WithModel("claude-2") to use the claude-2 model.
+	// note: You would include anthropic.WithModel("claude-3-opus-20240229") to use the claude-3 opus model.
 	if err != nil {
 		log.Fatal(err)
 	}
 	ctx := context.Background()
-	completion, err := llms.GenerateFromSinglePrompt(ctx, llm, "Human: Who was the first man to walk on the moon?\nAssistant:",
+	completion, err := llms.GenerateFromSinglePrompt(ctx, llm, "Who was the first man to walk on the moon?",
 		llms.WithTemperature(0.8),
 		llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
 			fmt.Print(string(chunk))
@@ -4,7 +4,10 @@
 // and/or pass in the schema in []byte format.
 package jsonschema
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 type DataType string
 
@@ -23,17 +26,50 @@ const (
 type Definition struct {
 	// Type specifies the data type of the schema.
 	Type DataType `json:"type,omitempty"`
+	// Nullable allows null in addition to values of Type. It is encoded as a
+	// list of types, e.g. ["string", "null"].
+	Nullable bool `json:"-"`
 	// Description is the description of the schema.
 	Description string `json:"description,omitempty"`
 	// Enum is used to restrict a value to a fixed set of values. It must be an array with at least
 	// one element, where each element is unique. You will probably only use this with strings.
 	Enum []string `json:"enum,omitempty"`
+	// Format is the format of a string, e.g. "date-time", "email" or "uri".
+	Format string `json:"format,omitempty"`
+	// Pattern is a regular expression a string must match.
+	Pattern string `json:"pattern,omitempty"`
+	// Minimum is the minimum value of a number, if set.
+	Minimum *float64 `json:"minimum,omitempty"`
+	// Maximum is the maximum value of a number, if set.
+	Maximum *float64 `json:"maximum,omitempty"`
+	// Default is the value used when the value is omitted. It isn't used in
+	// validation.
+	Default any `json:"default,omitempty"`
 	// Properties describes the properties of an object, if the schema type is Object.
 	Properties map[string]Definition `json:"properties"`
 	// Required specifies which properties are required, if the schema type is Object.
 	Required []string `json:"required,omitempty"`
+	// AdditionalProperties controls the properties of an object that aren't
+	// in Properties. It is either a bool, which allows or forbids them, or a
+	// Definition they must match. If nil, they are allowed.
+	AdditionalProperties any `json:"additionalProperties,omitempty"`
 	// Items specifies which data type an array contains, if the schema type is Array.
 	Items *Definition `json:"items,omitempty"`
+	// AnyOf lists definitions of which a value must match at least one.
+	AnyOf []Definition `json:"anyOf,omitempty"`
+	// OneOf lists definitions of which a value must match exactly one.
+	OneOf []Definition `json:"oneOf,omitempty"`
+	// Ref is a reference to a definition of Defs in the root definition, e.g.
+	// "#/$defs/address", or to the root definition itself with "#".
+	Ref string `json:"$ref,omitempty"`
+	// Defs holds definitions referenced with Ref. It is only used in the root
+	// definition.
+	Defs map[string]Definition `json:"$defs,omitempty"`
+}
+
+// Float returns a pointer to f, to set Minimum or Maximum.
+func Float(f float64) *float64 {
+	return &f
 }
 
 func (d Definition) MarshalJSON() ([]byte, error) {
@@ -41,9 +77,73 @@ func (d Definition) MarshalJSON() ([]byte, error) {
 		d.Properties = make(map[string]Definition)
 	}
 	type Alias Definition
+	var typ any
+	switch {
+	case d.Type != "" && d.Nullable:
+		typ = []DataType{d.Type, Null}
+	case d.Type != "":
+		typ = d.Type
+	}
 	return json.Marshal(struct {
+		Type any `json:"type,omitempty"`
 		Alias
 	}{
+		Type:  typ,
 		Alias: (Alias)(d),
 	})
 }
+
+func (d *Definition) UnmarshalJSON(data []byte) error {
+	type Alias Definition
+	aux := struct {
+		Type                 json.RawMessage `json:"type,omitempty"`
+		AdditionalProperties json.RawMessage `json:"additionalProperties,omitempty"`
+		*Alias
+	}{
+		Alias: (*Alias)(d),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	d.Type, d.Nullable = "", false
+	if len(aux.Type) > 0 {
+		var types []DataType
+		if aux.Type[0] == '[' {
+			if err := json.Unmarshal(aux.Type, &types); err != nil {
+				return err
+			}
+		} else {
+			var t DataType
+			if err := json.Unmarshal(aux.Type, &t); err != nil {
+				return err
+			}
+			types = []DataType{t}
+		}
+		for _, t := range types {
+			switch {
+			case t == Null && len(types) > 1:
+				d.Nullable = true
+			case d.Type == "":
+				d.Type = t
+			default:
+				return fmt.Errorf("unsupported list of types %s", aux.Type) //nolint:goerr113
+			}
+		}
+	}
+
+	d.AdditionalProperties = nil
+	if len(aux.AdditionalProperties) > 0 {
+		var allowed bool
+		if err := json.Unmarshal(aux.AdditionalProperties, &allowed); err == nil {
+			d.AdditionalProperties = allowed
+			return nil
+		}
+		var def Definition
+		if err := json.Unmarshal(aux.AdditionalProperties, &def); err != nil {
+			return err
+		}
+		d.AdditionalProperties = def
+	}
+	return nil
+}
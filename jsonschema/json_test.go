@@ -5,6 +5,8 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/tmc/langchaingo/jsonschema"
 )
 
@@ -212,3 +214,45 @@ func structToMap(t *testing.T, v any) map[string]any {
 	}
 	return got
 }
+
+func TestDefinition_UnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	data := `{
+		"type": "object",
+		"properties": {
+			"name": {"type": ["string", "null"], "pattern": "^[a-z]+$", "default": "bob"},
+			"age": {"type": "integer", "minimum": 0, "maximum": 150},
+			"home": {"$ref": "#/$defs/address"},
+			"id": {"anyOf": [{"type": "string", "format": "uuid"}, {"type": "integer"}]}
+		},
+		"additionalProperties": {"type": "string"},
+		"$defs": {
+			"address": {"type": "object", "additionalProperties": false}
+		}
+	}`
+	var def jsonschema.Definition
+	require.NoError(t, json.Unmarshal([]byte(data), &def))
+
+	name := def.Properties["name"]
+	assert.Equal(t, jsonschema.String, name.Type)
+	assert.True(t, name.Nullable)
+	assert.Equal(t, "bob", name.Default)
+	assert.Equal(t, jsonschema.Float(150), def.Properties["age"].Maximum)
+	assert.Equal(t, "#/$defs/address", def.Properties["home"].Ref)
+	assert.Len(t, def.Properties["id"].AnyOf, 2)
+	assert.Equal(t, jsonschema.Definition{Type: jsonschema.String}, def.AdditionalProperties)
+	assert.Equal(t, false, def.Defs["address"].AdditionalProperties)
+
+	// The definition survives a round trip.
+	out, err := json.Marshal(def)
+	require.NoError(t, err)
+	var again jsonschema.Definition
+	require.NoError(t, json.Unmarshal(out, &again))
+	outAgain, err := json.Marshal(again)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(out), string(outAgain))
+	assert.Contains(t, string(out), `"type":["string","null"]`)
+
+	require.Error(t, json.Unmarshal([]byte(`{"type":["string","integer"]}`), &def))
+}
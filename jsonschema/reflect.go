@@ -0,0 +1,137 @@
+package jsonschema
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Reflect derives the definition of the JSON encoding of v, which is
+// typically a struct or a pointer to a struct, from its type.
+//
+// Struct fields are named after their json tag, and are required unless the
+// tag has the omitempty option. A field's description is taken from its
+// description tag, and a comma-separated list of allowed values from its enum
+// tag:
+//
+//	type Weather struct {
+//		City  string `json:"city" description:"The name of the city"`
+//		Units string `json:"units,omitempty" enum:"celsius,fahrenheit"`
+//	}
+func Reflect(v any) (*Definition, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil, fmt.Errorf("cannot reflect nil") //nolint:goerr113
+	}
+	d, err := reflectType(t, map[reflect.Type]bool{})
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// reflectType returns the definition of the JSON encoding of values of type
+// t. Types being reflected are in seen, to detect recursive types.
+func reflectType(t reflect.Type, seen map[reflect.Type]bool) (Definition, error) { //nolint:cyclop
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == reflect.TypeOf(time.Time{}):
+		return Definition{Type: String}, nil
+	case reflect.PointerTo(t).Implements(reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()):
+		return Definition{Type: String}, nil
+	}
+
+	switch t.Kind() { //nolint:exhaustive
+	case reflect.Bool:
+		return Definition{Type: Boolean}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Definition{Type: Integer}, nil
+	case reflect.Float32, reflect.Float64:
+		return Definition{Type: Number}, nil
+	case reflect.String:
+		return Definition{Type: String}, nil
+	case reflect.Slice, reflect.Array:
+		// Byte slices are encoded as base64 strings.
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return Definition{Type: String}, nil
+		}
+		items, err := reflectType(t.Elem(), seen)
+		if err != nil {
+			return Definition{}, err
+		}
+		return Definition{Type: Array, Items: &items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return Definition{}, fmt.Errorf("unsupported map key type %v", t.Key()) //nolint:goerr113
+		}
+		return Definition{Type: Object}, nil
+	case reflect.Interface:
+		// Any value is allowed.
+		return Definition{}, nil
+	case reflect.Struct:
+		if seen[t] {
+			return Definition{}, fmt.Errorf("recursive type %v is not supported", t) //nolint:goerr113
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		d := Definition{Type: Object, Properties: map[string]Definition{}}
+		if err := reflectFields(t, &d, seen); err != nil {
+			return Definition{}, err
+		}
+		return d, nil
+	default:
+		return Definition{}, fmt.Errorf("unsupported type %v", t) //nolint:goerr113
+	}
+}
+
+// reflectFields adds the fields of the struct type t to d. Fields of embedded
+// structs are promoted, as with encoding/json.
+func reflectFields(t reflect.Type, d *Definition, seen map[reflect.Type]bool) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			if err := reflectFields(ft, d, seen); err != nil {
+				return err
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		fd, err := reflectType(f.Type, seen)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		fd.Description = f.Tag.Get("description")
+		if enum := f.Tag.Get("enum"); enum != "" {
+			fd.Enum = strings.Split(enum, ",")
+		}
+
+		d.Properties[name] = fd
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			d.Required = append(d.Required, name)
+		}
+	}
+	return nil
+}
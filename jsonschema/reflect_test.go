@@ -0,0 +1,66 @@
+package jsonschema_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/jsonschema"
+)
+
+type Address struct {
+	City string `json:"city"`
+}
+
+type person struct {
+	Address
+	Name     string            `json:"name" description:"Full name"`
+	Age      int               `json:"age,omitempty"`
+	Role     string            `json:"role" enum:"admin,user"`
+	Tags     []string          `json:"tags"`
+	Born     time.Time         `json:"born"`
+	Manager  *person           `json:"-"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Untagged bool
+	internal string
+}
+
+func TestReflect(t *testing.T) {
+	t.Parallel()
+
+	def, err := jsonschema.Reflect(&person{})
+	require.NoError(t, err)
+
+	want := &jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"city":     {Type: jsonschema.String},
+			"name":     {Type: jsonschema.String, Description: "Full name"},
+			"age":      {Type: jsonschema.Integer},
+			"role":     {Type: jsonschema.String, Enum: []string{"admin", "user"}},
+			"tags":     {Type: jsonschema.Array, Items: &jsonschema.Definition{Type: jsonschema.String}},
+			"born":     {Type: jsonschema.String},
+			"labels":   {Type: jsonschema.Object},
+			"Untagged": {Type: jsonschema.Boolean},
+		},
+		Required: []string{"city", "name", "role", "tags", "born", "Untagged"},
+	}
+	assert.Equal(t, want, def)
+}
+
+func TestReflectErrors(t *testing.T) {
+	t.Parallel()
+
+	type node struct {
+		Next *node `json:"next"`
+	}
+	_, err := jsonschema.Reflect(node{})
+	require.ErrorContains(t, err, "recursive")
+
+	_, err = jsonschema.Reflect(make(chan int))
+	require.Error(t, err)
+
+	_, err = jsonschema.Reflect(nil)
+	require.Error(t, err)
+}
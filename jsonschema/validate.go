@@ -0,0 +1,332 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ValidationError describes a part of a value that doesn't match a
+// definition.
+type ValidationError struct {
+	// Path locates the part of the value, e.g. "$.items[0].name".
+	Path string
+	// Message describes the mismatch.
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// ValidationErrors is the list of errors found when validating a value.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Validate checks that value matches the definition. The value is either
+// JSON text, as a json.RawMessage or []byte, or a Go value, which is checked
+// through its JSON encoding. The returned error, if any, is of type
+// ValidationErrors unless value can't be encoded or decoded. For example, the
+// arguments of a tool call are checked against the tool's parameters with:
+//
+//	err := params.Validate(json.RawMessage(call.FunctionCall.Arguments))
+//
+// References are resolved against the Defs of d. Keywords next to a Ref are
+// ignored, and unknown formats are accepted.
+func (d *Definition) Validate(value any) error {
+	v, err := normalize(value)
+	if err != nil {
+		return err
+	}
+	val := &validator{root: d}
+	val.validate(d, v, "$")
+	if len(val.errs) > 0 {
+		return val.errs
+	}
+	return nil
+}
+
+// normalize returns the value decoded from the JSON encoding of value, with
+// numbers as json.Number.
+func normalize(value any) (any, error) {
+	var data []byte
+	switch v := value.(type) {
+	case json.RawMessage:
+		data = v
+	case []byte:
+		data = v
+	default:
+		var err error
+		if data, err = json.Marshal(value); err != nil {
+			return nil, err
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return v, nil
+}
+
+// validator validates values against the definitions of a root definition.
+type validator struct {
+	root *Definition
+	errs ValidationErrors
+}
+
+func (val *validator) fail(path, format string, args ...any) {
+	val.errs = append(val.errs, &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (val *validator) validate(d *Definition, v any, path string) { //nolint:cyclop
+	d, err := val.resolve(d)
+	if err != nil {
+		val.fail(path, "%v", err)
+		return
+	}
+
+	if v == nil && d.Nullable {
+		return
+	}
+	if d.Type != "" && !hasType(v, d.Type) {
+		val.fail(path, "expected %s, got %s", d.Type, typeOf(v))
+		return
+	}
+	if len(d.Enum) > 0 && !inEnum(v, d.Enum) {
+		val.fail(path, "must be one of %s", strings.Join(quoteAll(d.Enum), ", "))
+	}
+	if len(d.AnyOf) > 0 && val.matches(d.AnyOf, v, path) == 0 {
+		val.fail(path, "doesn't match any schema of anyOf")
+	}
+	if len(d.OneOf) > 0 {
+		if n := val.matches(d.OneOf, v, path); n != 1 {
+			val.fail(path, "matches %d schemas of oneOf instead of 1", n)
+		}
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		val.validateObject(d, v, path)
+	case []any:
+		if d.Items != nil {
+			for i, item := range v {
+				val.validate(d.Items, item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			val.fail(path, "invalid number %s", v)
+			return
+		}
+		if d.Minimum != nil && f < *d.Minimum {
+			val.fail(path, "must be at least %v", *d.Minimum)
+		}
+		if d.Maximum != nil && f > *d.Maximum {
+			val.fail(path, "must be at most %v", *d.Maximum)
+		}
+	case string:
+		if d.Pattern != "" {
+			re, err := regexp.Compile(d.Pattern)
+			if err != nil {
+				val.fail(path, "invalid pattern %q: %v", d.Pattern, err)
+			} else if !re.MatchString(v) {
+				val.fail(path, "must match pattern %q", d.Pattern)
+			}
+		}
+		if d.Format != "" && !hasFormat(v, d.Format) {
+			val.fail(path, "must be a valid %s", d.Format)
+		}
+	}
+}
+
+func (val *validator) validateObject(d *Definition, v map[string]any, path string) {
+	for _, name := range d.Required {
+		if _, ok := v[name]; !ok {
+			val.fail(path, "missing required property %q", name)
+		}
+	}
+
+	allowed, additional := d.additionalProperties()
+	names := make([]string, 0, len(v))
+	for name := range v {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p, ok := d.Properties[name]
+		switch {
+		case ok:
+			val.validate(&p, v[name], path+"."+name)
+		case !allowed:
+			val.fail(path, "unexpected property %q", name)
+		case additional != nil:
+			val.validate(additional, v[name], path+"."+name)
+		}
+	}
+}
+
+// matches returns how many of defs v matches.
+func (val *validator) matches(defs []Definition, v any, path string) int {
+	n := 0
+	for i := range defs {
+		sub := &validator{root: val.root}
+		sub.validate(&defs[i], v, path)
+		if len(sub.errs) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// resolve follows the references of d to the definition they refer to.
+func (val *validator) resolve(d *Definition) (*Definition, error) {
+	seen := map[string]bool{}
+	for d.Ref != "" {
+		if seen[d.Ref] {
+			return nil, fmt.Errorf("circular reference %q", d.Ref) //nolint:goerr113
+		}
+		seen[d.Ref] = true
+
+		switch {
+		case d.Ref == "#":
+			d = val.root
+		case strings.HasPrefix(d.Ref, "#/$defs/"):
+			name := strings.NewReplacer("~1", "/", "~0", "~").Replace(strings.TrimPrefix(d.Ref, "#/$defs/"))
+			def, ok := val.root.Defs[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown reference %q", d.Ref) //nolint:goerr113
+			}
+			d = &def
+		default:
+			return nil, fmt.Errorf("unsupported reference %q", d.Ref) //nolint:goerr113
+		}
+	}
+	return d, nil
+}
+
+// additionalProperties reports whether properties not in d.Properties are
+// allowed, and the definition they must match, if any.
+func (d *Definition) additionalProperties() (bool, *Definition) {
+	switch ap := d.AdditionalProperties.(type) {
+	case bool:
+		return ap, nil
+	case Definition:
+		return true, &ap
+	case *Definition:
+		return true, ap
+	}
+	return true, nil
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// hasFormat reports whether s has the given format. Unknown formats are
+// accepted.
+func hasFormat(s, format string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	case "date":
+		_, err := time.Parse(time.DateOnly, s)
+		return err == nil
+	case "time":
+		_, err := time.Parse("15:04:05Z07:00", s)
+		return err == nil
+	case "email":
+		addr, err := mail.ParseAddress(s)
+		return err == nil && addr.Address == s
+	case "uri":
+		u, err := url.Parse(s)
+		return err == nil && u.IsAbs()
+	case "uuid":
+		return uuidPattern.MatchString(s)
+	case "ipv4":
+		ip := net.ParseIP(s)
+		return ip != nil && ip.To4() != nil && !strings.Contains(s, ":")
+	case "ipv6":
+		ip := net.ParseIP(s)
+		return ip != nil && strings.Contains(s, ":")
+	}
+	return true
+}
+
+func hasType(v any, t DataType) bool {
+	switch t {
+	case Integer:
+		n, ok := v.(json.Number)
+		if !ok {
+			return false
+		}
+		if _, err := n.Int64(); err == nil {
+			return true
+		}
+		// Integers may be written with a fraction or an exponent, e.g. 1.0.
+		f, err := n.Float64()
+		return err == nil && f == float64(int64(f))
+	case Object, Number, String, Array, Null, Boolean:
+		return typeOf(v) == t
+	}
+	return true
+}
+
+func typeOf(v any) DataType {
+	switch v.(type) {
+	case map[string]any:
+		return Object
+	case []any:
+		return Array
+	case string:
+		return String
+	case json.Number:
+		return Number
+	case bool:
+		return Boolean
+	default:
+		return Null
+	}
+}
+
+func inEnum(v any, enum []string) bool {
+	s, ok := v.(string)
+	if !ok {
+		// Enums of other types are listed by their JSON encoding.
+		data, err := json.Marshal(v)
+		if err != nil {
+			return false
+		}
+		s = string(data)
+	}
+	for _, e := range enum {
+		if s == e {
+			return true
+		}
+	}
+	return false
+}
+
+func quoteAll(ss []string) []string {
+	quoted := make([]string, 0, len(ss))
+	for _, s := range ss {
+		quoted = append(quoted, fmt.Sprintf("%q", s))
+	}
+	return quoted
+}
@@ -0,0 +1,154 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/jsonschema"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	def := &jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"name":  {Type: jsonschema.String},
+			"count": {Type: jsonschema.Integer},
+			"kind":  {Type: jsonschema.String, Enum: []string{"a", "b"}},
+			"items": {Type: jsonschema.Array, Items: &jsonschema.Definition{Type: jsonschema.Number}},
+		},
+		Required: []string{"name", "count"},
+	}
+
+	tests := []struct {
+		name  string
+		value any
+		want  []string
+	}{
+		{"valid", json.RawMessage(`{"name":"x","count":1.0,"kind":"a","items":[1,2.5]}`), nil},
+		{"valid go value", map[string]any{"name": "x", "count": 3}, nil},
+		{"missing", json.RawMessage(`{"name":"x"}`), []string{`$: missing required property "count"`}},
+		{
+			"wrong types",
+			json.RawMessage(`{"name":1,"count":1.5,"kind":"c","items":[1,"2"]}`),
+			[]string{
+				"$.count: expected integer, got number",
+				`$.items[1]: expected number, got string`,
+				`$.kind: must be one of "a", "b"`,
+				"$.name: expected string, got number",
+			},
+		},
+		{"not an object", json.RawMessage(`[]`), []string{"$: expected object, got array"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := def.Validate(tt.value)
+			if tt.want == nil {
+				require.NoError(t, err)
+				return
+			}
+			var errs jsonschema.ValidationErrors
+			require.ErrorAs(t, err, &errs)
+			got := make([]string, 0, len(errs))
+			for _, e := range errs {
+				got = append(got, e.Error())
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	require.Error(t, def.Validate(json.RawMessage(`{`)))
+}
+
+func TestValidateKeywords(t *testing.T) {
+	t.Parallel()
+
+	def := &jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"name":  {Type: jsonschema.String, Nullable: true, Pattern: "^[a-z]+$"},
+			"age":   {Type: jsonschema.Integer, Minimum: jsonschema.Float(0), Maximum: jsonschema.Float(150)},
+			"email": {Type: jsonschema.String, Format: "email"},
+			"home":  {Ref: "#/$defs/address"},
+			"id": {AnyOf: []jsonschema.Definition{
+				{Type: jsonschema.String, Format: "uuid"},
+				{Type: jsonschema.Integer},
+			}},
+			"size": {OneOf: []jsonschema.Definition{
+				{Type: jsonschema.Number, Minimum: jsonschema.Float(0)},
+				{Type: jsonschema.Integer},
+			}},
+			"parent": {Ref: "#"},
+		},
+		AdditionalProperties: false,
+		Defs: map[string]jsonschema.Definition{
+			"address": {
+				Type:                 jsonschema.Object,
+				Required:             []string{"city"},
+				AdditionalProperties: jsonschema.Definition{Type: jsonschema.String},
+			},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{
+			"valid",
+			`{"name":null,"age":30,"email":"a@b.c","home":{"city":"Paris","zip":"75001"},
+			  "id":"123e4567-e89b-12d3-a456-426614174000","size":1.5,"parent":{"name":"bob"}}`,
+			nil,
+		},
+		{
+			"invalid",
+			`{"name":"Bob","age":200,"email":"bob","home":{"zip":1},"id":true,"size":-1.5,"parent":{"age":-1},"x":1}`,
+			[]string{
+				"$.age: must be at most 150",
+				"$.email: must be a valid email",
+				`$.home: missing required property "city"`,
+				"$.home.zip: expected string, got number",
+				"$.id: doesn't match any schema of anyOf",
+				`$.name: must match pattern "^[a-z]+$"`,
+				"$.parent.age: must be at least 0",
+				"$.size: matches 0 schemas of oneOf instead of 1",
+				`$: unexpected property "x"`,
+			},
+		},
+		{"one of both", `{"size":2}`, []string{"$.size: matches 2 schemas of oneOf instead of 1"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := def.Validate(json.RawMessage(tt.value))
+			if tt.want == nil {
+				require.NoError(t, err)
+				return
+			}
+			var errs jsonschema.ValidationErrors
+			require.ErrorAs(t, err, &errs)
+			got := make([]string, 0, len(errs))
+			for _, e := range errs {
+				got = append(got, e.Error())
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidateBadReference(t *testing.T) {
+	t.Parallel()
+
+	def := &jsonschema.Definition{Ref: "#/$defs/missing"}
+	require.ErrorContains(t, def.Validate(1), `unknown reference "#/$defs/missing"`)
+
+	def = &jsonschema.Definition{Ref: "#"}
+	require.ErrorContains(t, def.Validate(1), "circular reference")
+}
@@ -2,12 +2,17 @@ package anthropic
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
+	"strings"
 
 	"github.com/tmc/langchaingo/callbacks"
 	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/llms/anthropic/internal/anthropicclient"
+	"github.com/tmc/langchaingo/schema"
 )
 
 var (
@@ -15,6 +20,14 @@ var (
 	ErrMissingToken  = errors.New("missing the Anthropic API key, set it in the ANTHROPIC_API_KEY environment variable")
 
 	ErrUnexpectedResponseLength = errors.New("unexpected length of response")
+	ErrUnsupportedRole          = errors.New("role not supported")
+	ErrUnsupportedContentType   = errors.New("content type not supported")
+	ErrUnsupportedToolType      = errors.New("tool type not supported")
+)
+
+const (
+	roleUser      = "user"
+	roleAssistant = "assistant"
 )
 
 type LLM struct {
@@ -22,7 +35,10 @@ type LLM struct {
 	client           *anthropicclient.Client
 }
 
-var _ llms.Model = (*LLM)(nil)
+var (
+	_ llms.Model          = (*LLM)(nil)
+	_ llms.StreamingModel = (*LLM)(nil)
+)
 
 // New returns a new Anthropic LLM.
 func New(opts ...Option) (*LLM, error) {
@@ -45,7 +61,15 @@ func newClient(opts ...Option) (*anthropicclient.Client, error) {
 		return nil, ErrMissingToken
 	}
 
-	return anthropicclient.New(options.token, options.model)
+	var clientOpts []anthropicclient.Option
+	if options.baseURL != "" {
+		clientOpts = append(clientOpts, anthropicclient.WithBaseURL(options.baseURL))
+	}
+	if options.httpClient != nil {
+		clientOpts = append(clientOpts, anthropicclient.WithHTTPClient(options.httpClient))
+	}
+
+	return anthropicclient.New(options.token, options.model, clientOpts...)
 }
 
 // Call requests a completion for the given prompt.
@@ -65,18 +89,15 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 		opt(opts)
 	}
 
-	// Assume we get a single text message
-	msg0 := messages[0]
-	part := msg0.Parts[0]
-	result, err := o.client.CreateCompletion(ctx, &anthropicclient.CompletionRequest{
-		Model:         opts.Model,
-		Prompt:        part.(llms.TextContent).Text,
-		MaxTokens:     opts.MaxTokens,
-		StopWords:     opts.StopWords,
-		Temperature:   opts.Temperature,
-		TopP:          opts.TopP,
-		StreamingFunc: opts.StreamingFunc,
-	})
+	req, err := newMessageRequest(messages, opts)
+	if err != nil {
+		if o.CallbacksHandler != nil {
+			o.CallbacksHandler.HandleLLMError(ctx, err)
+		}
+		return nil, err
+	}
+
+	result, err := o.client.CreateMessage(ctx, req)
 	if err != nil {
 		if o.CallbacksHandler != nil {
 			o.CallbacksHandler.HandleLLMError(ctx, err)
@@ -84,12 +105,225 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 		return nil, err
 	}
 
+	choice := &llms.ContentChoice{
+		StopReason: result.StopReason,
+	}
+	for _, c := range result.Content {
+		switch c.Type {
+		case anthropicclient.ContentTypeText:
+			choice.Content += c.Text
+		case anthropicclient.ContentTypeToolUse:
+			choice.ToolCalls = append(choice.ToolCalls, llms.ToolCall{
+				ID:   c.ID,
+				Type: llms.ToolTypeFunction,
+				FunctionCall: &schema.FunctionCall{
+					Name:      c.Name,
+					Arguments: string(c.Input),
+				},
+			})
+		}
+	}
+	if len(choice.ToolCalls) > 0 {
+		choice.FuncCall = choice.ToolCalls[0].FunctionCall
+	}
+
 	resp := &llms.ContentResponse{
-		Choices: []*llms.ContentChoice{
-			{
-				Content: result.Text,
-			},
-		},
+		Choices: []*llms.ContentChoice{choice},
+		Usage:   llms.NewUsage(result.Usage.InputTokens, result.Usage.OutputTokens),
+		Model:   result.Model,
+	}
+	if o.CallbacksHandler != nil {
+		o.CallbacksHandler.HandleLLMGenerateContentEnd(ctx, resp)
 	}
 	return resp, nil
 }
+
+// GenerateContentStream implements the StreamingModel interface.
+func (o *LLM) GenerateContentStream(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentStream, error) { //nolint:lll
+	return llms.GenerateContentEventStream(ctx, o, messages, options...)
+}
+
+// newMessageRequest returns the Messages API request for messages and opts.
+func newMessageRequest(messages []llms.MessageContent, opts *llms.CallOptions) (*anthropicclient.MessageRequest, error) { //nolint:lll
+	chatMessages, systemPrompt, err := processMessages(messages)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &anthropicclient.MessageRequest{
+		Model:              opts.Model,
+		Messages:           chatMessages,
+		System:             systemPrompt,
+		MaxTokens:          opts.MaxTokens,
+		StopWords:          opts.StopWords,
+		Temperature:        opts.Temperature,
+		TopP:               opts.TopP,
+		StreamingFunc:      opts.StreamingFunc,
+		StreamingEventFunc: opts.StreamingEventFunc,
+	}
+	// A "none" tool choice is expressed by not sending tools at all, and the
+	// API rejects a tool choice without tools.
+	if opts.ToolChoice != llms.ToolChoiceNone && len(opts.Tools) > 0 {
+		req.Tools, err = convertTools(opts.Tools)
+		if err != nil {
+			return nil, err
+		}
+		req.ToolChoice = convertToolChoice(opts.ToolChoice)
+	}
+	return req, nil
+}
+
+// processMessages converts messages to the Messages API format. System
+// messages are returned separately since they are sent as a top-level prompt.
+func processMessages(messages []llms.MessageContent) ([]anthropicclient.ChatMessage, string, error) {
+	chatMessages := make([]anthropicclient.ChatMessage, 0, len(messages))
+	var systemPrompt string
+	for _, mc := range messages {
+		content, err := convertParts(mc.Parts)
+		if err != nil {
+			return nil, "", err
+		}
+
+		var role string
+		switch mc.Role {
+		case schema.ChatMessageTypeSystem:
+			for _, c := range content {
+				if c.Type != anthropicclient.ContentTypeText {
+					return nil, "", fmt.Errorf("%w: %v in system message", ErrUnsupportedContentType, c.Type)
+				}
+				if systemPrompt != "" {
+					systemPrompt += "\n\n"
+				}
+				systemPrompt += c.Text
+			}
+			continue
+		case schema.ChatMessageTypeAI:
+			role = roleAssistant
+		case schema.ChatMessageTypeHuman, schema.ChatMessageTypeGeneric, schema.ChatMessageTypeTool:
+			role = roleUser
+		case schema.ChatMessageTypeFunction:
+			fallthrough
+		default:
+			return nil, "", fmt.Errorf("%w: %v", ErrUnsupportedRole, mc.Role)
+		}
+
+		// The API expects user and assistant turns to alternate, so
+		// consecutive messages with the same role are merged.
+		if n := len(chatMessages); n > 0 && chatMessages[n-1].Role == role {
+			chatMessages[n-1].Content = append(chatMessages[n-1].Content, content...)
+			continue
+		}
+		chatMessages = append(chatMessages, anthropicclient.ChatMessage{Role: role, Content: content})
+	}
+	return chatMessages, systemPrompt, nil
+}
+
+func convertParts(parts []llms.ContentPart) ([]anthropicclient.Content, error) {
+	content := make([]anthropicclient.Content, 0, len(parts))
+	for _, part := range parts {
+		switch p := part.(type) {
+		case llms.TextContent:
+			content = append(content, anthropicclient.Content{
+				Type: anthropicclient.ContentTypeText,
+				Text: p.Text,
+			})
+		case llms.BinaryContent:
+			content = append(content, imageContent(p.MIMEType, base64.StdEncoding.EncodeToString(p.Data)))
+		case llms.ImageURLContent:
+			// The API only accepts inline images, so only data URLs can be sent.
+			mediaType, data, ok := parseDataURL(p.URL)
+			if !ok {
+				return nil, fmt.Errorf("%w: image URLs other than base64 data URLs", ErrUnsupportedContentType)
+			}
+			content = append(content, imageContent(mediaType, data))
+		case llms.ToolCall:
+			if p.FunctionCall == nil {
+				return nil, fmt.Errorf("%w: tool call %v has no function call", ErrUnsupportedContentType, p.ID)
+			}
+			input := json.RawMessage(p.FunctionCall.Arguments)
+			if len(input) == 0 {
+				input = json.RawMessage("{}")
+			}
+			content = append(content, anthropicclient.Content{
+				Type:  anthropicclient.ContentTypeToolUse,
+				ID:    p.ID,
+				Name:  p.FunctionCall.Name,
+				Input: input,
+			})
+		case llms.ToolCallResponse:
+			content = append(content, anthropicclient.Content{
+				Type:      anthropicclient.ContentTypeToolResult,
+				ToolUseID: p.ToolCallID,
+				Content:   p.Content,
+			})
+		default:
+			return nil, fmt.Errorf("%w: %T", ErrUnsupportedContentType, part)
+		}
+	}
+	return content, nil
+}
+
+func imageContent(mediaType, data string) anthropicclient.Content {
+	return anthropicclient.Content{
+		Type: anthropicclient.ContentTypeImage,
+		Source: &anthropicclient.ImageSource{
+			Type:      "base64",
+			MediaType: mediaType,
+			Data:      data,
+		},
+	}
+}
+
+// parseDataURL returns the media type and base64 data of a URL of the form
+// "data:image/png;base64,...".
+func parseDataURL(url string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(url, "data:")
+	if !ok {
+		return "", "", false
+	}
+	header, data, ok := strings.Cut(rest, ",")
+	if !ok {
+		return "", "", false
+	}
+	mediaType, ok := strings.CutSuffix(header, ";base64")
+	if !ok || mediaType == "" {
+		return "", "", false
+	}
+	return mediaType, data, true
+}
+
+func convertTools(tools []llms.Tool) ([]anthropicclient.Tool, error) {
+	converted := make([]anthropicclient.Tool, 0, len(tools))
+	for _, t := range tools {
+		if t.Type != llms.ToolTypeFunction || t.Function == nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnsupportedToolType, t.Type)
+		}
+		converted = append(converted, anthropicclient.Tool{
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+			InputSchema: t.Function.Parameters,
+		})
+	}
+	return converted, nil
+}
+
+func convertToolChoice(choice any) *anthropicclient.ToolChoice {
+	switch c := choice.(type) {
+	case string:
+		switch c {
+		case llms.ToolChoiceAuto:
+			return &anthropicclient.ToolChoice{Type: "auto"}
+		case llms.ToolChoiceRequired:
+			return &anthropicclient.ToolChoice{Type: "any"}
+		}
+	case llms.ToolChoice:
+		if c.Function != nil {
+			return &anthropicclient.ToolChoice{Type: "tool", Name: c.Function.Name}
+		}
+	case *llms.ToolChoice:
+		if c != nil {
+			return convertToolChoice(*c)
+		}
+	}
+	return nil
+}
@@ -1,12 +1,18 @@
 package anthropic
 
+import (
+	"github.com/tmc/langchaingo/llms/anthropic/internal/anthropicclient"
+)
+
 const (
 	tokenEnvVarName = "ANTHROPIC_API_KEY" //nolint:gosec
 )
 
 type options struct {
-	token string
-	model string
+	token      string
+	model      string
+	baseURL    string
+	httpClient anthropicclient.Doer
 }
 
 type Option func(*options)
@@ -25,3 +31,18 @@ func WithModel(model string) Option {
 		opts.model = model
 	}
 }
+
+// WithBaseURL passes the Anthropic base URL to the client. If not set, the
+// public Anthropic API is used.
+func WithBaseURL(baseURL string) Option {
+	return func(opts *options) {
+		opts.baseURL = baseURL
+	}
+}
+
+// WithHTTPClient allows setting a custom HTTP client.
+func WithHTTPClient(client anthropicclient.Doer) Option {
+	return func(opts *options) {
+		opts.httpClient = client
+	}
+}
@@ -0,0 +1,229 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+func TestGenerateContentWithTools(t *testing.T) {
+	t.Parallel()
+
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/messages", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","stop_reason":"tool_use",
+			"content":[
+				{"type":"text","text":"Checking both."},
+				{"type":"tool_use","id":"toolu_1","name":"weather","input":{"city":"Paris"}},
+				{"type":"tool_use","id":"toolu_2","name":"weather","input":{"city":"Rome"}}
+			],
+			"usage":{"input_tokens":10,"output_tokens":20}}`))
+	}))
+	defer srv.Close()
+
+	llm, err := New(WithToken("test"), WithBaseURL(srv.URL))
+	require.NoError(t, err)
+
+	messages := []llms.MessageContent{
+		llms.TextParts(schema.ChatMessageTypeSystem, "Be brief."),
+		llms.TextParts(schema.ChatMessageTypeHuman, "Weather in Oslo?"),
+		{
+			Role: schema.ChatMessageTypeAI,
+			Parts: []llms.ContentPart{llms.ToolCall{
+				ID: "toolu_0", Type: "function",
+				FunctionCall: &schema.FunctionCall{Name: "weather", Arguments: `{"city":"Oslo"}`},
+			}},
+		},
+		{
+			Role:  schema.ChatMessageTypeTool,
+			Parts: []llms.ContentPart{llms.ToolCallResponse{ToolCallID: "toolu_0", Content: "cold"}},
+		},
+		llms.TextParts(schema.ChatMessageTypeHuman, "And Paris and Rome?"),
+	}
+	tools := []llms.Tool{{
+		Type: "function",
+		Function: &llms.FunctionDefinition{
+			Name:       "weather",
+			Parameters: map[string]any{"type": "object"},
+		},
+	}}
+
+	resp, err := llm.GenerateContent(context.Background(), messages,
+		llms.WithTools(tools), llms.WithToolChoice(llms.ToolChoiceRequired))
+	require.NoError(t, err)
+
+	require.Len(t, resp.Choices, 1)
+	c := resp.Choices[0]
+	assert.Equal(t, "Checking both.", c.Content)
+	assert.Equal(t, "tool_use", c.StopReason)
+	require.Len(t, c.ToolCalls, 2)
+	assert.Equal(t, "toolu_2", c.ToolCalls[1].ID)
+	assert.JSONEq(t, `{"city":"Rome"}`, c.ToolCalls[1].FunctionCall.Arguments)
+	assert.Equal(t, &llms.Usage{PromptTokens: 10, CompletionTokens: 20, TotalTokens: 30}, resp.Usage)
+
+	assert.Equal(t, "Be brief.", got["system"])
+	assert.Equal(t, map[string]any{"type": "any"}, got["tool_choice"])
+	sent, ok := got["messages"].([]any)
+	require.True(t, ok)
+	// The tool result and the following human message are merged into a
+	// single user turn.
+	require.Len(t, sent, 3)
+	last := sent[2].(map[string]any)
+	assert.Equal(t, "user", last["role"])
+	assert.Len(t, last["content"], 2)
+	assert.Equal(t, "tool_result", last["content"].([]any)[0].(map[string]any)["type"])
+}
+
+func TestGenerateContentMultiTurn(t *testing.T) {
+	t.Parallel()
+
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "test", r.Header.Get("x-api-key"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","stop_reason":"end_turn",
+			"content":[{"type":"text","text":"A parrot."}],
+			"usage":{"input_tokens":30,"output_tokens":4}}`))
+	}))
+	defer srv.Close()
+
+	llm, err := New(WithToken("test"), WithBaseURL(srv.URL), WithModel("claude-3-haiku-20240307"))
+	require.NoError(t, err)
+
+	messages := []llms.MessageContent{
+		llms.TextParts(schema.ChatMessageTypeSystem, "You describe images."),
+		llms.TextParts(schema.ChatMessageTypeSystem, "Be brief."),
+		llms.TextParts(schema.ChatMessageTypeHuman, "Hello"),
+		llms.TextParts(schema.ChatMessageTypeAI, "Hi, send me an image."),
+		{
+			Role: schema.ChatMessageTypeHuman,
+			Parts: []llms.ContentPart{
+				llms.BinaryPart("image/png", []byte("png")),
+				llms.ImageURLPart("data:image/jpeg;base64,anBn"),
+				llms.TextPart("What are these?"),
+			},
+		},
+	}
+	resp, err := llm.GenerateContent(context.Background(), messages, llms.WithMaxTokens(100),
+		llms.WithToolChoice(llms.ToolChoiceAuto))
+	require.NoError(t, err)
+
+	require.Len(t, resp.Choices, 1)
+	assert.Equal(t, "A parrot.", resp.Choices[0].Content)
+	assert.Equal(t, "end_turn", resp.Choices[0].StopReason)
+	assert.Equal(t, &llms.Usage{PromptTokens: 30, CompletionTokens: 4, TotalTokens: 34}, resp.Usage)
+
+	assert.Equal(t, "claude-3-haiku-20240307", got["model"])
+	assert.Equal(t, "You describe images.\n\nBe brief.", got["system"])
+	assert.EqualValues(t, 100, got["max_tokens"])
+	assert.NotContains(t, got, "stream")
+	// A tool choice without tools isn't sent.
+	assert.NotContains(t, got, "tool_choice")
+	sent, ok := got["messages"].([]any)
+	require.True(t, ok)
+	require.Len(t, sent, 3)
+	assert.Equal(t, "user", sent[0].(map[string]any)["role"])
+	assert.Equal(t, "assistant", sent[1].(map[string]any)["role"])
+	parts := sent[2].(map[string]any)["content"].([]any)
+	require.Len(t, parts, 3)
+	assert.Equal(t, map[string]any{
+		"type":   "image",
+		"source": map[string]any{"type": "base64", "media_type": "image/png", "data": "cG5n"},
+	}, parts[0])
+	assert.Equal(t, map[string]any{
+		"type":   "image",
+		"source": map[string]any{"type": "base64", "media_type": "image/jpeg", "data": "anBn"},
+	}, parts[1])
+
+	handler := &errorHandler{}
+	llm.CallbacksHandler = handler
+	_, err = llm.GenerateContent(context.Background(), []llms.MessageContent{{
+		Role:  schema.ChatMessageTypeHuman,
+		Parts: []llms.ContentPart{llms.ImageURLPart("https://example.com/parrot.png")},
+	}})
+	require.ErrorIs(t, err, ErrUnsupportedContentType)
+	assert.Equal(t, []error{err}, handler.errs)
+}
+
+// errorHandler records the errors it is called with.
+type errorHandler struct {
+	callbacks.SimpleHandler
+	errs []error
+}
+
+func (h *errorHandler) HandleLLMError(_ context.Context, err error) {
+	h.errs = append(h.errs, err)
+}
+
+func TestGenerateContentStream(t *testing.T) {
+	t.Parallel()
+
+	fixture, err := os.ReadFile(filepath.Join("testdata", "messages_stream.txt"))
+	require.NoError(t, err)
+
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write(fixture)
+	}))
+	defer srv.Close()
+
+	llm, err := New(WithToken("test"), WithBaseURL(srv.URL))
+	require.NoError(t, err)
+
+	stream, err := llm.GenerateContentStream(context.Background(),
+		[]llms.MessageContent{llms.TextParts(schema.ChatMessageTypeHuman, "Weather in Paris?")})
+	require.NoError(t, err)
+
+	var text, args string
+	for stream.Next() {
+		e := stream.Event()
+		switch e.Type {
+		case llms.StreamEventText:
+			text += e.Text
+		case llms.StreamEventToolCall:
+			args += e.ToolCall.Arguments
+		}
+	}
+	require.NoError(t, stream.Err())
+	assert.Equal(t, true, got["stream"])
+
+	assert.Equal(t, "Let me check.", text)
+	assert.Equal(t, `{"city": "Paris"}`, args)
+	c := stream.Response().Choices[0]
+	assert.Equal(t, "Let me check.", c.Content)
+	assert.Equal(t, "tool_use", c.StopReason)
+	require.Len(t, c.ToolCalls, 1)
+	assert.Equal(t, "toolu_01", c.ToolCalls[0].ID)
+	assert.Equal(t, "weather", c.ToolCalls[0].FunctionCall.Name)
+	assert.Equal(t, &llms.Usage{PromptTokens: 25, CompletionTokens: 42, TotalTokens: 67}, stream.Usage())
+
+	// The raw streaming func sees the text chunks, and the final response
+	// carries the assembled tool call.
+	var chunks []string
+	resp, err := llm.GenerateContent(context.Background(),
+		[]llms.MessageContent{llms.TextParts(schema.ChatMessageTypeHuman, "Weather in Paris?")},
+		llms.WithStreamingFunc(func(_ context.Context, chunk []byte) error {
+			chunks = append(chunks, string(chunk))
+			return nil
+		}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Let me", " check."}, chunks)
+	assert.JSONEq(t, `{"city":"Paris"}`, resp.Choices[0].ToolCalls[0].FunctionCall.Arguments)
+	assert.Equal(t, &llms.Usage{PromptTokens: 25, CompletionTokens: 42, TotalTokens: 67}, resp.Usage)
+}
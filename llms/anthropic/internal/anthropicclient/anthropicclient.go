@@ -1,9 +1,9 @@
 package anthropicclient
 
 import (
-	"context"
 	"errors"
 	"net/http"
+	"strings"
 )
 
 const (
@@ -39,6 +39,15 @@ func WithHTTPClient(client Doer) Option {
 	}
 }
 
+// WithBaseURL allows overriding the base URL of the API.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) error {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+
+		return nil
+	}
+}
+
 // New returns a new Anthropic client.
 func New(token string, model string, opts ...Option) (*Client, error) {
 	c := &Client{
@@ -57,44 +66,11 @@ func New(token string, model string, opts ...Option) (*Client, error) {
 	return c, nil
 }
 
-// CompletionRequest is a request to create a completion.
-type CompletionRequest struct {
-	Model       string   `json:"model"`
-	Prompt      string   `json:"prompt"`
-	Temperature float64  `json:"temperature,omitempty"`
-	MaxTokens   int      `json:"max_tokens_to_sample,omitempty"`
-	StopWords   []string `json:"stop_sequences,omitempty"`
-	TopP        float64  `json:"top_p,omitempty"`
-	Stream      bool     `json:"stream,omitempty"`
-
-	// StreamingFunc is a function to be called for each chunk of a streaming response.
-	// Return an error to stop streaming early.
-	StreamingFunc func(ctx context.Context, chunk []byte) error `json:"-"`
-}
-
-// Completion is a completion.
-type Completion struct {
-	Text string `json:"text"`
-}
-
-// CreateCompletion creates a completion.
-func (c *Client) CreateCompletion(ctx context.Context, r *CompletionRequest) (*Completion, error) {
-	resp, err := c.createCompletion(ctx, &completionPayload{
-		Model:         r.Model,
-		Prompt:        r.Prompt,
-		Temperature:   r.Temperature,
-		MaxTokens:     r.MaxTokens,
-		StopWords:     r.StopWords,
-		TopP:          r.TopP,
-		Stream:        r.Stream,
-		StreamingFunc: r.StreamingFunc,
-	})
-	if err != nil {
-		return nil, err
-	}
-	return &Completion{
-		Text: resp.Completion,
-	}, nil
+type errorMessage struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error"`
 }
 
 func (c *Client) setHeaders(req *http.Request) {
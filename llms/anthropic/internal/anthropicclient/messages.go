@@ -0,0 +1,356 @@
+package anthropicclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+const (
+	defaultMessageModel = "claude-3-haiku-20240307"
+
+	// Content block types.
+	ContentTypeText       = "text"
+	ContentTypeImage      = "image"
+	ContentTypeToolUse    = "tool_use"
+	ContentTypeToolResult = "tool_result"
+)
+
+// ChatMessage is a message in a Messages API request.
+type ChatMessage struct {
+	// Role is either "user" or "assistant".
+	Role    string    `json:"role"`
+	Content []Content `json:"content"`
+}
+
+// Content is a single content block of a message. The fields that are set
+// depend on the Type of the block.
+type Content struct {
+	Type string `json:"type"`
+
+	// Text is set for text blocks.
+	Text string `json:"text,omitempty"`
+
+	// Source is set for image blocks.
+	Source *ImageSource `json:"source,omitempty"`
+
+	// ID, Name and Input are set for tool_use blocks.
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+
+	// ToolUseID and Content are set for tool_result blocks.
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Content   string `json:"content,omitempty"`
+}
+
+// ImageSource is the data of an image block. Type is "base64" and Data
+// holds the base64 encoded image.
+type ImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
+}
+
+// Tool is a tool the model may use.
+type Tool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	InputSchema any    `json:"input_schema"`
+}
+
+// ToolChoice controls how the model uses tools. Type is one of "auto", "any"
+// or "tool"; Name is set when Type is "tool".
+type ToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+}
+
+// MessageRequest is a request to the Messages API.
+type MessageRequest struct {
+	Model       string        `json:"model"`
+	Messages    []ChatMessage `json:"messages"`
+	System      string        `json:"system,omitempty"`
+	Temperature float64       `json:"temperature,omitempty"`
+	MaxTokens   int           `json:"max_tokens"`
+	StopWords   []string      `json:"stop_sequences,omitempty"`
+	TopP        float64       `json:"top_p,omitempty"`
+	Tools       []Tool        `json:"tools,omitempty"`
+	ToolChoice  *ToolChoice   `json:"tool_choice,omitempty"`
+	Stream      bool          `json:"stream,omitempty"`
+
+	// StreamingFunc is a function to be called for each chunk of text of a
+	// streaming response. Return an error to stop streaming early.
+	StreamingFunc func(ctx context.Context, chunk []byte) error `json:"-"`
+
+	// StreamingEventFunc is a function to be called for each typed event of a
+	// streaming response. Return an error to stop streaming early.
+	StreamingEventFunc llms.StreamEventFunc `json:"-"`
+}
+
+// MessageUsage is the token usage reported for a Messages API call.
+type MessageUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// MessageResponsePayload is the response of the Messages API.
+type MessageResponsePayload struct {
+	ID           string       `json:"id"`
+	Type         string       `json:"type"`
+	Role         string       `json:"role"`
+	Content      []Content    `json:"content"`
+	Model        string       `json:"model"`
+	StopReason   string       `json:"stop_reason"`
+	StopSequence string       `json:"stop_sequence"`
+	Usage        MessageUsage `json:"usage"`
+}
+
+// CreateMessage creates a message using the Messages API.
+func (c *Client) CreateMessage(ctx context.Context, r *MessageRequest) (*MessageResponsePayload, error) {
+	c.setMessageDefaults(r)
+	return c.createMessage(ctx, r)
+}
+
+func (c *Client) setMessageDefaults(payload *MessageRequest) {
+	if payload.MaxTokens == 0 {
+		payload.MaxTokens = 256
+	}
+
+	if len(payload.StopWords) == 0 {
+		payload.StopWords = nil
+	}
+
+	switch {
+	// Prefer the model specified in the payload.
+	case payload.Model != "":
+
+	// If no model is set in the payload, take the one specified in the client.
+	case c.Model != "":
+		payload.Model = c.Model
+	// Fallback: use the default model
+	default:
+		payload.Model = defaultMessageModel
+	}
+	if payload.StreamingFunc != nil || payload.StreamingEventFunc != nil {
+		payload.Stream = true
+	}
+}
+
+func (c *Client) createMessage(ctx context.Context, payload *MessageRequest) (*MessageResponsePayload, error) {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal payload: %w", err)
+	}
+
+	if c.baseURL == "" {
+		c.baseURL = defaultBaseURL
+	}
+
+	url := fmt.Sprintf("%s/messages", c.baseURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payloadBytes))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	c.setHeaders(req)
+
+	r, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode != http.StatusOK {
+		// No need to check the error here: if it fails, we'll just return the
+		// status code.
+		var errResp errorMessage
+		_ = json.NewDecoder(r.Body).Decode(&errResp)
+		return nil, llms.NewHTTPError(r, errResp.Error.Message)
+	}
+	if payload.Stream {
+		return parseStreamingMessageResponse(ctx, r, payload)
+	}
+
+	var response MessageResponsePayload
+	if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("parse response: %w", err)
+	}
+
+	return &response, nil
+}
+
+// streamEvent is an event of a streaming Messages API response. The fields
+// that are set depend on its Type.
+type streamEvent struct {
+	Type string `json:"type"`
+
+	// Message is set for message_start events.
+	Message *MessageResponsePayload `json:"message"`
+
+	// Index is set for content_block_* events, ContentBlock for
+	// content_block_start events.
+	Index        int      `json:"index"`
+	ContentBlock *Content `json:"content_block"`
+
+	// Delta is set for content_block_delta and message_delta events.
+	Delta struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta"`
+
+	// Usage is set for message_delta events.
+	Usage *MessageUsage `json:"usage"`
+
+	// Error is set for error events.
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func parseStreamingMessageResponse(ctx context.Context, r *http.Response, payload *MessageRequest) (*MessageResponsePayload, error) { //nolint:lll
+	s := &messageStream{payload: payload, response: &MessageResponsePayload{}}
+	scanner := bufio.NewScanner(r.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// Only the data lines matter: they repeat the type of their event.
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		var event streamEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &event); err != nil {
+			return nil, fmt.Errorf("failed to decode stream payload: %w", err)
+		}
+		if event.Type == "error" && event.Error != nil {
+			return nil, fmt.Errorf("stream error: %s: %s", event.Error.Type, event.Error.Message) //nolint:goerr113
+		}
+		if err := s.process(ctx, &event); err != nil {
+			return nil, fmt.Errorf("streaming func returned an error: %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("issue scanning response: %w", err)
+	}
+	return s.response, nil
+}
+
+// messageStream assembles the response of a streaming Messages API call.
+type messageStream struct {
+	payload  *MessageRequest
+	response *MessageResponsePayload
+	// toolIndexes maps the indexes of tool_use blocks to their position
+	// among the tool calls of the response.
+	toolIndexes map[int]int
+	// inputs accumulates the JSON input of tool_use blocks, by block index.
+	inputs map[int]*strings.Builder
+}
+
+// process adds a streamed event to the response, and passes it on to the
+// streaming functions of the payload.
+func (s *messageStream) process(ctx context.Context, event *streamEvent) error { //nolint:cyclop
+	switch event.Type {
+	case "message_start":
+		if event.Message != nil {
+			content := s.response.Content
+			*s.response = *event.Message
+			s.response.Content = content
+		}
+
+	case "content_block_start":
+		if event.ContentBlock == nil {
+			return nil
+		}
+		for len(s.response.Content) <= event.Index {
+			s.response.Content = append(s.response.Content, Content{})
+		}
+		block := *event.ContentBlock
+		block.Input = nil
+		s.response.Content[event.Index] = block
+		if block.Type != ContentTypeToolUse {
+			return s.emitText(ctx, block.Text)
+		}
+		if s.toolIndexes == nil {
+			s.toolIndexes = map[int]int{}
+			s.inputs = map[int]*strings.Builder{}
+		}
+		s.toolIndexes[event.Index] = len(s.toolIndexes)
+		s.inputs[event.Index] = &strings.Builder{}
+		return s.emit(ctx, llms.StreamEvent{Type: llms.StreamEventToolCall, ToolCall: &llms.ToolCallDelta{
+			Index: s.toolIndexes[event.Index],
+			ID:    block.ID,
+			Type:  "function",
+			Name:  block.Name,
+		}})
+
+	case "content_block_delta":
+		if event.Index >= len(s.response.Content) {
+			return nil
+		}
+		switch event.Delta.Type {
+		case "text_delta":
+			s.response.Content[event.Index].Text += event.Delta.Text
+			return s.emitText(ctx, event.Delta.Text)
+		case "input_json_delta":
+			if input, ok := s.inputs[event.Index]; ok {
+				input.WriteString(event.Delta.PartialJSON)
+				return s.emit(ctx, llms.StreamEvent{Type: llms.StreamEventToolCall, ToolCall: &llms.ToolCallDelta{
+					Index:     s.toolIndexes[event.Index],
+					Arguments: event.Delta.PartialJSON,
+				}})
+			}
+		}
+
+	case "content_block_stop":
+		if input, ok := s.inputs[event.Index]; ok && event.Index < len(s.response.Content) {
+			s.response.Content[event.Index].Input = json.RawMessage(input.String())
+			if input.Len() == 0 {
+				s.response.Content[event.Index].Input = json.RawMessage("{}")
+			}
+		}
+
+	case "message_delta":
+		if event.Usage != nil {
+			s.response.Usage.OutputTokens = event.Usage.OutputTokens
+		}
+		if event.Delta.StopReason != "" {
+			s.response.StopReason = event.Delta.StopReason
+			return s.emit(ctx, llms.StreamEvent{Type: llms.StreamEventFinish, StopReason: event.Delta.StopReason})
+		}
+
+	case "message_stop":
+		usage := s.response.Usage
+		return s.emit(ctx, llms.StreamEvent{
+			Type:  llms.StreamEventUsage,
+			Usage: llms.NewUsage(usage.InputTokens, usage.OutputTokens),
+		})
+	}
+	return nil
+}
+
+func (s *messageStream) emitText(ctx context.Context, text string) error {
+	if text == "" {
+		return nil
+	}
+	if s.payload.StreamingFunc != nil {
+		if err := s.payload.StreamingFunc(ctx, []byte(text)); err != nil {
+			return err
+		}
+	}
+	return s.emit(ctx, llms.StreamEvent{Type: llms.StreamEventText, Text: text})
+}
+
+func (s *messageStream) emit(ctx context.Context, event llms.StreamEvent) error {
+	if s.payload.StreamingEventFunc == nil {
+		return nil
+	}
+	return s.payload.StreamingEventFunc(ctx, event)
+}
@@ -0,0 +1,511 @@
+// Package batch runs large numbers of requests to a model as offline jobs.
+// Models implementing Provider receive the requests through the batch
+// endpoint of their provider, which is cheaper and has higher limits than
+// live calls; other models are called concurrently, within a rate limit.
+// Jobs fall back to live calls when the backend of a provider turns out to
+// have no batch endpoint.
+//
+// The state of jobs is persisted in a Store, so a job interrupted by a crash
+// is resumed by running it again with the same ID:
+//
+//	store, err := cache.NewDisk("jobs", 0)
+//	...
+//	runner := batch.New(llm, batch.WithStore(store))
+//	results, err := runner.Run(ctx, "nightly-2024-06-01", requests)
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+	"golang.org/x/time/rate"
+)
+
+var (
+	// ErrBatchFailed is returned when the provider rejects a batch as a whole,
+	// e.g. because it is invalid.
+	ErrBatchFailed = errors.New("batch failed")
+	// ErrNoResult is the error of requests for which the provider returned no
+	// result, e.g. because the batch expired before they were processed.
+	ErrNoResult = errors.New("no result for the request")
+	// ErrDuplicateID is returned when several requests of a job have the same
+	// ID.
+	ErrDuplicateID = errors.New("duplicate request ID")
+	// ErrNotSupported is returned by providers whose backend has no batch
+	// endpoint. Jobs are then sent as live calls.
+	ErrNotSupported = errors.New("batches not supported")
+)
+
+// Request is a request of a job.
+type Request struct {
+	// ID identifies the request, and its result. It must be unique within a
+	// job, and identify the same request when a job is resumed.
+	ID string
+	// Messages are the messages sent to the model.
+	Messages []llms.MessageContent
+	// Options are the options of the call. Streaming options are ignored.
+	Options []llms.CallOption
+}
+
+// Result is the result of a request.
+type Result struct {
+	// ID is the ID of the request.
+	ID string
+	// Response is the response of the model, if the request succeeded.
+	Response *llms.ContentResponse
+	// Err is the reason the request failed, if it did.
+	Err error
+}
+
+// State is the state of a batch.
+type State string
+
+const (
+	// StateInProgress is the state of batches being processed.
+	StateInProgress State = "in_progress"
+	// StateCompleted is the state of batches whose requests were processed.
+	StateCompleted State = "completed"
+	// StateFailed is the state of batches rejected by the provider.
+	StateFailed State = "failed"
+	// StateExpired is the state of batches that weren't processed in time.
+	// Some of their requests may have results.
+	StateExpired State = "expired"
+	// StateCanceled is the state of canceled batches. Some of their requests
+	// may have results.
+	StateCanceled State = "canceled"
+)
+
+// Done reports whether a batch in this state won't make further progress.
+func (s State) Done() bool {
+	return s != StateInProgress
+}
+
+// Status is the progress of a batch.
+type Status struct {
+	State State
+	// Total is the number of requests of the batch.
+	Total int
+	// Completed and Failed are the numbers of requests processed so far.
+	Completed int
+	Failed    int
+	// Message explains why a batch failed.
+	Message string
+}
+
+// Provider is implemented by models whose provider has a batch endpoint.
+type Provider interface {
+	// SubmitBatch submits requests as a batch, and returns its ID.
+	SubmitBatch(ctx context.Context, requests []Request) (string, error)
+	// BatchStatus returns the status of a batch.
+	BatchStatus(ctx context.Context, id string) (Status, error)
+	// BatchResults returns the results of the requests a batch processed.
+	BatchResults(ctx context.Context, id string) ([]Result, error)
+}
+
+// Supporter is implemented by providers whose batch endpoint depends on their
+// backend. Jobs are sent as live calls when SupportsBatches returns false.
+type Supporter interface {
+	SupportsBatches() bool
+}
+
+// Splitter is implemented by providers limiting the size of batches. Jobs
+// over the limits are submitted as several batches.
+type Splitter interface {
+	// BatchLimits returns the maximum number of requests of a batch and its
+	// maximum size in bytes. Zero means no limit.
+	BatchLimits() (maxRequests, maxBytes int)
+	// BatchRequestSize returns the size of a request in a batch, in bytes.
+	BatchRequestSize(req Request) (int, error)
+}
+
+// Runner runs jobs with a model.
+type Runner struct {
+	model llms.Model
+	opts  options
+}
+
+// New returns a runner of jobs calling model.
+func New(model llms.Model, opts ...Option) *Runner {
+	o := options{
+		pollInterval: defaultPollInterval,
+		concurrency:  defaultConcurrency,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.store == nil {
+		o.store = newMemoryStore()
+	}
+	if o.concurrency < 1 {
+		o.concurrency = 1
+	}
+	return &Runner{model: model, opts: o}
+}
+
+// jobState is the persisted state of a job. The responses of the requests
+// are persisted separately, as they complete.
+type jobState struct {
+	// Batches are the batches submitted to the provider, until their results
+	// are collected.
+	Batches []submittedBatch `json:"batches,omitempty"`
+}
+
+// submittedBatch is a batch of a job submitted to the provider.
+type submittedBatch struct {
+	ID string `json:"id"`
+	// Requests are the IDs of the requests of the batch.
+	Requests []string `json:"requests"`
+}
+
+// Run runs requests as the job with the given ID, and returns their results
+// in the same order. Requests that fail have a result with an error; the
+// error returned is about the job as a whole.
+//
+// The responses of the requests are persisted as they are received, along
+// with the IDs of the batches submitted to the provider, if any. When the
+// store has state for the job, e.g. left by an interrupted process, the job is
+// resumed: submitted batches are awaited rather than submitted again, and only
+// requests without a response are sent. Failed requests are sent again, so
+// running a completed job again retries its failed requests.
+func (r *Runner) Run(ctx context.Context, jobID string, requests []Request) ([]Result, error) {
+	results := make([]Result, len(requests))
+	index := make(map[string]int, len(requests))
+	var pending []Request
+	for i, req := range requests {
+		if _, ok := index[req.ID]; ok {
+			return nil, fmt.Errorf("%w: %q", ErrDuplicateID, req.ID)
+		}
+		index[req.ID] = i
+		results[i].ID = req.ID
+
+		resp, ok, err := r.loadResponse(ctx, jobID, req.ID)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			results[i].Response = resp
+			continue
+		}
+		pending = append(pending, req)
+	}
+	if len(pending) == 0 {
+		return results, nil
+	}
+
+	collect := func(result Result) error {
+		i, ok := index[result.ID]
+		if !ok {
+			return nil
+		}
+		results[i] = result
+		if result.Err != nil {
+			return nil
+		}
+		return r.saveResponse(ctx, jobID, result)
+	}
+
+	var err error
+	if provider, ok := r.provider(); ok {
+		err = r.runBatch(ctx, provider, jobID, pending, collect)
+	} else {
+		err = r.runCalls(ctx, pending, collect)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		if results[i].Response == nil && results[i].Err == nil {
+			results[i].Err = ErrNoResult
+		}
+	}
+	return results, nil
+}
+
+// provider returns the batch provider of the model, unless live calls are
+// forced or the backend of the provider has no batch endpoint.
+func (r *Runner) provider() (Provider, bool) { //nolint:ireturn
+	provider, ok := r.model.(Provider)
+	if !ok || r.opts.liveCalls {
+		return nil, false
+	}
+	if s, ok := provider.(Supporter); ok && !s.SupportsBatches() {
+		return nil, false
+	}
+	return provider, true
+}
+
+// runBatch submits pending requests to the batch endpoint of provider, as
+// several batches if they exceed its limits, awaits the batches of the job,
+// including those submitted by a previous run, and collects their results.
+// If the first batch of the job is rejected because the backend has no batch
+// endpoint, the requests are sent as live calls instead.
+func (r *Runner) runBatch(ctx context.Context, provider Provider, jobID string, pending []Request, collect func(Result) error) error { //nolint:lll
+	state, err := r.loadState(ctx, jobID)
+	if err != nil {
+		return err
+	}
+
+	submitted := make(map[string]bool)
+	for _, b := range state.Batches {
+		for _, id := range b.Requests {
+			submitted[id] = true
+		}
+	}
+	var unsubmitted []Request
+	for _, req := range pending {
+		if !submitted[req.ID] {
+			unsubmitted = append(unsubmitted, req)
+		}
+	}
+	chunks, err := split(provider, unsubmitted)
+	if err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		id, err := provider.SubmitBatch(ctx, chunk)
+		if err != nil {
+			if len(state.Batches) == 0 && notSupported(err) {
+				return r.runCalls(ctx, pending, collect)
+			}
+			return fmt.Errorf("submit batch: %w", err)
+		}
+		b := submittedBatch{ID: id, Requests: make([]string, 0, len(chunk))}
+		for _, req := range chunk {
+			b.Requests = append(b.Requests, req.ID)
+		}
+		state.Batches = append(state.Batches, b)
+		if err := r.saveState(ctx, jobID, state); err != nil {
+			return err
+		}
+	}
+
+	var failures []string
+	for len(state.Batches) > 0 {
+		id := state.Batches[0].ID
+		status, err := r.wait(ctx, provider, id)
+		if err != nil {
+			return err
+		}
+		if status.State == StateFailed {
+			failures = append(failures, status.Message)
+		} else {
+			batchResults, err := provider.BatchResults(ctx, id)
+			if err != nil {
+				return fmt.Errorf("get batch results: %w", err)
+			}
+			for _, result := range batchResults {
+				if err := collect(result); err != nil {
+					return err
+				}
+			}
+		}
+
+		// The batch is over; running the job again submits the requests left
+		// without a response in a new batch.
+		state.Batches = state.Batches[1:]
+		if err := r.saveState(ctx, jobID, state); err != nil {
+			return err
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%w: %s", ErrBatchFailed, strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// split splits requests into batches within the limits of provider.
+func split(provider Provider, requests []Request) ([][]Request, error) {
+	if len(requests) == 0 {
+		return nil, nil
+	}
+	splitter, ok := provider.(Splitter)
+	if !ok {
+		return [][]Request{requests}, nil
+	}
+	maxRequests, maxBytes := splitter.BatchLimits()
+
+	var (
+		chunks      [][]Request
+		start, size int
+	)
+	for i, req := range requests {
+		n := 0
+		if maxBytes > 0 {
+			var err error
+			if n, err = splitter.BatchRequestSize(req); err != nil {
+				return nil, err
+			}
+		}
+		full := (maxRequests > 0 && i-start >= maxRequests) || (maxBytes > 0 && size+n > maxBytes)
+		if full && i > start {
+			chunks = append(chunks, requests[start:i])
+			start, size = i, 0
+		}
+		size += n
+	}
+	return append(chunks, requests[start:]), nil
+}
+
+// notSupported reports whether err is the rejection of a batch by a backend
+// without a batch endpoint.
+func notSupported(err error) bool {
+	var httpErr *llms.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusNotFound || httpErr.StatusCode == http.StatusMethodNotAllowed
+	}
+	return errors.Is(err, ErrNotSupported)
+}
+
+// wait polls the status of a batch until it is done.
+func (r *Runner) wait(ctx context.Context, provider Provider, id string) (Status, error) {
+	for {
+		status, err := provider.BatchStatus(ctx, id)
+		if err != nil {
+			return Status{}, fmt.Errorf("get batch status: %w", err)
+		}
+		if r.opts.progress != nil {
+			r.opts.progress(status)
+		}
+		if status.State.Done() {
+			return status, nil
+		}
+
+		timer := time.NewTimer(r.opts.pollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return Status{}, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// runCalls sends pending requests to the model concurrently, within the rate
+// limit.
+func (r *Runner) runCalls(ctx context.Context, pending []Request, collect func(Result) error) error {
+	var limiter *rate.Limiter
+	if n := r.opts.requestsPerMinute; n > 0 {
+		limiter = rate.NewLimiter(rate.Limit(float64(n)/time.Minute.Seconds()), 1)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	jobs := make(chan Request)
+	results := make(chan Result)
+	var wg sync.WaitGroup
+	for w := 0; w < r.opts.concurrency && w < len(pending); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for req := range jobs {
+				if limiter != nil {
+					if err := limiter.Wait(ctx); err != nil {
+						return
+					}
+				}
+				resp, err := r.model.GenerateContent(ctx, req.Messages, withoutStreaming(req.Options)...)
+				select {
+				case results <- Result{ID: req.ID, Response: resp, Err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for _, req := range pending {
+			select {
+			case jobs <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	status := Status{State: StateInProgress, Total: len(pending)}
+	for result := range results {
+		if err := collect(result); err != nil {
+			cancel()
+			return err
+		}
+		if result.Err != nil {
+			status.Failed++
+		} else {
+			status.Completed++
+		}
+		if status.Completed+status.Failed == status.Total {
+			status.State = StateCompleted
+		}
+		if r.opts.progress != nil {
+			r.opts.progress(status)
+		}
+	}
+	return ctx.Err()
+}
+
+// withoutStreaming returns options that disable the streaming options of
+// options.
+func withoutStreaming(options []llms.CallOption) []llms.CallOption {
+	return append(options[:len(options):len(options)], func(o *llms.CallOptions) {
+		o.StreamingFunc = nil
+		o.StreamingEventFunc = nil
+	})
+}
+
+func (r *Runner) loadState(ctx context.Context, jobID string) (jobState, error) {
+	var state jobState
+	b, ok, err := r.opts.store.Get(ctx, stateKey(jobID))
+	if err != nil || !ok {
+		return state, err
+	}
+	return state, json.Unmarshal(b, &state)
+}
+
+func (r *Runner) saveState(ctx context.Context, jobID string, state jobState) error {
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return r.opts.store.Put(ctx, stateKey(jobID), b)
+}
+
+func (r *Runner) loadResponse(ctx context.Context, jobID, requestID string) (*llms.ContentResponse, bool, error) {
+	b, ok, err := r.opts.store.Get(ctx, responseKey(jobID, requestID))
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	var resp llms.ContentResponse
+	if err := json.Unmarshal(b, &resp); err != nil {
+		return nil, false, err
+	}
+	return &resp, true, nil
+}
+
+func (r *Runner) saveResponse(ctx context.Context, jobID string, result Result) error {
+	b, err := json.Marshal(result.Response)
+	if err != nil {
+		return err
+	}
+	return r.opts.store.Put(ctx, responseKey(jobID, result.ID), b)
+}
+
+func stateKey(jobID string) string {
+	return "batch/" + jobID
+}
+
+func responseKey(jobID, requestID string) string {
+	return "batch/" + jobID + "/responses/" + requestID
+}
@@ -0,0 +1,289 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/cache"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// echoModel replies with the text of the last message, and fails when it is
+// "fail".
+type echoModel struct {
+	calls    atomic.Int32
+	inFlight atomic.Int32
+	maxSeen  atomic.Int32
+}
+
+func (m *echoModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+func (m *echoModel) GenerateContent(_ context.Context, messages []llms.MessageContent, _ ...llms.CallOption) (*llms.ContentResponse, error) { //nolint:lll
+	m.calls.Add(1)
+	n := m.inFlight.Add(1)
+	defer m.inFlight.Add(-1)
+	for {
+		seen := m.maxSeen.Load()
+		if n <= seen || m.maxSeen.CompareAndSwap(seen, n) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond)
+
+	text := messages[len(messages)-1].Parts[0].(llms.TextContent).Text
+	if text == "fail" {
+		return nil, errors.New("failed")
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: strings.ToUpper(text)}}}, nil
+}
+
+// fakeProvider is an echo model with a batch endpoint, whose batches complete
+// after a number of status checks. The size of requests is the length of
+// their ID.
+type fakeProvider struct {
+	echoModel
+	mu          sync.Mutex
+	batches     map[string][]Request
+	polls       int
+	pollsLeft   int
+	state       State
+	submitErr   error
+	noBatches   bool
+	maxRequests int
+	maxBytes    int
+}
+
+func (p *fakeProvider) SupportsBatches() bool {
+	return !p.noBatches
+}
+
+func (p *fakeProvider) BatchLimits() (int, int) {
+	return p.maxRequests, p.maxBytes
+}
+
+func (p *fakeProvider) BatchRequestSize(req Request) (int, error) {
+	return len(req.ID), nil
+}
+
+func (p *fakeProvider) SubmitBatch(_ context.Context, requests []Request) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.submitErr != nil {
+		return "", p.submitErr
+	}
+	if p.batches == nil {
+		p.batches = make(map[string][]Request)
+	}
+	id := fmt.Sprintf("batch-%d", len(p.batches))
+	p.batches[id] = requests
+	return id, nil
+}
+
+func (p *fakeProvider) BatchStatus(_ context.Context, id string) (Status, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.polls++
+	if p.pollsLeft > 0 {
+		p.pollsLeft--
+		return Status{State: StateInProgress, Total: len(p.batches[id])}, nil
+	}
+	if p.state == StateFailed {
+		return Status{State: StateFailed, Message: "invalid input"}, nil
+	}
+	return Status{State: StateCompleted, Total: len(p.batches[id]), Completed: len(p.batches[id])}, nil
+}
+
+func (p *fakeProvider) BatchResults(ctx context.Context, id string) ([]Result, error) {
+	p.mu.Lock()
+	requests := p.batches[id]
+	p.mu.Unlock()
+
+	var results []Result
+	for _, r := range requests {
+		if r.ID == "lost" {
+			continue
+		}
+		resp, err := p.echoModel.GenerateContent(ctx, r.Messages)
+		results = append(results, Result{ID: r.ID, Response: resp, Err: err})
+	}
+	return results, nil
+}
+
+func requests(texts ...string) []Request {
+	reqs := make([]Request, 0, len(texts))
+	for _, text := range texts {
+		reqs = append(reqs, Request{
+			ID:       text,
+			Messages: []llms.MessageContent{llms.TextParts(schema.ChatMessageTypeHuman, text)},
+		})
+	}
+	return reqs
+}
+
+func contents(results []Result) []string {
+	var out []string
+	for _, r := range results {
+		if r.Err != nil {
+			out = append(out, "error: "+r.Err.Error())
+			continue
+		}
+		out = append(out, r.Response.Choices[0].Content)
+	}
+	return out
+}
+
+func TestRunBatch(t *testing.T) {
+	t.Parallel()
+
+	store, err := cache.NewDisk(t.TempDir(), 0)
+	require.NoError(t, err)
+	provider := &fakeProvider{pollsLeft: 100}
+	reqs := requests("a", "fail", "lost", "b")
+
+	// The first run is interrupted while waiting for the batch.
+	ctx, cancel := context.WithCancel(context.Background())
+	runner := New(provider, WithStore(store), WithPollInterval(time.Millisecond),
+		WithProgress(func(Status) {
+			if provider.polls == 3 {
+				cancel()
+			}
+		}))
+	_, err = runner.Run(ctx, "job", reqs)
+	require.ErrorIs(t, err, context.Canceled)
+
+	// A new runner resumes waiting for the same batch.
+	provider.pollsLeft = 1
+	runner = New(provider, WithStore(store), WithPollInterval(time.Millisecond))
+	results, err := runner.Run(context.Background(), "job", reqs)
+	require.NoError(t, err)
+	assert.Len(t, provider.batches, 1)
+	assert.Equal(t, []string{"A", "error: failed", "error: " + ErrNoResult.Error(), "B"}, contents(results))
+	assert.Equal(t, int32(3), provider.calls.Load())
+
+	// Running the job again only submits the requests without a response.
+	results, err = runner.Run(context.Background(), "job", reqs)
+	require.NoError(t, err)
+	assert.Len(t, provider.batches, 2)
+	assert.Equal(t, []string{"fail", "lost"}, []string{provider.batches["batch-1"][0].ID, provider.batches["batch-1"][1].ID})
+	assert.Equal(t, "A", results[0].Response.Choices[0].Content)
+}
+
+func TestRunBatchFailed(t *testing.T) {
+	t.Parallel()
+
+	provider := &fakeProvider{state: StateFailed}
+	runner := New(provider, WithPollInterval(time.Millisecond))
+	_, err := runner.Run(context.Background(), "job", requests("a"))
+	require.ErrorIs(t, err, ErrBatchFailed)
+	assert.Contains(t, err.Error(), "invalid input")
+
+	// The failed batch isn't awaited again.
+	provider.state = StateCompleted
+	results, err := runner.Run(context.Background(), "job", requests("a"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"A"}, contents(results))
+	assert.Len(t, provider.batches, 2)
+}
+
+func TestRunBatchSplit(t *testing.T) {
+	t.Parallel()
+
+	provider := &fakeProvider{maxRequests: 2, maxBytes: 4}
+	runner := New(provider, WithPollInterval(time.Millisecond))
+	results, err := runner.Run(context.Background(), "job", requests("a", "bb", "ccc", "d", "e", "f"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"A", "BB", "CCC", "D", "E", "F"}, contents(results))
+
+	var sizes []int
+	for i := 0; i < len(provider.batches); i++ {
+		sizes = append(sizes, len(provider.batches[fmt.Sprintf("batch-%d", i)]))
+	}
+	assert.Equal(t, []int{2, 2, 2}, sizes)
+	assert.Equal(t, "ccc", provider.batches["batch-1"][0].ID)
+}
+
+func TestRunBatchFallback(t *testing.T) {
+	t.Parallel()
+
+	for name, provider := range map[string]*fakeProvider{
+		"not supported": {submitErr: fmt.Errorf("submit: %w", ErrNotSupported)},
+		"not found":     {submitErr: &llms.HTTPError{StatusCode: 404, Message: "not found"}},
+		"no batches":    {noBatches: true, submitErr: errors.New("submitted")},
+	} {
+		results, err := New(provider).Run(context.Background(), "job", requests("a", "b"))
+		require.NoError(t, err, name)
+		assert.Equal(t, []string{"A", "B"}, contents(results), name)
+		assert.Equal(t, int32(2), provider.calls.Load(), name)
+	}
+
+	provider := &fakeProvider{submitErr: &llms.HTTPError{StatusCode: 500}}
+	_, err := New(provider).Run(context.Background(), "job", requests("a"))
+	require.Error(t, err)
+	assert.Zero(t, provider.calls.Load())
+}
+
+func TestRunLiveCalls(t *testing.T) {
+	t.Parallel()
+
+	provider := &fakeProvider{}
+	results, err := New(provider, WithLiveCalls()).Run(context.Background(), "job", requests("a", "b"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"A", "B"}, contents(results))
+	assert.Empty(t, provider.batches)
+	assert.Equal(t, int32(2), provider.calls.Load())
+}
+
+func TestRunCalls(t *testing.T) {
+	t.Parallel()
+
+	model := &echoModel{}
+	var last Status
+	runner := New(model, WithConcurrency(3), WithProgress(func(s Status) { last = s }))
+	texts := make([]string, 0, 20)
+	for i := 0; i < 20; i++ {
+		texts = append(texts, fmt.Sprintf("r%d", i))
+	}
+	texts[7] = "fail"
+
+	results, err := runner.Run(context.Background(), "job", requests(texts...))
+	require.NoError(t, err)
+	require.Len(t, results, 20)
+	assert.Equal(t, "R0", results[0].Response.Choices[0].Content)
+	assert.Equal(t, "R19", results[19].Response.Choices[0].Content)
+	assert.EqualError(t, results[7].Err, "failed")
+	assert.LessOrEqual(t, model.maxSeen.Load(), int32(3))
+	assert.Equal(t, Status{State: StateCompleted, Total: 20, Completed: 19, Failed: 1}, last)
+
+	// Only the failed request is sent again.
+	_, err = runner.Run(context.Background(), "job", requests(texts...))
+	require.NoError(t, err)
+	assert.Equal(t, int32(21), model.calls.Load())
+}
+
+func TestRunCallsRateLimited(t *testing.T) {
+	t.Parallel()
+
+	runner := New(&echoModel{}, WithRequestsPerMinute(600))
+	start := time.Now()
+	_, err := runner.Run(context.Background(), "job", requests("a", "b", "c"))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestRunDuplicateID(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(&echoModel{}).Run(context.Background(), "job", requests("a", "a"))
+	require.ErrorIs(t, err, ErrDuplicateID)
+}
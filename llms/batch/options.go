@@ -0,0 +1,69 @@
+package batch
+
+import "time"
+
+const (
+	defaultPollInterval = 30 * time.Second
+	defaultConcurrency  = 4
+)
+
+type options struct {
+	store             Store
+	pollInterval      time.Duration
+	concurrency       int
+	requestsPerMinute int
+	progress          func(Status)
+	liveCalls         bool
+}
+
+// Option is a function that configures a Runner.
+type Option func(*options)
+
+// WithStore sets the store persisting the state of jobs. By default, it is
+// kept in memory, so jobs can be resumed by the same process only.
+func WithStore(store Store) Option {
+	return func(o *options) {
+		o.store = store
+	}
+}
+
+// WithPollInterval sets how often the status of batches is checked. The
+// default is 30s.
+func WithPollInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.pollInterval = d
+	}
+}
+
+// WithConcurrency sets the maximum number of concurrent calls to models
+// without a batch endpoint. The default is 4.
+func WithConcurrency(n int) Option {
+	return func(o *options) {
+		o.concurrency = n
+	}
+}
+
+// WithRequestsPerMinute limits the rate of calls to models without a batch
+// endpoint. By default, it is unlimited.
+func WithRequestsPerMinute(n int) Option {
+	return func(o *options) {
+		o.requestsPerMinute = n
+	}
+}
+
+// WithProgress sets a function called with the status of jobs: each time the
+// status of a batch is checked, or a call to a model without a batch endpoint
+// completes.
+func WithProgress(fn func(Status)) Option {
+	return func(o *options) {
+		o.progress = fn
+	}
+}
+
+// WithLiveCalls sends the requests of jobs as live calls, even to models with
+// a batch endpoint, e.g. to get their results sooner.
+func WithLiveCalls() Option {
+	return func(o *options) {
+		o.liveCalls = true
+	}
+}
@@ -0,0 +1,41 @@
+package batch
+
+import (
+	"context"
+	"sync"
+)
+
+// Store persists the state of jobs. Its methods are those of cache.Backend,
+// so the backends of the cache package can be used as stores; cache.NewDisk
+// with no TTL persists jobs across restarts. Implementations must be safe for
+// concurrent use.
+type Store interface {
+	// Get returns the value stored for key, and false if there is none.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Put stores value for key.
+	Put(ctx context.Context, key string, value []byte) error
+}
+
+// memoryStore is a Store keeping values in memory.
+type memoryStore struct {
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{values: make(map[string][]byte)}
+}
+
+func (s *memoryStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.values[key]
+	return value, ok, nil
+}
+
+func (s *memoryStore) Put(_ context.Context, key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+	return nil
+}
@@ -0,0 +1,34 @@
+// Package cache provides wrappers that cache the responses of models and the
+// vectors of embedders, so repeated identical requests aren't sent to the
+// provider again. Cached values are kept in a Backend: an in-memory LRU cache
+// and an on-disk store are provided.
+//
+//	model := cache.New(llm, cache.NewMemory(1000, time.Hour))
+//	embedder := cache.NewEmbedder(e, cache.NewMemory(10000, 0), cache.WithNamespace("ada-002"))
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// Backend stores cached values by key. Implementations must be safe for
+// concurrent use.
+type Backend interface {
+	// Get returns the value stored for key, and false if there is none.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Put stores value for key.
+	Put(ctx context.Context, key string, value []byte) error
+}
+
+// makeKey returns the cache key of the JSON encoding of v.
+func makeKey(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := NewMemory(2, 0)
+	require.NoError(t, m.Put(ctx, "a", []byte("1")))
+	require.NoError(t, m.Put(ctx, "b", []byte("2")))
+
+	// Reading a makes b the least recently used value.
+	v, ok, err := m.Get(ctx, "a")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, []byte("1"), v)
+
+	require.NoError(t, m.Put(ctx, "c", []byte("3")))
+	assert.Equal(t, 2, m.Len())
+	_, ok, _ = m.Get(ctx, "b")
+	assert.False(t, ok)
+	_, ok, _ = m.Get(ctx, "c")
+	assert.True(t, ok)
+}
+
+func TestMemoryTTL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := NewMemory(0, 10*time.Millisecond)
+	require.NoError(t, m.Put(ctx, "a", []byte("1")))
+	_, ok, _ := m.Get(ctx, "a")
+	require.True(t, ok)
+
+	time.Sleep(20 * time.Millisecond)
+	_, ok, _ = m.Get(ctx, "a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, m.Len())
+}
+
+func TestDisk(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	d, err := NewDisk(filepath.Join(dir, "cache"), time.Hour)
+	require.NoError(t, err)
+
+	_, ok, err := d.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, d.Put(ctx, "a", []byte("1")))
+	require.NoError(t, d.Put(ctx, "../a", []byte("2")))
+
+	// Values survive reopening the store.
+	d, err = NewDisk(filepath.Join(dir, "cache"), time.Hour)
+	require.NoError(t, err)
+	v, ok, err := d.Get(ctx, "a")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, []byte("1"), v)
+	v, _, _ = d.Get(ctx, "../a")
+	assert.Equal(t, []byte("2"), v)
+
+	// Expired values are ignored.
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(d.path("a"), old, old))
+	_, ok, err = d.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
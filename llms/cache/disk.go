@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Disk is a Backend that stores each value in a file of a directory, so the
+// cache survives restarts. Values older than its TTL are ignored.
+type Disk struct {
+	dir string
+	ttl time.Duration
+}
+
+var _ Backend = (*Disk)(nil)
+
+// NewDisk returns a backend storing values in dir, which is created if
+// needed, for at most ttl. A zero ttl means no limit.
+func NewDisk(dir string, ttl time.Duration) (*Disk, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:gomnd
+		return nil, err
+	}
+	return &Disk{dir: dir, ttl: ttl}, nil
+}
+
+// Get implements the Backend interface.
+func (d *Disk) Get(_ context.Context, key string) ([]byte, bool, error) {
+	path := d.path(key)
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if d.ttl > 0 && time.Since(info.ModTime()) > d.ttl {
+		return nil, false, nil
+	}
+
+	value, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Put implements the Backend interface. Values are written to a temporary
+// file first, so concurrent readers never see partial values.
+func (d *Disk) Put(_ context.Context, key string, value []byte) error {
+	path := d.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:gomnd
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(value); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// path returns the path of the file of key. Files are named after the hash
+// of their key, and spread over subdirectories to keep directories small.
+func (d *Disk) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(d.dir, name[:2], name)
+}
@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/tmc/langchaingo/embeddings"
+)
+
+// ErrUnexpectedLength is returned when the wrapped embedder doesn't return
+// one vector per text.
+var ErrUnexpectedLength = errors.New("unexpected number of vectors")
+
+// Embedder is an embedder that caches the vectors of the embedder it wraps,
+// text by text.
+type Embedder struct {
+	embedder embeddings.Embedder
+	backend  Backend
+	opts     options
+}
+
+var _ embeddings.Embedder = (*Embedder)(nil)
+
+// NewEmbedder returns an embedder that caches the vectors of embedder in
+// backend. As the cache keys don't identify the embedding model, embedders of
+// different models sharing a backend must use different namespaces.
+func NewEmbedder(embedder embeddings.Embedder, backend Backend, opts ...Option) *Embedder {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Embedder{embedder: embedder, backend: backend, opts: o}
+}
+
+// EmbedDocuments implements the embeddings.Embedder interface. Only the texts
+// missing from the cache are sent to the wrapped embedder, in a single call.
+func (e *Embedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	keys := make([]string, len(texts))
+	// missing maps each text missing from the cache to its indexes in texts.
+	missing := make(map[string][]int)
+	var misses []string
+
+	for i, text := range texts {
+		key, err := e.key("document", text)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+		if v, ok := e.get(ctx, key); ok {
+			vectors[i] = v
+			continue
+		}
+		if _, ok := missing[text]; !ok {
+			misses = append(misses, text)
+		}
+		missing[text] = append(missing[text], i)
+	}
+	if len(misses) == 0 {
+		return vectors, nil
+	}
+
+	embedded, err := e.embedder.EmbedDocuments(ctx, misses)
+	if err != nil {
+		return nil, err
+	}
+	if len(embedded) != len(misses) {
+		return nil, ErrUnexpectedLength
+	}
+	for i, text := range misses {
+		indexes := missing[text]
+		for _, j := range indexes {
+			vectors[j] = embedded[i]
+		}
+		e.put(ctx, keys[indexes[0]], embedded[i])
+	}
+	return vectors, nil
+}
+
+// EmbedQuery implements the embeddings.Embedder interface.
+func (e *Embedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	key, err := e.key("query", text)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := e.get(ctx, key); ok {
+		return v, nil
+	}
+
+	v, err := e.embedder.EmbedQuery(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	e.put(ctx, key, v)
+	return v, nil
+}
+
+// embeddingKey is what identifies a cached vector. Queries and documents are
+// cached separately, as some embedders embed them differently.
+type embeddingKey struct {
+	Namespace string `json:"namespace"`
+	Kind      string `json:"kind"`
+	Text      string `json:"text"`
+}
+
+func (e *Embedder) key(kind, text string) (string, error) {
+	return makeKey(embeddingKey{Namespace: e.opts.namespace, Kind: kind, Text: text})
+}
+
+// get returns the cached vector of key. Errors of the backend are treated as
+// misses.
+func (e *Embedder) get(ctx context.Context, key string) ([]float32, bool) {
+	value, ok, err := e.backend.Get(ctx, key)
+	if err != nil || !ok {
+		return nil, false
+	}
+	var v []float32
+	if err := json.Unmarshal(value, &v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// put caches the vector of key. Errors of the backend are ignored.
+func (e *Embedder) put(ctx context.Context, key string, v []float32) {
+	if value, err := json.Marshal(v); err == nil {
+		_ = e.backend.Put(ctx, key, value)
+	}
+}
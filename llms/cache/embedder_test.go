@@ -0,0 +1,63 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/llms/cache"
+)
+
+// lengthEmbedder embeds texts as their length, and records its batches.
+type lengthEmbedder struct {
+	batches [][]string
+	queries []string
+}
+
+var _ embeddings.Embedder = (*lengthEmbedder)(nil)
+
+func (e *lengthEmbedder) EmbedDocuments(_ context.Context, texts []string) ([][]float32, error) {
+	e.batches = append(e.batches, texts)
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = []float32{float32(len(text))}
+	}
+	return vectors, nil
+}
+
+func (e *lengthEmbedder) EmbedQuery(_ context.Context, text string) ([]float32, error) {
+	e.queries = append(e.queries, text)
+	return []float32{float32(len(text))}, nil
+}
+
+func TestEmbedderCache(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	inner := &lengthEmbedder{}
+	e := cache.NewEmbedder(inner, cache.NewMemory(0, 0))
+
+	vectors, err := e.EmbedDocuments(ctx, []string{"a", "bb"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1}, {2}}, vectors)
+
+	// Only misses are embedded, in a single batch, once each.
+	vectors, err = e.EmbedDocuments(ctx, []string{"bb", "ccc", "a", "ccc", "dddd"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{2}, {3}, {1}, {3}, {4}}, vectors)
+	assert.Equal(t, [][]string{{"a", "bb"}, {"ccc", "dddd"}}, inner.batches)
+
+	_, err = e.EmbedDocuments(ctx, []string{"a", "dddd"})
+	require.NoError(t, err)
+	assert.Len(t, inner.batches, 2)
+
+	// Queries are cached separately from documents.
+	for i := 0; i < 2; i++ {
+		v, err := e.EmbedQuery(ctx, "a")
+		require.NoError(t, err)
+		assert.Equal(t, []float32{1}, v)
+	}
+	assert.Equal(t, []string{"a"}, inner.queries)
+}
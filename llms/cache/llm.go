@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// LLM is a model that caches the responses of the model it wraps.
+type LLM struct {
+	model   llms.Model
+	backend Backend
+	opts    options
+}
+
+var _ llms.Model = (*LLM)(nil)
+
+// New returns a model that caches the responses of model in backend.
+func New(model llms.Model, backend Backend, opts ...Option) *LLM {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &LLM{model: model, backend: backend, opts: o}
+}
+
+// Call implements the llms.Model interface.
+func (l *LLM) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, l, prompt, options...)
+}
+
+// GenerateContent implements the llms.Model interface. Responses are cached
+// by messages and call options. Streaming calls and calls with a non-zero
+// temperature bypass the cache, unless allowed by options. Errors of the
+// backend are ignored, so the cache never fails a call the model would serve.
+func (l *LLM) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) { //nolint:lll
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if !l.cacheable(opts) {
+		return l.model.GenerateContent(ctx, messages, options...)
+	}
+	key, err := l.key(messages, opts)
+	if err != nil {
+		return l.model.GenerateContent(ctx, messages, options...)
+	}
+
+	if value, ok, err := l.backend.Get(ctx, key); err == nil && ok {
+		var resp llms.ContentResponse
+		if err := json.Unmarshal(value, &resp); err == nil {
+			if err := replay(ctx, &resp, opts); err != nil {
+				return nil, err
+			}
+			return &resp, nil
+		}
+	}
+
+	resp, err := l.model.GenerateContent(ctx, messages, options...)
+	if err != nil {
+		return nil, err
+	}
+	if value, err := json.Marshal(resp); err == nil {
+		_ = l.backend.Put(ctx, key, value)
+	}
+	return resp, nil
+}
+
+func (l *LLM) cacheable(opts llms.CallOptions) bool {
+	streaming := opts.StreamingFunc != nil || opts.StreamingEventFunc != nil
+	if streaming && !l.opts.allowStreaming {
+		return false
+	}
+	return opts.Temperature == 0 || l.opts.allowNonZeroTemperature
+}
+
+// llmKey is what identifies a cached response.
+type llmKey struct {
+	Namespace string            `json:"namespace"`
+	Messages  []messageKey      `json:"messages"`
+	Options   *llms.CallOptions `json:"options"`
+}
+
+type messageKey struct {
+	Role  string    `json:"role"`
+	Parts []partKey `json:"parts"`
+}
+
+// partKey records the type of a part, as parts of different types may have
+// the same JSON encoding.
+type partKey struct {
+	Type string           `json:"type"`
+	Part llms.ContentPart `json:"part"`
+}
+
+func (l *LLM) key(messages []llms.MessageContent, opts llms.CallOptions) (string, error) {
+	// The streaming functions aren't part of the JSON encoding of opts, as
+	// streaming doesn't change the response.
+	k := llmKey{Namespace: l.opts.namespace, Options: &opts}
+	for _, m := range messages {
+		mk := messageKey{Role: string(m.Role)}
+		for _, p := range m.Parts {
+			mk.Parts = append(mk.Parts, partKey{Type: fmt.Sprintf("%T", p), Part: p})
+		}
+		k.Messages = append(k.Messages, mk)
+	}
+	return makeKey(k)
+}
+
+// replay streams a cached response to the streaming functions of opts, if
+// any.
+func replay(ctx context.Context, resp *llms.ContentResponse, opts llms.CallOptions) error {
+	if opts.StreamingFunc != nil && len(resp.Choices) > 0 {
+		if err := opts.StreamingFunc(ctx, []byte(resp.Choices[0].Content)); err != nil {
+			return err
+		}
+	}
+	if opts.StreamingEventFunc == nil {
+		return nil
+	}
+
+	for i, c := range resp.Choices {
+		events := []llms.StreamEvent{{Type: llms.StreamEventText, ChoiceIndex: i, Text: c.Content}}
+		for j, tc := range c.ToolCalls {
+			delta := &llms.ToolCallDelta{Index: j, ID: tc.ID, Type: tc.Type}
+			if tc.FunctionCall != nil {
+				delta.Name = tc.FunctionCall.Name
+				delta.Arguments = tc.FunctionCall.Arguments
+			}
+			events = append(events, llms.StreamEvent{Type: llms.StreamEventToolCall, ChoiceIndex: i, ToolCall: delta})
+		}
+		events = append(events, llms.StreamEvent{Type: llms.StreamEventFinish, ChoiceIndex: i, StopReason: c.StopReason})
+		for _, e := range events {
+			if err := opts.StreamingEventFunc(ctx, e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/cache"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// countingModel answers with the number of calls it received.
+type countingModel struct {
+	calls int
+}
+
+func (m *countingModel) GenerateContent(_ context.Context, _ []llms.MessageContent, _ ...llms.CallOption) (*llms.ContentResponse, error) { //nolint:lll
+	m.calls++
+	return &llms.ContentResponse{
+		Choices: []*llms.ContentChoice{{Content: string(rune('0' + m.calls)), StopReason: "stop"}},
+		Usage:   llms.NewUsage(1, 1),
+	}, nil
+}
+
+func (m *countingModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+func TestLLMCache(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	inner := &countingModel{}
+	model := cache.New(inner, cache.NewMemory(10, 0))
+
+	out, err := llms.GenerateFromSinglePrompt(ctx, model, "hello")
+	require.NoError(t, err)
+	assert.Equal(t, "1", out)
+
+	resp, err := model.GenerateContent(ctx, []llms.MessageContent{llms.TextParts(schema.ChatMessageTypeHuman, "hello")})
+	require.NoError(t, err)
+	assert.Equal(t, "1", resp.Choices[0].Content)
+	assert.Equal(t, "stop", resp.Choices[0].StopReason)
+	assert.Equal(t, llms.NewUsage(1, 1), resp.Usage)
+
+	// Different messages, roles and options miss.
+	out, _ = llms.GenerateFromSinglePrompt(ctx, model, "bye")
+	assert.Equal(t, "2", out)
+	resp, _ = model.GenerateContent(ctx, []llms.MessageContent{llms.TextParts(schema.ChatMessageTypeSystem, "hello")})
+	assert.Equal(t, "3", resp.Choices[0].Content)
+	out, _ = llms.GenerateFromSinglePrompt(ctx, model, "hello", llms.WithMaxTokens(10))
+	assert.Equal(t, "4", out)
+	assert.Equal(t, 4, inner.calls)
+}
+
+func TestLLMCacheBypass(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	inner := &countingModel{}
+	model := cache.New(inner, cache.NewMemory(10, 0))
+	stream := llms.WithStreamingFunc(func(context.Context, []byte) error { return nil })
+
+	for i := 0; i < 2; i++ {
+		_, err := llms.GenerateFromSinglePrompt(ctx, model, "hello", llms.WithTemperature(0.7))
+		require.NoError(t, err)
+		_, err = llms.GenerateFromSinglePrompt(ctx, model, "hello", llms.WithTemperature(0), stream)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 4, inner.calls)
+
+	// Calls with a zero temperature are cached.
+	for i := 0; i < 2; i++ {
+		out, err := llms.GenerateFromSinglePrompt(ctx, model, "hello", llms.WithTemperature(0))
+		require.NoError(t, err)
+		assert.Equal(t, "5", out)
+	}
+	assert.Equal(t, 5, inner.calls)
+}
+
+func TestLLMCacheAllowed(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	inner := &countingModel{}
+	model := cache.New(inner, cache.NewMemory(10, 0), cache.WithStreaming(), cache.WithNonZeroTemperature())
+
+	var chunks []string
+	stream := llms.WithStreamingFunc(func(_ context.Context, chunk []byte) error {
+		chunks = append(chunks, string(chunk))
+		return nil
+	})
+	for i := 0; i < 2; i++ {
+		out, err := llms.GenerateFromSinglePrompt(ctx, model, "hello", llms.WithTemperature(0.7), stream)
+		require.NoError(t, err)
+		assert.Equal(t, "1", out)
+	}
+	assert.Equal(t, 1, inner.calls)
+	// The cached response is streamed as a single chunk.
+	assert.Equal(t, []string{"1"}, chunks)
+}
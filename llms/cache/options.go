@@ -0,0 +1,35 @@
+package cache
+
+type options struct {
+	namespace               string
+	allowStreaming          bool
+	allowNonZeroTemperature bool
+}
+
+// Option is a function that configures a cache wrapper.
+type Option func(*options)
+
+// WithNamespace sets a namespace that is part of all the cache keys, so that
+// wrappers of different models or embedders can share a backend.
+func WithNamespace(ns string) Option {
+	return func(o *options) {
+		o.namespace = ns
+	}
+}
+
+// WithStreaming allows caching streaming calls. A cached response is
+// streamed as a single chunk. By default, streaming calls bypass the cache.
+func WithStreaming() Option {
+	return func(o *options) {
+		o.allowStreaming = true
+	}
+}
+
+// WithNonZeroTemperature allows caching calls with a non-zero temperature,
+// whose responses are expected to vary. By default, such calls bypass the
+// cache.
+func WithNonZeroTemperature() Option {
+	return func(o *options) {
+		o.allowNonZeroTemperature = true
+	}
+}
@@ -0,0 +1,280 @@
+// Package chattemplate renders conversations into the prompts expected by the
+// chat models of a family, for backends that only take a raw prompt.
+//
+// Built-in templates cover the common model families, and the Jinja chat
+// templates shipped with models, e.g. in the tokenizer_config.json file of
+// HuggingFace models, can be loaded with Parse and ParseTokenizerConfig.
+package chattemplate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+var (
+	// ErrUnsupportedRole is returned when rendering a message whose role has
+	// no place in a prompt.
+	ErrUnsupportedRole = errors.New("role not supported by chat templates")
+	// ErrUnsupportedContent is returned when rendering a message with
+	// non-text parts.
+	ErrUnsupportedContent = errors.New("only text content is supported by chat templates")
+	// ErrUnknownTemplate is returned by Builtin for unknown template names.
+	ErrUnknownTemplate = errors.New("unknown chat template")
+)
+
+// Message is a text message of a conversation, as seen by templates. Its role
+// is one of system, human and ai.
+type Message struct {
+	Role    schema.ChatMessageType
+	Content string
+}
+
+// Template renders conversations into the prompt of a model family. The
+// prompt ends with the opening of a turn of the model, for the model to
+// complete.
+//
+// The beginning-of-sequence token isn't part of the prompt, as tokenizers add
+// it.
+type Template struct {
+	// Name is the name of the template.
+	Name string
+	// Stop are the sequences ending the turns of the model, at which a
+	// backend should stop generating.
+	Stop []string
+
+	render func(messages []Message) (string, error)
+}
+
+// Render renders messages into a prompt. Generic messages are rendered as
+// human messages.
+func (t Template) Render(messages []llms.MessageContent) (string, error) {
+	converted := make([]Message, 0, len(messages))
+	for _, mc := range messages {
+		var text strings.Builder
+		for _, part := range mc.Parts {
+			p, ok := part.(llms.TextContent)
+			if !ok {
+				return "", fmt.Errorf("%w: %T", ErrUnsupportedContent, part)
+			}
+			text.WriteString(p.Text)
+		}
+
+		role := mc.Role
+		switch role {
+		case schema.ChatMessageTypeSystem, schema.ChatMessageTypeHuman, schema.ChatMessageTypeAI:
+		case schema.ChatMessageTypeGeneric:
+			role = schema.ChatMessageTypeHuman
+		case schema.ChatMessageTypeFunction, schema.ChatMessageTypeTool:
+			fallthrough
+		default:
+			return "", fmt.Errorf("%w: %v", ErrUnsupportedRole, mc.Role)
+		}
+		converted = append(converted, Message{Role: role, Content: text.String()})
+	}
+	return t.render(converted)
+}
+
+// builtins are the constructors of the built-in templates, by name.
+var builtins = map[string]func() Template{ //nolint:gochecknoglobals
+	"chatml":  ChatML,
+	"llama2":  Llama2,
+	"llama3":  Llama3,
+	"mistral": Mistral,
+	"gemma":   Gemma,
+	"alpaca":  Alpaca,
+	"vicuna":  Vicuna,
+}
+
+// Builtin returns the built-in template of the given name, one of "chatml",
+// "llama2", "llama3", "mistral", "gemma", "alpaca" and "vicuna".
+func Builtin(name string) (Template, error) {
+	newTemplate, ok := builtins[name]
+	if !ok {
+		return Template{}, fmt.Errorf("%w: %q", ErrUnknownTemplate, name)
+	}
+	return newTemplate(), nil
+}
+
+// newTemplate returns a template whose rendering can't fail.
+func newTemplate(name string, stop []string, render func([]Message) string) Template {
+	return Template{
+		Name: name,
+		Stop: stop,
+		render: func(messages []Message) (string, error) {
+			return render(messages), nil
+		},
+	}
+}
+
+// ChatML returns the ChatML template, used by Qwen, Yi, Hermes and many other
+// fine-tunes.
+func ChatML() Template {
+	return newTemplate("chatml", []string{"<|im_end|>"}, func(messages []Message) string {
+		var sb strings.Builder
+		for _, m := range messages {
+			fmt.Fprintf(&sb, "<|im_start|>%s\n%s<|im_end|>\n", roleName(m.Role, "assistant"), m.Content)
+		}
+		sb.WriteString("<|im_start|>assistant\n")
+		return sb.String()
+	})
+}
+
+// Llama2 returns the template of the Llama 2 chat models. System messages are
+// put at the start of the first human message.
+func Llama2() Template {
+	return newTemplate("llama2", []string{"</s>"}, func(messages []Message) string {
+		var system []string
+		var sb strings.Builder
+		first := true
+		for _, m := range messages {
+			switch m.Role {
+			case schema.ChatMessageTypeSystem:
+				system = append(system, m.Content)
+			case schema.ChatMessageTypeAI:
+				fmt.Fprintf(&sb, " %s </s>", strings.TrimSpace(m.Content))
+			default:
+				if !first {
+					sb.WriteString("<s>")
+				}
+				content := strings.TrimSpace(m.Content)
+				if first && len(system) > 0 {
+					content = fmt.Sprintf("<<SYS>>\n%s\n<</SYS>>\n\n%s", strings.Join(system, "\n\n"), content)
+				}
+				fmt.Fprintf(&sb, "[INST] %s [/INST]", content)
+				first = false
+			}
+		}
+		return sb.String()
+	})
+}
+
+// Llama3 returns the template of the Llama 3 instruct models.
+func Llama3() Template {
+	return newTemplate("llama3", []string{"<|eot_id|>"}, func(messages []Message) string {
+		var sb strings.Builder
+		for _, m := range messages {
+			fmt.Fprintf(&sb, "<|start_header_id|>%s<|end_header_id|>\n\n%s<|eot_id|>",
+				roleName(m.Role, "assistant"), strings.TrimSpace(m.Content))
+		}
+		sb.WriteString("<|start_header_id|>assistant<|end_header_id|>\n\n")
+		return sb.String()
+	})
+}
+
+// Mistral returns the template of the Mistral instruct models. They have no
+// system role, so system messages are prepended to the first human message.
+func Mistral() Template {
+	return newTemplate("mistral", []string{"</s>"}, func(messages []Message) string {
+		var sb strings.Builder
+		for _, m := range mergeSystem(messages) {
+			if m.Role == schema.ChatMessageTypeAI {
+				fmt.Fprintf(&sb, " %s</s>", strings.TrimSpace(m.Content))
+				continue
+			}
+			fmt.Fprintf(&sb, "[INST] %s [/INST]", strings.TrimSpace(m.Content))
+		}
+		return sb.String()
+	})
+}
+
+// Gemma returns the template of the Gemma instruct models. They have no system
+// role, so system messages are prepended to the first human message.
+func Gemma() Template {
+	return newTemplate("gemma", []string{"<end_of_turn>"}, func(messages []Message) string {
+		var sb strings.Builder
+		for _, m := range mergeSystem(messages) {
+			fmt.Fprintf(&sb, "<start_of_turn>%s\n%s<end_of_turn>\n",
+				roleName(m.Role, "model"), strings.TrimSpace(m.Content))
+		}
+		sb.WriteString("<start_of_turn>model\n")
+		return sb.String()
+	})
+}
+
+const alpacaSystem = "Below is an instruction that describes a task. " +
+	"Write a response that appropriately completes the request."
+
+// Alpaca returns the template of Alpaca and the instruction-following models
+// tuned like it. Human messages are instructions; without system messages,
+// the conversation starts with the usual Alpaca preamble.
+func Alpaca() Template {
+	return newTemplate("alpaca", []string{"### Instruction:"}, func(messages []Message) string {
+		return renderTurns(messages, alpacaSystem, "\n\n", "### Instruction:\n%s\n\n", "### Response:\n%s\n\n",
+			"### Response:\n")
+	})
+}
+
+const vicunaSystem = "A chat between a curious user and an artificial intelligence assistant. " +
+	"The assistant gives helpful, detailed, and polite answers to the user's questions."
+
+// Vicuna returns the template of the Vicuna v1.1 models. Without system
+// messages, the conversation starts with the usual Vicuna preamble.
+func Vicuna() Template {
+	return newTemplate("vicuna", []string{"</s>", "USER:"}, func(messages []Message) string {
+		return renderTurns(messages, vicunaSystem, " ", "USER: %s ", "ASSISTANT: %s</s>", "ASSISTANT:")
+	})
+}
+
+// renderTurns renders messages as a preamble, made of the system messages or
+// of defaultSystem, followed by the turns formatted with human and ai, and
+// the opening of the turn of the model.
+func renderTurns(messages []Message, defaultSystem, systemSuffix, human, ai, generation string) string {
+	var system []string
+	var turns strings.Builder
+	for _, m := range messages {
+		content := strings.TrimSpace(m.Content)
+		switch m.Role {
+		case schema.ChatMessageTypeSystem:
+			system = append(system, content)
+		case schema.ChatMessageTypeAI:
+			fmt.Fprintf(&turns, ai, content)
+		default:
+			fmt.Fprintf(&turns, human, content)
+		}
+	}
+	if len(system) == 0 {
+		system = []string{defaultSystem}
+	}
+	return strings.Join(system, "\n\n") + systemSuffix + turns.String() + generation
+}
+
+// roleName returns the name of a role in a template, in which the model is
+// named ai.
+func roleName(role schema.ChatMessageType, ai string) string {
+	switch role {
+	case schema.ChatMessageTypeSystem:
+		return "system"
+	case schema.ChatMessageTypeAI:
+		return ai
+	default:
+		return "user"
+	}
+}
+
+// mergeSystem prepends the content of system messages to the first human
+// message, for templates without a system role.
+func mergeSystem(messages []Message) []Message {
+	var system []string
+	merged := make([]Message, 0, len(messages))
+	for _, m := range messages {
+		if m.Role == schema.ChatMessageTypeSystem {
+			system = append(system, m.Content)
+			continue
+		}
+		merged = append(merged, m)
+	}
+	if len(system) == 0 {
+		return merged
+	}
+	for i, m := range merged {
+		if m.Role == schema.ChatMessageTypeHuman {
+			merged[i].Content = strings.Join(append(system, m.Content), "\n\n")
+			return merged
+		}
+	}
+	return append([]Message{{Role: schema.ChatMessageTypeHuman, Content: strings.Join(system, "\n\n")}}, merged...)
+}
@@ -0,0 +1,103 @@
+package chattemplate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+func conversation() []llms.MessageContent {
+	return []llms.MessageContent{
+		llms.TextParts(schema.ChatMessageTypeSystem, "Be brief."),
+		llms.TextParts(schema.ChatMessageTypeHuman, "Hi"),
+		llms.TextParts(schema.ChatMessageTypeAI, "Hello!"),
+		llms.TextParts(schema.ChatMessageTypeHuman, "Who are you?"),
+	}
+}
+
+func TestBuiltins(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{
+			name: "chatml",
+			want: "<|im_start|>system\nBe brief.<|im_end|>\n<|im_start|>user\nHi<|im_end|>\n" +
+				"<|im_start|>assistant\nHello!<|im_end|>\n<|im_start|>user\nWho are you?<|im_end|>\n" +
+				"<|im_start|>assistant\n",
+		},
+		{
+			name: "llama3",
+			want: "<|start_header_id|>system<|end_header_id|>\n\nBe brief.<|eot_id|>" +
+				"<|start_header_id|>user<|end_header_id|>\n\nHi<|eot_id|>" +
+				"<|start_header_id|>assistant<|end_header_id|>\n\nHello!<|eot_id|>" +
+				"<|start_header_id|>user<|end_header_id|>\n\nWho are you?<|eot_id|>" +
+				"<|start_header_id|>assistant<|end_header_id|>\n\n",
+		},
+		{
+			name: "mistral",
+			want: "[INST] Be brief.\n\nHi [/INST] Hello!</s>[INST] Who are you? [/INST]",
+		},
+		{
+			name: "gemma",
+			want: "<start_of_turn>user\nBe brief.\n\nHi<end_of_turn>\n<start_of_turn>model\nHello!<end_of_turn>\n" +
+				"<start_of_turn>user\nWho are you?<end_of_turn>\n<start_of_turn>model\n",
+		},
+		{
+			name: "llama2",
+			want: "[INST] <<SYS>>\nBe brief.\n<</SYS>>\n\nHi [/INST] Hello! </s><s>[INST] Who are you? [/INST]",
+		},
+		{
+			name: "alpaca",
+			want: "Be brief.\n\n### Instruction:\nHi\n\n### Response:\nHello!\n\n" +
+				"### Instruction:\nWho are you?\n\n### Response:\n",
+		},
+		{
+			name: "vicuna",
+			want: "Be brief. USER: Hi ASSISTANT: Hello!</s>USER: Who are you? ASSISTANT:",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tmpl, err := Builtin(tt.name)
+			require.NoError(t, err)
+			assert.Equal(t, tt.name, tmpl.Name)
+			assert.NotEmpty(t, tmpl.Stop)
+
+			got, err := tmpl.Render(conversation())
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := Builtin("nope")
+	require.ErrorIs(t, err, ErrUnknownTemplate)
+
+	_, err = ChatML().Render([]llms.MessageContent{{
+		Role:  schema.ChatMessageTypeHuman,
+		Parts: []llms.ContentPart{llms.BinaryPart("image/png", []byte{1})},
+	}})
+	require.ErrorIs(t, err, ErrUnsupportedContent)
+
+	_, err = ChatML().Render([]llms.MessageContent{llms.TextParts(schema.ChatMessageTypeTool, "42")})
+	require.ErrorIs(t, err, ErrUnsupportedRole)
+}
+
+func TestMergeSystemWithoutHuman(t *testing.T) {
+	t.Parallel()
+
+	got, err := Gemma().Render([]llms.MessageContent{llms.TextParts(schema.ChatMessageTypeSystem, "Say hi.")})
+	require.NoError(t, err)
+	assert.Equal(t, "<start_of_turn>user\nSay hi.<end_of_turn>\n<start_of_turn>model\n", got)
+}
@@ -0,0 +1,224 @@
+package chattemplate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/nikolalohinski/gonja"
+	"github.com/nikolalohinski/gonja/exec"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// ErrUnsupportedTemplate is returned when a Jinja chat template can't be
+// rendered and doesn't match any built-in template.
+var ErrUnsupportedTemplate = errors.New("unsupported chat template")
+
+// Detect returns the built-in template of the family of a Jinja chat
+// template, recognized from its special tokens.
+func Detect(source string) (Template, bool) {
+	markers := []struct {
+		marker string
+		name   string
+	}{
+		{"<|start_header_id|>", "llama3"},
+		{"<start_of_turn>", "gemma"},
+		{"<<SYS>>", "llama2"},
+		{"[INST]", "mistral"},
+		{"<|im_start|>", "chatml"},
+		{"### Instruction", "alpaca"},
+		{"ASSISTANT:", "vicuna"},
+	}
+	for _, m := range markers {
+		if strings.Contains(source, m.marker) {
+			return builtins[m.name](), true
+		}
+	}
+	return Template{}, false
+}
+
+// Parse returns a template rendering conversations with a Jinja chat
+// template, as shipped with HuggingFace models, given the end-of-sequence
+// token of the model. The template is rendered with the messages, the tokens
+// and add_generation_prompt set; bos_token is empty, as tokenizers add it.
+//
+// Jinja templates are rendered with gonja, which lacks some features of Jinja
+// used by chat templates; notably, + is rewritten as ~ to concatenate
+// strings, so templates adding numbers are not supported. When the template
+// can't be rendered, the built-in template of its family is returned if it is
+// recognized by Detect.
+func Parse(source, eosToken string) (Template, error) {
+	builtin, detected := Detect(source)
+	tmpl, err := compileJinja(source)
+	if err == nil {
+		_, err = renderJinja(tmpl, eosToken, []Message{
+			{Role: schema.ChatMessageTypeHuman, Content: "Hi"},
+			{Role: schema.ChatMessageTypeAI, Content: "Hello"},
+			{Role: schema.ChatMessageTypeHuman, Content: "Bye"},
+		})
+	}
+	if err != nil {
+		if detected {
+			return builtin, nil
+		}
+		return Template{}, fmt.Errorf("%w: %w", ErrUnsupportedTemplate, err)
+	}
+
+	var stop []string
+	if detected {
+		stop = builtin.Stop
+	}
+	if eosToken != "" && !contains(stop, eosToken) {
+		stop = append(stop[:len(stop):len(stop)], eosToken)
+	}
+	return Template{
+		Name: "jinja",
+		Stop: stop,
+		render: func(messages []Message) (string, error) {
+			prompt, err := renderJinja(tmpl, eosToken, messages)
+			if err != nil && len(mergeSystem(messages)) != len(messages) {
+				// Many templates reject system messages; retry with them
+				// merged into the first human message.
+				prompt, err = renderJinja(tmpl, eosToken, mergeSystem(messages))
+			}
+			return prompt, err
+		},
+	}, nil
+}
+
+// tokenizerConfig is the part of a tokenizer_config.json file describing chat
+// templates.
+type tokenizerConfig struct {
+	ChatTemplate json.RawMessage `json:"chat_template"`
+	EOSToken     json.RawMessage `json:"eos_token"`
+}
+
+// ParseTokenizerConfig returns the default chat template of a HuggingFace
+// tokenizer_config.json file. See Parse.
+func ParseTokenizerConfig(data []byte) (Template, error) {
+	var config tokenizerConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Template{}, err
+	}
+
+	// The chat template is either a string or a list of named templates.
+	var source string
+	if err := json.Unmarshal(config.ChatTemplate, &source); err != nil {
+		var named []struct {
+			Name     string `json:"name"`
+			Template string `json:"template"`
+		}
+		if err := json.Unmarshal(config.ChatTemplate, &named); err != nil {
+			return Template{}, fmt.Errorf("%w: no chat template", ErrUnsupportedTemplate)
+		}
+		for _, t := range named {
+			if t.Name == "default" {
+				source = t.Template
+			}
+		}
+	}
+	if source == "" {
+		return Template{}, fmt.Errorf("%w: no chat template", ErrUnsupportedTemplate)
+	}
+
+	// The token is either a string or an added token object.
+	var eos string
+	if err := json.Unmarshal(config.EOSToken, &eos); err != nil {
+		var token struct {
+			Content string `json:"content"`
+		}
+		_ = json.Unmarshal(config.EOSToken, &token)
+		eos = token.Content
+	}
+	return Parse(source, eos)
+}
+
+// LoadTokenizerConfig returns the default chat template of the HuggingFace
+// tokenizer_config.json file at path. See Parse.
+func LoadTokenizerConfig(path string) (Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Template{}, err
+	}
+	return ParseTokenizerConfig(data)
+}
+
+var (
+	// lstripBlocks and trimBlocks match the whitespace removed around block
+	// tags by the lstrip_blocks and trim_blocks settings of Jinja, which
+	// HuggingFace renders chat templates with.
+	lstripBlocks = regexp.MustCompile(`(?m)^[ \t]+(\{%)`) //nolint:gochecknoglobals
+	trimBlocks   = regexp.MustCompile(`(%\})\r?\n`)       //nolint:gochecknoglobals
+)
+
+// compileJinja compiles a Jinja chat template for gonja.
+func compileJinja(source string) (*exec.Template, error) {
+	source = lstripBlocks.ReplaceAllString(source, "$1")
+	source = trimBlocks.ReplaceAllString(source, "$1")
+	return gonja.FromString(concatWithTilde(source))
+}
+
+// concatWithTilde replaces + with ~ in the tags of a template, outside string
+// literals, as gonja only adds numbers with +.
+func concatWithTilde(source string) string {
+	var sb strings.Builder
+	inTag := false
+	var quote byte
+	for i := 0; i < len(source); i++ {
+		c := source[i]
+		switch {
+		case !inTag:
+			if c == '{' && i+1 < len(source) && (source[i+1] == '{' || source[i+1] == '%') {
+				inTag = true
+			}
+		case quote != 0:
+			if c == '\\' && i+1 < len(source) {
+				sb.WriteByte(c)
+				i++
+				c = source[i]
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case (c == '}' || c == '%') && i+1 < len(source) && source[i+1] == '}':
+			inTag = false
+		case c == '+':
+			c = '~'
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// renderJinja renders messages with a compiled Jinja chat template.
+func renderJinja(tmpl *exec.Template, eosToken string, messages []Message) (string, error) {
+	converted := make([]map[string]any, 0, len(messages))
+	for _, m := range messages {
+		converted = append(converted, map[string]any{
+			"role":    roleName(m.Role, "assistant"),
+			"content": m.Content,
+		})
+	}
+	return tmpl.Execute(map[string]any{
+		"messages":              converted,
+		"bos_token":             "",
+		"eos_token":             eosToken,
+		"add_generation_prompt": true,
+		"raise_exception": func(message string) (string, error) {
+			return "", errors.New(message) //nolint:goerr113
+		},
+	})
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package chattemplate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// zephyrTemplate is the chat template of Zephyr, which matches no built-in
+// template.
+const zephyrTemplate = `{% for message in messages %}
+    {% if message['role'] == 'user' %}
+        {{ '<|user|>\n' + message['content'] + eos_token }}
+    {% elif message['role'] == 'system' %}
+        {{ '<|system|>\n' + message['content'] + eos_token }}
+    {% elif message['role'] == 'assistant' %}
+        {{ '<|assistant|>\n'  + message['content'] + eos_token }}
+    {% endif %}
+    {% if loop.last and add_generation_prompt %}
+        {{ '<|assistant|>' }}
+    {% endif %}
+{% endfor %}`
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tmpl, err := Parse(zephyrTemplate, "</s>")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"</s>"}, tmpl.Stop)
+
+	got, err := tmpl.Render(conversation())
+	require.NoError(t, err)
+	want := "        <|system|>\nBe brief.</s>\n        <|user|>\nHi</s>\n        <|assistant|>\nHello!</s>\n" +
+		"        <|user|>\nWho are you?</s>\n        <|assistant|>\n"
+	assert.Equal(t, want, got)
+}
+
+func TestParseSystemNotSupported(t *testing.T) {
+	t.Parallel()
+
+	source := `{% for m in messages %}{% if m.role == 'system' %}{{ raise_exception('no system') }}{% endif %}` +
+		`{{ '<' + m.role + '>' + m.content }}{% endfor %}`
+	tmpl, err := Parse(source, "")
+	require.NoError(t, err)
+
+	got, err := tmpl.Render(conversation())
+	require.NoError(t, err)
+	assert.Equal(t, "<user>Be brief.\n\nHi<assistant>Hello!<user>Who are you?", got)
+}
+
+func TestParseFallback(t *testing.T) {
+	t.Parallel()
+
+	// gonja can't slice lists, so the template falls back to its family.
+	tmpl, err := Parse("{% for m in messages[1:] %}<|im_start|>{{ m.content }}{% endfor %}", "<|im_end|>")
+	require.NoError(t, err)
+	assert.Equal(t, "chatml", tmpl.Name)
+
+	_, err = Parse("{% for m in messages[1:] %}{{ m.content }}{% endfor %}", "")
+	require.ErrorIs(t, err, ErrUnsupportedTemplate)
+}
+
+func TestParseTokenizerConfig(t *testing.T) {
+	t.Parallel()
+
+	config := `{
+		"eos_token": {"content": "<|im_end|>", "lstrip": false},
+		"chat_template": [
+			{"name": "tool_use", "template": "unused"},
+			{"name": "default", "template": "{% for m in messages %}<|im_start|>{{ m.role + '\n' + m.content }}<|im_end|>\n{% endfor %}{% if add_generation_prompt %}<|im_start|>assistant\n{% endif %}"}
+		]
+	}`
+	path := filepath.Join(t.TempDir(), "tokenizer_config.json")
+	require.NoError(t, os.WriteFile(path, []byte(config), 0o600))
+
+	tmpl, err := LoadTokenizerConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, "jinja", tmpl.Name)
+	assert.Equal(t, []string{"<|im_end|>"}, tmpl.Stop)
+
+	got, err := tmpl.Render([]llms.MessageContent{llms.TextParts(schema.ChatMessageTypeHuman, "Hi")})
+	require.NoError(t, err)
+	assert.Equal(t, "<|im_start|>user\nHi<|im_end|>\n<|im_start|>assistant\n", got)
+
+	_, err = ParseTokenizerConfig([]byte(`{"eos_token": "</s>"}`))
+	require.ErrorIs(t, err, ErrUnsupportedTemplate)
+}
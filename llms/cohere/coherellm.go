@@ -3,11 +3,14 @@ package cohere
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
+	"strings"
 
 	"github.com/tmc/langchaingo/callbacks"
 	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/llms/cohere/internal/cohereclient"
+	"github.com/tmc/langchaingo/schema"
 )
 
 var (
@@ -15,20 +18,43 @@ var (
 	ErrMissingToken  = errors.New("missing the COHERE_API_KEY key, set it in the COHERE_API_KEY environment variable")
 
 	ErrUnexpectedResponseLength = errors.New("unexpected length of response")
+	ErrUnsupportedRole          = errors.New("role not supported")
+	ErrUnsupportedContentType   = errors.New("content type not supported")
+	ErrNoUserMessage            = errors.New("the last message must be a human message")
 )
 
+// Citation is a span of a reply grounded on documents. The citations of a
+// reply are in the "citations" entry of the GenerationInfo of its choice.
+type Citation struct {
+	// Start and End are the byte offsets of the span in the reply.
+	Start int
+	End   int
+	// Text is the text of the span.
+	Text string
+	// DocumentIDs are the IDs of the documents supporting the span.
+	DocumentIDs []string
+}
+
 type LLM struct {
 	CallbacksHandler callbacks.Handler
 	client           *cohereclient.Client
+	options          *options
 }
 
-var _ llms.Model = (*LLM)(nil)
+var (
+	_ llms.Model          = (*LLM)(nil)
+	_ llms.StreamingModel = (*LLM)(nil)
+)
 
 func (o *LLM) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
 	return llms.GenerateFromSinglePrompt(ctx, o, prompt, options...)
 }
 
-// GenerateContent implements the Model interface.
+// GenerateContent implements the Model interface. The last message is sent as
+// the message of the chat, the previous ones as its history and system
+// messages as its preamble. Documents passed with llms.WithDocuments ground
+// the reply, and the resulting citations are returned in the GenerationInfo
+// of the choice.
 func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) { //nolint: lll, cyclop, whitespace
 
 	if o.CallbacksHandler != nil {
@@ -40,37 +66,190 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 		opt(opts)
 	}
 
-	// Assume we get a single text message
-	msg0 := messages[0]
-	part := msg0.Parts[0]
-	result, err := o.client.CreateGeneration(ctx, &cohereclient.GenerationRequest{
-		Prompt: part.(llms.TextContent).Text,
-	})
+	req, err := newChatRequest(messages)
 	if err != nil {
 		if o.CallbacksHandler != nil {
 			o.CallbacksHandler.HandleLLMError(ctx, err)
 		}
 		return nil, err
 	}
+	req.Model = opts.Model
+	req.Temperature = opts.Temperature
+	req.MaxTokens = opts.MaxTokens
+	req.StopSequences = opts.StopWords
+	req.K = opts.TopK
+	req.P = opts.TopP
+	req.Seed = opts.Seed
+	req.FrequencyPenalty = opts.FrequencyPenalty
+	req.PresencePenalty = opts.PresencePenalty
+	req.StreamingFunc = opts.StreamingFunc
+	req.StreamingEventFunc = opts.StreamingEventFunc
+	req.Documents = convertDocuments(opts.Documents)
+	for _, id := range o.options.connectors {
+		req.Connectors = append(req.Connectors, cohereclient.Connector{ID: id})
+	}
+
+	result, err := o.client.CreateChat(ctx, req)
+	if err != nil {
+		if o.CallbacksHandler != nil {
+			o.CallbacksHandler.HandleLLMError(ctx, err)
+		}
+		return nil, err
+	}
+
+	choice := &llms.ContentChoice{
+		Content:    result.Text,
+		StopReason: result.FinishReason,
+	}
+	if len(result.Citations) > 0 {
+		citations := make([]Citation, 0, len(result.Citations))
+		for _, c := range result.Citations {
+			citations = append(citations, Citation(c))
+		}
+		choice.GenerationInfo = map[string]any{"citations": citations}
+	}
 
+	units := result.Meta.BilledUnits
 	resp := &llms.ContentResponse{
-		Choices: []*llms.ContentChoice{
-			{
-				Content: result.Text,
-			},
-		},
+		Choices: []*llms.ContentChoice{choice},
+		Usage:   llms.NewUsage(int(units.InputTokens), int(units.OutputTokens)),
+	}
+	if o.CallbacksHandler != nil {
+		o.CallbacksHandler.HandleLLMGenerateContentEnd(ctx, resp)
 	}
 	return resp, nil
 }
 
-func New(opts ...Option) (*LLM, error) {
-	c, err := newClient(opts...)
-	return &LLM{
-		client: c,
-	}, err
+// GenerateContentStream implements the StreamingModel interface.
+func (o *LLM) GenerateContentStream(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentStream, error) { //nolint:lll
+	return llms.GenerateContentEventStream(ctx, o, messages, options...)
+}
+
+// newChatRequest converts messages to a chat request.
+func newChatRequest(messages []llms.MessageContent) (*cohereclient.ChatRequest, error) {
+	req := &cohereclient.ChatRequest{}
+	var preamble []string
+	for i, mc := range messages {
+		text, err := textOf(mc.Parts)
+		if err != nil {
+			return nil, err
+		}
+
+		var role string
+		switch mc.Role {
+		case schema.ChatMessageTypeSystem:
+			preamble = append(preamble, text)
+			continue
+		case schema.ChatMessageTypeHuman, schema.ChatMessageTypeGeneric:
+			role = cohereclient.RoleUser
+		case schema.ChatMessageTypeAI:
+			role = cohereclient.RoleChatbot
+		case schema.ChatMessageTypeFunction, schema.ChatMessageTypeTool:
+			fallthrough
+		default:
+			return nil, fmt.Errorf("%w: %v", ErrUnsupportedRole, mc.Role)
+		}
+
+		if i == len(messages)-1 {
+			if role != cohereclient.RoleUser {
+				return nil, ErrNoUserMessage
+			}
+			req.Message = text
+			break
+		}
+		req.ChatHistory = append(req.ChatHistory, cohereclient.ChatMessage{Role: role, Message: text})
+	}
+	if req.Message == "" {
+		return nil, ErrNoUserMessage
+	}
+	req.Preamble = strings.Join(preamble, "\n\n")
+	return req, nil
 }
 
-func newClient(opts ...Option) (*cohereclient.Client, error) {
+// textOf returns the text of parts, which must all be text.
+func textOf(parts []llms.ContentPart) (string, error) {
+	var sb strings.Builder
+	for _, part := range parts {
+		p, ok := part.(llms.TextContent)
+		if !ok {
+			return "", fmt.Errorf("%w: %T", ErrUnsupportedContentType, part)
+		}
+		sb.WriteString(p.Text)
+	}
+	return sb.String(), nil
+}
+
+// convertDocuments converts documents to the format of the chat endpoint: the
+// page content is the "text" of the document, and its metadata the other
+// fields. Documents without an "id" in their metadata are numbered.
+func convertDocuments(docs []schema.Document) []map[string]string {
+	if len(docs) == 0 {
+		return nil
+	}
+	converted := make([]map[string]string, 0, len(docs))
+	for i, doc := range docs {
+		d := make(map[string]string, len(doc.Metadata)+2)
+		for k, v := range doc.Metadata {
+			d[k] = fmt.Sprint(v)
+		}
+		if _, ok := d["id"]; !ok {
+			d["id"] = fmt.Sprintf("doc_%d", i)
+		}
+		d["text"] = doc.PageContent
+		converted = append(converted, d)
+	}
+	return converted
+}
+
+// CreateEmbedding implements the embeddings.EmbedderClient interface.
+func (o *LLM) CreateEmbedding(ctx context.Context, texts []string) ([][]float32, error) {
+	result, err := o.client.CreateEmbedding(ctx, &cohereclient.EmbeddingRequest{
+		Texts:     texts,
+		Model:     o.options.embeddingModel,
+		InputType: o.options.embeddingInputType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.Embeddings, nil
+}
+
+// Rerank returns the topN documents most relevant to the query, by decreasing
+// relevance, or all of them if topN is 0. The relevance of each document is
+// set in the "relevance_score" entry of its metadata.
+func (o *LLM) Rerank(ctx context.Context, query string, docs []schema.Document, topN int) ([]schema.Document, error) { //nolint:lll
+	texts := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		texts = append(texts, doc.PageContent)
+	}
+	result, err := o.client.Rerank(ctx, &cohereclient.RerankRequest{
+		Query:     query,
+		Documents: texts,
+		Model:     o.options.rerankModel,
+		TopN:      topN,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reranked := make([]schema.Document, 0, len(result.Results))
+	for _, r := range result.Results {
+		if r.Index < 0 || r.Index >= len(docs) {
+			return nil, ErrUnexpectedResponseLength
+		}
+		doc := docs[r.Index]
+		metadata := make(map[string]any, len(doc.Metadata)+1)
+		for k, v := range doc.Metadata {
+			metadata[k] = v
+		}
+		metadata["relevance_score"] = r.RelevanceScore
+		doc.Metadata = metadata
+		reranked = append(reranked, doc)
+	}
+	return reranked, nil
+}
+
+func New(opts ...Option) (*LLM, error) {
 	options := &options{
 		token:   os.Getenv(tokenEnvVarName),
 		baseURL: os.Getenv(baseURLEnvVarName),
@@ -81,9 +260,22 @@ func newClient(opts ...Option) (*cohereclient.Client, error) {
 		opt(options)
 	}
 
+	c, err := newClient(options)
+	return &LLM{
+		client:  c,
+		options: options,
+	}, err
+}
+
+func newClient(options *options) (*cohereclient.Client, error) {
 	if len(options.token) == 0 {
 		return nil, ErrMissingToken
 	}
 
-	return cohereclient.New(options.token, options.baseURL, options.model)
+	var clientOpts []cohereclient.Option
+	if options.httpClient != nil {
+		clientOpts = append(clientOpts, cohereclient.WithHTTPClient(options.httpClient))
+	}
+
+	return cohereclient.New(options.token, options.baseURL, options.model, clientOpts...)
 }
@@ -1,5 +1,9 @@
 package cohere
 
+import (
+	"github.com/tmc/langchaingo/llms/cohere/internal/cohereclient"
+)
+
 const (
 	tokenEnvVarName   = "COHERE_API_KEY"  //nolint:gosec
 	modelEnvVarName   = "COHERE_MODEL"    //nolint:gosec
@@ -7,9 +11,14 @@ const (
 )
 
 type options struct {
-	token   string
-	model   string
-	baseURL string
+	token              string
+	model              string
+	baseURL            string
+	httpClient         cohereclient.Doer
+	connectors         []string
+	embeddingModel     string
+	embeddingInputType string
+	rerankModel        string
 }
 
 type Option func(*options)
@@ -38,3 +47,43 @@ func WithBaseURL(baseURL string) Option {
 		opts.baseURL = baseURL
 	}
 }
+
+// WithHTTPClient allows setting a custom HTTP client.
+func WithHTTPClient(client cohereclient.Doer) Option {
+	return func(opts *options) {
+		opts.httpClient = client
+	}
+}
+
+// WithConnectors sets the IDs of the connectors, e.g. "web-search", the model
+// searches to ground its replies.
+func WithConnectors(ids ...string) Option {
+	return func(opts *options) {
+		opts.connectors = ids
+	}
+}
+
+// WithEmbeddingModel sets the model used by CreateEmbedding. If not set,
+// embed-english-v3.0 is used.
+func WithEmbeddingModel(model string) Option {
+	return func(opts *options) {
+		opts.embeddingModel = model
+	}
+}
+
+// WithEmbeddingInputType sets the purpose of the embeddings created by
+// CreateEmbedding: "search_document" (the default), "search_query",
+// "classification" or "clustering".
+func WithEmbeddingInputType(inputType string) Option {
+	return func(opts *options) {
+		opts.embeddingInputType = inputType
+	}
+}
+
+// WithRerankModel sets the model used by Rerank. If not set,
+// rerank-english-v3.0 is used.
+func WithRerankModel(model string) Option {
+	return func(opts *options) {
+		opts.rerankModel = model
+	}
+}
@@ -0,0 +1,201 @@
+package cohere
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+func newTestLLM(t *testing.T, handler http.HandlerFunc, opts ...Option) *LLM {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	llm, err := New(append([]Option{WithToken("test"), WithBaseURL(srv.URL), WithModel("command-r")}, opts...)...)
+	require.NoError(t, err)
+	return llm
+}
+
+func TestGenerateContentChat(t *testing.T) {
+	t.Parallel()
+
+	var got map[string]any
+	llm := newTestLLM(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat", r.URL.Path)
+		assert.Equal(t, "bearer test", r.Header.Get("authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte(`{"response_id":"r1","generation_id":"g1",
+			"text":"Emperor penguins are the tallest.",
+			"citations":[{"start":0,"end":16,"text":"Emperor penguins","document_ids":["doc_0"]}],
+			"finish_reason":"COMPLETE",
+			"meta":{"billed_units":{"input_tokens":40,"output_tokens":7}}}`))
+	}, WithConnectors("web-search"))
+
+	messages := []llms.MessageContent{
+		llms.TextParts(schema.ChatMessageTypeSystem, "You are an expert on penguins."),
+		llms.TextParts(schema.ChatMessageTypeHuman, "Hi"),
+		llms.TextParts(schema.ChatMessageTypeAI, "Hello, ask me about penguins."),
+		llms.TextParts(schema.ChatMessageTypeHuman, "Which penguins are the tallest?"),
+	}
+	resp, err := llm.GenerateContent(context.Background(), messages,
+		llms.WithTemperature(0.3),
+		llms.WithMaxTokens(50),
+		llms.WithStopWords([]string{"END"}),
+		llms.WithDocuments(
+			schema.Document{PageContent: "Emperor penguins are the tallest.", Metadata: map[string]any{"title": "Tall penguins"}},
+			schema.Document{PageContent: "Baby penguins are cute.", Metadata: map[string]any{"id": "cute", "year": 2023}},
+		))
+	require.NoError(t, err)
+
+	require.Len(t, resp.Choices, 1)
+	c := resp.Choices[0]
+	assert.Equal(t, "Emperor penguins are the tallest.", c.Content)
+	assert.Equal(t, "COMPLETE", c.StopReason)
+	assert.Equal(t, []Citation{{Start: 0, End: 16, Text: "Emperor penguins", DocumentIDs: []string{"doc_0"}}},
+		c.GenerationInfo["citations"])
+	assert.Equal(t, &llms.Usage{PromptTokens: 40, CompletionTokens: 7, TotalTokens: 47}, resp.Usage)
+
+	assert.Equal(t, "Which penguins are the tallest?", got["message"])
+	assert.Equal(t, "command-r", got["model"])
+	assert.Equal(t, "You are an expert on penguins.", got["preamble"])
+	assert.Equal(t, []any{
+		map[string]any{"role": "USER", "message": "Hi"},
+		map[string]any{"role": "CHATBOT", "message": "Hello, ask me about penguins."},
+	}, got["chat_history"])
+	assert.Equal(t, []any{
+		map[string]any{"id": "doc_0", "title": "Tall penguins", "text": "Emperor penguins are the tallest."},
+		map[string]any{"id": "cute", "year": "2023", "text": "Baby penguins are cute."},
+	}, got["documents"])
+	assert.Equal(t, []any{map[string]any{"id": "web-search"}}, got["connectors"])
+	assert.InDelta(t, 0.3, got["temperature"], 1e-9)
+	assert.EqualValues(t, 50, got["max_tokens"])
+	assert.Equal(t, []any{"END"}, got["stop_sequences"])
+}
+
+func TestGenerateContentChatErrors(t *testing.T) {
+	t.Parallel()
+
+	llm := newTestLLM(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"message":"invalid request"}`))
+	})
+
+	_, err := llm.GenerateContent(context.Background(), []llms.MessageContent{
+		llms.TextParts(schema.ChatMessageTypeHuman, "Hi"),
+		llms.TextParts(schema.ChatMessageTypeAI, "Hello"),
+	})
+	require.ErrorIs(t, err, ErrNoUserMessage)
+
+	handler := &errorHandler{}
+	llm.CallbacksHandler = handler
+	_, err = llm.GenerateContent(context.Background(), []llms.MessageContent{{
+		Role:  schema.ChatMessageTypeHuman,
+		Parts: []llms.ContentPart{llms.BinaryPart("image/png", []byte("png"))},
+	}})
+	require.ErrorIs(t, err, ErrUnsupportedContentType)
+	assert.Equal(t, []error{err}, handler.errs)
+
+	_, err = llm.GenerateContent(context.Background(), []llms.MessageContent{
+		llms.TextParts(schema.ChatMessageTypeHuman, "Hi"),
+	})
+	require.ErrorContains(t, err, "invalid request")
+	assert.Len(t, handler.errs, 2)
+}
+
+// errorHandler records the errors it is called with.
+type errorHandler struct {
+	callbacks.SimpleHandler
+	errs []error
+}
+
+func (h *errorHandler) HandleLLMError(_ context.Context, err error) {
+	h.errs = append(h.errs, err)
+}
+
+func TestGenerateContentStream(t *testing.T) {
+	t.Parallel()
+
+	var got map[string]any
+	llm := newTestLLM(t, func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte(`{"is_finished":false,"event_type":"stream-start","generation_id":"g1"}
+{"is_finished":false,"event_type":"text-generation","text":"Hel"}
+{"is_finished":false,"event_type":"text-generation","text":"lo"}
+{"is_finished":true,"event_type":"stream-end","finish_reason":"COMPLETE","response":{"response_id":"r1","text":"Hello","finish_reason":"COMPLETE","meta":{"billed_units":{"input_tokens":3,"output_tokens":2}}}}
+`))
+	})
+
+	stream, err := llm.GenerateContentStream(context.Background(),
+		[]llms.MessageContent{llms.TextParts(schema.ChatMessageTypeHuman, "Say hello")})
+	require.NoError(t, err)
+
+	var text string
+	for stream.Next() {
+		if e := stream.Event(); e.Type == llms.StreamEventText {
+			text += e.Text
+		}
+	}
+	require.NoError(t, stream.Err())
+
+	assert.Equal(t, true, got["stream"])
+	assert.Equal(t, "Hello", text)
+	assert.Equal(t, "Hello", stream.Response().Choices[0].Content)
+	assert.Equal(t, "COMPLETE", stream.Response().Choices[0].StopReason)
+	assert.Equal(t, &llms.Usage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5}, stream.Usage())
+}
+
+func TestCreateEmbedding(t *testing.T) {
+	t.Parallel()
+
+	var got map[string]any
+	llm := newTestLLM(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/embed", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte(`{"id":"e1","embeddings":[[0.1,0.2],[0.3,0.4]],"meta":{"billed_units":{"input_tokens":4}}}`))
+	}, WithEmbeddingInputType("search_query"))
+
+	embeddings, err := llm.CreateEmbedding(context.Background(), []string{"hello", "world"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{0.1, 0.2}, {0.3, 0.4}}, embeddings)
+	assert.Equal(t, []any{"hello", "world"}, got["texts"])
+	assert.Equal(t, "embed-english-v3.0", got["model"])
+	assert.Equal(t, "search_query", got["input_type"])
+}
+
+func TestRerank(t *testing.T) {
+	t.Parallel()
+
+	var got map[string]any
+	llm := newTestLLM(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/rerank", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte(`{"id":"r1","results":[{"index":2,"relevance_score":0.9},{"index":0,"relevance_score":0.2}]}`))
+	}, WithRerankModel("rerank-multilingual-v3.0"))
+
+	docs := []schema.Document{
+		{PageContent: "Carson City is the capital of Nevada.", Metadata: map[string]any{"source": "a"}},
+		{PageContent: "Penguins live in Antarctica."},
+		{PageContent: "Washington, D.C. is the capital of the United States."},
+	}
+	reranked, err := llm.Rerank(context.Background(), "What is the capital of the United States?", docs, 2)
+	require.NoError(t, err)
+
+	require.Len(t, reranked, 2)
+	assert.Equal(t, docs[2].PageContent, reranked[0].PageContent)
+	assert.InDelta(t, 0.9, reranked[0].Metadata["relevance_score"], 1e-9)
+	assert.Equal(t, map[string]any{"source": "a", "relevance_score": 0.2}, reranked[1].Metadata)
+	// The metadata of the original documents is left untouched.
+	assert.Equal(t, map[string]any{"source": "a"}, docs[0].Metadata)
+
+	assert.Equal(t, "rerank-multilingual-v3.0", got["model"])
+	assert.EqualValues(t, 2, got["top_n"])
+	assert.Len(t, got["documents"], 3)
+}
@@ -0,0 +1,169 @@
+package cohereclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Roles of the messages of a chat history.
+const (
+	RoleUser    = "USER"
+	RoleChatbot = "CHATBOT"
+	RoleSystem  = "SYSTEM"
+)
+
+// ChatMessage is a message of a chat history.
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Message string `json:"message"`
+}
+
+// Connector is a data source the model searches to ground its reply, e.g.
+// "web-search".
+type Connector struct {
+	ID string `json:"id"`
+}
+
+// ChatRequest is a request to the chat endpoint.
+type ChatRequest struct {
+	Message          string              `json:"message"`
+	Model            string              `json:"model,omitempty"`
+	Preamble         string              `json:"preamble,omitempty"`
+	ChatHistory      []ChatMessage       `json:"chat_history,omitempty"`
+	Documents        []map[string]string `json:"documents,omitempty"`
+	Connectors       []Connector         `json:"connectors,omitempty"`
+	Temperature      float64             `json:"temperature,omitempty"`
+	MaxTokens        int                 `json:"max_tokens,omitempty"`
+	StopSequences    []string            `json:"stop_sequences,omitempty"`
+	K                int                 `json:"k,omitempty"`
+	P                float64             `json:"p,omitempty"`
+	Seed             int                 `json:"seed,omitempty"`
+	FrequencyPenalty float64             `json:"frequency_penalty,omitempty"`
+	PresencePenalty  float64             `json:"presence_penalty,omitempty"`
+	Stream           bool                `json:"stream,omitempty"`
+
+	// StreamingFunc is a function to be called for each chunk of text of a
+	// streaming response. Return an error to stop streaming early.
+	StreamingFunc func(ctx context.Context, chunk []byte) error `json:"-"`
+
+	// StreamingEventFunc is a function to be called for each typed event of a
+	// streaming response. Return an error to stop streaming early.
+	StreamingEventFunc llms.StreamEventFunc `json:"-"`
+}
+
+// Citation is a span of the reply grounded on documents.
+type Citation struct {
+	Start       int      `json:"start"`
+	End         int      `json:"end"`
+	Text        string   `json:"text"`
+	DocumentIDs []string `json:"document_ids"`
+}
+
+// ChatResponse is a response of the chat endpoint.
+type ChatResponse struct {
+	ResponseID   string              `json:"response_id"`
+	GenerationID string              `json:"generation_id"`
+	Text         string              `json:"text"`
+	Citations    []Citation          `json:"citations,omitempty"`
+	Documents    []map[string]string `json:"documents,omitempty"`
+	FinishReason string              `json:"finish_reason"`
+	Meta         Meta                `json:"meta"`
+}
+
+// chatStreamEvent is an event of a streaming chat response.
+type chatStreamEvent struct {
+	EventType    string        `json:"event_type"`
+	Text         string        `json:"text"`
+	Citations    []Citation    `json:"citations"`
+	FinishReason string        `json:"finish_reason"`
+	Response     *ChatResponse `json:"response"`
+}
+
+// CreateChat sends a message to the chat endpoint.
+func (c *Client) CreateChat(ctx context.Context, r *ChatRequest) (*ChatResponse, error) {
+	if r.Model == "" {
+		r.Model = c.model
+	}
+	if r.StreamingFunc != nil || r.StreamingEventFunc != nil {
+		r.Stream = true
+	}
+
+	if !r.Stream {
+		var response ChatResponse
+		if err := c.decode(ctx, "/v1/chat", r, &response); err != nil {
+			return nil, err
+		}
+		return &response, nil
+	}
+
+	res, err := c.post(ctx, "/v1/chat", r)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	// The stream is a sequence of JSON events, one per line.
+	response := &ChatResponse{}
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var event chatStreamEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("failed to decode stream payload: %w", err)
+		}
+		if err := processChatStreamEvent(ctx, response, &event, r); err != nil {
+			return nil, fmt.Errorf("streaming func returned an error: %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("issue scanning response: %w", err)
+	}
+	return response, nil
+}
+
+// processChatStreamEvent adds a streamed event to the response, and passes it
+// on to the streaming functions of the request.
+func processChatStreamEvent(ctx context.Context, response *ChatResponse, event *chatStreamEvent, r *ChatRequest) error { //nolint:lll
+	emit := func(event llms.StreamEvent) error {
+		if r.StreamingEventFunc == nil {
+			return nil
+		}
+		return r.StreamingEventFunc(ctx, event)
+	}
+
+	switch event.EventType {
+	case "text-generation":
+		response.Text += event.Text
+		if r.StreamingFunc != nil {
+			if err := r.StreamingFunc(ctx, []byte(event.Text)); err != nil {
+				return err
+			}
+		}
+		return emit(llms.StreamEvent{Type: llms.StreamEventText, Text: event.Text})
+
+	case "citation-generation":
+		response.Citations = append(response.Citations, event.Citations...)
+
+	case "stream-end":
+		// The last event carries the complete response.
+		if event.Response != nil {
+			*response = *event.Response
+		}
+		response.FinishReason = event.FinishReason
+		if err := emit(llms.StreamEvent{Type: llms.StreamEventFinish, StopReason: event.FinishReason}); err != nil {
+			return err
+		}
+		units := response.Meta.BilledUnits
+		return emit(llms.StreamEvent{
+			Type:  llms.StreamEventUsage,
+			Usage: llms.NewUsage(int(units.InputTokens), int(units.OutputTokens)),
+		})
+	}
+	return nil
+}
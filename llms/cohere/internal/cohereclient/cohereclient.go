@@ -10,6 +10,11 @@ import (
 	"strings"
 
 	"github.com/cohere-ai/tokenizer"
+	"github.com/tmc/langchaingo/llms"
+)
+
+const (
+	defaultBaseURL = "https://api.cohere.ai"
 )
 
 var (
@@ -64,39 +69,32 @@ func New(token string, baseURL string, model string, opts ...Option) (*Client, e
 	return c, nil
 }
 
-type GenerationRequest struct {
-	Prompt string `json:"prompt"`
-}
-
-type Generation struct {
-	Text string `json:"text"`
+// errorResponse is the body of error responses.
+type errorResponse struct {
+	Message string `json:"message"`
 }
 
-type generateRequestPayload struct {
-	Prompt string `json:"prompt"`
-	Model  string `json:"model"`
+// BilledUnits are the units billed for a request.
+type BilledUnits struct {
+	InputTokens     float64 `json:"input_tokens"`
+	OutputTokens    float64 `json:"output_tokens"`
+	SearchUnits     float64 `json:"search_units"`
+	Classifications float64 `json:"classifications"`
 }
 
-type generateResponsePayload struct {
-	ID          string `json:"id,omitempty"`
-	Message     string `json:"message,omitempty"`
-	Generations []struct {
-		ID   string `json:"id,omitempty"`
-		Text string `json:"text,omitempty"`
-	} `json:"generations,omitempty"`
+// Meta is the metadata of a response.
+type Meta struct {
+	BilledUnits BilledUnits `json:"billed_units"`
 }
 
-func (c *Client) CreateGeneration(ctx context.Context, r *GenerationRequest) (*Generation, error) {
+// post sends a request with the given payload to the endpoint at path, and
+// returns the response. The caller must close its body.
+func (c *Client) post(ctx context.Context, path string, payload any) (*http.Response, error) {
 	if c.baseURL == "" {
-		c.baseURL = "https://api.cohere.ai"
+		c.baseURL = defaultBaseURL
 	}
 
-	payload := generateRequestPayload{
-		Prompt: r.Prompt,
-		Model:  c.model,
-	}
-
-	payloadBytes, err := json.Marshal(&payload)
+	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
 		return nil, fmt.Errorf("marshal payload: %w", err)
 	}
@@ -104,7 +102,7 @@ func (c *Client) CreateGeneration(ctx context.Context, r *GenerationRequest) (*G
 	req, err := http.NewRequestWithContext(
 		ctx,
 		http.MethodPost,
-		fmt.Sprintf("%s/v1/generate", c.baseURL),
+		fmt.Sprintf("%s%s", strings.TrimSuffix(c.baseURL, "/"), path),
 		bytes.NewReader(payloadBytes),
 	)
 	if err != nil {
@@ -118,22 +116,32 @@ func (c *Client) CreateGeneration(ctx context.Context, r *GenerationRequest) (*G
 	if err != nil {
 		return nil, fmt.Errorf("send request: %w", err)
 	}
-	defer res.Body.Close()
-
-	var response generateResponsePayload
-	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
-	}
 
-	if len(response.Generations) == 0 {
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		// No need to check the error here: if it fails, we'll just return the
+		// status code.
+		var response errorResponse
+		_ = json.NewDecoder(res.Body).Decode(&response)
 		if strings.HasPrefix(response.Message, "model not found") {
 			return nil, ErrModelNotFound
 		}
-		return nil, ErrEmptyResponse
+		return nil, llms.NewHTTPError(res, response.Message)
 	}
+	return res, nil
+}
 
-	var generation Generation
-	generation.Text = response.Generations[0].Text
+// decode sends a request with the given payload to the endpoint at path, and
+// decodes the response into v.
+func (c *Client) decode(ctx context.Context, path string, payload, v any) error {
+	res, err := c.post(ctx, path, payload)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
 
-	return &generation, nil
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		return fmt.Errorf("parse response: %w", err)
+	}
+	return nil
 }
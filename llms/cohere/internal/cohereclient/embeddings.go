@@ -0,0 +1,46 @@
+package cohereclient
+
+import (
+	"context"
+)
+
+const (
+	defaultEmbeddingModel     = "embed-english-v3.0"
+	defaultEmbeddingInputType = "search_document"
+)
+
+// EmbeddingRequest is a request to the embed endpoint.
+type EmbeddingRequest struct {
+	Texts []string `json:"texts"`
+	Model string   `json:"model,omitempty"`
+	// InputType is the purpose of the embeddings, one of "search_document",
+	// "search_query", "classification" or "clustering".
+	InputType string `json:"input_type,omitempty"`
+	Truncate  string `json:"truncate,omitempty"`
+}
+
+// EmbeddingResponse is a response of the embed endpoint.
+type EmbeddingResponse struct {
+	ID         string      `json:"id"`
+	Embeddings [][]float32 `json:"embeddings"`
+	Meta       Meta        `json:"meta"`
+}
+
+// CreateEmbedding embeds texts.
+func (c *Client) CreateEmbedding(ctx context.Context, r *EmbeddingRequest) (*EmbeddingResponse, error) {
+	if r.Model == "" {
+		r.Model = defaultEmbeddingModel
+	}
+	if r.InputType == "" {
+		r.InputType = defaultEmbeddingInputType
+	}
+
+	var response EmbeddingResponse
+	if err := c.decode(ctx, "/v1/embed", r, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Embeddings) != len(r.Texts) {
+		return nil, ErrEmptyResponse
+	}
+	return &response, nil
+}
@@ -0,0 +1,46 @@
+package cohereclient
+
+import (
+	"context"
+)
+
+const (
+	defaultRerankModel = "rerank-english-v3.0"
+)
+
+// RerankRequest is a request to the rerank endpoint.
+type RerankRequest struct {
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	Model     string   `json:"model,omitempty"`
+	// TopN is the number of results to return, or 0 for all of them.
+	TopN int `json:"top_n,omitempty"`
+}
+
+// RerankResult is the relevance of a document to the query.
+type RerankResult struct {
+	// Index is the index of the document in the request.
+	Index          int     `json:"index"`
+	RelevanceScore float64 `json:"relevance_score"`
+}
+
+// RerankResponse is a response of the rerank endpoint. Results are sorted
+// by decreasing relevance.
+type RerankResponse struct {
+	ID      string         `json:"id"`
+	Results []RerankResult `json:"results"`
+	Meta    Meta           `json:"meta"`
+}
+
+// Rerank sorts documents by relevance to a query.
+func (c *Client) Rerank(ctx context.Context, r *RerankRequest) (*RerankResponse, error) {
+	if r.Model == "" {
+		r.Model = defaultRerankModel
+	}
+
+	var response RerankResponse
+	if err := c.decode(ctx, "/v1/rerank", r, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
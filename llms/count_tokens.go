@@ -2,66 +2,59 @@ package llms
 
 import (
 	"log"
-
-	"github.com/pkoukk/tiktoken-go"
+	"sync"
 )
 
 const (
 	_tokenApproximation = 4
 )
 
-const (
-	_gpt35TurboContextSize   = 4096
-	_gpt432KContextSize      = 32768
-	_gpt4ContextSize         = 8192
-	_textDavinci3ContextSize = 4097
-	_textBabbage1ContextSize = 2048
-	_textAda1ContextSize     = 2048
-	_textCurie1ContextSize   = 2048
-	_codeDavinci2ContextSize = 8000
-	_codeCushman1ContextSize = 2048
-	_textBisonContextSize    = 2048
-	_chatBisonContextSize    = 2048
-	_defaultContextSize      = 2048
-)
-
-// nolint:gochecknoglobals
-var modelToContextSize = map[string]int{
-	"gpt-3.5-turbo":    _gpt35TurboContextSize,
-	"gpt-4-32k":        _gpt432KContextSize,
-	"gpt-4":            _gpt4ContextSize,
-	"text-davinci-003": _textDavinci3ContextSize,
-	"text-curie-001":   _textCurie1ContextSize,
-	"text-babbage-001": _textBabbage1ContextSize,
-	"text-ada-001":     _textBabbage1ContextSize,
-	"code-davinci-002": _codeDavinci2ContextSize,
-	"code-cushman-001": _codeCushman1ContextSize,
-}
+const _defaultContextSize = 2048
 
-// ModelContextSize gets the max number of tokens for a language model. If the model
-// name isn't recognized the default value 2048 is returned.
+// GetModelContextSize gets the max number of tokens for a language model from
+// the DefaultModelRegistry. If the model name isn't recognized the default
+// value 2048 is returned.
 func GetModelContextSize(model string) int {
-	contextSize, ok := modelToContextSize[model]
-	if !ok {
+	info, ok := LookupModel("", model)
+	if !ok || info.ContextWindow == 0 {
 		return _defaultContextSize
 	}
-	return contextSize
+	return info.ContextWindow
 }
 
-// CountTokens gets the number of tokens the text contains.
+// CountTokens gets the number of tokens the text contains, using the tokenizer
+// of the model. Models without a known tokenizer are counted with the gpt2
+// encoding, or approximately if the model is known to use another tokenizer.
 func CountTokens(model, text string) int {
-	e, err := tiktoken.EncodingForModel(model)
+	t, err := TokenizerForModel(model)
 	if err != nil {
-		e, err = tiktoken.GetEncoding("gpt2")
-		if err != nil {
-			log.Printf("[WARN] Failed to calculate number of tokens for model, falling back to approximate count")
-			return len([]rune(text)) / _tokenApproximation
+		t = fallbackTokenizer(model)
+	}
+	return t.Count(text)
+}
+
+// approximatedModels are the models whose tokens were counted approximately,
+// so that the warning is logged once per model.
+var approximatedModels sync.Map //nolint:gochecknoglobals
+
+func fallbackTokenizer(model string) Tokenizer {
+	if _, ok := LookupModel("", model); !ok {
+		if t, err := NewTiktokenTokenizer("gpt2"); err == nil {
+			return t
 		}
 	}
-	return len(e.Encode(text, nil, nil))
+	if _, warned := approximatedModels.LoadOrStore(model, struct{}{}); !warned {
+		log.Printf("[WARN] Failed to calculate number of tokens for model %q, falling back to approximate count", model)
+	}
+	return approximateTokenizer{}
 }
 
 // CalculateMaxTokens calculates the max number of tokens that could be added to a text.
+// It is limited by the max output tokens of the model, if known.
 func CalculateMaxTokens(model, text string) int {
-	return GetModelContextSize(model) - CountTokens(model, text)
+	maxTokens := GetModelContextSize(model) - CountTokens(model, text)
+	if info, ok := LookupModel("", model); ok && info.MaxOutputTokens > 0 && maxTokens > info.MaxOutputTokens {
+		return info.MaxOutputTokens
+	}
+	return maxTokens
 }
@@ -11,13 +11,15 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/tmc/langchaingo/llms"
 )
 
 var (
 	ErrNotSetAuth      = errors.New("both accessToken and apiKey secretKey are not set")
-	ErrCompletionCode  = errors.New("completion API returned unexpected status code")
-	ErrAccessTokenCode = errors.New("get access_token API returned unexpected status code")
-	ErrEmbeddingCode   = errors.New("embedding API returned unexpected status code")
+	ErrCompletionCode  = errors.New("completion API request failed")
+	ErrAccessTokenCode = errors.New("get access_token API request failed")
+	ErrEmbeddingCode   = errors.New("embedding API request failed")
 	ErrEmptyResponse   = errors.New("empty response")
 )
 
@@ -206,7 +208,7 @@ func (c *Client) CreateCompletion(ctx context.Context, modelPath ModelPath, r *C
 
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("%w: %d", ErrCompletionCode, resp.StatusCode)
+		return nil, fmt.Errorf("%w: %w", ErrCompletionCode, llms.NewHTTPError(resp, ""))
 	}
 
 	if r.Stream {
@@ -242,7 +244,7 @@ func (c *Client) CreateEmbedding(ctx context.Context, texts []string) (*Embeddin
 
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("%w: %d", ErrEmbeddingCode, resp.StatusCode)
+		return nil, fmt.Errorf("%w: %w", ErrEmbeddingCode, llms.NewHTTPError(resp, ""))
 	}
 
 	var response EmbeddingResponse
@@ -267,7 +269,7 @@ func (c *Client) getAccessToken(ctx context.Context) (*authResponse, error) {
 
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("%w: %d", ErrAccessTokenCode, resp.StatusCode)
+		return nil, fmt.Errorf("%w: %w", ErrAccessTokenCode, llms.NewHTTPError(resp, ""))
 	}
 
 	var response authResponse
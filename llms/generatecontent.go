@@ -82,10 +82,47 @@ type BinaryContent struct {
 
 func (BinaryContent) isPart() {}
 
+// ToolCall is a call to a tool requested by the model. It appears in
+// ContentChoice.ToolCalls, and as a part of AI messages sent back to the model
+// in later turns.
+type ToolCall struct {
+	// ID is the unique identifier of the tool call, used to match the call
+	// with its ToolCallResponse. It is empty for legacy function calls.
+	ID string `json:"id"`
+	// Type is the type of the tool call, typically "function".
+	Type string `json:"type"`
+	// FunctionCall is the function call to be executed.
+	FunctionCall *schema.FunctionCall `json:"function,omitempty"`
+}
+
+func (ToolCall) isPart() {}
+
+// ToolCallResponse is the result of executing a ToolCall, sent back to the
+// model in a message with the schema.ChatMessageTypeTool role, or with the
+// schema.ChatMessageTypeFunction role for legacy function calls.
+type ToolCallResponse struct {
+	// ToolCallID is the ID of the tool call this is a response to.
+	ToolCallID string `json:"tool_call_id"`
+	// Name is the name of the tool that was called.
+	Name string `json:"name"`
+	// Content is the textual result of the call.
+	Content string `json:"content"`
+}
+
+func (ToolCallResponse) isPart() {}
+
 // ContentResponse is the response returned by a GenerateContent call.
 // It can potentially return multiple content choices.
 type ContentResponse struct {
 	Choices []*ContentChoice
+
+	// Usage is the number of tokens used by the call, summed over all choices.
+	// It is nil when the model doesn't report usage.
+	Usage *Usage
+
+	// Model is the name of the model that served the call, as reported by the
+	// provider, or empty if it is unknown.
+	Model string
 }
 
 // ContentChoice is one of the response choices returned by GenerateContent
@@ -102,6 +139,10 @@ type ContentChoice struct {
 
 	// FuncCall is non-nil when the model asks to invoke a function/tool.
 	FuncCall *schema.FunctionCall
+
+	// ToolCalls is a list of tool calls the model asks to invoke. A model may
+	// request several tool calls in a single turn.
+	ToolCalls []ToolCall
 }
 
 // TextParts is a helper function to create a MessageContent with a role and a
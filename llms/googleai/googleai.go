@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"strings"
 
 	"github.com/google/generative-ai-go/genai"
@@ -15,10 +14,16 @@ import (
 )
 
 var (
-	ErrNoContentInResponse    = errors.New("no content in generation response")
-	ErrUnknownPartInResponse  = errors.New("unknown part type in generation response")
-	ErrInvalidMimeType        = errors.New("invalid mime type on content")
+	ErrNoContentInResponse   = errors.New("no content in generation response")
+	ErrUnknownPartInResponse = errors.New("unknown part type in generation response")
+	ErrInvalidMimeType       = errors.New("invalid mime type on content")
+	// ErrNoUserMessage is returned when a conversation doesn't end with a user
+	// turn, which Gemini requires. System messages are moved to the start of
+	// the conversation, so they don't count.
+	ErrNoUserMessage = errors.New("the last message must be a human, tool or function message")
+	// Deprecated: system messages are supported; this error isn't returned.
 	ErrSystemRoleNotSupported = errors.New("system role isn't supporeted yet")
+	ErrToolsNotSupported      = errors.New("tools aren't supported by this provider")
 )
 
 const (
@@ -33,6 +38,11 @@ func (g *GoogleAI) Call(ctx context.Context, prompt string, options ...llms.Call
 	return llms.GenerateFromSinglePrompt(ctx, g, prompt, options...)
 }
 
+// GenerateContentStream implements the [llms.StreamingModel] interface.
+func (g *GoogleAI) GenerateContentStream(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentStream, error) {
+	return llms.GenerateContentEventStream(ctx, g, messages, options...)
+}
+
 // GenerateContent implements the [llms.Model] interface.
 func (g *GoogleAI) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
 	if g.CallbacksHandler != nil {
@@ -51,6 +61,23 @@ func (g *GoogleAI) GenerateContent(ctx context.Context, messages []llms.MessageC
 		opt(&opts)
 	}
 
+	response, ok, err := g.generateWithTools(ctx, messages, &opts)
+	if !ok {
+		response, err = g.generateWithModel(ctx, messages, &opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if g.CallbacksHandler != nil {
+		g.CallbacksHandler.HandleLLMGenerateContentEnd(ctx, response)
+	}
+
+	return response, nil
+}
+
+// generateWithModel generates content with the SDK's generative model.
+func (g *GoogleAI) generateWithModel(ctx context.Context, messages []llms.MessageContent, opts *llms.CallOptions) (*llms.ContentResponse, error) {
 	model := g.client.GenerativeModel(opts.Model)
 	model.SetCandidateCount(int32(opts.CandidateCount))
 	model.SetMaxOutputTokens(int32(opts.MaxTokens))
@@ -58,27 +85,32 @@ func (g *GoogleAI) GenerateContent(ctx context.Context, messages []llms.MessageC
 	model.SetTopP(float32(opts.TopP))
 	model.SetTopK(int32(opts.TopK))
 	model.StopSequences = opts.StopWords
-
-	var response *llms.ContentResponse
-	var err error
-
-	if len(messages) == 1 {
-		theMessage := messages[0]
-		if theMessage.Role != schema.ChatMessageTypeHuman {
-			return nil, fmt.Errorf("got %v message role, want human", theMessage.Role)
-		}
-		response, err = generateFromSingleMessage(ctx, model, theMessage.Parts, &opts)
-	} else {
-		response, err = generateFromMessages(ctx, model, messages, &opts)
+	if err := convertTools(model, opts); err != nil {
+		return nil, err
 	}
+
+	contents, err := convertMessages(messages)
 	if err != nil {
 		return nil, err
 	}
 
-	if g.CallbacksHandler != nil {
-		g.CallbacksHandler.HandleLLMGenerateContentEnd(ctx, response)
+	if len(contents) == 1 {
+		return generateFromSingleMessage(ctx, model, contents[0].Parts, opts)
 	}
+	return generateFromMessages(ctx, model, contents, opts)
+}
 
+// convertResponse converts a complete genai.GenerateContentResponse to a
+// response.
+func convertResponse(resp *genai.GenerateContentResponse) (*llms.ContentResponse, error) {
+	if len(resp.Candidates) == 0 {
+		return nil, ErrNoContentInResponse
+	}
+	response, err := convertCandidates(resp.Candidates)
+	if err != nil {
+		return nil, err
+	}
+	response.Usage = updateUsage(nil, resp)
 	return response, nil
 }
 
@@ -88,6 +120,7 @@ func convertCandidates(candidates []*genai.Candidate) (*llms.ContentResponse, er
 
 	for _, candidate := range candidates {
 		buf := strings.Builder{}
+		var toolCalls []llms.ToolCall
 
 		if candidate.Content != nil {
 			for _, part := range candidate.Content.Parts {
@@ -96,6 +129,8 @@ func convertCandidates(candidates []*genai.Candidate) (*llms.ContentResponse, er
 					if err != nil {
 						return nil, err
 					}
+				} else if toolCall, ok := convertResponsePart(part); ok {
+					toolCalls = append(toolCalls, *toolCall)
 				} else {
 					return nil, ErrUnknownPartInResponse
 				}
@@ -106,12 +141,16 @@ func convertCandidates(candidates []*genai.Candidate) (*llms.ContentResponse, er
 		metadata[CITATIONS] = candidate.CitationMetadata
 		metadata[SAFETY] = candidate.SafetyRatings
 
-		contentResponse.Choices = append(contentResponse.Choices,
-			&llms.ContentChoice{
-				Content:        buf.String(),
-				StopReason:     candidate.FinishReason.String(),
-				GenerationInfo: metadata,
-			})
+		choice := &llms.ContentChoice{
+			Content:        buf.String(),
+			StopReason:     candidate.FinishReason.String(),
+			GenerationInfo: metadata,
+			ToolCalls:      toolCalls,
+		}
+		if len(toolCalls) > 0 {
+			choice.FuncCall = toolCalls[0].FunctionCall
+		}
+		contentResponse.Choices = append(contentResponse.Choices, choice)
 	}
 	return &contentResponse, nil
 }
@@ -133,6 +172,12 @@ func convertParts(parts []llms.ContentPart) ([]genai.Part, error) {
 				return nil, err
 			}
 			out = genai.ImageData(typ, data)
+		case llms.ToolCall, llms.ToolCallResponse:
+			var err error
+			out, err = convertToolPart(p)
+			if err != nil {
+				return nil, err
+			}
 		}
 
 		convertedParts = append(convertedParts, out)
@@ -140,44 +185,101 @@ func convertParts(parts []llms.ContentPart) ([]genai.Part, error) {
 	return convertedParts, nil
 }
 
-// convertContent converts between a langchain MessageContent and genai content.
-func convertContent(content llms.MessageContent) (*genai.Content, error) {
-	parts, err := convertParts(content.Parts)
-	if err != nil {
-		return nil, err
+// arrangeMessages returns messages in the order Gemini accepts them. Neither
+// SDK supports a system instruction yet, so system messages are moved to the
+// start of the conversation and sent as a user turn, which is the way Gemini
+// recommends to instruct models without one. Turns must alternate between the
+// user and the model, so the parts of consecutive messages with the same role
+// are merged into one message.
+func arrangeMessages(messages []llms.MessageContent) ([]llms.MessageContent, error) {
+	ordered := make([]llms.MessageContent, 0, len(messages))
+	for _, mc := range messages {
+		if mc.Role == schema.ChatMessageTypeSystem {
+			ordered = append(ordered, mc)
+		}
+	}
+	for _, mc := range messages {
+		if mc.Role != schema.ChatMessageTypeSystem {
+			ordered = append(ordered, mc)
+		}
 	}
 
-	c := &genai.Content{
-		Parts: parts,
+	arranged := make([]llms.MessageContent, 0, len(ordered))
+	lastRole := ""
+	for _, mc := range ordered {
+		role, err := convertRole(mc.Role)
+		if err != nil {
+			return nil, err
+		}
+		if role == lastRole {
+			last := &arranged[len(arranged)-1]
+			parts := make([]llms.ContentPart, 0, len(last.Parts)+len(mc.Parts))
+			last.Parts = append(append(parts, last.Parts...), mc.Parts...)
+			continue
+		}
+		arranged = append(arranged, mc)
+		lastRole = role
 	}
+	return arranged, nil
+}
 
-	switch content.Role {
-	case schema.ChatMessageTypeSystem:
-		return nil, ErrSystemRoleNotSupported
+// convertRole converts a langchain message role to a genai role. Tool and
+// function messages, which carry the responses to function calls, and system
+// messages are sent as user contents.
+func convertRole(role schema.ChatMessageType) (string, error) {
+	switch role {
 	case schema.ChatMessageTypeAI:
-		c.Role = RoleModel
-	case schema.ChatMessageTypeHuman:
-		c.Role = RoleUser
-	case schema.ChatMessageTypeGeneric:
-		c.Role = RoleUser
-	case schema.ChatMessageTypeFunction:
-		fallthrough
-	default:
-		return nil, fmt.Errorf("role %v not supported", content.Role)
+		return RoleModel, nil
+	case schema.ChatMessageTypeHuman, schema.ChatMessageTypeGeneric,
+		schema.ChatMessageTypeTool, schema.ChatMessageTypeFunction,
+		schema.ChatMessageTypeSystem:
+		return RoleUser, nil
 	}
+	return "", fmt.Errorf("role %v not supported", role) //nolint:goerr113
+}
 
-	return c, nil
+// convertMessages converts langchain messages to genai contents, the last of
+// which is from the user and is the request.
+func convertMessages(messages []llms.MessageContent) ([]*genai.Content, error) {
+	arranged, err := arrangeMessages(messages)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := make([]*genai.Content, 0, len(arranged))
+	for _, mc := range arranged {
+		content, err := convertContent(mc)
+		if err != nil {
+			return nil, err
+		}
+		contents = append(contents, content)
+	}
+
+	if len(contents) == 0 || contents[len(contents)-1].Role != RoleUser {
+		return nil, ErrNoUserMessage
+	}
+	return contents, nil
 }
 
-// generateFromSingleMessage generates content from the parts of a single
-// message.
-func generateFromSingleMessage(ctx context.Context, model *genai.GenerativeModel, parts []llms.ContentPart, opts *llms.CallOptions) (*llms.ContentResponse, error) {
-	convertedParts, err := convertParts(parts)
+// convertContent converts between a langchain MessageContent and genai content.
+func convertContent(content llms.MessageContent) (*genai.Content, error) {
+	role, err := convertRole(content.Role)
 	if err != nil {
 		return nil, err
 	}
 
-	if opts.StreamingFunc == nil {
+	parts, err := convertParts(content.Parts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &genai.Content{Role: role, Parts: parts}, nil
+}
+
+// generateFromSingleMessage generates content from the parts of a single
+// user content.
+func generateFromSingleMessage(ctx context.Context, model *genai.GenerativeModel, convertedParts []genai.Part, opts *llms.CallOptions) (*llms.ContentResponse, error) {
+	if opts.StreamingFunc == nil && opts.StreamingEventFunc == nil {
 		// When no streaming is requested, just call GenerateContent and return
 		// the complete response with a list of candidates.
 		resp, err := model.GenerateContent(ctx, convertedParts...)
@@ -185,48 +287,30 @@ func generateFromSingleMessage(ctx context.Context, model *genai.GenerativeModel
 			return nil, err
 		}
 
-		if len(resp.Candidates) == 0 {
-			return nil, ErrNoContentInResponse
-		}
-		return convertCandidates(resp.Candidates)
+		return convertResponse(resp)
 	}
 	iter := model.GenerateContentStream(ctx, convertedParts...)
 	return convertAndStreamFromIterator(ctx, iter, opts)
 }
 
-func generateFromMessages(ctx context.Context, model *genai.GenerativeModel, messages []llms.MessageContent, opts *llms.CallOptions) (*llms.ContentResponse, error) {
-	history := make([]*genai.Content, 0, len(messages))
-	for _, mc := range messages {
-		content, err := convertContent(mc)
-		if err != nil {
-			return nil, err
-		}
-		history = append(history, content)
-	}
-
-	// Given N total messages, genai's chat expects the first N-1 messages as
-	// history and the last message as the actual request.
-	n := len(history)
-	reqContent := history[n-1]
-	history = history[:n-1]
-
-	if reqContent.Role != RoleUser {
-		return nil, fmt.Errorf("got %v message role, want user/human", reqContent.Role)
-	}
+// generateFromMessages generates content from a conversation of contents.
+func generateFromMessages(ctx context.Context, model *genai.GenerativeModel, contents []*genai.Content, opts *llms.CallOptions) (*llms.ContentResponse, error) {
+	// Given N total contents, genai's chat expects the first N-1 contents as
+	// history and the last content as the actual request.
+	n := len(contents)
+	reqContent := contents[n-1]
+	history := contents[:n-1]
 
 	session := model.StartChat()
 	session.History = history
 
-	if opts.StreamingFunc == nil {
+	if opts.StreamingFunc == nil && opts.StreamingEventFunc == nil {
 		resp, err := session.SendMessage(ctx, reqContent.Parts...)
 		if err != nil {
 			return nil, err
 		}
 
-		if len(resp.Candidates) == 0 {
-			return nil, ErrNoContentInResponse
-		}
-		return convertCandidates(resp.Candidates)
+		return convertResponse(resp)
 	}
 	iter := session.SendMessageStream(ctx, reqContent.Parts...)
 	return convertAndStreamFromIterator(ctx, iter, opts)
@@ -234,13 +318,22 @@ func generateFromMessages(ctx context.Context, model *genai.GenerativeModel, mes
 
 // convertAndStreamFromIterator takes an iterator of GenerateContentResponse
 // and produces a llms.ContentResponse reply from it, while streaming the
-// resulting text into the opts-provided streaming function.
+// resulting text into the opts-provided streaming function, and typed events
+// into the opts-provided streaming event function.
 // Note that this is tricky in the face of multiple
 // candidates, so this code assumes only a single candidate for now.
 func convertAndStreamFromIterator(ctx context.Context, iter *genai.GenerateContentResponseIterator, opts *llms.CallOptions) (*llms.ContentResponse, error) {
 	candidate := &genai.Candidate{
 		Content: &genai.Content{},
 	}
+	emit := func(event llms.StreamEvent) error {
+		if opts.StreamingEventFunc == nil {
+			return nil
+		}
+		return opts.StreamingEventFunc(ctx, event)
+	}
+	toolCallIndex := 0
+	var usage *llms.Usage
 DoStream:
 	for {
 		resp, err := iter.Next()
@@ -248,13 +341,14 @@ DoStream:
 			break DoStream
 		}
 		if err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
 
 		if len(resp.Candidates) != 1 {
 			return nil, fmt.Errorf("expect single candidate in stream mode; got %v", len(resp.Candidates))
 		}
 		respCandidate := resp.Candidates[0]
+		usage = updateUsage(usage, resp)
 
 		if respCandidate.Content == nil {
 			break DoStream
@@ -268,12 +362,40 @@ DoStream:
 
 		for _, part := range respCandidate.Content.Parts {
 			if text, ok := part.(genai.Text); ok {
-				if opts.StreamingFunc(ctx, []byte(text)) != nil {
+				if opts.StreamingFunc != nil && opts.StreamingFunc(ctx, []byte(text)) != nil {
+					break DoStream
+				}
+				if emit(llms.StreamEvent{Type: llms.StreamEventText, Text: string(text)}) != nil {
+					break DoStream
+				}
+			} else if toolCall, ok := convertResponsePart(part); ok {
+				delta := &llms.ToolCallDelta{
+					Index:     toolCallIndex,
+					ID:        toolCall.ID,
+					Type:      toolCall.Type,
+					Name:      toolCall.FunctionCall.Name,
+					Arguments: toolCall.FunctionCall.Arguments,
+				}
+				toolCallIndex++
+				if emit(llms.StreamEvent{Type: llms.StreamEventToolCall, ToolCall: delta}) != nil {
 					break DoStream
 				}
 			}
 		}
 	}
 
-	return convertCandidates([]*genai.Candidate{candidate})
+	if usage != nil {
+		if err := emit(llms.StreamEvent{Type: llms.StreamEventUsage, Usage: usage}); err != nil {
+			return nil, err
+		}
+	}
+	if err := emit(llms.StreamEvent{Type: llms.StreamEventFinish, StopReason: candidate.FinishReason.String()}); err != nil {
+		return nil, err
+	}
+	response, err := convertCandidates([]*genai.Candidate{candidate})
+	if err != nil {
+		return nil, err
+	}
+	response.Usage = usage
+	return response, nil
 }
@@ -0,0 +1,60 @@
+package googleai
+
+import (
+	"testing"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+func TestConvertMessages(t *testing.T) {
+	t.Parallel()
+
+	contents, err := convertMessages([]llms.MessageContent{
+		llms.TextParts(schema.ChatMessageTypeHuman, "Hello"),
+		llms.TextParts(schema.ChatMessageTypeAI, "Ahoy"),
+		llms.TextParts(schema.ChatMessageTypeHuman, "Where are you?"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []*genai.Content{
+		{Role: RoleUser, Parts: []genai.Part{genai.Text("Hello")}},
+		{Role: RoleModel, Parts: []genai.Part{genai.Text("Ahoy")}},
+		{Role: RoleUser, Parts: []genai.Part{genai.Text("Where are you?")}},
+	}, contents)
+}
+
+func TestConvertMessagesSystem(t *testing.T) {
+	t.Parallel()
+
+	contents, err := convertMessages([]llms.MessageContent{
+		llms.TextParts(schema.ChatMessageTypeHuman, "Hello"),
+		llms.TextParts(schema.ChatMessageTypeSystem, "You are a pirate."),
+		llms.TextParts(schema.ChatMessageTypeAI, "Ahoy"),
+		llms.TextParts(schema.ChatMessageTypeHuman, "Where are you?"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []*genai.Content{
+		{Role: RoleUser, Parts: []genai.Part{genai.Text("You are a pirate."), genai.Text("Hello")}},
+		{Role: RoleModel, Parts: []genai.Part{genai.Text("Ahoy")}},
+		{Role: RoleUser, Parts: []genai.Part{genai.Text("Where are you?")}},
+	}, contents)
+}
+
+func TestConvertMessagesErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := convertMessages([]llms.MessageContent{
+		llms.TextParts(schema.ChatMessageTypeHuman, "Hello"),
+		llms.TextParts(schema.ChatMessageTypeAI, "Ahoy"),
+	})
+	require.ErrorIs(t, err, ErrNoUserMessage)
+
+	_, err = convertMessages([]llms.MessageContent{
+		llms.TextParts(schema.ChatMessageTypeSystem, "You are a pirate."),
+		llms.TextParts(schema.ChatMessageTypeAI, "Ahoy"),
+	})
+	require.ErrorIs(t, err, ErrNoUserMessage)
+}
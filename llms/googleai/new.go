@@ -5,6 +5,7 @@ package googleai
 import (
 	"context"
 
+	generativelanguage "cloud.google.com/go/ai/generativelanguage/apiv1beta"
 	"github.com/google/generative-ai-go/genai"
 	"github.com/tmc/langchaingo/callbacks"
 	"github.com/tmc/langchaingo/llms"
@@ -15,10 +16,14 @@ import (
 type GoogleAI struct {
 	CallbacksHandler callbacks.Handler
 	client           *genai.Client
+	toolsClient      *generativelanguage.GenerativeClient
 	opts             options
 }
 
-var _ llms.Model = &GoogleAI{}
+var (
+	_ llms.Model          = &GoogleAI{}
+	_ llms.StreamingModel = &GoogleAI{}
+)
 
 // New creates a new GoogleAI client.
 func New(ctx context.Context, opts ...Option) (*GoogleAI, error) {
@@ -37,5 +42,12 @@ func New(ctx context.Context, opts ...Option) (*GoogleAI, error) {
 	}
 
 	gi.client = client
+
+	toolsClient, err := generativelanguage.NewGenerativeClient(ctx, option.WithAPIKey(clientOptions.apiKey))
+	if err != nil {
+		return gi, err
+	}
+
+	gi.toolsClient = toolsClient
 	return gi, nil
 }
@@ -66,6 +66,8 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 				Content: results[0].Text,
 			},
 		},
+		// Usage isn't reported, so it is estimated.
+		Usage: llms.EstimateUsage(opts.Model, part.(llms.TextContent).Text, results[0].Text),
 	}
 	if o.CallbacksHandler != nil {
 		o.CallbacksHandler.HandleLLMGenerateContentEnd(ctx, resp)
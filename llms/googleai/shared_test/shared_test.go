@@ -76,6 +76,8 @@ var testFuncs = []testFunc{
 	testCandidateCountSetting,
 	testMaxTokensSetting,
 	testWithStreaming,
+	testSystemMessage,
+	testFunctionCall,
 }
 
 func TestShared(t *testing.T) {
@@ -327,3 +329,67 @@ func testMaxTokensSetting(t *testing.T, llm llms.Model) {
 		assert.Regexp(t, "(?i)dog|breed|canid|canine", c1.Content)
 	}
 }
+
+func testSystemMessage(t *testing.T, llm llms.Model) {
+	t.Helper()
+	t.Parallel()
+
+	content := []llms.MessageContent{
+		llms.TextParts(schema.ChatMessageTypeSystem, "You are a parrot. Repeat the user's message word for word."),
+		llms.TextParts(schema.ChatMessageTypeHuman, "Polly wants a cracker"),
+	}
+
+	rsp, err := llm.GenerateContent(context.Background(), content)
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, rsp.Choices)
+	c1 := rsp.Choices[0]
+	assert.Regexp(t, "(?i)polly wants a cracker", c1.Content)
+}
+
+func testFunctionCall(t *testing.T, llm llms.Model) {
+	t.Helper()
+	t.Parallel()
+
+	weather := llms.FunctionDefinition{
+		Name:        "getCurrentWeather",
+		Description: "Get the current weather in a given location",
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"location": map[string]any{"type": "string", "description": "The city, e.g. Paris"},
+			},
+			"required": []string{"location"},
+		},
+	}
+	content := []llms.MessageContent{
+		llms.TextParts(schema.ChatMessageTypeHuman, "What is the weather like in Paris?"),
+	}
+
+	rsp, err := llm.GenerateContent(context.Background(), content, llms.WithFunctions([]llms.FunctionDefinition{weather}))
+	require.NoError(t, err)
+	require.NotEmpty(t, rsp.Choices)
+	c1 := rsp.Choices[0]
+	require.NotNil(t, c1.FuncCall)
+	assert.Equal(t, weather.Name, c1.FuncCall.Name)
+	assert.Regexp(t, "(?i)paris", c1.FuncCall.Arguments)
+
+	content = append(content,
+		llms.MessageContent{
+			Role:  schema.ChatMessageTypeAI,
+			Parts: []llms.ContentPart{c1.ToolCalls[0]},
+		},
+		llms.MessageContent{
+			Role: schema.ChatMessageTypeFunction,
+			Parts: []llms.ContentPart{llms.ToolCallResponse{
+				Name:    weather.Name,
+				Content: `{"temperature": "21", "unit": "celsius", "sky": "sunny"}`,
+			}},
+		},
+	)
+
+	rsp, err = llm.GenerateContent(context.Background(), content, llms.WithFunctions([]llms.FunctionDefinition{weather}))
+	require.NoError(t, err)
+	require.NotEmpty(t, rsp.Choices)
+	assert.Regexp(t, "(?i)21|sunny", rsp.Choices[0].Content)
+}
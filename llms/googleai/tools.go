@@ -0,0 +1,444 @@
+package googleai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	pb "cloud.google.com/go/ai/generativelanguage/apiv1beta/generativelanguagepb"
+	"github.com/google/generative-ai-go/genai"
+	"github.com/tmc/langchaingo/internal/util"
+	"github.com/tmc/langchaingo/jsonschema"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// The version of the Google AI SDK used by this package doesn't support
+// function calling yet, so calls that use tools are sent with the v1beta
+// Generative Language API client, which does.
+// Unlike googleai.go, this file is not shared with the vertex package.
+
+// generateWithTools generates content for the calls that the SDK can't make:
+// those that send tools or functions, or whose messages hold tool calls or
+// tool call responses. It reports whether the call was one of them.
+func (g *GoogleAI) generateWithTools(ctx context.Context, messages []llms.MessageContent, opts *llms.CallOptions) (*llms.ContentResponse, bool, error) {
+	if !usesTools(messages, opts) {
+		return nil, false, nil
+	}
+
+	req, err := newToolsRequest(messages, opts)
+	if err != nil {
+		return nil, true, err
+	}
+
+	if opts.StreamingFunc == nil && opts.StreamingEventFunc == nil {
+		resp, err := g.toolsClient.GenerateContent(ctx, req)
+		if err != nil {
+			return nil, true, err
+		}
+		response, err := convertToolsResponse(resp)
+		return response, true, err
+	}
+	stream, err := g.toolsClient.StreamGenerateContent(ctx, req)
+	if err != nil {
+		return nil, true, err
+	}
+	response, err := convertAndStreamFromToolsStream(ctx, stream, opts)
+	return response, true, err
+}
+
+// convertTools sets the tools and functions from opts on the model. Calls
+// with tools are made by generateWithTools, so the model never has any.
+func convertTools(_ *genai.GenerativeModel, _ *llms.CallOptions) error {
+	return nil
+}
+
+// convertToolPart converts a tool call or tool call response part to a genai
+// part. Calls with tool parts are made by generateWithTools, so the SDK is
+// never asked to send one.
+func convertToolPart(_ llms.ContentPart) (genai.Part, error) {
+	return nil, ErrToolsNotSupported
+}
+
+// convertResponsePart converts a non-text part of a response candidate to a
+// tool call, if it is one.
+func convertResponsePart(_ genai.Part) (*llms.ToolCall, bool) {
+	return nil, false
+}
+
+// usesTools reports whether a call sends tools or functions, or tool calls or
+// tool call responses in its messages.
+func usesTools(messages []llms.MessageContent, opts *llms.CallOptions) bool {
+	if len(opts.Tools) > 0 || len(opts.Functions) > 0 {
+		return true
+	}
+	for _, mc := range messages {
+		for _, part := range mc.Parts {
+			switch part.(type) {
+			case llms.ToolCall, llms.ToolCallResponse:
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// newToolsRequest builds the request for a call made by generateWithTools.
+func newToolsRequest(messages []llms.MessageContent, opts *llms.CallOptions) (*pb.GenerateContentRequest, error) {
+	arranged, err := arrangeMessages(messages)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := make([]*pb.Content, 0, len(arranged))
+	for _, mc := range arranged {
+		role, err := convertRole(mc.Role)
+		if err != nil {
+			return nil, err
+		}
+		parts, err := convertToolsParts(mc.Parts)
+		if err != nil {
+			return nil, err
+		}
+		contents = append(contents, &pb.Content{Role: role, Parts: parts})
+	}
+	if len(contents) == 0 || contents[len(contents)-1].Role != RoleUser {
+		return nil, ErrNoUserMessage
+	}
+
+	tools, err := convertFunctionDeclarations(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	model := opts.Model
+	if !strings.HasPrefix(model, "models/") {
+		model = "models/" + model
+	}
+
+	return &pb.GenerateContentRequest{
+		Model:    model,
+		Contents: contents,
+		Tools:    tools,
+		GenerationConfig: &pb.GenerationConfig{
+			CandidateCount:  proto.Int32(int32(opts.CandidateCount)),
+			StopSequences:   opts.StopWords,
+			MaxOutputTokens: proto.Int32(int32(opts.MaxTokens)),
+			Temperature:     proto.Float32(float32(opts.Temperature)),
+			TopP:            proto.Float32(float32(opts.TopP)),
+			TopK:            proto.Int32(int32(opts.TopK)),
+		},
+	}, nil
+}
+
+// convertToolsParts converts between a sequence of langchain parts and
+// Generative Language API parts.
+func convertToolsParts(parts []llms.ContentPart) ([]*pb.Part, error) {
+	convertedParts := make([]*pb.Part, 0, len(parts))
+	for _, part := range parts {
+		var out *pb.Part
+
+		switch p := part.(type) {
+		case llms.TextContent:
+			out = &pb.Part{Data: &pb.Part_Text{Text: p.Text}}
+		case llms.BinaryContent:
+			out = &pb.Part{Data: &pb.Part_InlineData{InlineData: &pb.Blob{MimeType: p.MIMEType, Data: p.Data}}}
+		case llms.ImageURLContent:
+			typ, data, err := util.DownloadImageData(p.URL)
+			if err != nil {
+				return nil, err
+			}
+			out = &pb.Part{Data: &pb.Part_InlineData{InlineData: &pb.Blob{MimeType: "image/" + typ, Data: data}}}
+		case llms.ToolCall:
+			if p.FunctionCall == nil {
+				return nil, fmt.Errorf("tool call %v has no function call", p.ID) //nolint:goerr113
+			}
+			args := map[string]any{}
+			if p.FunctionCall.Arguments != "" {
+				if err := json.Unmarshal([]byte(p.FunctionCall.Arguments), &args); err != nil {
+					return nil, err
+				}
+			}
+			s, err := structpb.NewStruct(args)
+			if err != nil {
+				return nil, err
+			}
+			out = &pb.Part{Data: &pb.Part_FunctionCall{FunctionCall: &pb.FunctionCall{Name: p.FunctionCall.Name, Args: s}}}
+		case llms.ToolCallResponse:
+			// Function calls are identified by name, so the ID of the call is
+			// the name when the response has none.
+			name := p.Name
+			if name == "" {
+				name = p.ToolCallID
+			}
+			s, err := structpb.NewStruct(map[string]any{"content": p.Content})
+			if err != nil {
+				return nil, err
+			}
+			out = &pb.Part{Data: &pb.Part_FunctionResponse{FunctionResponse: &pb.FunctionResponse{Name: name, Response: s}}}
+		default:
+			return nil, fmt.Errorf("part %T not supported", part) //nolint:goerr113
+		}
+
+		convertedParts = append(convertedParts, out)
+	}
+	return convertedParts, nil
+}
+
+// convertFunctionDeclarations converts the tools and functions from opts to
+// function declarations.
+func convertFunctionDeclarations(opts *llms.CallOptions) ([]*pb.Tool, error) {
+	// The API version in use has no tool choice setting; "none" is expressed
+	// by not sending tools at all.
+	if opts.ToolChoice == llms.ToolChoiceNone || opts.FunctionCallBehavior == llms.FunctionCallBehaviorNone {
+		return nil, nil
+	}
+
+	functions := make([]llms.FunctionDefinition, 0, len(opts.Tools)+len(opts.Functions))
+	for _, t := range opts.Tools {
+		if t.Type != llms.ToolTypeFunction || t.Function == nil {
+			return nil, fmt.Errorf("tool type %v not supported", t.Type) //nolint:goerr113
+		}
+		functions = append(functions, *t.Function)
+	}
+	functions = append(functions, opts.Functions...)
+	if len(functions) == 0 {
+		return nil, nil
+	}
+
+	decls := make([]*pb.FunctionDeclaration, 0, len(functions))
+	for _, f := range functions {
+		params, err := convertSchema(f.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("function %v: %w", f.Name, err)
+		}
+		decls = append(decls, &pb.FunctionDeclaration{
+			Name:        f.Name,
+			Description: f.Description,
+			Parameters:  params,
+		})
+	}
+	return []*pb.Tool{{FunctionDeclarations: decls}}, nil
+}
+
+// convertSchema converts function parameters, given as any value that
+// marshals to a JSON schema, to a Generative Language API schema.
+func convertSchema(params any) (*pb.Schema, error) {
+	if params == nil {
+		return nil, nil //nolint:nilnil
+	}
+	b, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	var def jsonschema.Definition
+	if err := json.Unmarshal(b, &def); err != nil {
+		return nil, err
+	}
+	return convertDefinition(&def), nil
+}
+
+func convertDefinition(def *jsonschema.Definition) *pb.Schema {
+	if def == nil {
+		return nil
+	}
+	s := &pb.Schema{
+		Type:        convertDataType(def.Type),
+		Format:      def.Format,
+		Description: def.Description,
+		Nullable:    def.Nullable,
+		Enum:        def.Enum,
+		Items:       convertDefinition(def.Items),
+		Required:    def.Required,
+	}
+	if len(def.Properties) > 0 {
+		s.Properties = make(map[string]*pb.Schema, len(def.Properties))
+		for name, prop := range def.Properties {
+			prop := prop
+			s.Properties[name] = convertDefinition(&prop)
+		}
+	}
+	return s
+}
+
+func convertDataType(t jsonschema.DataType) pb.Type {
+	switch t {
+	case jsonschema.String:
+		return pb.Type_STRING
+	case jsonschema.Number:
+		return pb.Type_NUMBER
+	case jsonschema.Integer:
+		return pb.Type_INTEGER
+	case jsonschema.Boolean:
+		return pb.Type_BOOLEAN
+	case jsonschema.Array:
+		return pb.Type_ARRAY
+	case jsonschema.Object:
+		return pb.Type_OBJECT
+	}
+	return pb.Type_TYPE_UNSPECIFIED
+}
+
+// convertToolsResponse converts a complete Generative Language API response
+// to a response.
+func convertToolsResponse(resp *pb.GenerateContentResponse) (*llms.ContentResponse, error) {
+	if len(resp.Candidates) == 0 {
+		return nil, ErrNoContentInResponse
+	}
+	var response llms.ContentResponse
+	for _, candidate := range resp.Candidates {
+		choice, err := convertToolsCandidate(candidate)
+		if err != nil {
+			return nil, err
+		}
+		response.Choices = append(response.Choices, choice)
+	}
+	response.Usage = updateToolsUsage(nil, resp)
+	return &response, nil
+}
+
+// convertToolsCandidate converts a Generative Language API candidate to a
+// choice. Finish reasons are named as in genai, like those of the SDK's
+// responses.
+func convertToolsCandidate(candidate *pb.Candidate) (*llms.ContentChoice, error) {
+	buf := strings.Builder{}
+	var toolCalls []llms.ToolCall
+
+	for _, part := range candidate.GetContent().GetParts() {
+		switch d := part.Data.(type) {
+		case *pb.Part_Text:
+			buf.WriteString(d.Text)
+		case *pb.Part_FunctionCall:
+			toolCall, err := convertFunctionCall(d.FunctionCall)
+			if err != nil {
+				return nil, err
+			}
+			toolCalls = append(toolCalls, *toolCall)
+		default:
+			return nil, ErrUnknownPartInResponse
+		}
+	}
+
+	metadata := make(map[string]any)
+	metadata[CITATIONS] = candidate.CitationMetadata
+	metadata[SAFETY] = candidate.SafetyRatings
+
+	choice := &llms.ContentChoice{
+		Content:        buf.String(),
+		StopReason:     genai.FinishReason(candidate.FinishReason).String(),
+		GenerationInfo: metadata,
+		ToolCalls:      toolCalls,
+	}
+	if len(toolCalls) > 0 {
+		choice.FuncCall = toolCalls[0].FunctionCall
+	}
+	return choice, nil
+}
+
+// convertFunctionCall converts a function call of a response candidate to a
+// tool call.
+func convertFunctionCall(fc *pb.FunctionCall) (*llms.ToolCall, error) {
+	args, err := json.Marshal(fc.GetArgs().AsMap())
+	if err != nil {
+		return nil, err
+	}
+	return &llms.ToolCall{
+		// Gemini doesn't assign IDs to function calls; calls are matched with
+		// their responses by name.
+		ID:   fc.Name,
+		Type: llms.ToolTypeFunction,
+		FunctionCall: &schema.FunctionCall{
+			Name:      fc.Name,
+			Arguments: string(args),
+		},
+	}, nil
+}
+
+// convertAndStreamFromToolsStream is convertAndStreamFromIterator for the
+// responses of a Generative Language API stream.
+func convertAndStreamFromToolsStream(ctx context.Context, stream pb.GenerativeService_StreamGenerateContentClient, opts *llms.CallOptions) (*llms.ContentResponse, error) {
+	candidate := &pb.Candidate{
+		Content: &pb.Content{},
+	}
+	emit := func(event llms.StreamEvent) error {
+		if opts.StreamingEventFunc == nil {
+			return nil
+		}
+		return opts.StreamingEventFunc(ctx, event)
+	}
+	toolCallIndex := 0
+	var usage *llms.Usage
+DoStream:
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break DoStream
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(resp.Candidates) != 1 {
+			return nil, fmt.Errorf("expect single candidate in stream mode; got %v", len(resp.Candidates)) //nolint:goerr113
+		}
+		respCandidate := resp.Candidates[0]
+		usage = updateToolsUsage(usage, resp)
+
+		if respCandidate.Content == nil {
+			break DoStream
+		}
+		candidate.Content.Parts = append(candidate.Content.Parts, respCandidate.Content.Parts...)
+		candidate.Content.Role = respCandidate.Content.Role
+		candidate.FinishReason = respCandidate.FinishReason
+		candidate.SafetyRatings = respCandidate.SafetyRatings
+		candidate.CitationMetadata = respCandidate.CitationMetadata
+
+		for _, part := range respCandidate.Content.Parts {
+			switch d := part.Data.(type) {
+			case *pb.Part_Text:
+				if opts.StreamingFunc != nil && opts.StreamingFunc(ctx, []byte(d.Text)) != nil {
+					break DoStream
+				}
+				if emit(llms.StreamEvent{Type: llms.StreamEventText, Text: d.Text}) != nil {
+					break DoStream
+				}
+			case *pb.Part_FunctionCall:
+				toolCall, err := convertFunctionCall(d.FunctionCall)
+				if err != nil {
+					return nil, err
+				}
+				delta := &llms.ToolCallDelta{
+					Index:     toolCallIndex,
+					ID:        toolCall.ID,
+					Type:      toolCall.Type,
+					Name:      toolCall.FunctionCall.Name,
+					Arguments: toolCall.FunctionCall.Arguments,
+				}
+				toolCallIndex++
+				if emit(llms.StreamEvent{Type: llms.StreamEventToolCall, ToolCall: delta}) != nil {
+					break DoStream
+				}
+			}
+		}
+	}
+
+	stopReason := genai.FinishReason(candidate.FinishReason).String()
+	if usage != nil {
+		if err := emit(llms.StreamEvent{Type: llms.StreamEventUsage, Usage: usage}); err != nil {
+			return nil, err
+		}
+	}
+	if err := emit(llms.StreamEvent{Type: llms.StreamEventFinish, StopReason: stopReason}); err != nil {
+		return nil, err
+	}
+	choice, err := convertToolsCandidate(candidate)
+	if err != nil {
+		return nil, err
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{choice}, Usage: usage}, nil
+}
@@ -0,0 +1,180 @@
+package googleai
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	generativelanguage "cloud.google.com/go/ai/generativelanguage/apiv1beta"
+	pb "cloud.google.com/go/ai/generativelanguage/apiv1beta/generativelanguagepb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// fakeGenerativeService answers every request with resp, and records the last
+// request.
+type fakeGenerativeService struct {
+	pb.UnimplementedGenerativeServiceServer
+	resp *pb.GenerateContentResponse
+	req  *pb.GenerateContentRequest
+}
+
+func (s *fakeGenerativeService) GenerateContent(_ context.Context, req *pb.GenerateContentRequest) (*pb.GenerateContentResponse, error) {
+	s.req = req
+	return s.resp, nil
+}
+
+func (s *fakeGenerativeService) StreamGenerateContent(req *pb.GenerateContentRequest, stream pb.GenerativeService_StreamGenerateContentServer) error {
+	s.req = req
+	return stream.Send(s.resp)
+}
+
+func newToolsTestClient(t *testing.T, service *fakeGenerativeService) *GoogleAI {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	pb.RegisterGenerativeServiceServer(server, service)
+	go server.Serve(lis) //nolint:errcheck
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	client, err := generativelanguage.NewGenerativeClient(context.Background(), option.WithGRPCConn(conn))
+	require.NoError(t, err)
+
+	return &GoogleAI{toolsClient: client, opts: defaultOptions()}
+}
+
+func weatherCallResponse(t *testing.T) *pb.GenerateContentResponse {
+	t.Helper()
+
+	args, err := structpb.NewStruct(map[string]any{"city": "Paris"})
+	require.NoError(t, err)
+	return &pb.GenerateContentResponse{Candidates: []*pb.Candidate{{
+		Content: &pb.Content{Role: RoleModel, Parts: []*pb.Part{
+			{Data: &pb.Part_FunctionCall{FunctionCall: &pb.FunctionCall{Name: "weather", Args: args}}},
+		}},
+		FinishReason: pb.Candidate_STOP,
+		TokenCount:   7,
+	}}}
+}
+
+var weatherFunction = llms.FunctionDefinition{ //nolint:gochecknoglobals
+	Name:        "weather",
+	Description: "Get the weather",
+	Parameters: map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"city": map[string]any{"type": "string", "description": "City name"},
+			"note": map[string]any{"type": []string{"string", "null"}},
+		},
+		"required": []string{"city"},
+	},
+}
+
+func TestGenerateWithTools(t *testing.T) {
+	t.Parallel()
+
+	service := &fakeGenerativeService{resp: weatherCallResponse(t)}
+	llm := newToolsTestClient(t, service)
+
+	rsp, err := llm.GenerateContent(context.Background(), []llms.MessageContent{
+		llms.TextParts(schema.ChatMessageTypeSystem, "Be brief."),
+		llms.TextParts(schema.ChatMessageTypeHuman, "What's the weather in Paris?"),
+	}, llms.WithTools([]llms.Tool{{Type: llms.ToolTypeFunction, Function: &weatherFunction}}))
+	require.NoError(t, err)
+
+	req := service.req
+	assert.Equal(t, "models/gemini-pro", req.Model)
+	require.Len(t, req.Contents, 1)
+	assert.Equal(t, RoleUser, req.Contents[0].Role)
+	assert.Equal(t, "Be brief.", req.Contents[0].Parts[0].GetText())
+	assert.Equal(t, "What's the weather in Paris?", req.Contents[0].Parts[1].GetText())
+	require.Len(t, req.Tools, 1)
+	decl := req.Tools[0].FunctionDeclarations[0]
+	assert.Equal(t, "weather", decl.Name)
+	assert.Equal(t, pb.Type_OBJECT, decl.Parameters.Type)
+	assert.Equal(t, []string{"city"}, decl.Parameters.Required)
+	assert.Equal(t, pb.Type_STRING, decl.Parameters.Properties["city"].Type)
+	assert.True(t, decl.Parameters.Properties["note"].Nullable)
+
+	require.Len(t, rsp.Choices, 1)
+	c1 := rsp.Choices[0]
+	assert.Equal(t, "FinishReasonStop", c1.StopReason)
+	require.Len(t, c1.ToolCalls, 1)
+	assert.Equal(t, "weather", c1.FuncCall.Name)
+	assert.JSONEq(t, `{"city":"Paris"}`, c1.FuncCall.Arguments)
+	assert.Equal(t, &llms.Usage{CompletionTokens: 7, TotalTokens: 7}, rsp.Usage)
+}
+
+func TestGenerateWithToolResponses(t *testing.T) {
+	t.Parallel()
+
+	service := &fakeGenerativeService{resp: &pb.GenerateContentResponse{Candidates: []*pb.Candidate{{
+		Content:      &pb.Content{Role: RoleModel, Parts: []*pb.Part{{Data: &pb.Part_Text{Text: "Sunny"}}}},
+		FinishReason: pb.Candidate_STOP,
+	}}}}
+	llm := newToolsTestClient(t, service)
+
+	rsp, err := llm.GenerateContent(context.Background(), []llms.MessageContent{
+		llms.TextParts(schema.ChatMessageTypeHuman, "What's the weather in Paris?"),
+		{Role: schema.ChatMessageTypeAI, Parts: []llms.ContentPart{llms.ToolCall{
+			ID:           "weather",
+			Type:         llms.ToolTypeFunction,
+			FunctionCall: &schema.FunctionCall{Name: "weather", Arguments: `{"city":"Paris"}`},
+		}}},
+		{Role: schema.ChatMessageTypeTool, Parts: []llms.ContentPart{llms.ToolCallResponse{
+			ToolCallID: "weather",
+			Content:    "sunny",
+		}}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Sunny", rsp.Choices[0].Content)
+
+	req := service.req
+	assert.Empty(t, req.Tools)
+	require.Len(t, req.Contents, 3)
+	call := req.Contents[1].Parts[0].GetFunctionCall()
+	require.NotNil(t, call)
+	assert.Equal(t, map[string]any{"city": "Paris"}, call.Args.AsMap())
+	resp := req.Contents[2].Parts[0].GetFunctionResponse()
+	require.NotNil(t, resp)
+	assert.Equal(t, "weather", resp.Name)
+	assert.Equal(t, map[string]any{"content": "sunny"}, resp.Response.AsMap())
+}
+
+func TestGenerateWithToolsStreaming(t *testing.T) {
+	t.Parallel()
+
+	service := &fakeGenerativeService{resp: weatherCallResponse(t)}
+	llm := newToolsTestClient(t, service)
+
+	var events []llms.StreamEvent
+	rsp, err := llm.GenerateContent(context.Background(), []llms.MessageContent{
+		llms.TextParts(schema.ChatMessageTypeHuman, "What's the weather in Paris?"),
+	},
+		llms.WithFunctions([]llms.FunctionDefinition{weatherFunction}),
+		llms.WithStreamingEventFunc(func(_ context.Context, event llms.StreamEvent) error {
+			events = append(events, event)
+			return nil
+		}))
+	require.NoError(t, err)
+
+	require.Len(t, events, 3)
+	assert.Equal(t, llms.StreamEventToolCall, events[0].Type)
+	assert.Equal(t, "weather", events[0].ToolCall.Name)
+	assert.Equal(t, llms.StreamEventUsage, events[1].Type)
+	assert.Equal(t, llms.StreamEventFinish, events[2].Type)
+	assert.Equal(t, "FinishReasonStop", events[2].StopReason)
+	assert.Equal(t, "weather", rsp.Choices[0].FuncCall.Name)
+}
@@ -0,0 +1,37 @@
+package googleai
+
+import (
+	pb "cloud.google.com/go/ai/generativelanguage/apiv1beta/generativelanguagepb"
+	"github.com/google/generative-ai-go/genai"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// The version of the Google AI SDK used by this package only reports the
+// number of tokens of each candidate, so prompt tokens aren't counted.
+// Unlike googleai.go, this file is not shared with the vertex package.
+
+// updateUsage adds the usage reported in resp to usage, which is nil for the
+// first response of a call, and returns the result.
+func updateUsage(usage *llms.Usage, resp *genai.GenerateContentResponse) *llms.Usage {
+	if usage == nil {
+		usage = &llms.Usage{}
+	}
+	for _, c := range resp.Candidates {
+		usage.CompletionTokens += int(c.TokenCount)
+		usage.TotalTokens += int(c.TokenCount)
+	}
+	return usage
+}
+
+// updateToolsUsage is updateUsage for the responses of calls made by
+// generateWithTools.
+func updateToolsUsage(usage *llms.Usage, resp *pb.GenerateContentResponse) *llms.Usage {
+	if usage == nil {
+		usage = &llms.Usage{}
+	}
+	for _, c := range resp.Candidates {
+		usage.CompletionTokens += int(c.TokenCount)
+		usage.TotalTokens += int(c.TokenCount)
+	}
+	return usage
+}
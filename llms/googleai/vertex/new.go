@@ -23,7 +23,10 @@ type Vertex struct {
 	palmClient       *palmclient.PaLMClient
 }
 
-var _ llms.Model = &Vertex{}
+var (
+	_ llms.Model          = &Vertex{}
+	_ llms.StreamingModel = &Vertex{}
+)
 
 // New creates a new Vertex client.
 func New(ctx context.Context, opts ...Option) (*Vertex, error) {
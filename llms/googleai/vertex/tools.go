@@ -0,0 +1,209 @@
+package vertex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"cloud.google.com/go/vertexai/genai"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// This file is not generated from googleai.go: tool support differs between
+// the Google AI and Vertex AI SDKs, so each package implements these hooks.
+
+// generateWithTools generates content for the calls that the SDK can't make.
+// The Vertex AI SDK supports tools, so there are none.
+func (g *Vertex) generateWithTools(_ context.Context, _ []llms.MessageContent, _ *llms.CallOptions) (*llms.ContentResponse, bool, error) {
+	return nil, false, nil
+}
+
+// convertTools sets the tools and functions from opts on the model as function
+// declarations.
+func convertTools(model *genai.GenerativeModel, opts *llms.CallOptions) error {
+	// Vertex has no tool choice setting; "none" is expressed by not sending
+	// tools at all.
+	if opts.ToolChoice == llms.ToolChoiceNone || opts.FunctionCallBehavior == llms.FunctionCallBehaviorNone {
+		return nil
+	}
+
+	functions := make([]llms.FunctionDefinition, 0, len(opts.Tools)+len(opts.Functions))
+	for _, t := range opts.Tools {
+		if t.Type != llms.ToolTypeFunction || t.Function == nil {
+			return fmt.Errorf("tool type %v not supported", t.Type) //nolint:goerr113
+		}
+		functions = append(functions, *t.Function)
+	}
+	functions = append(functions, opts.Functions...)
+	if len(functions) == 0 {
+		return nil
+	}
+
+	decls := make([]*genai.FunctionDeclaration, 0, len(functions))
+	for _, f := range functions {
+		params, err := convertSchema(f.Parameters)
+		if err != nil {
+			return fmt.Errorf("function %v: %w", f.Name, err)
+		}
+		decls = append(decls, &genai.FunctionDeclaration{
+			Name:        f.Name,
+			Description: f.Description,
+			Parameters:  params,
+		})
+	}
+	model.Tools = []*genai.Tool{{FunctionDeclarations: decls}}
+	return nil
+}
+
+// convertToolPart converts a tool call or tool call response part to a genai
+// part.
+func convertToolPart(part llms.ContentPart) (genai.Part, error) {
+	switch p := part.(type) {
+	case llms.ToolCall:
+		if p.FunctionCall == nil {
+			return nil, fmt.Errorf("tool call %v has no function call", p.ID) //nolint:goerr113
+		}
+		args := map[string]any{}
+		if p.FunctionCall.Arguments != "" {
+			if err := json.Unmarshal([]byte(p.FunctionCall.Arguments), &args); err != nil {
+				return nil, err
+			}
+		}
+		return genai.FunctionCall{Name: p.FunctionCall.Name, Args: args}, nil
+	case llms.ToolCallResponse:
+		// Function calls are identified by name, so the ID of the call is the
+		// name when the response has none.
+		name := p.Name
+		if name == "" {
+			name = p.ToolCallID
+		}
+		return genai.FunctionResponse{
+			Name:     name,
+			Response: map[string]any{"content": p.Content},
+		}, nil
+	}
+	return nil, fmt.Errorf("part %T is not a tool part", part) //nolint:goerr113
+}
+
+// convertResponsePart converts a non-text part of a response candidate to a
+// tool call, if it is one.
+func convertResponsePart(part genai.Part) (*llms.ToolCall, bool) {
+	fc, ok := part.(genai.FunctionCall)
+	if !ok {
+		return nil, false
+	}
+	args, err := json.Marshal(fc.Args)
+	if err != nil {
+		return nil, false
+	}
+	return &llms.ToolCall{
+		// Gemini doesn't assign IDs to function calls; calls are matched with
+		// their responses by name.
+		ID:   fc.Name,
+		Type: llms.ToolTypeFunction,
+		FunctionCall: &schema.FunctionCall{
+			Name:      fc.Name,
+			Arguments: string(args),
+		},
+	}, true
+}
+
+// jsonSchema is the subset of JSON schema that Vertex function declarations
+// support.
+type jsonSchema struct {
+	Type        schemaType             `json:"type"`
+	Format      string                 `json:"format"`
+	Description string                 `json:"description"`
+	Nullable    bool                   `json:"nullable"`
+	Enum        []string               `json:"enum"`
+	Items       *jsonSchema            `json:"items"`
+	Properties  map[string]*jsonSchema `json:"properties"`
+	Required    []string               `json:"required"`
+}
+
+// convertSchema converts function parameters, given as any value that
+// marshals to a JSON schema, to a genai schema.
+func convertSchema(params any) (*genai.Schema, error) {
+	if params == nil {
+		return nil, nil //nolint:nilnil
+	}
+	b, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	var s jsonSchema
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, err
+	}
+	return s.toGenAI(), nil
+}
+
+func (s *jsonSchema) toGenAI() *genai.Schema {
+	if s == nil {
+		return nil
+	}
+	out := &genai.Schema{
+		Type:        s.Type.toGenAI(),
+		Format:      s.Format,
+		Description: s.Description,
+		Nullable:    s.Nullable || s.Type.nullable,
+		Enum:        s.Enum,
+		Items:       s.Items.toGenAI(),
+		Required:    s.Required,
+	}
+	if len(s.Properties) > 0 {
+		out.Properties = make(map[string]*genai.Schema, len(s.Properties))
+		for name, prop := range s.Properties {
+			out.Properties[name] = prop.toGenAI()
+		}
+	}
+	return out
+}
+
+// schemaType is the type of a JSON schema, given either as the name of a
+// type or as a list of names of which only one may be other than "null".
+type schemaType struct {
+	name     string
+	nullable bool
+}
+
+func (t *schemaType) UnmarshalJSON(data []byte) error {
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		var name string
+		if err := json.Unmarshal(data, &name); err != nil {
+			return err
+		}
+		names = []string{name}
+	}
+	for _, name := range names {
+		switch {
+		case name == "null":
+			t.nullable = true
+		case t.name == "":
+			t.name = name
+		default:
+			return fmt.Errorf("unsupported list of types %s", data) //nolint:goerr113
+		}
+	}
+	return nil
+}
+
+func (t schemaType) toGenAI() genai.Type {
+	switch t.name {
+	case "string":
+		return genai.TypeString
+	case "number":
+		return genai.TypeNumber
+	case "integer":
+		return genai.TypeInteger
+	case "boolean":
+		return genai.TypeBoolean
+	case "array":
+		return genai.TypeArray
+	case "object":
+		return genai.TypeObject
+	}
+	return genai.TypeUnspecified
+}
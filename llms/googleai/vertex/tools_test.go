@@ -0,0 +1,113 @@
+package vertex
+
+import (
+	"testing"
+
+	"cloud.google.com/go/vertexai/genai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+func TestConvertTools(t *testing.T) {
+	t.Parallel()
+
+	model := &genai.GenerativeModel{}
+	opts := &llms.CallOptions{Tools: []llms.Tool{{
+		Type: "function",
+		Function: &llms.FunctionDefinition{
+			Name: "weather",
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"city":  map[string]any{"type": "string", "description": "City name"},
+					"days":  map[string]any{"type": "integer"},
+					"units": map[string]any{"type": "string", "enum": []string{"C", "F"}},
+					"note":  map[string]any{"type": []string{"string", "null"}},
+				},
+				"required": []string{"city"},
+			},
+		},
+	}}}
+	require.NoError(t, convertTools(model, opts))
+
+	require.Len(t, model.Tools, 1)
+	decl := model.Tools[0].FunctionDeclarations[0]
+	assert.Equal(t, "weather", decl.Name)
+	assert.Equal(t, genai.TypeObject, decl.Parameters.Type)
+	assert.Equal(t, []string{"city"}, decl.Parameters.Required)
+	assert.Equal(t, genai.TypeString, decl.Parameters.Properties["city"].Type)
+	assert.Equal(t, genai.TypeInteger, decl.Parameters.Properties["days"].Type)
+	assert.Equal(t, []string{"C", "F"}, decl.Parameters.Properties["units"].Enum)
+	assert.Equal(t, genai.TypeString, decl.Parameters.Properties["note"].Type)
+	assert.True(t, decl.Parameters.Properties["note"].Nullable)
+}
+
+func TestConvertToolParts(t *testing.T) {
+	t.Parallel()
+
+	part, err := convertToolPart(llms.ToolCall{
+		ID:           "weather",
+		Type:         "function",
+		FunctionCall: &schema.FunctionCall{Name: "weather", Arguments: `{"city":"Paris"}`},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, genai.FunctionCall{Name: "weather", Args: map[string]any{"city": "Paris"}}, part)
+
+	toolCall, ok := convertResponsePart(part)
+	require.True(t, ok)
+	assert.Equal(t, "weather", toolCall.FunctionCall.Name)
+	assert.JSONEq(t, `{"city":"Paris"}`, toolCall.FunctionCall.Arguments)
+
+	part, err = convertToolPart(llms.ToolCallResponse{Name: "weather", Content: "sunny"})
+	require.NoError(t, err)
+	assert.Equal(t, genai.FunctionResponse{Name: "weather", Response: map[string]any{"content": "sunny"}}, part)
+}
+
+func TestConvertToolsFunctions(t *testing.T) {
+	t.Parallel()
+
+	model := &genai.GenerativeModel{}
+	opts := &llms.CallOptions{Functions: []llms.FunctionDefinition{{
+		Name:        "weather",
+		Description: "Get the weather",
+		Parameters:  map[string]any{"type": "object"},
+	}}}
+	require.NoError(t, convertTools(model, opts))
+	require.Len(t, model.Tools, 1)
+	decl := model.Tools[0].FunctionDeclarations[0]
+	assert.Equal(t, "weather", decl.Name)
+	assert.Equal(t, "Get the weather", decl.Description)
+	assert.Equal(t, genai.TypeObject, decl.Parameters.Type)
+
+	model = &genai.GenerativeModel{}
+	opts.FunctionCallBehavior = llms.FunctionCallBehaviorNone
+	require.NoError(t, convertTools(model, opts))
+	assert.Empty(t, model.Tools)
+}
+
+func TestConvertMessagesFunctionRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	contents, err := convertMessages([]llms.MessageContent{
+		llms.TextParts(schema.ChatMessageTypeHuman, "What's the weather in Paris?"),
+		{
+			Role: schema.ChatMessageTypeAI,
+			Parts: []llms.ContentPart{llms.ToolCall{
+				Type:         "function",
+				FunctionCall: &schema.FunctionCall{Name: "weather", Arguments: `{"city":"Paris"}`},
+			}},
+		},
+		{
+			Role:  schema.ChatMessageTypeFunction,
+			Parts: []llms.ContentPart{llms.ToolCallResponse{ToolCallID: "weather", Content: "sunny"}},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, contents, 3)
+	assert.Equal(t, RoleModel, contents[1].Role)
+	assert.Equal(t, []genai.Part{genai.FunctionCall{Name: "weather", Args: map[string]any{"city": "Paris"}}}, contents[1].Parts)
+	assert.Equal(t, RoleUser, contents[2].Role)
+	assert.Equal(t, []genai.Part{genai.FunctionResponse{Name: "weather", Response: map[string]any{"content": "sunny"}}}, contents[2].Parts)
+}
@@ -0,0 +1,20 @@
+package vertex
+
+import (
+	"cloud.google.com/go/vertexai/genai"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// updateUsage adds the usage reported in resp to usage, which is nil for the
+// first response of a call, and returns the result. Vertex reports the usage
+// of the whole call so far, so the latest report replaces earlier ones.
+func updateUsage(usage *llms.Usage, resp *genai.GenerateContentResponse) *llms.Usage {
+	if resp.UsageMetadata == nil {
+		return usage
+	}
+	return &llms.Usage{
+		PromptTokens:     int(resp.UsageMetadata.PromptTokenCount),
+		CompletionTokens: int(resp.UsageMetadata.CandidatesTokenCount),
+		TotalTokens:      int(resp.UsageMetadata.TotalTokenCount),
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/tmc/langchaingo/callbacks"
 	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/chattemplate"
 	"github.com/tmc/langchaingo/llms/huggingface/internal/huggingfaceclient"
 )
 
@@ -19,6 +20,7 @@ var (
 type LLM struct {
 	CallbacksHandler callbacks.Handler
 	client           *huggingfaceclient.Client
+	template         *chattemplate.Template
 }
 
 var _ llms.Model = (*LLM)(nil)
@@ -40,12 +42,13 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 		opt(opts)
 	}
 
-	// Assume we get a single text message
-	msg0 := messages[0]
-	part := msg0.Parts[0]
+	prompt, stop, err := o.prompt(messages)
+	if err != nil {
+		return nil, err
+	}
 	result, err := o.client.RunInference(ctx, &huggingfaceclient.InferenceRequest{
 		Model:             o.client.Model,
-		Prompt:            part.(llms.TextContent).Text,
+		Prompt:            prompt,
 		Task:              huggingfaceclient.InferenceTaskTextGeneration,
 		Temperature:       opts.Temperature,
 		TopP:              opts.TopP,
@@ -54,6 +57,7 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 		MaxLength:         opts.MaxLength,
 		RepetitionPenalty: opts.RepetitionPenalty,
 		Seed:              opts.Seed,
+		Stop:              append(stop, opts.StopWords...),
 	})
 	if err != nil {
 		if o.CallbacksHandler != nil {
@@ -68,10 +72,31 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 				Content: result.Text,
 			},
 		},
+		// Usage isn't reported, so it is estimated.
+		Usage: llms.EstimateUsage(o.client.Model, prompt, result.Text),
+	}
+	if o.CallbacksHandler != nil {
+		o.CallbacksHandler.HandleLLMGenerateContentEnd(ctx, resp)
 	}
 	return resp, nil
 }
 
+// prompt returns the prompt of messages and the sequences ending the turns of
+// the model, if any.
+func (o *LLM) prompt(messages []llms.MessageContent) (string, []string, error) {
+	if o.template == nil {
+		// Assume we get a single text message
+		msg0 := messages[0]
+		part := msg0.Parts[0]
+		return part.(llms.TextContent).Text, nil, nil
+	}
+	prompt, err := o.template.Render(messages)
+	if err != nil {
+		return "", nil, err
+	}
+	return prompt, o.template.Stop[:len(o.template.Stop):len(o.template.Stop)], nil
+}
+
 func New(opts ...Option) (*LLM, error) {
 	options := &options{
 		token: os.Getenv(tokenEnvVarName),
@@ -93,7 +118,8 @@ func New(opts ...Option) (*LLM, error) {
 	}
 
 	return &LLM{
-		client: c,
+		client:   c,
+		template: options.template,
 	}, nil
 }
 
@@ -1,5 +1,7 @@
 package huggingface
 
+import "github.com/tmc/langchaingo/llms/chattemplate"
+
 const (
 	tokenEnvVarName = "HUGGINGFACEHUB_API_TOKEN"
 	defaultModel    = "gpt2"
@@ -10,6 +12,8 @@ type options struct {
 	token string
 	model string
 	url   string
+
+	template *chattemplate.Template
 }
 
 type Option func(*options)
@@ -37,3 +41,12 @@ func WithURL(url string) Option {
 		opts.url = url
 	}
 }
+
+// WithChatTemplate renders the messages of calls into a prompt with the chat
+// template of the model, which makes multi-turn conversations possible. If not
+// set, the text of the first message is the prompt.
+func WithChatTemplate(template chattemplate.Template) Option {
+	return func(opts *options) {
+		opts.template = &template
+	}
+}
@@ -39,6 +39,7 @@ type InferenceRequest struct {
 	MaxLength         int           `json:"max_length,omitempty"`
 	RepetitionPenalty float64       `json:"repetition_penalty,omitempty"`
 	Seed              int           `json:"seed,omitempty"`
+	Stop              []string      `json:"stop,omitempty"`
 }
 
 type InferenceResponse struct {
@@ -57,6 +58,7 @@ func (c *Client) RunInference(ctx context.Context, request *InferenceRequest) (*
 			MaxLength:         request.MaxLength,
 			RepetitionPenalty: request.RepetitionPenalty,
 			Seed:              request.Seed,
+			Stop:              request.Stop,
 		},
 	}
 	resp, err := c.runInference(ctx, payload)
@@ -0,0 +1,35 @@
+// Package streaming has helpers for models that wrap other models and need
+// to know whether a call streamed part of its response.
+package streaming
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Track wraps the streaming functions set in options, if any, so that
+// streamed is set once they are called. The options are returned with the
+// wrapped functions appended, to be passed to the wrapped model.
+func Track(options []llms.CallOption, streamed *atomic.Bool) []llms.CallOption {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	options = options[:len(options):len(options)]
+	if fn := opts.StreamingFunc; fn != nil {
+		options = append(options, llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+			streamed.Store(true)
+			return fn(ctx, chunk)
+		}))
+	}
+	if fn := opts.StreamingEventFunc; fn != nil {
+		options = append(options, llms.WithStreamingEventFunc(func(ctx context.Context, event llms.StreamEvent) error {
+			streamed.Store(true)
+			return fn(ctx, event)
+		}))
+	}
+	return options
+}
@@ -0,0 +1,214 @@
+package llamacpp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms/chattemplate"
+)
+
+// ErrNotGGUF is returned when the model file isn't a GGUF file.
+var ErrNotGGUF = errors.New("not a GGUF file")
+
+const ggufMagic = 0x46554747 // "GGUF" in little endian.
+
+// GGUF metadata value types.
+const (
+	ggufUint8 uint32 = iota
+	ggufInt8
+	ggufUint16
+	ggufInt16
+	ggufUint32
+	ggufInt32
+	ggufFloat32
+	ggufBool
+	ggufString
+	ggufArray
+	ggufUint64
+	ggufInt64
+	ggufFloat64
+)
+
+// ggufMetadata is the metadata of a GGUF model, without its arrays. Integers
+// are stored as uint64 or int64, floats as float64.
+type ggufMetadata map[string]any
+
+// readGGUFMetadata reads the metadata of the GGUF file at path. Only GGUF
+// versions 2 and later are supported.
+func readGGUFMetadata(path string) (ggufMetadata, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return decodeGGUFMetadata(bufio.NewReader(f))
+}
+
+func decodeGGUFMetadata(r io.Reader) (ggufMetadata, error) {
+	var magic uint32
+	if err := binary.Read(r, binary.LittleEndian, &magic); err != nil || magic != ggufMagic {
+		return nil, ErrNotGGUF
+	}
+	var header struct {
+		Version     uint32
+		TensorCount uint64
+		KVCount     uint64
+	}
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("read GGUF header: %w", err)
+	}
+	if header.Version < 2 {
+		return nil, fmt.Errorf("%w: version %d not supported", ErrNotGGUF, header.Version)
+	}
+
+	d := ggufDecoder{r: r}
+	meta := make(ggufMetadata)
+	for i := uint64(0); i < header.KVCount && d.err == nil; i++ {
+		key := d.string()
+		typ := d.uint32()
+		if typ == ggufArray {
+			d.skipArray()
+			continue
+		}
+		meta[key] = d.value(typ)
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("read GGUF metadata: %w", d.err)
+	}
+	return meta, nil
+}
+
+// ggufDecoder decodes GGUF values, recording the first error.
+type ggufDecoder struct {
+	r   io.Reader
+	err error
+}
+
+func (d *ggufDecoder) read(v any) {
+	if d.err == nil {
+		d.err = binary.Read(d.r, binary.LittleEndian, v)
+	}
+}
+
+func (d *ggufDecoder) uint32() uint32 {
+	var v uint32
+	d.read(&v)
+	return v
+}
+
+func (d *ggufDecoder) uint64() uint64 {
+	var v uint64
+	d.read(&v)
+	return v
+}
+
+func (d *ggufDecoder) string() string {
+	n := d.uint64()
+	if d.err != nil {
+		return ""
+	}
+	var sb strings.Builder
+	_, d.err = io.CopyN(&sb, d.r, int64(n))
+	return sb.String()
+}
+
+func (d *ggufDecoder) value(typ uint32) any { //nolint:cyclop
+	switch typ {
+	case ggufUint8:
+		var v uint8
+		d.read(&v)
+		return uint64(v)
+	case ggufInt8:
+		var v int8
+		d.read(&v)
+		return int64(v)
+	case ggufUint16:
+		var v uint16
+		d.read(&v)
+		return uint64(v)
+	case ggufInt16:
+		var v int16
+		d.read(&v)
+		return int64(v)
+	case ggufUint32:
+		return uint64(d.uint32())
+	case ggufInt32:
+		var v int32
+		d.read(&v)
+		return int64(v)
+	case ggufFloat32:
+		var v float32
+		d.read(&v)
+		return float64(v)
+	case ggufBool:
+		var v uint8
+		d.read(&v)
+		return v != 0
+	case ggufString:
+		return d.string()
+	case ggufUint64:
+		return d.uint64()
+	case ggufInt64:
+		var v int64
+		d.read(&v)
+		return v
+	case ggufFloat64:
+		var v float64
+		d.read(&v)
+		return v
+	}
+	if d.err == nil {
+		d.err = fmt.Errorf("unknown value type %d", typ) //nolint:goerr113
+	}
+	return nil
+}
+
+func (d *ggufDecoder) skipArray() {
+	typ := d.uint32()
+	n := d.uint64()
+	for i := uint64(0); i < n && d.err == nil; i++ {
+		if typ == ggufArray {
+			d.skipArray()
+			continue
+		}
+		d.value(typ)
+	}
+}
+
+// contextLength returns the context length the model was trained with, or 0
+// if unknown.
+func (m ggufMetadata) contextLength() int {
+	arch, _ := m["general.architecture"].(string)
+	n, _ := m[arch+".context_length"].(uint64)
+	return int(n)
+}
+
+// chatTemplate returns the built-in template matching the chat template of
+// the model, which is recognized from its special tokens. Unrecognized chat
+// templates are rendered as Jinja templates when possible. Models without a
+// chat template get the template of their architecture, or ChatML.
+func (m ggufMetadata) chatTemplate() chattemplate.Template {
+	source, _ := m["tokenizer.chat_template"].(string)
+	if template, ok := chattemplate.Detect(source); ok {
+		return template
+	}
+	if source != "" {
+		// The tokens are arrays, which aren't read, so the end-of-sequence
+		// token is unknown; the server stops at it anyway.
+		if template, err := chattemplate.Parse(source, ""); err == nil {
+			return template
+		}
+	}
+
+	arch, _ := m["general.architecture"].(string)
+	switch arch {
+	case "gemma", "gemma2":
+		return chattemplate.Gemma()
+	}
+	return chattemplate.ChatML()
+}
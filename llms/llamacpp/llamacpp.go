@@ -0,0 +1,260 @@
+// Package llamacpp runs GGUF models locally with llama.cpp, fully offline. The
+// model is loaded once by a llama.cpp server process owned by the LLM, which
+// listens on the loopback interface only and is stopped by Close; no separate
+// server needs to be run.
+package llamacpp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/chattemplate"
+	"github.com/tmc/langchaingo/schema"
+)
+
+var (
+	// ErrEmptyResponse is returned when the server returns an empty response.
+	ErrEmptyResponse = errors.New("no response")
+	// ErrMissingModel is returned when no model is given.
+	ErrMissingModel = errors.New("missing the path to the GGUF model, set it in the LLAMACPP_MODEL environment variable")
+	// ErrMissingBin is returned when the server binary isn't found.
+	ErrMissingBin = errors.New("missing the llama.cpp server binary, set its path in the LLAMACPP_SERVER_BIN environment variable") //nolint:lll
+	// ErrServerExited is returned when the server process exits unexpectedly.
+	ErrServerExited = errors.New("llama.cpp server exited")
+	// ErrStartupTimeout is returned when the model takes too long to load.
+	ErrStartupTimeout = errors.New("llama.cpp server not ready")
+	// ErrContextExceeded is returned when the last message and the tokens to
+	// generate don't fit in the context.
+	ErrContextExceeded = errors.New("prompt exceeds the context size")
+)
+
+// LLM is a GGUF model served by a llama.cpp server process. It is safe for
+// concurrent use; requests beyond the parallel slots of the server wait in a
+// queue.
+type LLM struct {
+	CallbacksHandler callbacks.Handler
+
+	server      *server
+	template    chattemplate.Template
+	contextSize int
+	// slots holds a value for each request being processed.
+	slots     chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+var (
+	_ llms.Model          = (*LLM)(nil)
+	_ llms.StreamingModel = (*LLM)(nil)
+)
+
+// New loads a model and returns an LLM serving it. Close must be called to
+// stop the server process.
+func New(opts ...Option) (*LLM, error) {
+	options := &options{
+		bin:            os.Getenv(binEnvVarName),
+		model:          os.Getenv(modelEnvVarName),
+		parallel:       1,
+		startupTimeout: defaultStartupTimeout,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if options.model == "" {
+		return nil, ErrMissingModel
+	}
+	if options.bin == "" {
+		options.bin = defaultBin
+	}
+	bin, err := exec.LookPath(options.bin)
+	if err != nil {
+		return nil, errors.Join(ErrMissingBin, err)
+	}
+	options.bin = bin
+	if options.parallel < 1 {
+		options.parallel = 1
+	}
+
+	meta, err := readGGUFMetadata(options.model)
+	if err != nil {
+		return nil, fmt.Errorf("read model %s: %w", options.model, err)
+	}
+	contextSize := options.contextSize
+	if contextSize == 0 {
+		contextSize = defaultContextSize
+		if n := meta.contextLength(); n > 0 && n < contextSize {
+			contextSize = n
+		}
+	}
+	template := meta.chatTemplate()
+	if options.template != nil {
+		template = *options.template
+	}
+
+	s, err := startServer(options, contextSize)
+	if err != nil {
+		return nil, err
+	}
+	return &LLM{
+		server:      s,
+		template:    template,
+		contextSize: contextSize,
+		slots:       make(chan struct{}, options.parallel),
+	}, nil
+}
+
+// Close stops the server process. Calls in progress fail.
+func (o *LLM) Close() error {
+	o.closeOnce.Do(func() {
+		o.closeErr = o.server.close()
+	})
+	return o.closeErr
+}
+
+// Call implements the Model interface.
+func (o *LLM) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, o, prompt, options...)
+}
+
+// GenerateContent implements the Model interface. The messages are rendered
+// with the chat template of the model. When they don't fit in the context
+// along with the tokens to generate, the oldest messages other than system
+// messages are dropped.
+func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) { //nolint:lll
+	if o.CallbacksHandler != nil {
+		o.CallbacksHandler.HandleLLMGenerateContentStart(ctx, messages)
+	}
+
+	opts := llms.CallOptions{
+		Temperature: defaultTemperature,
+		TopK:        defaultTopK,
+		TopP:        defaultTopP,
+		Seed:        defaultSeed,
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	select {
+	case o.slots <- struct{}{}:
+		defer func() { <-o.slots }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	resp, err := o.generate(ctx, messages, &opts)
+	if err != nil {
+		if o.CallbacksHandler != nil {
+			o.CallbacksHandler.HandleLLMError(ctx, err)
+		}
+		return nil, err
+	}
+
+	if o.CallbacksHandler != nil {
+		o.CallbacksHandler.HandleLLMGenerateContentEnd(ctx, resp)
+	}
+	return resp, nil
+}
+
+func (o *LLM) generate(ctx context.Context, messages []llms.MessageContent, opts *llms.CallOptions) (*llms.ContentResponse, error) { //nolint:lll
+	prompt, promptTokens, err := o.fitPrompt(ctx, messages, opts.MaxTokens)
+	if err != nil {
+		return nil, err
+	}
+	maxTokens := opts.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = o.contextSize - promptTokens
+	}
+
+	req := &completionRequest{
+		Prompt:        prompt,
+		NPredict:      maxTokens,
+		Temperature:   opts.Temperature,
+		TopK:          opts.TopK,
+		TopP:          opts.TopP,
+		RepeatPenalty: opts.RepetitionPenalty,
+		Seed:          opts.Seed,
+		Stop:          append(o.template.Stop[:len(o.template.Stop):len(o.template.Stop)], opts.StopWords...),
+		Stream:        opts.StreamingFunc != nil || opts.StreamingEventFunc != nil,
+		CachePrompt:   true,
+	}
+	result, err := o.server.complete(ctx, req, func(text string) error {
+		if opts.StreamingFunc != nil {
+			if err := opts.StreamingFunc(ctx, []byte(text)); err != nil {
+				return err
+			}
+		}
+		if opts.StreamingEventFunc != nil {
+			return opts.StreamingEventFunc(ctx, llms.StreamEvent{Type: llms.StreamEventText, Text: text})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stopReason := "stop"
+	if result.StoppedLimit {
+		stopReason = "length"
+	}
+	resp := &llms.ContentResponse{
+		Choices: []*llms.ContentChoice{{Content: result.Content, StopReason: stopReason}},
+		Usage:   llms.NewUsage(result.TokensEvaluated, result.TokensPredicted),
+	}
+	if opts.StreamingEventFunc != nil {
+		events := []llms.StreamEvent{
+			{Type: llms.StreamEventUsage, Usage: resp.Usage},
+			{Type: llms.StreamEventFinish, StopReason: stopReason},
+		}
+		for _, event := range events {
+			if err := opts.StreamingEventFunc(ctx, event); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return resp, nil
+}
+
+// fitPrompt renders messages into a prompt fitting in the context along with
+// maxTokens tokens, dropping the oldest messages other than system messages
+// as needed. It returns the prompt and its number of tokens.
+func (o *LLM) fitPrompt(ctx context.Context, messages []llms.MessageContent, maxTokens int) (string, int, error) {
+	for {
+		prompt, err := o.template.Render(messages)
+		if err != nil {
+			return "", 0, err
+		}
+		n, err := o.server.tokenize(ctx, prompt)
+		if err != nil {
+			return "", 0, err
+		}
+		if n+maxTokens < o.contextSize {
+			return prompt, n, nil
+		}
+
+		oldest := -1
+		for i := 0; i < len(messages)-1; i++ {
+			if messages[i].Role != schema.ChatMessageTypeSystem {
+				oldest = i
+				break
+			}
+		}
+		if oldest < 0 {
+			return "", 0, fmt.Errorf("%w: %d tokens, %d to generate, context of %d",
+				ErrContextExceeded, n, maxTokens, o.contextSize)
+		}
+		messages = append(messages[:oldest:oldest], messages[oldest+1:]...)
+	}
+}
+
+// GenerateContentStream implements the StreamingModel interface.
+func (o *LLM) GenerateContentStream(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentStream, error) { //nolint:lll
+	return llms.GenerateContentEventStream(ctx, o, messages, options...)
+}
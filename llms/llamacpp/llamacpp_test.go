@@ -0,0 +1,272 @@
+package llamacpp
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/chattemplate"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// TestMain runs a fake llama.cpp server when the test binary is started as
+// the server binary, with the arguments of a server.
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 && os.Args[1] == "--model" {
+		runFakeServer(os.Args[1:])
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// runFakeServer serves completions echoing their prompt. Prompts are
+// tokenized into words, and at most one completion is served at a time.
+func runFakeServer(args []string) {
+	fs := flag.NewFlagSet("llama-server", flag.ExitOnError)
+	port := fs.Int("port", 0, "")
+	fs.String("model", "", "")
+	fs.String("host", "", "")
+	fs.Int("ctx-size", 0, "")
+	fs.Int("parallel", 0, "")
+	fs.Int("n-gpu-layers", 0, "")
+	_ = fs.Parse(args)
+
+	var inFlight atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	})
+	mux.HandleFunc("/tokenize", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Content string `json:"content"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		tokens := make([]int, len(strings.Fields(req.Content)))
+		_ = json.NewEncoder(w).Encode(map[string]any{"tokens": tokens})
+	})
+	mux.HandleFunc("/completion", func(w http.ResponseWriter, r *http.Request) {
+		if inFlight.Add(1) > 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"error":{"message":"no slot available"}}`))
+			inFlight.Add(-1)
+			return
+		}
+		defer inFlight.Add(-1)
+		time.Sleep(5 * time.Millisecond)
+
+		var req completionRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		content := fmt.Sprintf("stop=%v n=%d temperature=%v top_k=%d top_p=%v seed=%d\n%s",
+			req.Stop, req.NPredict, req.Temperature, req.TopK, req.TopP, req.Seed, req.Prompt)
+		final := map[string]any{
+			"stop":             true,
+			"stopped_limit":    false,
+			"tokens_evaluated": len(strings.Fields(req.Prompt)),
+			"tokens_predicted": 2,
+		}
+		if !req.Stream {
+			final["content"] = content
+			_ = json.NewEncoder(w).Encode(final)
+			return
+		}
+		half := len(content) / 2
+		for _, chunk := range []string{content[:half], content[half:]} {
+			b, _ := json.Marshal(map[string]any{"content": chunk, "stop": false})
+			fmt.Fprintf(w, "data: %s\n\n", b)
+		}
+		b, _ := json.Marshal(final)
+		fmt.Fprintf(w, "data: %s\n\n", b)
+	})
+	_ = http.ListenAndServe(fmt.Sprintf("127.0.0.1:%d", *port), mux) //nolint:gosec
+}
+
+// writeGGUF writes a GGUF file without tensors, with the given metadata, whose
+// values must be strings, uint32s or string slices.
+func writeGGUF(t *testing.T, metadata map[string]any) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := func(v any) { require.NoError(t, binary.Write(&buf, binary.LittleEndian, v)) }
+	str := func(s string) {
+		w(uint64(len(s)))
+		buf.WriteString(s)
+	}
+	w(uint32(ggufMagic))
+	w(uint32(3))
+	w(uint64(0))
+	w(uint64(len(metadata)))
+	for k, v := range metadata {
+		str(k)
+		switch v := v.(type) {
+		case string:
+			w(ggufString)
+			str(v)
+		case uint32:
+			w(ggufUint32)
+			w(v)
+		case []string:
+			w(ggufArray)
+			w(ggufString)
+			w(uint64(len(v)))
+			for _, s := range v {
+				str(s)
+			}
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "model.gguf")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+	return path
+}
+
+func newTestLLM(t *testing.T, opts ...Option) *LLM {
+	t.Helper()
+
+	model := writeGGUF(t, map[string]any{
+		"general.architecture":    "llama",
+		"llama.context_length":    uint32(8192),
+		"tokenizer.ggml.tokens":   []string{"<s>", "</s>"},
+		"tokenizer.chat_template": "{% for m in messages %}<|im_start|>{{ m.role }}{% endfor %}",
+	})
+	llm, err := New(append([]Option{WithBin(os.Args[0]), WithModel(model), WithStartupTimeout(10 * time.Second)}, opts...)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, llm.Close()) })
+	return llm
+}
+
+func TestReadGGUFMetadata(t *testing.T) {
+	t.Parallel()
+
+	meta, err := readGGUFMetadata(writeGGUF(t, map[string]any{
+		"general.architecture":    "gemma",
+		"gemma.context_length":    uint32(8192),
+		"tokenizer.ggml.tokens":   []string{"<bos>", "<eos>"},
+		"tokenizer.chat_template": "",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, 8192, meta.contextLength())
+	assert.Equal(t, "gemma", meta.chatTemplate().Name)
+	assert.NotContains(t, meta, "tokenizer.ggml.tokens")
+
+	assert.Equal(t, "llama3", ggufMetadata{"tokenizer.chat_template": "<|start_header_id|>"}.chatTemplate().Name)
+	assert.Equal(t, "chatml", ggufMetadata{}.chatTemplate().Name)
+
+	path := filepath.Join(t.TempDir(), "model.bin")
+	require.NoError(t, os.WriteFile(path, []byte("not a model at all"), 0o600))
+	_, err = readGGUFMetadata(path)
+	require.ErrorIs(t, err, ErrNotGGUF)
+}
+
+func TestGenerateContent(t *testing.T) {
+	t.Parallel()
+
+	llm := newTestLLM(t)
+	resp, err := llm.GenerateContent(context.Background(), []llms.MessageContent{
+		llms.TextParts(schema.ChatMessageTypeSystem, "Be brief."),
+		llms.TextParts(schema.ChatMessageTypeHuman, "Hi"),
+	}, llms.WithStopWords([]string{"END"}), llms.WithMaxTokens(16), llms.WithTemperature(0), llms.WithSeed(0))
+	require.NoError(t, err)
+
+	// A zero temperature and seed are sent; unset settings are the server's.
+	want := "stop=[<|im_end|> END] n=16 temperature=0 top_k=40 top_p=0.95 seed=0\n" +
+		"<|im_start|>system\nBe brief.<|im_end|>\n<|im_start|>user\nHi<|im_end|>\n<|im_start|>assistant\n"
+	assert.Equal(t, want, resp.Choices[0].Content)
+	assert.Equal(t, "stop", resp.Choices[0].StopReason)
+	assert.Equal(t, 2, resp.Usage.CompletionTokens)
+}
+
+func TestGenerateContentStream(t *testing.T) {
+	t.Parallel()
+
+	llm := newTestLLM(t, WithChatTemplate(chattemplate.Gemma()))
+	stream, err := llm.GenerateContentStream(context.Background(), []llms.MessageContent{
+		llms.TextParts(schema.ChatMessageTypeHuman, "Hi"),
+	}, llms.WithMaxTokens(16))
+	require.NoError(t, err)
+
+	var text strings.Builder
+	var types []llms.StreamEventType
+	for stream.Next() {
+		e := stream.Event()
+		types = append(types, e.Type)
+		text.WriteString(e.Text)
+	}
+	require.NoError(t, stream.Err())
+
+	want := "stop=[<end_of_turn>] n=16 temperature=0.8 top_k=40 top_p=0.95 seed=-1\n" +
+		"<start_of_turn>user\nHi<end_of_turn>\n<start_of_turn>model\n"
+	assert.Equal(t, want, text.String())
+	assert.Equal(t, want, stream.Response().Choices[0].Content)
+	assert.Equal(t, []llms.StreamEventType{
+		llms.StreamEventText, llms.StreamEventText, llms.StreamEventUsage, llms.StreamEventFinish,
+	}, types)
+}
+
+func TestGenerateContentBoundedContext(t *testing.T) {
+	t.Parallel()
+
+	llm := newTestLLM(t, WithContextSize(12))
+	messages := []llms.MessageContent{
+		llms.TextParts(schema.ChatMessageTypeSystem, "Be brief."),
+		llms.TextParts(schema.ChatMessageTypeHuman, "one two three four"),
+		llms.TextParts(schema.ChatMessageTypeAI, "five six"),
+		llms.TextParts(schema.ChatMessageTypeHuman, "seven"),
+	}
+	resp, err := llm.GenerateContent(context.Background(), messages)
+	require.NoError(t, err)
+
+	content := resp.Choices[0].Content
+	assert.Contains(t, content, "Be brief.")
+	assert.Contains(t, content, "seven")
+	assert.NotContains(t, content, "one two three four")
+
+	_, err = llm.GenerateContent(context.Background(), messages, llms.WithMaxTokens(12))
+	require.ErrorIs(t, err, ErrContextExceeded)
+}
+
+func TestGenerateContentConcurrent(t *testing.T) {
+	t.Parallel()
+
+	llm := newTestLLM(t)
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := llm.Call(context.Background(), fmt.Sprintf("request %d", i), llms.WithMaxTokens(8))
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(WithBin(os.Args[0]))
+	if os.Getenv(modelEnvVarName) == "" {
+		require.ErrorIs(t, err, ErrMissingModel)
+	}
+
+	_, err = New(WithBin(filepath.Join(t.TempDir(), "missing")), WithModel("model.gguf"))
+	require.ErrorIs(t, err, ErrMissingBin)
+}
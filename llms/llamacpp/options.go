@@ -0,0 +1,113 @@
+package llamacpp
+
+import (
+	"time"
+
+	"github.com/tmc/langchaingo/llms/chattemplate"
+)
+
+const (
+	// The name of the environment variable that contains the path to the
+	// llama.cpp server binary.
+	binEnvVarName = "LLAMACPP_SERVER_BIN"
+	// The name of the environment variable that contains the path to the GGUF
+	// model.
+	modelEnvVarName = "LLAMACPP_MODEL"
+
+	defaultBin            = "llama-server"
+	defaultContextSize    = 4096
+	defaultStartupTimeout = 2 * time.Minute
+
+	// The sampling settings of calls that don't set them, which are those of
+	// the server. A seed of -1 picks a random seed.
+	defaultTemperature = 0.8
+	defaultTopK        = 40
+	defaultTopP        = 0.95
+	defaultSeed        = -1
+)
+
+type options struct {
+	bin            string
+	model          string
+	contextSize    int
+	threads        int
+	parallel       int
+	gpuLayers      int
+	template       *chattemplate.Template
+	args           []string
+	startupTimeout time.Duration
+}
+
+type Option func(*options)
+
+// WithBin sets the path to the llama.cpp server binary. If not set, the
+// LLAMACPP_SERVER_BIN environment variable is used, and then llama-server is
+// looked up in the PATH.
+func WithBin(bin string) Option {
+	return func(opts *options) {
+		opts.bin = bin
+	}
+}
+
+// WithModel sets the path to the GGUF model to load. If not set, the
+// LLAMACPP_MODEL environment variable is used.
+func WithModel(path string) Option {
+	return func(opts *options) {
+		opts.model = path
+	}
+}
+
+// WithContextSize sets the size of the context of each request, in tokens.
+// It defaults to 4096, or the context length of the model if smaller.
+func WithContextSize(n int) Option {
+	return func(opts *options) {
+		opts.contextSize = n
+	}
+}
+
+// WithThreads sets the number of CPU threads used for inference. The server
+// picks it if not set.
+func WithThreads(n int) Option {
+	return func(opts *options) {
+		opts.threads = n
+	}
+}
+
+// WithParallel sets the number of requests processed at the same time. Other
+// requests wait in a queue. It defaults to 1.
+func WithParallel(n int) Option {
+	return func(opts *options) {
+		opts.parallel = n
+	}
+}
+
+// WithGPULayers sets the number of layers offloaded to the GPU. No layers are
+// offloaded if not set, so inference runs on CPU.
+func WithGPULayers(n int) Option {
+	return func(opts *options) {
+		opts.gpuLayers = n
+	}
+}
+
+// WithChatTemplate sets the template conversations are rendered with. If not
+// set, it is picked from the chat template in the metadata of the model.
+func WithChatTemplate(template chattemplate.Template) Option {
+	return func(opts *options) {
+		opts.template = &template
+	}
+}
+
+// WithArgs passes extra arguments to the server binary.
+func WithArgs(args ...string) Option {
+	return func(opts *options) {
+		opts.args = append(opts.args, args...)
+	}
+}
+
+// WithStartupTimeout sets how long to wait for the model to load. It defaults
+// to 2 minutes.
+func WithStartupTimeout(d time.Duration) Option {
+	return func(opts *options) {
+		opts.startupTimeout = d
+	}
+}
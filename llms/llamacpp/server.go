@@ -0,0 +1,266 @@
+package llamacpp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// server is a llama.cpp server process, listening on the loopback interface.
+type server struct {
+	cmd        *exec.Cmd
+	url        string
+	httpClient *http.Client
+	stderr     *tailBuffer
+
+	// exited is closed when the process exits, after which waitErr is set.
+	exited  chan struct{}
+	waitErr error
+}
+
+// startServer starts a server loading the model, and waits until the model is
+// loaded. Each of the parallel slots of the server gets a context of
+// contextSize tokens.
+func startServer(opts *options, contextSize int) (*server, error) {
+	port, err := freePort()
+	if err != nil {
+		return nil, err
+	}
+	args := []string{
+		"--model", opts.model,
+		"--host", "127.0.0.1",
+		"--port", strconv.Itoa(port),
+		"--ctx-size", strconv.Itoa(contextSize * opts.parallel),
+		"--parallel", strconv.Itoa(opts.parallel),
+		"--n-gpu-layers", strconv.Itoa(opts.gpuLayers),
+	}
+	if opts.threads > 0 {
+		args = append(args, "--threads", strconv.Itoa(opts.threads))
+	}
+	args = append(args, opts.args...)
+
+	s := &server{
+		cmd:        exec.Command(opts.bin, args...), //nolint:gosec
+		url:        fmt.Sprintf("http://127.0.0.1:%d", port),
+		httpClient: &http.Client{},
+		stderr:     &tailBuffer{max: 4096},
+		exited:     make(chan struct{}),
+	}
+	s.cmd.Stderr = s.stderr
+	if err := s.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start llama.cpp server: %w", err)
+	}
+	go func() {
+		s.waitErr = s.cmd.Wait()
+		close(s.exited)
+	}()
+
+	if err := s.waitReady(opts.startupTimeout); err != nil {
+		s.close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// freePort returns a free TCP port of the loopback interface.
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// waitReady polls the health endpoint of the server until the model is
+// loaded.
+func (s *server) waitReady(timeout time.Duration) error {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	tick := time.NewTicker(50 * time.Millisecond) //nolint:gomnd
+	defer tick.Stop()
+	for {
+		resp, err := s.httpClient.Get(s.url + "/health")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+		select {
+		case <-s.exited:
+			return fmt.Errorf("%w: %v: %s", ErrServerExited, s.waitErr, s.stderr)
+		case <-deadline.C:
+			return fmt.Errorf("%w after %v: %s", ErrStartupTimeout, timeout, s.stderr)
+		case <-tick.C:
+		}
+	}
+}
+
+// close stops the server.
+func (s *server) close() error {
+	select {
+	case <-s.exited:
+		return nil
+	default:
+	}
+	if err := s.cmd.Process.Kill(); err != nil {
+		return err
+	}
+	<-s.exited
+	return nil
+}
+
+// post sends a request to an endpoint of the server. The body of the response
+// must be closed by the caller.
+func (s *server) post(ctx context.Context, path string, payload any) (*http.Response, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		select {
+		case <-s.exited:
+			return nil, fmt.Errorf("%w: %v: %s", ErrServerExited, s.waitErr, s.stderr)
+		default:
+		}
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var errResp struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&errResp)
+		return nil, llms.NewHTTPError(resp, errResp.Error.Message)
+	}
+	return resp, nil
+}
+
+// tokenize returns the number of tokens of content.
+func (s *server) tokenize(ctx context.Context, content string) (int, error) {
+	resp, err := s.post(ctx, "/tokenize", map[string]any{"content": content, "add_special": true})
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Tokens []json.RawMessage `json:"tokens"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, err
+	}
+	return len(result.Tokens), nil
+}
+
+// completionRequest is a request to the completion endpoint.
+type completionRequest struct {
+	Prompt        string   `json:"prompt"`
+	NPredict      int      `json:"n_predict"`
+	Temperature   float64  `json:"temperature"`
+	TopK          int      `json:"top_k"`
+	TopP          float64  `json:"top_p"`
+	RepeatPenalty float64  `json:"repeat_penalty,omitempty"`
+	Seed          int      `json:"seed"`
+	Stop          []string `json:"stop,omitempty"`
+	Stream        bool     `json:"stream"`
+	CachePrompt   bool     `json:"cache_prompt"`
+}
+
+// completionResponse is a response, or a chunk of a streamed response, of the
+// completion endpoint.
+type completionResponse struct {
+	Content         string `json:"content"`
+	Stop            bool   `json:"stop"`
+	StoppedLimit    bool   `json:"stopped_limit"`
+	TokensPredicted int    `json:"tokens_predicted"`
+	TokensEvaluated int    `json:"tokens_evaluated"`
+}
+
+// complete sends a completion request. When streaming, chunk is called with
+// the text of each chunk of the response, which is then accumulated.
+func (s *server) complete(ctx context.Context, req *completionRequest, chunk func(string) error) (*completionResponse, error) { //nolint:lll
+	resp, err := s.post(ctx, "/completion", req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if !req.Stream {
+		var result completionResponse
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return nil, fmt.Errorf("decode response: %w", err)
+		}
+		return &result, nil
+	}
+
+	var content strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024) //nolint:gomnd
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var event completionResponse
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return nil, fmt.Errorf("decode stream event: %w", err)
+		}
+		if event.Content != "" {
+			content.WriteString(event.Content)
+			if err := chunk(event.Content); err != nil {
+				return nil, err
+			}
+		}
+		if event.Stop {
+			event.Content = content.String()
+			return &event, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, ErrEmptyResponse
+}
+
+// tailBuffer is a writer keeping the last bytes written to it.
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+	max int
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.max {
+		b.buf = b.buf[len(b.buf)-b.max:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.TrimSpace(string(b.buf))
+}
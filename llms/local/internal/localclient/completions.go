@@ -6,7 +6,8 @@ import (
 )
 
 type completionPayload struct {
-	Prompt string `json:"prompt"`
+	Prompt string   `json:"prompt"`
+	Args   []string `json:"-"`
 }
 
 type completionResponsePayload struct {
@@ -14,11 +15,15 @@ type completionResponsePayload struct {
 }
 
 func (c *Client) createCompletion(ctx context.Context, payload *completionPayload) (*completionResponsePayload, error) {
-	// Append the prompt to the args
-	c.Args = append(c.Args, payload.Prompt)
+	// Append the arguments of the request and the prompt to a copy of the
+	// args, so that concurrent calls don't share them.
+	args := make([]string, 0, len(c.Args)+len(payload.Args)+1)
+	args = append(args, c.Args...)
+	args = append(args, payload.Args...)
+	args = append(args, payload.Prompt)
 
 	// #nosec G204
-	out, err := exec.CommandContext(ctx, c.BinPath, c.Args...).Output()
+	out, err := exec.CommandContext(ctx, c.BinPath, args...).Output()
 	if err != nil {
 		return nil, err
 	}
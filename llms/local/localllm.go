@@ -10,6 +10,7 @@ import (
 
 	"github.com/tmc/langchaingo/callbacks"
 	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/chattemplate"
 	"github.com/tmc/langchaingo/llms/local/internal/localclient"
 )
 
@@ -24,6 +25,7 @@ var (
 type LLM struct {
 	CallbacksHandler callbacks.Handler
 	client           *localclient.Client
+	template         *chattemplate.Template
 }
 
 var _ llms.Model = (*LLM)(nil)
@@ -33,28 +35,32 @@ func (o *LLM) Call(ctx context.Context, prompt string, options ...llms.CallOptio
 	return llms.GenerateFromSinglePrompt(ctx, o, prompt, options...)
 }
 
-func (o *LLM) appendGlobalsToArgs(opts llms.CallOptions) {
+// globalsAsArgs returns the CLI arguments in --key=value format set from the
+// call options.
+func globalsAsArgs(opts llms.CallOptions) []string {
+	var args []string
 	if opts.Temperature != 0 {
-		o.client.Args = append(o.client.Args, fmt.Sprintf("--temperature=%f", opts.Temperature))
+		args = append(args, fmt.Sprintf("--temperature=%f", opts.Temperature))
 	}
 	if opts.TopP != 0 {
-		o.client.Args = append(o.client.Args, fmt.Sprintf("--top_p=%f", opts.TopP))
+		args = append(args, fmt.Sprintf("--top_p=%f", opts.TopP))
 	}
 	if opts.TopK != 0 {
-		o.client.Args = append(o.client.Args, fmt.Sprintf("--top_k=%d", opts.TopK))
+		args = append(args, fmt.Sprintf("--top_k=%d", opts.TopK))
 	}
 	if opts.MinLength != 0 {
-		o.client.Args = append(o.client.Args, fmt.Sprintf("--min_length=%d", opts.MinLength))
+		args = append(args, fmt.Sprintf("--min_length=%d", opts.MinLength))
 	}
 	if opts.MaxLength != 0 {
-		o.client.Args = append(o.client.Args, fmt.Sprintf("--max_length=%d", opts.MaxLength))
+		args = append(args, fmt.Sprintf("--max_length=%d", opts.MaxLength))
 	}
 	if opts.RepetitionPenalty != 0 {
-		o.client.Args = append(o.client.Args, fmt.Sprintf("--repetition_penalty=%f", opts.RepetitionPenalty))
+		args = append(args, fmt.Sprintf("--repetition_penalty=%f", opts.RepetitionPenalty))
 	}
 	if opts.Seed != 0 {
-		o.client.Args = append(o.client.Args, fmt.Sprintf("--seed=%d", opts.Seed))
+		args = append(args, fmt.Sprintf("--seed=%d", opts.Seed))
 	}
+	return args
 }
 
 // GenerateContent implements the Model interface.
@@ -69,17 +75,20 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 		opt(opts)
 	}
 
-	// If o.client.GlobalAsArgs is true
+	// The client is shared between calls, so the arguments of this call are
+	// passed with the request rather than added to the client.
+	var args []string
 	if o.client.GlobalAsArgs {
-		// Then add the option to the args in --key=value format
-		o.appendGlobalsToArgs(*opts)
+		args = globalsAsArgs(*opts)
 	}
 
-	// Assume we get a single text message
-	msg0 := messages[0]
-	part := msg0.Parts[0]
+	prompt, err := o.prompt(messages)
+	if err != nil {
+		return nil, err
+	}
 	result, err := o.client.CreateCompletion(ctx, &localclient.CompletionRequest{
-		Prompt: part.(llms.TextContent).Text,
+		Prompt: prompt,
+		Args:   args,
 	})
 	if err != nil {
 		return nil, err
@@ -91,6 +100,8 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 				Content: result.Text,
 			},
 		},
+		// Usage isn't reported, so it is estimated.
+		Usage: llms.EstimateUsage("", prompt, result.Text),
 	}
 
 	if o.CallbacksHandler != nil {
@@ -100,6 +111,18 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 	return resp, nil
 }
 
+// prompt returns the prompt of messages, rendered with the chat template if
+// any.
+func (o *LLM) prompt(messages []llms.MessageContent) (string, error) {
+	if o.template != nil {
+		return o.template.Render(messages)
+	}
+	// Assume we get a single text message
+	msg0 := messages[0]
+	part := msg0.Parts[0]
+	return part.(llms.TextContent).Text, nil
+}
+
 // New creates a new local LLM implementation.
 func New(opts ...Option) (*LLM, error) {
 	options := &options{
@@ -118,6 +141,7 @@ func New(opts ...Option) (*LLM, error) {
 
 	c, err := localclient.New(path, options.globalAsArgs, strings.Split(options.args, " ")...)
 	return &LLM{
-		client: c,
+		client:   c,
+		template: options.template,
 	}, err
 }
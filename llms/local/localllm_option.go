@@ -1,5 +1,7 @@
 package local
 
+import "github.com/tmc/langchaingo/llms/chattemplate"
+
 const (
 	// The name of the environment variable that contains the path to the local LLM binary.
 	localLLMBinVarName = "LOCAL_LLM_BIN"
@@ -11,6 +13,7 @@ type options struct {
 	bin          string
 	args         string
 	globalAsArgs bool // build key-value arguments from global llms.Options
+	template     *chattemplate.Template
 }
 
 type Option func(*options)
@@ -38,3 +41,12 @@ func WithGlobalAsArgs() Option {
 		opts.globalAsArgs = true
 	}
 }
+
+// WithChatTemplate renders the messages of calls into a prompt with the chat
+// template of the model, which makes multi-turn conversations possible.
+// If not set, the text of the first message is the prompt.
+func WithChatTemplate(template chattemplate.Template) Option {
+	return func(opts *options) {
+		opts.template = &template
+	}
+}
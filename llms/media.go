@@ -0,0 +1,220 @@
+package llms
+
+import (
+	"context"
+	"time"
+)
+
+// ImageGenerator is a model that generates images from a prompt.
+type ImageGenerator interface {
+	// GenerateImages generates images from a prompt.
+	GenerateImages(ctx context.Context, prompt string, options ...ImageOption) ([]GeneratedImage, error)
+}
+
+// GeneratedImage is an image generated by an ImageGenerator. Depending on the
+// ImageFormat requested, either URL or Data is set.
+type GeneratedImage struct {
+	// URL is the URL the image can be downloaded from, for a limited time.
+	URL string
+	// MIMEType is the MIME type of Data.
+	MIMEType string
+	// Data is the content of the image.
+	Data []byte
+	// RevisedPrompt is the prompt the image was generated from, when the
+	// model rewrote the prompt given.
+	RevisedPrompt string
+}
+
+// ImageFormat is how generated images are returned.
+type ImageFormat string
+
+const (
+	// ImageFormatURL returns the URL of the images.
+	ImageFormatURL ImageFormat = "url"
+	// ImageFormatData returns the content of the images.
+	ImageFormatData ImageFormat = "data"
+)
+
+// ImageOptions is a set of options for generating images.
+type ImageOptions struct {
+	// Model is the model to use.
+	Model string
+	// N is the number of images to generate.
+	N int
+	// Size is the size of the images, e.g. "1024x1024".
+	Size string
+	// Quality is the quality of the images, e.g. "standard" or "hd".
+	Quality string
+	// Style is the style of the images, e.g. "vivid" or "natural".
+	Style string
+	// Format is how the images are returned.
+	Format ImageFormat
+}
+
+// ImageOption is a function that configures an ImageOptions.
+type ImageOption func(*ImageOptions)
+
+// WithImageModel specifies which model to generate images with.
+func WithImageModel(model string) ImageOption {
+	return func(o *ImageOptions) {
+		o.Model = model
+	}
+}
+
+// WithImageCount specifies the number of images to generate.
+func WithImageCount(n int) ImageOption {
+	return func(o *ImageOptions) {
+		o.N = n
+	}
+}
+
+// WithImageSize specifies the size of the images to generate.
+func WithImageSize(size string) ImageOption {
+	return func(o *ImageOptions) {
+		o.Size = size
+	}
+}
+
+// WithImageQuality specifies the quality of the images to generate.
+func WithImageQuality(quality string) ImageOption {
+	return func(o *ImageOptions) {
+		o.Quality = quality
+	}
+}
+
+// WithImageStyle specifies the style of the images to generate.
+func WithImageStyle(style string) ImageOption {
+	return func(o *ImageOptions) {
+		o.Style = style
+	}
+}
+
+// WithImageFormat specifies how the generated images are returned.
+func WithImageFormat(format ImageFormat) ImageOption {
+	return func(o *ImageOptions) {
+		o.Format = format
+	}
+}
+
+// Transcriber is a model that transcribes speech to text.
+type Transcriber interface {
+	// Transcribe transcribes audio, whose MIME type is used to tell its
+	// format, to text.
+	Transcribe(ctx context.Context, audio BinaryContent, options ...TranscriptionOption) (*Transcription, error)
+}
+
+// Transcription is the text of an audio.
+type Transcription struct {
+	// Text is the text of the whole audio.
+	Text string
+	// Language is the language of the audio, when the model reports it.
+	Language string
+	// Duration is the duration of the audio, when the model reports it.
+	Duration time.Duration
+	// Segments are the timestamped segments of the text, when the model
+	// reports them.
+	Segments []TranscriptionSegment
+}
+
+// TranscriptionSegment is a segment of a transcription.
+type TranscriptionSegment struct {
+	// Start and End are the offsets of the segment in the audio.
+	Start time.Duration
+	End   time.Duration
+	// Text is the text of the segment.
+	Text string
+}
+
+// TranscriptionOptions is a set of options for transcribing audio.
+type TranscriptionOptions struct {
+	// Model is the model to use.
+	Model string
+	// Language is the language of the audio, as an ISO-639-1 code.
+	Language string
+	// Prompt is a text guiding the style of the transcription or continuing a
+	// previous segment of audio.
+	Prompt string
+	// Temperature is the sampling temperature, between 0 and 1.
+	Temperature float64
+}
+
+// TranscriptionOption is a function that configures a TranscriptionOptions.
+type TranscriptionOption func(*TranscriptionOptions)
+
+// WithTranscriptionModel specifies which model to transcribe audio with.
+func WithTranscriptionModel(model string) TranscriptionOption {
+	return func(o *TranscriptionOptions) {
+		o.Model = model
+	}
+}
+
+// WithTranscriptionLanguage specifies the language of the audio to transcribe.
+func WithTranscriptionLanguage(language string) TranscriptionOption {
+	return func(o *TranscriptionOptions) {
+		o.Language = language
+	}
+}
+
+// WithTranscriptionPrompt specifies a text guiding the transcription.
+func WithTranscriptionPrompt(prompt string) TranscriptionOption {
+	return func(o *TranscriptionOptions) {
+		o.Prompt = prompt
+	}
+}
+
+// WithTranscriptionTemperature specifies the sampling temperature of the
+// transcription.
+func WithTranscriptionTemperature(temperature float64) TranscriptionOption {
+	return func(o *TranscriptionOptions) {
+		o.Temperature = temperature
+	}
+}
+
+// SpeechSynthesizer is a model that synthesizes speech from text.
+type SpeechSynthesizer interface {
+	// SynthesizeSpeech returns the audio of text read aloud.
+	SynthesizeSpeech(ctx context.Context, text string, options ...SpeechOption) (*BinaryContent, error)
+}
+
+// SpeechOptions is a set of options for synthesizing speech.
+type SpeechOptions struct {
+	// Model is the model to use.
+	Model string
+	// Voice is the voice to read the text with.
+	Voice string
+	// Format is the format of the audio, e.g. "mp3" or "wav".
+	Format string
+	// Speed is the speed of the speech, 1 being the normal speed.
+	Speed float64
+}
+
+// SpeechOption is a function that configures a SpeechOptions.
+type SpeechOption func(*SpeechOptions)
+
+// WithSpeechModel specifies which model to synthesize speech with.
+func WithSpeechModel(model string) SpeechOption {
+	return func(o *SpeechOptions) {
+		o.Model = model
+	}
+}
+
+// WithVoice specifies the voice to read the text with.
+func WithVoice(voice string) SpeechOption {
+	return func(o *SpeechOptions) {
+		o.Voice = voice
+	}
+}
+
+// WithSpeechFormat specifies the format of the synthesized audio.
+func WithSpeechFormat(format string) SpeechOption {
+	return func(o *SpeechOptions) {
+		o.Format = format
+	}
+}
+
+// WithSpeechSpeed specifies the speed of the speech.
+func WithSpeechSpeed(speed float64) SpeechOption {
+	return func(o *SpeechOptions) {
+		o.Speed = speed
+	}
+}
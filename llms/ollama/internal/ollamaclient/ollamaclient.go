@@ -217,3 +217,46 @@ func (c *Client) CreateEmbedding(ctx context.Context, req *EmbeddingRequest) (*E
 	}
 	return resp, nil
 }
+
+// PullProgressFunc is called with the progress of a model download.
+type PullProgressFunc func(ProgressResponse) error
+
+// Pull downloads a model from the registry, calling fn with its progress.
+func (c *Client) Pull(ctx context.Context, req *PullRequest, fn PullProgressFunc) error {
+	return c.stream(ctx, http.MethodPost, "/api/pull", req, func(bts []byte) error {
+		var resp ProgressResponse
+		if err := json.Unmarshal(bts, &resp); err != nil {
+			return err
+		}
+
+		return fn(resp)
+	})
+}
+
+// List returns the models available locally.
+func (c *Client) List(ctx context.Context) (*ListResponse, error) {
+	resp := &ListResponse{}
+	if err := c.do(ctx, http.MethodGet, "/api/tags", nil, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Show returns the details of a model.
+func (c *Client) Show(ctx context.Context, req *ShowRequest) (*ShowResponse, error) {
+	resp := &ShowResponse{}
+	if err := c.do(ctx, http.MethodPost, "/api/show", req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Copy creates a model with another name from an existing model.
+func (c *Client) Copy(ctx context.Context, req *CopyRequest) error {
+	return c.do(ctx, http.MethodPost, "/api/copy", req, nil)
+}
+
+// Delete deletes a model and its data.
+func (c *Client) Delete(ctx context.Context, req *DeleteRequest) error {
+	return c.do(ctx, http.MethodDelete, "/api/delete", req, nil)
+}
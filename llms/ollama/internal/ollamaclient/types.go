@@ -1,6 +1,7 @@
 package ollamaclient
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"time"
@@ -26,13 +27,28 @@ func (e StatusError) Error() string {
 	}
 }
 
+// Duration is a duration sent to Ollama as a string, such as "5m0s". Negative
+// durations are infinite.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
 type GenerateRequest struct {
-	Model    string `json:"model"`
-	Prompt   string `json:"prompt"`
-	System   string `json:"system"`
-	Template string `json:"template"`
-	Context  []int  `json:"context,omitempty"`
-	Stream   *bool  `json:"stream"`
+	Model    string      `json:"model"`
+	Prompt   string      `json:"prompt"`
+	System   string      `json:"system"`
+	Template string      `json:"template"`
+	Context  []int       `json:"context,omitempty"`
+	Stream   *bool       `json:"stream"`
+	Format   string      `json:"format,omitempty"`
+	Images   []ImageData `json:"images,omitempty"`
+	// Raw sends the prompt to the model as is, without applying the
+	// template of the model.
+	Raw bool `json:"raw,omitempty"`
+	// KeepAlive is how long the model stays loaded after the request.
+	KeepAlive *Duration `json:"keep_alive,omitempty"`
 
 	Options Options `json:"options"`
 }
@@ -40,9 +56,30 @@ type GenerateRequest struct {
 type ImageData []byte
 
 type Message struct {
-	Role    string      `json:"role"` // one of ["system", "user", "assistant"]
-	Content string      `json:"content"`
-	Images  []ImageData `json:"images,omitempty"`
+	Role      string      `json:"role"` // one of ["system", "user", "assistant", "tool"]
+	Content   string      `json:"content"`
+	Images    []ImageData `json:"images,omitempty"`
+	ToolCalls []ToolCall  `json:"tool_calls,omitempty"`
+}
+
+type ToolCall struct {
+	Function ToolCallFunction `json:"function"`
+}
+
+type ToolCallFunction struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+type Tool struct {
+	Type     string       `json:"type"`
+	Function ToolFunction `json:"function"`
+}
+
+type ToolFunction struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Parameters  any    `json:"parameters"`
 }
 
 type ChatRequest struct {
@@ -50,6 +87,9 @@ type ChatRequest struct {
 	Messages []*Message `json:"messages"`
 	Stream   *bool      `json:"stream,omitempty"`
 	Format   string     `json:"format"`
+	Tools    []Tool     `json:"tools,omitempty"`
+	// KeepAlive is how long the model stays loaded after the request.
+	KeepAlive *Duration `json:"keep_alive,omitempty"`
 
 	Options Options `json:"options"`
 }
@@ -67,6 +107,8 @@ type EmbeddingRequest struct {
 	Model   string  `json:"model"`
 	Prompt  string  `json:"prompt"`
 	Options Options `json:"options"`
+	// KeepAlive is how long the model stays loaded after the request.
+	KeepAlive *Duration `json:"keep_alive,omitempty"`
 }
 
 type EmbeddingResponse struct {
@@ -97,6 +139,70 @@ type ChatResponse struct {
 	Metrics
 }
 
+// PullRequest is a request to download a model.
+type PullRequest struct {
+	Model string `json:"name"`
+	// Insecure allows insecure connections to the registry.
+	Insecure bool  `json:"insecure,omitempty"`
+	Stream   *bool `json:"stream,omitempty"`
+}
+
+// ProgressResponse is the progress of a model download. Total and Completed
+// are the sizes of the layer being downloaded, named by its digest.
+type ProgressResponse struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+}
+
+// ModelDetails describes the weights of a model.
+type ModelDetails struct {
+	ParentModel       string   `json:"parent_model,omitempty"`
+	Format            string   `json:"format"`
+	Family            string   `json:"family"`
+	Families          []string `json:"families,omitempty"`
+	ParameterSize     string   `json:"parameter_size"`
+	QuantizationLevel string   `json:"quantization_level"`
+}
+
+// ModelResponse is a model available locally.
+type ModelResponse struct {
+	Name       string       `json:"name"`
+	Model      string       `json:"model"`
+	ModifiedAt time.Time    `json:"modified_at"`
+	Size       int64        `json:"size"`
+	Digest     string       `json:"digest"`
+	Details    ModelDetails `json:"details"`
+}
+
+type ListResponse struct {
+	Models []ModelResponse `json:"models"`
+}
+
+type ShowRequest struct {
+	Model string `json:"name"`
+}
+
+// ShowResponse is the description of a model.
+type ShowResponse struct {
+	License    string       `json:"license,omitempty"`
+	Modelfile  string       `json:"modelfile,omitempty"`
+	Parameters string       `json:"parameters,omitempty"`
+	Template   string       `json:"template,omitempty"`
+	System     string       `json:"system,omitempty"`
+	Details    ModelDetails `json:"details,omitempty"`
+}
+
+type CopyRequest struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+}
+
+type DeleteRequest struct {
+	Model string `json:"name"`
+}
+
 func (r *GenerateResponse) Summary() {
 	if r.TotalDuration > 0 {
 		fmt.Fprintf(os.Stderr, "total duration:       %v\n", r.TotalDuration)
@@ -0,0 +1,56 @@
+package ollama
+
+import (
+	"context"
+
+	"github.com/tmc/langchaingo/llms/ollama/internal/ollamaclient"
+)
+
+type (
+	// ModelInfo is a model available on the Ollama server.
+	ModelInfo = ollamaclient.ModelResponse
+	// ModelDetails describes the weights of a model: their format, family,
+	// parameter size and quantization level.
+	ModelDetails = ollamaclient.ModelDetails
+	// ModelDescription is the description of a model: its Modelfile,
+	// parameters, template and details.
+	ModelDescription = ollamaclient.ShowResponse
+	// PullProgress is the progress of a model download.
+	PullProgress = ollamaclient.ProgressResponse
+)
+
+// PullModel downloads a model from the Ollama library to the server. If not
+// nil, progress is called with the progress of the download; returning an
+// error cancels it.
+func (o *LLM) PullModel(ctx context.Context, model string, progress func(PullProgress) error) error {
+	return o.client.Pull(ctx, &ollamaclient.PullRequest{Model: model}, func(p ollamaclient.ProgressResponse) error {
+		if progress == nil {
+			return nil
+		}
+		return progress(p)
+	})
+}
+
+// ListModels returns the models available on the server.
+func (o *LLM) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	resp, err := o.client.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Models, nil
+}
+
+// ShowModel returns the description of a model.
+func (o *LLM) ShowModel(ctx context.Context, model string) (*ModelDescription, error) {
+	return o.client.Show(ctx, &ollamaclient.ShowRequest{Model: model})
+}
+
+// CopyModel copies a model under another name.
+func (o *LLM) CopyModel(ctx context.Context, source, destination string) error {
+	return o.client.Copy(ctx, &ollamaclient.CopyRequest{Source: source, Destination: destination})
+}
+
+// DeleteModel deletes a model and its data from the server.
+func (o *LLM) DeleteModel(ctx context.Context, model string) error {
+	return o.client.Delete(ctx, &ollamaclient.DeleteRequest{Model: model})
+}
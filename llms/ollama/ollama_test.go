@@ -2,13 +2,19 @@ package ollama
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/chattemplate"
 	"github.com/tmc/langchaingo/schema"
 )
 
@@ -74,3 +80,269 @@ func TestWithStreaming(t *testing.T) {
 	assert.Regexp(t, "feet", strings.ToLower(c1.Content))
 	assert.Regexp(t, "feet", strings.ToLower(sb.String()))
 }
+
+func TestGenerateContentWithTools(t *testing.T) {
+	t.Parallel()
+
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/chat", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte(`{"model":"llama3","message":{"role":"assistant","content":"",` +
+			`"tool_calls":[{"function":{"name":"weather","arguments":{"city":"Paris"}}},` +
+			`{"function":{"name":"weather","arguments":{"city":"Rome"}}}]},"done":true,` +
+			`"prompt_eval_count":15,"eval_count":5}` + "\n"))
+	}))
+	defer srv.Close()
+
+	llm, err := New(WithServerURL(srv.URL), WithModel("llama3"))
+	require.NoError(t, err)
+
+	messages := []llms.MessageContent{
+		llms.TextParts(schema.ChatMessageTypeHuman, "Weather in Paris and Rome?"),
+		{
+			Role: schema.ChatMessageTypeAI,
+			Parts: []llms.ContentPart{llms.ToolCall{
+				Type:         "function",
+				FunctionCall: &schema.FunctionCall{Name: "weather", Arguments: `{"city":"Oslo"}`},
+			}},
+		},
+		{
+			Role:  schema.ChatMessageTypeTool,
+			Parts: []llms.ContentPart{llms.ToolCallResponse{Name: "weather", Content: "cold"}},
+		},
+	}
+	tools := []llms.Tool{{
+		Type:     "function",
+		Function: &llms.FunctionDefinition{Name: "weather", Parameters: map[string]any{"type": "object"}},
+	}}
+
+	rsp, err := llm.GenerateContent(context.Background(), messages, llms.WithTools(tools))
+	require.NoError(t, err)
+
+	require.Len(t, rsp.Choices, 1)
+	require.Len(t, rsp.Choices[0].ToolCalls, 2)
+	assert.Equal(t, "weather", rsp.Choices[0].ToolCalls[0].FunctionCall.Name)
+	assert.JSONEq(t, `{"city":"Rome"}`, rsp.Choices[0].ToolCalls[1].FunctionCall.Arguments)
+	assert.Equal(t, &llms.Usage{PromptTokens: 15, CompletionTokens: 5, TotalTokens: 20}, rsp.Usage)
+
+	assert.Len(t, got["tools"], 1)
+	sent, ok := got["messages"].([]any)
+	require.True(t, ok)
+	require.Len(t, sent, 3)
+	assert.Equal(t, "tool", sent[2].(map[string]any)["role"])
+	assert.Equal(t, "cold", sent[2].(map[string]any)["content"])
+}
+
+func TestGenerateContentWithChatTemplate(t *testing.T) {
+	t.Parallel()
+
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/generate", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte(`{"model":"phi","response":"Hel","done":false}` + "\n" +
+			`{"model":"phi","response":"lo!","done":true,"prompt_eval_count":12,"eval_count":3}` + "\n"))
+	}))
+	defer srv.Close()
+
+	llm, err := New(WithServerURL(srv.URL), WithModel("phi"), WithChatTemplate(chattemplate.ChatML()))
+	require.NoError(t, err)
+
+	rsp, err := llm.GenerateContent(context.Background(), []llms.MessageContent{
+		llms.TextParts(schema.ChatMessageTypeSystem, "Be brief."),
+		llms.TextParts(schema.ChatMessageTypeHuman, "Hi"),
+	}, llms.WithStopWords([]string{"END"}))
+	require.NoError(t, err)
+	assert.Equal(t, "Hello!", rsp.Choices[0].Content)
+	assert.Equal(t, &llms.Usage{PromptTokens: 12, CompletionTokens: 3, TotalTokens: 15}, rsp.Usage)
+
+	assert.Equal(t, true, got["raw"])
+	assert.Equal(t, "<|im_start|>system\nBe brief.<|im_end|>\n<|im_start|>user\nHi<|im_end|>\n<|im_start|>assistant\n",
+		got["prompt"])
+	assert.Equal(t, []any{"<|im_end|>", "END"}, got["options"].(map[string]any)["stop"])
+
+	_, err = llm.GenerateContent(context.Background(), []llms.MessageContent{
+		llms.TextParts(schema.ChatMessageTypeHuman, "Hi"),
+	}, llms.WithTools([]llms.Tool{{Type: "function", Function: &llms.FunctionDefinition{Name: "f"}}}))
+	require.ErrorIs(t, err, ErrToolsWithChatTemplate)
+}
+
+func TestModelManagement(t *testing.T) {
+	t.Parallel()
+
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if r.Body != http.NoBody {
+			_ = json.NewDecoder(r.Body).Decode(&body)
+		}
+		requests = append(requests, fmt.Sprintf("%s %s %v", r.Method, r.URL.Path, body))
+		switch r.URL.Path {
+		case "/api/pull":
+			_, _ = w.Write([]byte(`{"status":"pulling manifest"}` + "\n" +
+				`{"status":"downloading","digest":"sha256:abc","total":100,"completed":50}` + "\n" +
+				`{"status":"success"}` + "\n"))
+		case "/api/tags":
+			_, _ = w.Write([]byte(`{"models":[{"name":"llama3:latest","size":42,"digest":"abc",` +
+				`"details":{"family":"llama","parameter_size":"8B","quantization_level":"Q4_0"}}]}`))
+		case "/api/show":
+			_, _ = w.Write([]byte(`{"template":"{{ .Prompt }}","parameters":"stop <|eot_id|>",` +
+				`"details":{"family":"llama"}}`))
+		case "/api/delete":
+			if body["name"] == "missing" {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"error":"model 'missing' not found"}`))
+			}
+		}
+	}))
+	defer srv.Close()
+
+	llm, err := New(WithServerURL(srv.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	var progress []PullProgress
+	require.NoError(t, llm.PullModel(ctx, "llama3", func(p PullProgress) error {
+		progress = append(progress, p)
+		return nil
+	}))
+	require.Len(t, progress, 3)
+	assert.Equal(t, PullProgress{Status: "downloading", Digest: "sha256:abc", Total: 100, Completed: 50}, progress[1])
+
+	models, err := llm.ListModels(ctx)
+	require.NoError(t, err)
+	require.Len(t, models, 1)
+	assert.Equal(t, "llama3:latest", models[0].Name)
+	assert.Equal(t, "Q4_0", models[0].Details.QuantizationLevel)
+
+	desc, err := llm.ShowModel(ctx, "llama3")
+	require.NoError(t, err)
+	assert.Equal(t, "{{ .Prompt }}", desc.Template)
+	assert.Equal(t, "llama", desc.Details.Family)
+
+	require.NoError(t, llm.CopyModel(ctx, "llama3", "backup"))
+	require.NoError(t, llm.DeleteModel(ctx, "backup"))
+	err = llm.DeleteModel(ctx, "missing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+
+	assert.Equal(t, []string{
+		"POST /api/pull map[name:llama3]",
+		"GET /api/tags map[]",
+		"POST /api/show map[name:llama3]",
+		"POST /api/copy map[destination:backup source:llama3]",
+		"DELETE /api/delete map[name:backup]",
+		"DELETE /api/delete map[name:missing]",
+	}, requests)
+}
+
+func TestGenerateContentCallOptions(t *testing.T) {
+	t.Parallel()
+
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/chat", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte(`{"message":{"role":"assistant","content":"{}"},"done":true}` + "\n"))
+	}))
+	defer srv.Close()
+
+	llm, err := New(WithServerURL(srv.URL), WithModel("llava"), WithRunnerNumCtx(2048),
+		WithKeepAlive(10*time.Minute))
+	require.NoError(t, err)
+
+	_, err = llm.GenerateContent(context.Background(), []llms.MessageContent{{
+		Role: schema.ChatMessageTypeHuman,
+		Parts: []llms.ContentPart{
+			llms.TextContent{Text: "Describe this image as JSON."},
+			llms.BinaryPart("image/png", []byte("png")),
+		},
+	}}, llms.WithJSONMode(), llms.WithTemperature(0.5),
+		WithCallOptions(WithRunnerNumCtx(8192), WithPredictMirostat(2)),
+		WithCallOptions(WithKeepAlive(-1)))
+	require.NoError(t, err)
+
+	assert.Equal(t, "llava", got["model"])
+	assert.Equal(t, "json", got["format"])
+	assert.Equal(t, "-1ns", got["keep_alive"])
+	options, ok := got["options"].(map[string]any)
+	require.True(t, ok)
+	assert.InDelta(t, 8192, options["num_ctx"], 0)
+	assert.InDelta(t, 2, options["mirostat"], 0)
+	assert.InDelta(t, 0.5, options["temperature"], 0)
+	sent, ok := got["messages"].([]any)
+	require.True(t, ok)
+	assert.Equal(t, []any{"cG5n"}, sent[0].(map[string]any)["images"])
+
+	// The options of the LLM are unchanged.
+	_, err = llm.GenerateContent(context.Background(), []llms.MessageContent{
+		llms.TextParts(schema.ChatMessageTypeHuman, "Hi"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "10m0s", got["keep_alive"])
+	assert.InDelta(t, 2048, got["options"].(map[string]any)["num_ctx"], 0)
+
+	_, err = llm.GenerateContent(context.Background(), []llms.MessageContent{{
+		Role:  schema.ChatMessageTypeHuman,
+		Parts: []llms.ContentPart{llms.BinaryPart("audio/wav", []byte("wav"))},
+	}})
+	require.ErrorIs(t, err, ErrUnsupportedContent)
+}
+
+func TestCallOptionsMarshal(t *testing.T) {
+	t.Parallel()
+
+	marshal := func(options ...llms.CallOption) string {
+		opts := llms.CallOptions{}
+		for _, opt := range options {
+			opt(&opts)
+		}
+		b, err := json.Marshal(opts)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	// Call options with overrides can be used as cache keys.
+	numCtx := marshal(WithCallOptions(WithRunnerNumCtx(8192)), WithCallOptions(WithKeepAlive(time.Minute)))
+	assert.Contains(t, numCtx, `"num_ctx":8192`)
+	assert.Contains(t, numCtx, `"keep_alive":60000000000`)
+	assert.NotEqual(t, numCtx, marshal(WithCallOptions(WithRunnerNumCtx(4096))))
+	assert.Equal(t, numCtx, marshal(WithCallOptions(WithRunnerNumCtx(8192), WithKeepAlive(time.Minute))))
+}
+
+func TestGenerateContentRawMode(t *testing.T) {
+	t.Parallel()
+
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/generate", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte(`{"response":"a cat","done":true,"prompt_eval_count":7,"eval_count":2}` + "\n"))
+	}))
+	defer srv.Close()
+
+	llm, err := New(WithServerURL(srv.URL), WithModel("llava"))
+	require.NoError(t, err)
+
+	var chunks []string
+	rsp, err := llm.GenerateContent(context.Background(), []llms.MessageContent{{
+		Role: schema.ChatMessageTypeHuman,
+		Parts: []llms.ContentPart{
+			llms.TextContent{Text: "[INST] What is this? [/INST]"},
+			llms.BinaryPart("image/jpeg", []byte("jpg")),
+		},
+	}}, WithCallOptions(WithRawMode()), llms.WithStreamingFunc(func(_ context.Context, chunk []byte) error {
+		chunks = append(chunks, string(chunk))
+		return nil
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "a cat", rsp.Choices[0].Content)
+	assert.Equal(t, []string{"a cat"}, chunks)
+	assert.Equal(t, 9, rsp.Usage.TotalTokens)
+
+	assert.Equal(t, true, got["raw"])
+	assert.Equal(t, true, got["stream"])
+	assert.Equal(t, "[INST] What is this? [/INST]", got["prompt"])
+	assert.Equal(t, []any{"anBn"}, got["images"])
+}
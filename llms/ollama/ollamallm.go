@@ -2,7 +2,10 @@ package ollama
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/tmc/langchaingo/callbacks"
 	"github.com/tmc/langchaingo/llms"
@@ -13,6 +16,12 @@ import (
 var (
 	ErrEmptyResponse       = errors.New("no response")
 	ErrIncompleteEmbedding = errors.New("no all input got emmbedded")
+	// ErrToolsWithChatTemplate is returned when calling tools with a chat
+	// template or in raw mode, as prompts sent as is don't describe tools.
+	ErrToolsWithChatTemplate = errors.New("tools are not supported with chat templates")
+	// ErrUnsupportedContent is returned when sending content models don't
+	// take, such as binary content other than images.
+	ErrUnsupportedContent = errors.New("unsupported content")
 )
 
 // LLM is a ollama LLM implementation.
@@ -56,12 +65,92 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 		opt(&opts)
 	}
 
+	callOptions := o.callOptions(opts)
+
+	var resp ollamaclient.ChatResponse
+	var err error
+	if callOptions.template != nil || callOptions.raw {
+		resp, err = o.generate(ctx, messages, callOptions, opts)
+	} else {
+		resp, err = o.generateChat(ctx, messages, callOptions, opts)
+	}
+	if err != nil {
+		if o.CallbacksHandler != nil {
+			o.CallbacksHandler.HandleLLMError(ctx, err)
+		}
+		return nil, err
+	}
+
+	choices := []*llms.ContentChoice{
+		{
+			Content: resp.Message.Content,
+			GenerationInfo: map[string]any{
+				"CompletionTokens": resp.EvalCount,
+				"PromptTokens":     resp.PromptEvalCount,
+				"TotalTokesn":      resp.EvalCount + resp.PromptEvalCount,
+			},
+		},
+	}
+	for i, tc := range resp.Message.ToolCalls {
+		choices[0].ToolCalls = append(choices[0].ToolCalls, llms.ToolCall{
+			// Ollama doesn't assign IDs to tool calls, so synthesize them for
+			// callers that match calls with their responses.
+			ID:   fmt.Sprintf("call_%d", i),
+			Type: llms.ToolTypeFunction,
+			FunctionCall: &schema.FunctionCall{
+				Name:      tc.Function.Name,
+				Arguments: string(tc.Function.Arguments),
+			},
+		})
+	}
+	if len(choices[0].ToolCalls) > 0 {
+		choices[0].FuncCall = choices[0].ToolCalls[0].FunctionCall
+	}
+
+	response := &llms.ContentResponse{
+		Choices: choices,
+		Usage:   llms.NewUsage(resp.PromptEvalCount, resp.EvalCount),
+		Model:   resp.Model,
+	}
+
+	if o.CallbacksHandler != nil {
+		o.CallbacksHandler.HandleLLMGenerateContentEnd(ctx, response)
+	}
+
+	return response, nil
+}
+
+// callOptions returns the options of a call: the options of the LLM, with
+// those set with WithCallOptions and the model and sampling settings of opts.
+func (o *LLM) callOptions(opts llms.CallOptions) options {
+	callOptions := o.options
+	overrides, _ := opts.Metadata[metadataKey].(callOverrides)
+	for _, opt := range overrides {
+		opt(&callOptions)
+	}
+
 	// Override LLM model if set as llms.CallOption
-	model := o.options.model
 	if opts.Model != "" {
-		model = opts.Model
+		callOptions.model = opts.Model
 	}
+	// Get our ollamaOptions from llms.CallOptions
+	callOptions.ollamaOptions = makeOllamaOptionsFromOptions(callOptions.ollamaOptions, opts)
+	return callOptions
+}
 
+// keepAliveDuration returns the keep alive duration of requests, if set.
+func (opts options) keepAliveDuration() *ollamaclient.Duration {
+	if opts.keepAlive == nil {
+		return nil
+	}
+	d := ollamaclient.Duration(*opts.keepAlive)
+	return &d
+}
+
+// generateChat generates a response to messages with the chat endpoint, which
+// renders them with the template of the model.
+// nolint: goerr113
+func (o *LLM) generateChat(ctx context.Context, messages []llms.MessageContent, options options, opts llms.CallOptions) (ollamaclient.ChatResponse, error) { // nolint: lll, cyclop
 	// Our input is a sequence of MessageContent, each of which potentially has
 	// a sequence of Part that could be text, images etc.
 	// We have to convert it to a format Ollama undestands: ChatRequest, which
@@ -69,45 +158,44 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 	// text + potential images.
 	chatMsgs := make([]*ollamaclient.Message, 0, len(messages))
 	for _, mc := range messages {
-		msg := &ollamaclient.Message{Role: typeToRole(mc.Role)}
-
-		// Look at all the parts in mc; expect to find a single Text part and
-		// any number of binary parts.
-		var text string
-		foundText := false
-		var images []ollamaclient.ImageData
-
-		for _, p := range mc.Parts {
-			switch pt := p.(type) {
-			case llms.TextContent:
-				if foundText {
-					return nil, errors.New("expecting a single Text content")
-				}
-				foundText = true
-				text = pt.Text
-			case llms.BinaryContent:
-				images = append(images, ollamaclient.ImageData(pt.Data))
-			default:
-				return nil, errors.New("only support Text and BinaryContent parts right now")
-			}
+		msgs, err := convertMessageContent(mc)
+		if err != nil {
+			return ollamaclient.ChatResponse{}, err
 		}
-
-		msg.Content = text
-		msg.Images = images
-		chatMsgs = append(chatMsgs, msg)
+		chatMsgs = append(chatMsgs, msgs...)
 	}
 
-	// Get our ollamaOptions from llms.CallOptions
-	ollamaOptions := makeOllamaOptionsFromOptions(o.options.ollamaOptions, opts)
 	req := &ollamaclient.ChatRequest{
-		Model:    model,
-		Messages: chatMsgs,
-		Options:  ollamaOptions,
-		Stream:   func(b bool) *bool { return &b }(opts.StreamingFunc != nil),
+		Model:     options.model,
+		Messages:  chatMsgs,
+		Options:   options.ollamaOptions,
+		Stream:    func(b bool) *bool { return &b }(opts.StreamingFunc != nil),
+		KeepAlive: options.keepAliveDuration(),
+	}
+	if opts.JSONMode {
+		req.Format = "json"
+	}
+	// Ollama has no tool choice setting; "none" is expressed by not sending
+	// tools at all.
+	if opts.ToolChoice != llms.ToolChoiceNone {
+		for _, t := range opts.Tools {
+			if t.Type != llms.ToolTypeFunction || t.Function == nil {
+				return ollamaclient.ChatResponse{}, fmt.Errorf("tool type %v not supported", t.Type)
+			}
+			req.Tools = append(req.Tools, ollamaclient.Tool{
+				Type: t.Type,
+				Function: ollamaclient.ToolFunction{
+					Name:        t.Function.Name,
+					Description: t.Function.Description,
+					Parameters:  t.Function.Parameters,
+				},
+			})
+		}
 	}
 
 	var fn ollamaclient.ChatResponseFunc
 	streamedResponse := ""
+	var toolCalls []ollamaclient.ToolCall
 	var resp ollamaclient.ChatResponse
 
 	fn = func(response ollamaclient.ChatResponse) error {
@@ -118,43 +206,105 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 		}
 		if response.Message != nil {
 			streamedResponse += response.Message.Content
+			toolCalls = append(toolCalls, response.Message.ToolCalls...)
 		}
 		if response.Done {
 			resp = response
 			resp.Message = &ollamaclient.Message{
-				Role:    "assistant",
-				Content: streamedResponse,
+				Role:      "assistant",
+				Content:   streamedResponse,
+				ToolCalls: toolCalls,
 			}
 		}
 		return nil
 	}
 
 	err := o.client.GenerateChat(ctx, req, fn)
-	if err != nil {
-		if o.CallbacksHandler != nil {
-			o.CallbacksHandler.HandleLLMError(ctx, err)
-		}
-		return nil, err
+	return resp, err
+}
+
+// generate generates a response to messages with the generate endpoint in raw
+// mode. The prompt is rendered with the chat template set with
+// WithChatTemplate, or is the text of the messages in raw mode. The response
+// is returned as a chat response.
+func (o *LLM) generate(ctx context.Context, messages []llms.MessageContent, options options, opts llms.CallOptions) (ollamaclient.ChatResponse, error) { // nolint: lll
+	if len(opts.Tools) > 0 && opts.ToolChoice != llms.ToolChoiceNone {
+		return ollamaclient.ChatResponse{}, ErrToolsWithChatTemplate
 	}
 
-	choices := []*llms.ContentChoice{
-		{
-			Content: resp.Message.Content,
-			GenerationInfo: map[string]any{
-				"CompletionTokens": resp.EvalCount,
-				"PromptTokens":     resp.PromptEvalCount,
-				"TotalTokesn":      resp.EvalCount + resp.PromptEvalCount,
-			},
-		},
+	// Images are sent along with the prompt, which is made of the text parts.
+	var images []ollamaclient.ImageData
+	textMessages := make([]llms.MessageContent, 0, len(messages))
+	for _, mc := range messages {
+		textMessage := llms.MessageContent{Role: mc.Role}
+		for _, part := range mc.Parts {
+			if p, ok := part.(llms.BinaryContent); ok {
+				if err := checkImage(p); err != nil {
+					return ollamaclient.ChatResponse{}, err
+				}
+				images = append(images, ollamaclient.ImageData(p.Data))
+				continue
+			}
+			textMessage.Parts = append(textMessage.Parts, part)
+		}
+		textMessages = append(textMessages, textMessage)
 	}
 
-	response := &llms.ContentResponse{Choices: choices}
+	ollamaOptions := options.ollamaOptions
+	var prompt string
+	var err error
+	if options.template != nil {
+		prompt, err = options.template.Render(textMessages)
+		stop := options.template.Stop
+		ollamaOptions.Stop = append(stop[:len(stop):len(stop)], ollamaOptions.Stop...)
+	} else {
+		prompt, err = rawPrompt(textMessages)
+	}
+	if err != nil {
+		return ollamaclient.ChatResponse{}, err
+	}
 
-	if o.CallbacksHandler != nil {
-		o.CallbacksHandler.HandleLLMGenerateContentEnd(ctx, response)
+	req := &ollamaclient.GenerateRequest{
+		Model:     options.model,
+		Prompt:    prompt,
+		Images:    images,
+		Raw:       true,
+		Options:   ollamaOptions,
+		Stream:    func(b bool) *bool { return &b }(opts.StreamingFunc != nil),
+		KeepAlive: options.keepAliveDuration(),
+	}
+	if opts.JSONMode {
+		req.Format = "json"
 	}
 
-	return response, nil
+	var content strings.Builder
+	var resp ollamaclient.ChatResponse
+	err = o.client.Generate(ctx, req, func(response ollamaclient.GenerateResponse) error {
+		if opts.StreamingFunc != nil {
+			if err := opts.StreamingFunc(ctx, []byte(response.Response)); err != nil {
+				return err
+			}
+		}
+		content.WriteString(response.Response)
+		if response.Done {
+			resp = ollamaclient.ChatResponse{
+				Model:     response.Model,
+				CreatedAt: response.CreatedAt,
+				Done:      true,
+				Metrics: ollamaclient.Metrics{
+					TotalDuration:      response.TotalDuration,
+					LoadDuration:       response.LoadDuration,
+					PromptEvalCount:    response.PromptEvalCount,
+					PromptEvalDuration: response.PromptEvalDuration,
+					EvalCount:          response.EvalCount,
+					EvalDuration:       response.EvalDuration,
+				},
+			}
+		}
+		return nil
+	})
+	resp.Message = &ollamaclient.Message{Role: "assistant", Content: content.String()}
+	return resp, err
 }
 
 func (o *LLM) CreateEmbedding(ctx context.Context, inputTexts []string) ([][]float32, error) {
@@ -162,8 +312,9 @@ func (o *LLM) CreateEmbedding(ctx context.Context, inputTexts []string) ([][]flo
 
 	for _, input := range inputTexts {
 		embedding, err := o.client.CreateEmbedding(ctx, &ollamaclient.EmbeddingRequest{
-			Prompt: input,
-			Model:  o.options.model,
+			Prompt:    input,
+			Model:     o.options.model,
+			KeepAlive: o.options.keepAliveDuration(),
 		})
 		if err != nil {
 			return nil, err
@@ -183,6 +334,92 @@ func (o *LLM) CreateEmbedding(ctx context.Context, inputTexts []string) ([][]flo
 	return embeddings, nil
 }
 
+// convertMessageContent converts a MessageContent to the format Ollama
+// understands: a Message with a role and content - single text + potential
+// images. Tool results are sent as one message per tool call, so a single
+// MessageContent may produce several messages.
+func convertMessageContent(mc llms.MessageContent) ([]*ollamaclient.Message, error) {
+	msg := &ollamaclient.Message{Role: typeToRole(mc.Role)}
+
+	// Look at all the parts in mc; expect to find a single Text part and
+	// any number of binary parts.
+	var text string
+	foundText := false
+	var images []ollamaclient.ImageData
+	var toolResults []*ollamaclient.Message
+
+	for _, p := range mc.Parts {
+		switch pt := p.(type) {
+		case llms.TextContent:
+			if foundText {
+				return nil, errors.New("expecting a single Text content")
+			}
+			foundText = true
+			text = pt.Text
+		case llms.BinaryContent:
+			if err := checkImage(pt); err != nil {
+				return nil, err
+			}
+			images = append(images, ollamaclient.ImageData(pt.Data))
+		case llms.ToolCall:
+			if pt.FunctionCall == nil {
+				return nil, fmt.Errorf("tool call %v has no function call", pt.ID)
+			}
+			args := json.RawMessage(pt.FunctionCall.Arguments)
+			if len(args) == 0 {
+				args = json.RawMessage("{}")
+			}
+			msg.ToolCalls = append(msg.ToolCalls, ollamaclient.ToolCall{
+				Function: ollamaclient.ToolCallFunction{
+					Name:      pt.FunctionCall.Name,
+					Arguments: args,
+				},
+			})
+		case llms.ToolCallResponse:
+			toolResults = append(toolResults, &ollamaclient.Message{
+				Role:    "tool",
+				Content: pt.Content,
+			})
+		default:
+			return nil, errors.New("only support Text, BinaryContent and tool parts right now")
+		}
+	}
+
+	if len(toolResults) > 0 {
+		return toolResults, nil
+	}
+
+	msg.Content = text
+	msg.Images = images
+	return []*ollamaclient.Message{msg}, nil
+}
+
+// rawPrompt returns the text of messages, one message per line.
+func rawPrompt(messages []llms.MessageContent) (string, error) {
+	lines := make([]string, 0, len(messages))
+	for _, mc := range messages {
+		var text strings.Builder
+		for _, part := range mc.Parts {
+			p, ok := part.(llms.TextContent)
+			if !ok {
+				return "", fmt.Errorf("%w: %T", ErrUnsupportedContent, part)
+			}
+			text.WriteString(p.Text)
+		}
+		lines = append(lines, text.String())
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// checkImage checks that binary content is an image, which is the only binary
+// content models take.
+func checkImage(content llms.BinaryContent) error {
+	if content.MIMEType != "" && !strings.HasPrefix(content.MIMEType, "image/") {
+		return fmt.Errorf("%w: %s", ErrUnsupportedContent, content.MIMEType)
+	}
+	return nil
+}
+
 func typeToRole(typ schema.ChatMessageType) string {
 	switch typ {
 	case schema.ChatMessageTypeSystem:
@@ -195,6 +432,8 @@ func typeToRole(typ schema.ChatMessageType) string {
 		return "user"
 	case schema.ChatMessageTypeFunction:
 		return "function"
+	case schema.ChatMessageTypeTool:
+		return "tool"
 	}
 	return ""
 }
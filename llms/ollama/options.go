@@ -1,10 +1,14 @@
 package ollama
 
 import (
+	"encoding/json"
 	"log"
 	"net/http"
 	"net/url"
+	"time"
 
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/chattemplate"
 	"github.com/tmc/langchaingo/llms/ollama/internal/ollamaclient"
 )
 
@@ -15,10 +19,49 @@ type options struct {
 	ollamaOptions       ollamaclient.Options
 	customModelTemplate string
 	system              string
+	template            *chattemplate.Template
+	raw                 bool
+	keepAlive           *time.Duration
 }
 
 type Option func(*options)
 
+// metadataKey is the key of the options of a call in llms.CallOptions.Metadata.
+const metadataKey = "ollama"
+
+// callOverrides are the options of a call set with WithCallOptions. They are
+// applied in order on top of the options of the LLM, and marshal to the
+// settings they make, so that call options stay serializable, e.g. for the
+// keys of llms/cache.
+type callOverrides []Option
+
+// MarshalJSON implements the json.Marshaler interface.
+func (c callOverrides) MarshalJSON() ([]byte, error) {
+	var o options
+	for _, opt := range c {
+		opt(&o)
+	}
+	return json.Marshal(struct {
+		Model          string                 `json:"model,omitempty"`
+		System         string                 `json:"system,omitempty"`
+		CustomTemplate string                 `json:"custom_template,omitempty"`
+		Template       *chattemplate.Template `json:"template,omitempty"`
+		Raw            bool                   `json:"raw,omitempty"`
+		KeepAlive      *time.Duration         `json:"keep_alive,omitempty"`
+		Options        ollamaclient.Options   `json:"options"`
+	}{o.model, o.system, o.customModelTemplate, o.template, o.raw, o.keepAlive, o.ollamaOptions})
+}
+
+// WithCallOptions Override options for a single call, e.g. the context size
+// with WithRunnerNumCtx or the sampling with WithPredictMirostat. Options
+// configuring the client, WithServerURL and WithHTTPClient, have no effect.
+func WithCallOptions(opts ...Option) llms.CallOption {
+	return func(o *llms.CallOptions) {
+		previous, _ := o.Metadata[metadataKey].(callOverrides)
+		llms.WithMetadata(metadataKey, append(previous[:len(previous):len(previous)], opts...))(o)
+	}
+}
+
 // WithModel Set the model to use.
 func WithModel(model string) Option {
 	return func(opts *options) {
@@ -43,6 +86,33 @@ func WithCustomTemplate(template string) Option {
 	}
 }
 
+// WithChatTemplate Render messages with a chat template and send the prompt
+// to the generate endpoint in raw mode, bypassing the templating done on Ollama
+// model side. The stop sequences of the template are added to the stop words.
+func WithChatTemplate(template chattemplate.Template) Option {
+	return func(opts *options) {
+		opts.template = &template
+	}
+}
+
+// WithRawMode Send the text of the messages to the generate endpoint as the
+// prompt, without any templating. Callers are responsible for formatting the
+// prompt the way the model expects.
+func WithRawMode() Option {
+	return func(opts *options) {
+		opts.raw = true
+	}
+}
+
+// WithKeepAlive Set how long the model stays loaded in memory after a request
+// (default: 5 minutes). Zero unloads the model immediately, and negative
+// durations keep it loaded indefinitely.
+func WithKeepAlive(d time.Duration) Option {
+	return func(opts *options) {
+		opts.keepAlive = &d
+	}
+}
+
 // WithServerURL Set the URL of the ollama instance to use.
 func WithServerURL(rawURL string) Option {
 	return func(opts *options) {
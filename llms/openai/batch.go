@@ -0,0 +1,174 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/batch"
+	"github.com/tmc/langchaingo/llms/openai/internal/openaiclient"
+)
+
+const (
+	// maxBatchRequests is the maximum number of requests of a batch.
+	maxBatchRequests = 50_000
+	// maxBatchBytes is the maximum size of the input file of a batch.
+	maxBatchBytes = 200_000_000
+)
+
+var (
+	_ batch.Provider  = (*LLM)(nil)
+	_ batch.Supporter = (*LLM)(nil)
+	_ batch.Splitter  = (*LLM)(nil)
+)
+
+// SupportsBatches implements the batch.Supporter interface. Only OpenAI
+// itself, or backends whose profile has Batches set, have the batch API.
+func (o *LLM) SupportsBatches() bool {
+	return o.batches
+}
+
+// BatchLimits implements the batch.Splitter interface.
+func (o *LLM) BatchLimits() (int, int) {
+	return maxBatchRequests, maxBatchBytes
+}
+
+// BatchRequestSize implements the batch.Splitter interface.
+func (o *LLM) BatchRequestSize(r batch.Request) (int, error) {
+	req, err := o.batchRequest(r)
+	if err != nil {
+		return 0, err
+	}
+	return o.client.BatchRequestSize(req)
+}
+
+// SubmitBatch implements the batch.Provider interface. The requests are
+// uploaded as a JSONL file and processed by the batch API within 24 hours.
+func (o *LLM) SubmitBatch(ctx context.Context, requests []batch.Request) (string, error) {
+	if !o.batches {
+		return "", batch.ErrNotSupported
+	}
+	batchRequests := make([]openaiclient.BatchRequest, 0, len(requests))
+	for _, r := range requests {
+		req, err := o.batchRequest(r)
+		if err != nil {
+			return "", err
+		}
+		batchRequests = append(batchRequests, req)
+	}
+
+	b, err := o.client.CreateBatch(ctx, batchRequests)
+	if errors.Is(err, openaiclient.ErrBatchNotSupported) {
+		return "", fmt.Errorf("%w: %w", batch.ErrNotSupported, err)
+	}
+	if err != nil {
+		return "", err
+	}
+	return b.ID, nil
+}
+
+// batchRequest converts a request of a job to a chat request of a batch.
+func (o *LLM) batchRequest(r batch.Request) (openaiclient.BatchRequest, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range r.Options {
+		opt(&opts)
+	}
+	opts.StreamingFunc = nil
+	opts.StreamingEventFunc = nil
+	req, err := o.chatRequest(r.Messages, opts)
+	if err != nil {
+		return openaiclient.BatchRequest{}, fmt.Errorf("request %s: %w", r.ID, err)
+	}
+	return openaiclient.BatchRequest{CustomID: r.ID, Chat: req}, nil
+}
+
+// BatchStatus implements the batch.Provider interface.
+func (o *LLM) BatchStatus(ctx context.Context, id string) (batch.Status, error) {
+	b, err := o.client.GetBatch(ctx, id)
+	if err != nil {
+		return batch.Status{}, err
+	}
+
+	status := batch.Status{
+		State:     batchState(b.Status),
+		Total:     b.RequestCounts.Total,
+		Completed: b.RequestCounts.Completed,
+		Failed:    b.RequestCounts.Failed,
+	}
+	if b.Errors != nil {
+		messages := make([]string, 0, len(b.Errors.Data))
+		for _, e := range b.Errors.Data {
+			messages = append(messages, e.Message)
+		}
+		status.Message = strings.Join(messages, "; ")
+	}
+	return status, nil
+}
+
+// BatchResults implements the batch.Provider interface.
+func (o *LLM) BatchResults(ctx context.Context, id string) ([]batch.Result, error) {
+	b, err := o.client.GetBatch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	outputs, err := o.client.BatchOutputs(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]batch.Result, 0, len(outputs))
+	for _, output := range outputs {
+		results = append(results, batchResult(output))
+	}
+	return results, nil
+}
+
+// batchResult converts the output of a request of a batch to its result.
+// Requests rejected by the API fail with an llms.HTTPError.
+func batchResult(output openaiclient.BatchOutput) batch.Result {
+	result := batch.Result{ID: output.CustomID}
+	switch {
+	case output.Response != nil && output.Response.StatusCode == http.StatusOK:
+		var resp openaiclient.ChatResponse
+		if err := json.Unmarshal(output.Response.Body, &resp); err != nil {
+			result.Err = err
+		} else if len(resp.Choices) == 0 {
+			result.Err = ErrEmptyResponse
+		} else {
+			result.Response = contentResponse(&resp)
+		}
+	case output.Response != nil:
+		var errResp struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.Unmarshal(output.Response.Body, &errResp)
+		result.Err = &llms.HTTPError{StatusCode: output.Response.StatusCode, Message: errResp.Error.Message}
+	case output.Error != nil:
+		result.Err = fmt.Errorf("%s: %s", output.Error.Code, output.Error.Message) //nolint:goerr113
+	default:
+		result.Err = batch.ErrNoResult
+	}
+	return result
+}
+
+// batchState converts the status of a batch to its state.
+func batchState(status string) batch.State {
+	switch status {
+	case "completed":
+		return batch.StateCompleted
+	case "failed":
+		return batch.StateFailed
+	case "expired":
+		return batch.StateExpired
+	case "cancelled":
+		return batch.StateCanceled
+	default:
+		return batch.StateInProgress
+	}
+}
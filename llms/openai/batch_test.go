@@ -0,0 +1,126 @@
+package openai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/batch"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// fakeBatchAPI serves the files and batches endpoints, completing batches on
+// their second status check.
+type fakeBatchAPI struct {
+	input   []map[string]any
+	creates int
+	polls   int
+}
+
+func (f *fakeBatchAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/files":
+		file, _, err := r.FormFile("file")
+		if err != nil || r.FormValue("purpose") != "batch" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var line map[string]any
+			_ = json.Unmarshal(scanner.Bytes(), &line)
+			f.input = append(f.input, line)
+		}
+		_, _ = w.Write([]byte(`{"id":"file-in"}`))
+	case r.Method == http.MethodPost && r.URL.Path == "/batches":
+		f.creates++
+		_, _ = w.Write([]byte(`{"id":"batch_1","status":"validating"}`))
+	case r.Method == http.MethodGet && r.URL.Path == "/batches/batch_1":
+		f.polls++
+		status := "in_progress"
+		if f.polls >= 2 {
+			status = "completed"
+		}
+		fmt.Fprintf(w, `{"id":"batch_1","status":%q,"output_file_id":"file-out","error_file_id":"file-err",`+
+			`"request_counts":{"total":3,"completed":1,"failed":1}}`, status)
+	case r.URL.Path == "/files/file-out/content":
+		_, _ = w.Write([]byte(`{"custom_id":"a","response":{"status_code":200,"body":` +
+			`{"choices":[{"message":{"role":"assistant","content":"Paris"},"finish_reason":"stop"}],` +
+			`"usage":{"prompt_tokens":9,"completion_tokens":1,"total_tokens":10}}}}` + "\n" +
+			`{"custom_id":"b","response":{"status_code":400,"body":{"error":{"message":"bad request"}}}}` + "\n"))
+	case r.URL.Path == "/files/file-err/content":
+		_, _ = w.Write([]byte(`{"custom_id":"c","error":{"code":"batch_expired","message":"expired"}}` + "\n"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	t.Parallel()
+
+	api := &fakeBatchAPI{}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	llm, err := New(WithToken("test"), WithBaseURL(srv.URL), WithModel("gpt-4o-mini"))
+	require.NoError(t, err)
+
+	var states []batch.State
+	runner := batch.New(llm, batch.WithPollInterval(time.Millisecond),
+		batch.WithProgress(func(s batch.Status) { states = append(states, s.State) }))
+	results, err := runner.Run(context.Background(), "job", []batch.Request{
+		{ID: "a", Messages: []llms.MessageContent{llms.TextParts(schema.ChatMessageTypeHuman, "Capital of France?")}},
+		{ID: "b", Messages: []llms.MessageContent{llms.TextParts(schema.ChatMessageTypeHuman, "?")}},
+		{
+			ID:       "c",
+			Messages: []llms.MessageContent{llms.TextParts(schema.ChatMessageTypeHuman, "Hi")},
+			Options:  []llms.CallOption{llms.WithMaxTokens(5), llms.WithModel("gpt-4o")},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, results, 3)
+	assert.Equal(t, "Paris", results[0].Response.Choices[0].Content)
+	assert.Equal(t, 10, results[0].Response.Usage.TotalTokens)
+	var httpErr *llms.HTTPError
+	require.ErrorAs(t, results[1].Err, &httpErr)
+	assert.Equal(t, http.StatusBadRequest, httpErr.StatusCode)
+	assert.EqualError(t, results[2].Err, "batch_expired: expired")
+	assert.Equal(t, []batch.State{batch.StateInProgress, batch.StateCompleted}, states)
+
+	require.Len(t, api.input, 3)
+	assert.Equal(t, "a", api.input[0]["custom_id"])
+	assert.Equal(t, "/v1/chat/completions", api.input[0]["url"])
+	body, ok := api.input[2]["body"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "gpt-4o", body["model"])
+	assert.InDelta(t, 5, body["max_tokens"], 0)
+	assert.Equal(t, "gpt-4o-mini", api.input[0]["body"].(map[string]any)["model"])
+}
+
+func TestBatchWithoutBatchAPI(t *testing.T) {
+	t.Parallel()
+
+	for _, profile := range []Profile{AzureProfile(), LlamaCppProfile()} {
+		srv := newProfileServer(t)
+		llm, err := New(WithProfile(profile), WithBaseURL(srv.URL), WithToken("test"), WithModel("gpt-4o-mini"))
+		require.NoError(t, err)
+		assert.False(t, llm.SupportsBatches(), profile.Name)
+
+		results, err := batch.New(llm).Run(context.Background(), "job", []batch.Request{
+			{ID: "a", Messages: []llms.MessageContent{llms.TextParts(schema.ChatMessageTypeHuman, "Hi")}},
+		})
+		require.NoError(t, err, profile.Name)
+		assert.Equal(t, "ok", results[0].Response.Choices[0].Content, profile.Name)
+		assert.True(t, strings.HasSuffix(srv.req.URL.Path, "/chat/completions"), profile.Name)
+	}
+}
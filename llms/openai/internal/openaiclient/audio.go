@@ -0,0 +1,154 @@
+package openaiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultTranscriptionModel = "whisper-1"
+	defaultSpeechModel        = "tts-1"
+	defaultVoice              = "alloy"
+)
+
+// TranscriptionRequest is a request to transcribe audio.
+type TranscriptionRequest struct {
+	Model string
+	// Filename is the name of the audio file, whose extension tells the
+	// format of the audio.
+	Filename    string
+	Audio       []byte
+	Language    string
+	Prompt      string
+	Temperature float64
+}
+
+// TranscriptionSegment is a segment of a transcription, with its offsets in
+// seconds.
+type TranscriptionSegment struct {
+	ID    int     `json:"id"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// TranscriptionResponse is a response to a transcription request.
+type TranscriptionResponse struct {
+	Text     string                 `json:"text"`
+	Language string                 `json:"language"`
+	Duration float64                `json:"duration"`
+	Segments []TranscriptionSegment `json:"segments"`
+}
+
+// CreateTranscription transcribes audio. The response includes the segments of
+// the transcription.
+func (c *Client) CreateTranscription(ctx context.Context, r *TranscriptionRequest) (*TranscriptionResponse, error) { //nolint:lll
+	if r.Model == "" {
+		r.Model = defaultTranscriptionModel
+	}
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	fw, err := w.CreateFormFile("file", r.Filename)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fw.Write(r.Audio); err != nil {
+		return nil, err
+	}
+	fields := [][2]string{
+		{"model", r.Model},
+		{"response_format", "verbose_json"},
+		{"timestamp_granularities[]", "segment"},
+		{"language", r.Language},
+		{"prompt", r.Prompt},
+	}
+	if r.Temperature != 0 {
+		fields = append(fields, [2]string{"temperature", strconv.FormatFloat(r.Temperature, 'f', -1, 64)})
+	}
+	for _, f := range fields {
+		if f[1] == "" {
+			continue
+		}
+		if err := w.WriteField(f[0], f[1]); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	if c.baseURL == "" {
+		c.baseURL = defaultBaseURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.buildURL("/audio/transcriptions", r.Model), &body)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	c.setHeaders(req)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var response TranscriptionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return &response, nil
+}
+
+// SpeechRequest is a request to synthesize speech.
+type SpeechRequest struct {
+	Model          string  `json:"model"`
+	Input          string  `json:"input"`
+	Voice          string  `json:"voice"`
+	ResponseFormat string  `json:"response_format,omitempty"`
+	Speed          float64 `json:"speed,omitempty"`
+}
+
+// CreateSpeech synthesizes speech. It returns the audio and its content type.
+func (c *Client) CreateSpeech(ctx context.Context, r *SpeechRequest) ([]byte, string, error) {
+	if r.Model == "" {
+		r.Model = defaultSpeechModel
+	}
+	if r.Voice == "" {
+		r.Voice = defaultVoice
+	}
+	payloadBytes, err := json.Marshal(r)
+	if err != nil {
+		return nil, "", fmt.Errorf("marshal payload: %w", err)
+	}
+	if c.baseURL == "" {
+		c.baseURL = defaultBaseURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.buildURL("/audio/speech", r.Model), bytes.NewReader(payloadBytes)) //nolint:lll
+	if err != nil {
+		return nil, "", fmt.Errorf("create request: %w", err)
+	}
+	c.setHeaders(req)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	audio, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("read response: %w", err)
+	}
+	if len(audio) == 0 {
+		return nil, "", ErrEmptyResponse
+	}
+	return audio, resp.Header.Get("Content-Type"), nil
+}
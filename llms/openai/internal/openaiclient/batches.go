@@ -0,0 +1,248 @@
+package openaiclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+)
+
+const (
+	batchEndpoint         = "/v1/chat/completions"
+	batchCompletionWindow = "24h"
+	// maxBatchOutputLine is the maximum size of a line of a batch output file.
+	maxBatchOutputLine = 16 << 20
+)
+
+// ErrBatchNotSupported is returned when creating batches with a backend
+// serving models from deployments, which has its own batch API.
+var ErrBatchNotSupported = errors.New("batches are not supported by deployment backends")
+
+// BatchRequest is a chat request of a batch.
+type BatchRequest struct {
+	// CustomID identifies the request in the outputs of the batch.
+	CustomID string
+	Chat     *ChatRequest
+}
+
+// batchLine is a line of the input file of a batch.
+type batchLine struct {
+	CustomID string          `json:"custom_id"`
+	Method   string          `json:"method"`
+	URL      string          `json:"url"`
+	Body     json.RawMessage `json:"body"`
+}
+
+// BatchError is an error of a batch, or of one of its requests.
+type BatchError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Batch is a batch of requests.
+type Batch struct {
+	ID string `json:"id"`
+	// Status is one of validating, failed, in_progress, finalizing,
+	// completed, expired, cancelling and cancelled.
+	Status        string `json:"status"`
+	OutputFileID  string `json:"output_file_id,omitempty"`
+	ErrorFileID   string `json:"error_file_id,omitempty"`
+	RequestCounts struct {
+		Total     int `json:"total"`
+		Completed int `json:"completed"`
+		Failed    int `json:"failed"`
+	} `json:"request_counts"`
+	Errors *struct {
+		Data []BatchError `json:"data"`
+	} `json:"errors,omitempty"`
+}
+
+// BatchOutput is the outcome of a request of a batch: its response, whose
+// body is a chat response when its status code is 200, or an error.
+type BatchOutput struct {
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int             `json:"status_code"`
+		Body       json.RawMessage `json:"body"`
+	} `json:"response,omitempty"`
+	Error *BatchError `json:"error,omitempty"`
+}
+
+// CreateBatch uploads the input file of a batch of chat requests, and creates
+// the batch.
+func (c *Client) CreateBatch(ctx context.Context, requests []BatchRequest) (*Batch, error) {
+	if c.quirks.Deployment != nil {
+		return nil, ErrBatchNotSupported
+	}
+
+	var input bytes.Buffer
+	for _, r := range requests {
+		line, err := c.batchInputLine(r)
+		if err != nil {
+			return nil, err
+		}
+		input.Write(line)
+	}
+	fileID, err := c.uploadFile(ctx, "batch.jsonl", "batch", input.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("upload batch input: %w", err)
+	}
+
+	payload, err := json.Marshal(map[string]string{
+		"input_file_id":     fileID,
+		"endpoint":          batchEndpoint,
+		"completion_window": batchCompletionWindow,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var batch Batch
+	if err := c.doJSON(ctx, http.MethodPost, "/batches", bytes.NewReader(payload), &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// BatchRequestSize returns the size of a request in the input file of a
+// batch, in bytes.
+func (c *Client) BatchRequestSize(r BatchRequest) (int, error) {
+	line, err := c.batchInputLine(r)
+	return len(line), err
+}
+
+// batchInputLine returns the line of a request in the input file of a batch.
+func (c *Client) batchInputLine(r BatchRequest) ([]byte, error) {
+	c.setChatDefaults(r.Chat)
+	body, err := c.marshalChatPayload(r.Chat)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request %s: %w", r.CustomID, err)
+	}
+	line, err := json.Marshal(batchLine{CustomID: r.CustomID, Method: http.MethodPost, URL: batchEndpoint, Body: body})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request %s: %w", r.CustomID, err)
+	}
+	return append(line, '\n'), nil
+}
+
+// GetBatch returns a batch.
+func (c *Client) GetBatch(ctx context.Context, id string) (*Batch, error) {
+	var batch Batch
+	if err := c.doJSON(ctx, http.MethodGet, "/batches/"+url.PathEscape(id), nil, &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// BatchOutputs returns the outputs of the requests processed by a batch, read
+// from its output and error files.
+func (c *Client) BatchOutputs(ctx context.Context, batch *Batch) ([]BatchOutput, error) {
+	var outputs []BatchOutput
+	for _, fileID := range []string{batch.OutputFileID, batch.ErrorFileID} {
+		if fileID == "" {
+			continue
+		}
+		if err := c.readBatchOutputs(ctx, fileID, &outputs); err != nil {
+			return nil, fmt.Errorf("read batch file %s: %w", fileID, err)
+		}
+	}
+	return outputs, nil
+}
+
+func (c *Client) readBatchOutputs(ctx context.Context, fileID string, outputs *[]BatchOutput) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.apiURL("/files/"+url.PathEscape(fileID)+"/content"), nil)
+	if err != nil {
+		return err
+	}
+	c.setHeaders(req)
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxBatchOutputLine)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var output BatchOutput
+		if err := json.Unmarshal(scanner.Bytes(), &output); err != nil {
+			return err
+		}
+		*outputs = append(*outputs, output)
+	}
+	return scanner.Err()
+}
+
+// uploadFile uploads a file for the given purpose, and returns its ID.
+func (c *Client) uploadFile(ctx context.Context, filename, purpose string, data []byte) (string, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	if err := w.WriteField("purpose", purpose); err != nil {
+		return "", err
+	}
+	fw, err := w.CreateFormFile("file", filename)
+	if err != nil {
+		return "", err
+	}
+	if _, err := fw.Write(data); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL("/files"), &body)
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	c.setHeaders(req)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	resp, err := c.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var file struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&file); err != nil {
+		return "", fmt.Errorf("decode response: %w", err)
+	}
+	return file.ID, nil
+}
+
+// doJSON sends a request to the API, and decodes its JSON response into v.
+func (c *Client) doJSON(ctx context.Context, method, path string, body io.Reader, v any) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.apiURL(path), body)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	c.setHeaders(req)
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// apiURL returns the URL of an endpoint of the API that isn't served by
+// model deployments.
+func (c *Client) apiURL(path string) string {
+	if c.baseURL == "" {
+		c.baseURL = defaultBaseURL
+	}
+	return c.baseURL + path
+}
@@ -7,8 +7,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/tmc/langchaingo/llms"
@@ -33,6 +33,9 @@ type ChatRequest struct {
 	FrequencyPenalty float64        `json:"frequency_penalty,omitempty"`
 	PresencePenalty  float64        `json:"presence_penalty,omitempty"`
 
+	// ResponseFormat is the format of the reply, e.g. a JSON object.
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+
 	// Function definitions to include in the request.
 	Functions []FunctionDefinition `json:"functions,omitempty"`
 	// FunctionCallBehavior is the behavior to use when calling functions.
@@ -41,9 +44,34 @@ type ChatRequest struct {
 	// `{"name": "my_function"}`
 	FunctionCallBehavior FunctionCallBehavior `json:"function_call,omitempty"`
 
+	// Tools is a list of tools the model may call.
+	Tools []Tool `json:"tools,omitempty"`
+	// ToolChoice controls which (if any) tool is called by the model. It is
+	// either a string ("none", "auto" or "required") or a ToolChoice.
+	ToolChoice any `json:"tool_choice,omitempty"`
+
+	// StreamOptions are options for streaming responses.
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+
 	// StreamingFunc is a function to be called for each chunk of a streaming response.
 	// Return an error to stop streaming early.
 	StreamingFunc func(ctx context.Context, chunk []byte) error `json:"-"`
+
+	// StreamingEventFunc is a function to be called for each typed event of a
+	// streaming response. Return an error to stop streaming early.
+	StreamingEventFunc llms.StreamEventFunc `json:"-"`
+}
+
+// ResponseFormat is the format of the reply of the model.
+type ResponseFormat struct {
+	// Type is either "text" or "json_object".
+	Type string `json:"type"`
+}
+
+// StreamOptions are options for streaming responses.
+type StreamOptions struct {
+	// IncludeUsage asks for a final chunk carrying the token usage.
+	IncludeUsage bool `json:"include_usage,omitempty"`
 }
 
 // ChatMessage is a message in a chat request.
@@ -61,6 +89,12 @@ type ChatMessage struct { //nolint:musttag
 
 	// FunctionCall represents a function call to be made in the message.
 	FunctionCall *FunctionCall
+
+	// ToolCalls is a list of tool calls the assistant asked for.
+	ToolCalls []ToolCall
+
+	// ToolCallID is the ID of the tool call a tool message responds to.
+	ToolCallID string
 }
 
 func (m ChatMessage) MarshalJSON() ([]byte, error) {
@@ -74,6 +108,8 @@ func (m ChatMessage) MarshalJSON() ([]byte, error) {
 			MultiContent []llms.ContentPart `json:"content,omitempty"`
 			Name         string             `json:"name,omitempty"`
 			FunctionCall *FunctionCall      `json:"function_call,omitempty"`
+			ToolCalls    []ToolCall         `json:"tool_calls,omitempty"`
+			ToolCallID   string             `json:"tool_call_id,omitempty"`
 		}(m)
 		return json.Marshal(msg)
 	}
@@ -83,6 +119,8 @@ func (m ChatMessage) MarshalJSON() ([]byte, error) {
 		MultiContent []llms.ContentPart `json:"-"`
 		Name         string             `json:"name,omitempty"`
 		FunctionCall *FunctionCall      `json:"function_call,omitempty"`
+		ToolCalls    []ToolCall         `json:"tool_calls,omitempty"`
+		ToolCallID   string             `json:"tool_call_id,omitempty"`
 	}(m)
 	return json.Marshal(msg)
 }
@@ -94,6 +132,8 @@ func (m *ChatMessage) UnmarshalJSON(data []byte) error {
 		MultiContent []llms.ContentPart `json:"-"` // not expected in response
 		Name         string             `json:"name,omitempty"`
 		FunctionCall *FunctionCall      `json:"function_call,omitempty"`
+		ToolCalls    []ToolCall         `json:"tool_calls,omitempty"`
+		ToolCallID   string             `json:"tool_call_id,omitempty"`
 	}{}
 	err := json.Unmarshal(data, &msg)
 	if err != nil {
@@ -138,14 +178,25 @@ type StreamedChatResponsePayload struct {
 	Model   string  `json:"model,omitempty"`
 	Object  string  `json:"object,omitempty"`
 	Choices []struct {
-		Index float64 `json:"index,omitempty"`
+		Index int `json:"index,omitempty"`
 		Delta struct {
-			Role         string        `json:"role,omitempty"`
-			Content      string        `json:"content,omitempty"`
-			FunctionCall *FunctionCall `json:"function_call,omitempty"`
+			Role         string          `json:"role,omitempty"`
+			Content      string          `json:"content,omitempty"`
+			FunctionCall *FunctionCall   `json:"function_call,omitempty"`
+			ToolCalls    []ToolCallDelta `json:"tool_calls,omitempty"`
 		} `json:"delta,omitempty"`
 		FinishReason string `json:"finish_reason,omitempty"`
 	} `json:"choices,omitempty"`
+	// Usage is only set in the final chunk, when requested in StreamOptions.
+	Usage *ChatUsage `json:"usage,omitempty"`
+}
+
+// ToolCallDelta is a fragment of a tool call in a chunk from the stream.
+type ToolCallDelta struct {
+	Index    int          `json:"index"`
+	ID       string       `json:"id,omitempty"`
+	Type     ToolType     `json:"type,omitempty"`
+	Function FunctionCall `json:"function,omitempty"`
 }
 
 // FunctionDefinition is a definition of a function that can be called by the model.
@@ -170,6 +221,38 @@ const (
 	FunctionCallBehaviorAuto FunctionCallBehavior = "auto"
 )
 
+// ToolType is the type of a tool.
+type ToolType string
+
+const (
+	// ToolTypeFunction is the type of tools that are functions.
+	ToolTypeFunction ToolType = "function"
+)
+
+// Tool is a tool the model may call.
+type Tool struct {
+	Type     ToolType           `json:"type"`
+	Function FunctionDefinition `json:"function,omitempty"`
+}
+
+// ToolChoice forces the model to call a specific tool.
+type ToolChoice struct {
+	Type     ToolType     `json:"type"`
+	Function ToolFunction `json:"function,omitempty"`
+}
+
+// ToolFunction is a reference to a function by name.
+type ToolFunction struct {
+	Name string `json:"name"`
+}
+
+// ToolCall is a call to a tool the model asked for.
+type ToolCall struct {
+	ID       string       `json:"id,omitempty"`
+	Type     ToolType     `json:"type"`
+	Function FunctionCall `json:"function,omitempty"`
+}
+
 // FunctionCall is a call to a function.
 type FunctionCall struct {
 	// Name is the name of the function to call.
@@ -179,12 +262,16 @@ type FunctionCall struct {
 }
 
 func (c *Client) createChat(ctx context.Context, payload *ChatRequest) (*ChatResponse, error) {
-	if payload.StreamingFunc != nil {
+	if payload.StreamingFunc != nil || payload.StreamingEventFunc != nil {
 		payload.Stream = true
 	}
+	// Backends listing stream_options as unsupported stream without usage.
+	if payload.StreamingEventFunc != nil && !slices.Contains(c.quirks.UnsupportedParams, "stream_options") {
+		payload.StreamOptions = &StreamOptions{IncludeUsage: true}
+	}
 	// Build request payload
 
-	payloadBytes, err := json.Marshal(payload)
+	payloadBytes, err := c.marshalChatPayload(payload)
 	if err != nil {
 		return nil, err
 	}
@@ -194,7 +281,7 @@ func (c *Client) createChat(ctx context.Context, payload *ChatRequest) (*ChatRes
 	if c.baseURL == "" {
 		c.baseURL = defaultBaseURL
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.buildURL("/chat/completions", c.Model), body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.buildURL("/chat/completions", payload.Model), body)
 	if err != nil {
 		return nil, err
 	}
@@ -209,18 +296,13 @@ func (c *Client) createChat(ctx context.Context, payload *ChatRequest) (*ChatRes
 	defer r.Body.Close()
 
 	if r.StatusCode != http.StatusOK {
-		msg := fmt.Sprintf("API returned unexpected status code: %d", r.StatusCode)
-
 		// No need to check the error here: if it fails, we'll just return the
 		// status code.
 		var errResp errorMessage
-		if err := json.NewDecoder(r.Body).Decode(&errResp); err != nil {
-			return nil, errors.New(msg) // nolint:goerr113
-		}
-
-		return nil, fmt.Errorf("%s: %s", msg, errResp.Error.Message) // nolint:goerr113
+		_ = json.NewDecoder(r.Body).Decode(&errResp)
+		return nil, llms.NewHTTPError(r, errResp.Error.Message)
 	}
-	if payload.StreamingFunc != nil {
+	if payload.Stream {
 		return parseStreamingChatResponse(ctx, r, payload)
 	}
 	// Parse response
@@ -230,32 +312,6 @@ func (c *Client) createChat(ctx context.Context, payload *ChatRequest) (*ChatRes
 
 func parseStreamingChatResponse(ctx context.Context, r *http.Response, payload *ChatRequest) (*ChatResponse, error) { //nolint:cyclop,lll
 	scanner := bufio.NewScanner(r.Body)
-	responseChan := make(chan StreamedChatResponsePayload)
-	go func() {
-		defer close(responseChan)
-		for scanner.Scan() {
-			line := scanner.Text()
-			if line == "" {
-				continue
-			}
-			if !strings.HasPrefix(line, "data:") {
-				log.Fatalf("unexpected line: %v", line)
-			}
-			data := strings.TrimPrefix(line, "data: ")
-			if data == "[DONE]" {
-				return
-			}
-			var streamPayload StreamedChatResponsePayload
-			err := json.NewDecoder(bytes.NewReader([]byte(data))).Decode(&streamPayload)
-			if err != nil {
-				log.Fatalf("failed to decode stream payload: %v", err)
-			}
-			responseChan <- streamPayload
-		}
-		if err := scanner.Err(); err != nil {
-			log.Println("issue scanning response:", err)
-		}
-	}()
 	// Parse response
 	response := ChatResponse{
 		Choices: []*ChatChoice{
@@ -263,28 +319,147 @@ func parseStreamingChatResponse(ctx context.Context, r *http.Response, payload *
 		},
 	}
 
-	for streamResponse := range responseChan {
-		if len(streamResponse.Choices) == 0 {
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
 			continue
 		}
-		chunk := []byte(streamResponse.Choices[0].Delta.Content)
-		response.Choices[0].Message.Content += streamResponse.Choices[0].Delta.Content
-		response.Choices[0].FinishReason = streamResponse.Choices[0].FinishReason
-		if streamResponse.Choices[0].Delta.FunctionCall != nil {
-			if response.Choices[0].Message.FunctionCall == nil {
-				response.Choices[0].Message.FunctionCall = streamResponse.Choices[0].Delta.FunctionCall
-			} else {
-				response.Choices[0].Message.FunctionCall.Arguments += streamResponse.Choices[0].Delta.FunctionCall.Arguments
+		if !strings.HasPrefix(line, "data:") {
+			return nil, fmt.Errorf("unexpected line in stream: %v", line) // nolint:goerr113
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+		var streamResponse StreamedChatResponsePayload
+		if err := json.Unmarshal([]byte(data), &streamResponse); err != nil {
+			return nil, fmt.Errorf("failed to decode stream payload: %w", err)
+		}
+		if err := processStreamChunk(ctx, &response, &streamResponse, payload); err != nil {
+			return nil, fmt.Errorf("streaming func returned an error: %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("issue scanning response: %w", err)
+	}
+	return &response, nil
+}
+
+// processStreamChunk adds a chunk from the stream to the response, and passes
+// it on to the streaming functions of the payload.
+func processStreamChunk(ctx context.Context, response *ChatResponse, chunk *StreamedChatResponsePayload, payload *ChatRequest) error { //nolint:lll,cyclop
+	emit := func(event llms.StreamEvent) error {
+		if payload.StreamingEventFunc == nil {
+			return nil
+		}
+		return payload.StreamingEventFunc(ctx, event)
+	}
+
+	if chunk.Model != "" {
+		response.Model = chunk.Model
+	}
+	if chunk.Usage != nil {
+		response.Usage.PromptTokens = float64(chunk.Usage.PromptTokens)
+		response.Usage.CompletionTokens = float64(chunk.Usage.CompletionTokens)
+		response.Usage.TotalTokens = float64(chunk.Usage.TotalTokens)
+		if err := emit(llms.StreamEvent{Type: llms.StreamEventUsage, Usage: &llms.Usage{
+			PromptTokens:     chunk.Usage.PromptTokens,
+			CompletionTokens: chunk.Usage.CompletionTokens,
+			TotalTokens:      chunk.Usage.TotalTokens,
+		}}); err != nil {
+			return err
+		}
+	}
+
+	for _, streamChoice := range chunk.Choices {
+		for len(response.Choices) <= streamChoice.Index {
+			response.Choices = append(response.Choices, &ChatChoice{Index: len(response.Choices)})
+		}
+		choice := response.Choices[streamChoice.Index]
+		delta := streamChoice.Delta
+
+		chunkBytes := []byte(delta.Content)
+		choice.Message.Content += delta.Content
+		if delta.Content != "" {
+			if err := emit(llms.StreamEvent{
+				Type: llms.StreamEventText, ChoiceIndex: streamChoice.Index, Text: delta.Content,
+			}); err != nil {
+				return err
 			}
-			chunk, _ = json.Marshal(response.Choices[0].Message.FunctionCall) // nolint:errchkjson
 		}
 
-		if payload.StreamingFunc != nil {
-			err := payload.StreamingFunc(ctx, chunk)
-			if err != nil {
-				return nil, fmt.Errorf("streaming func returned an error: %w", err)
+		if delta.Role != "" {
+			choice.Message.Role = delta.Role
+		}
+
+		if delta.FunctionCall != nil {
+			if choice.Message.FunctionCall == nil {
+				choice.Message.FunctionCall = &FunctionCall{}
+			}
+			choice.Message.FunctionCall.Name += delta.FunctionCall.Name
+			choice.Message.FunctionCall.Arguments += delta.FunctionCall.Arguments
+			chunkBytes, _ = json.Marshal(choice.Message.FunctionCall) // nolint:errchkjson
+			// A legacy function call is reported as the only tool call of the
+			// choice, without an ID.
+			if err := emit(llms.StreamEvent{
+				Type:        llms.StreamEventToolCall,
+				ChoiceIndex: streamChoice.Index,
+				ToolCall: &llms.ToolCallDelta{
+					Type:      string(ToolTypeFunction),
+					Name:      delta.FunctionCall.Name,
+					Arguments: delta.FunctionCall.Arguments,
+				},
+			}); err != nil {
+				return err
+			}
+		}
+
+		for _, tc := range delta.ToolCalls {
+			for len(choice.Message.ToolCalls) <= tc.Index {
+				choice.Message.ToolCalls = append(choice.Message.ToolCalls, ToolCall{})
+			}
+			call := &choice.Message.ToolCalls[tc.Index]
+			if tc.ID != "" {
+				call.ID = tc.ID
+			}
+			if tc.Type != "" {
+				call.Type = tc.Type
+			}
+			call.Function.Name += tc.Function.Name
+			call.Function.Arguments += tc.Function.Arguments
+			if err := emit(llms.StreamEvent{
+				Type:        llms.StreamEventToolCall,
+				ChoiceIndex: streamChoice.Index,
+				ToolCall: &llms.ToolCallDelta{
+					Index:     tc.Index,
+					ID:        tc.ID,
+					Type:      string(tc.Type),
+					Name:      tc.Function.Name,
+					Arguments: tc.Function.Arguments,
+				},
+			}); err != nil {
+				return err
+			}
+		}
+		if len(delta.ToolCalls) > 0 {
+			chunkBytes, _ = json.Marshal(choice.Message.ToolCalls) // nolint:errchkjson
+		}
+
+		if streamChoice.FinishReason != "" {
+			choice.FinishReason = streamChoice.FinishReason
+			if err := emit(llms.StreamEvent{
+				Type: llms.StreamEventFinish, ChoiceIndex: streamChoice.Index, StopReason: streamChoice.FinishReason,
+			}); err != nil {
+				return err
+			}
+		}
+
+		// The raw streaming func only ever saw the first choice.
+		if payload.StreamingFunc != nil && streamChoice.Index == 0 {
+			if err := payload.StreamingFunc(ctx, chunkBytes); err != nil {
+				return err
 			}
 		}
 	}
-	return &response, nil
+	return nil
 }
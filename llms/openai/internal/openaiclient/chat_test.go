@@ -10,6 +10,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
 )
 
 func TestParseStreamingChatResponse_FinishReason(t *testing.T) {
@@ -52,3 +53,51 @@ func TestChatMessage_MarshalUnmarshal(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, msg, msg2)
 }
+
+func TestParseStreamingChatResponse_ToolCallsAndUsage(t *testing.T) {
+	t.Parallel()
+	mockBody := `data: {"choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"weather","arguments":""}}]}}]}
+
+data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"id":"call_2","type":"function","function":{"name":"weather","arguments":"{\"city\":\"Rome\"}"}}]}}]}
+
+data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":\"Paris\"}"}}]}}]}
+
+data: {"choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}
+
+data: {"choices":[],"usage":{"prompt_tokens":5,"completion_tokens":7,"total_tokens":12}}
+
+data: [DONE]
+`
+	r := &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewBufferString(mockBody)),
+	}
+
+	var events []llms.StreamEvent
+	req := &ChatRequest{
+		Stream: true,
+		StreamingEventFunc: func(ctx context.Context, event llms.StreamEvent) error {
+			events = append(events, event)
+			return nil
+		},
+	}
+
+	resp, err := parseStreamingChatResponse(context.Background(), r, req)
+	require.NoError(t, err)
+
+	require.Len(t, resp.Choices, 1)
+	assert.Equal(t, "tool_calls", resp.Choices[0].FinishReason)
+	assert.Equal(t, []ToolCall{
+		{ID: "call_1", Type: ToolTypeFunction, Function: FunctionCall{Name: "weather", Arguments: `{"city":"Paris"}`}},
+		{ID: "call_2", Type: ToolTypeFunction, Function: FunctionCall{Name: "weather", Arguments: `{"city":"Rome"}`}},
+	}, resp.Choices[0].Message.ToolCalls)
+	assert.Equal(t, float64(12), resp.Usage.TotalTokens)
+
+	require.Len(t, events, 5)
+	assert.Equal(t, llms.StreamEventToolCall, events[0].Type)
+	assert.Equal(t, "call_1", events[0].ToolCall.ID)
+	assert.Equal(t, 1, events[1].ToolCall.Index)
+	assert.Equal(t, `{"city":"Paris"}`, events[2].ToolCall.Arguments)
+	assert.Equal(t, llms.StreamEvent{Type: llms.StreamEventFinish, StopReason: "tool_calls"}, events[3])
+	assert.Equal(t, &llms.Usage{PromptTokens: 5, CompletionTokens: 7, TotalTokens: 12}, events[4].Usage)
+}
@@ -4,9 +4,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
+
+	"github.com/tmc/langchaingo/llms"
 )
 
 const (
@@ -41,7 +42,13 @@ func (c *Client) createEmbedding(ctx context.Context, payload *embeddingPayload)
 	if c.baseURL == "" {
 		c.baseURL = defaultBaseURL
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.buildURL("/embeddings", c.embeddingsModel), bytes.NewReader(payloadBytes))
+	// Embeddings are served by the deployment of the embeddings model, or
+	// else of the model of the request.
+	model := c.embeddingsModel
+	if model == "" {
+		model = payload.Model
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.buildURL("/embeddings", model), bytes.NewReader(payloadBytes))
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
@@ -55,16 +62,11 @@ func (c *Client) createEmbedding(ctx context.Context, payload *embeddingPayload)
 	defer r.Body.Close()
 
 	if r.StatusCode != http.StatusOK {
-		msg := fmt.Sprintf("API returned unexpected status code: %d", r.StatusCode)
-
 		// No need to check the error here: if it fails, we'll just return the
 		// status code.
 		var errResp errorMessage
-		if err := json.NewDecoder(r.Body).Decode(&errResp); err != nil {
-			return nil, errors.New(msg) // nolint:goerr113
-		}
-
-		return nil, fmt.Errorf("%s: %s", msg, errResp.Error.Message) // nolint:goerr113
+		_ = json.NewDecoder(r.Body).Decode(&errResp)
+		return nil, llms.NewHTTPError(r, errResp.Error.Message)
 	}
 
 	var response embeddingResponsePayload
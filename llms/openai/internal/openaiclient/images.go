@@ -0,0 +1,89 @@
+package openaiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+const defaultImageModel = "dall-e-3"
+
+// ImageRequest is a request to generate images.
+type ImageRequest struct {
+	Model          string `json:"model"`
+	Prompt         string `json:"prompt"`
+	N              int    `json:"n,omitempty"`
+	Size           string `json:"size,omitempty"`
+	Quality        string `json:"quality,omitempty"`
+	Style          string `json:"style,omitempty"`
+	ResponseFormat string `json:"response_format,omitempty"`
+}
+
+// ImageData is a generated image.
+type ImageData struct {
+	URL           string `json:"url,omitempty"`
+	B64JSON       string `json:"b64_json,omitempty"`
+	RevisedPrompt string `json:"revised_prompt,omitempty"`
+}
+
+// ImageResponse is a response to an image request.
+type ImageResponse struct {
+	Created int64       `json:"created"`
+	Data    []ImageData `json:"data"`
+}
+
+// CreateImage generates images.
+func (c *Client) CreateImage(ctx context.Context, r *ImageRequest) (*ImageResponse, error) {
+	if r.Model == "" {
+		r.Model = defaultImageModel
+	}
+	payloadBytes, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("marshal payload: %w", err)
+	}
+	if c.baseURL == "" {
+		c.baseURL = defaultBaseURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.buildURL("/images/generations", r.Model), bytes.NewReader(payloadBytes)) //nolint:lll
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	c.setHeaders(req)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var response ImageResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	if len(response.Data) == 0 {
+		return nil, ErrEmptyResponse
+	}
+	return &response, nil
+}
+
+// do sends a request and returns its response, or an error if its status
+// isn't 200 OK. The body of the response must be closed by the caller.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	r, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	if r.StatusCode != http.StatusOK {
+		defer r.Body.Close()
+		// No need to check the error here: if it fails, we'll just return the
+		// status code.
+		var errResp errorMessage
+		_ = json.NewDecoder(r.Body).Decode(&errResp)
+		return nil, llms.NewHTTPError(r, errResp.Error.Message)
+	}
+	return r, nil
+}
@@ -2,6 +2,7 @@ package openaiclient
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -24,6 +25,50 @@ const (
 	APITypeAzureAD APIType = "AZURE_AD"
 )
 
+// AuthScheme is how the token is sent to the API.
+type AuthScheme string
+
+const (
+	// AuthBearer sends the token in an "Authorization: Bearer" header.
+	AuthBearer AuthScheme = "bearer"
+	// AuthAPIKey sends the token in an "api-key" header, as Azure does.
+	AuthAPIKey AuthScheme = "api-key"
+	// AuthNone doesn't send the token.
+	AuthNone AuthScheme = "none"
+)
+
+// Quirks are the differences of a backend from the OpenAI API.
+type Quirks struct {
+	// Auth is how the token is sent.
+	Auth AuthScheme
+	// Headers are extra headers sent with every request.
+	Headers map[string]string
+	// ExtraBody are extra fields added to the body of chat requests. They
+	// take precedence over the fields of the request.
+	ExtraBody map[string]any
+	// UnsupportedParams are the JSON names of the fields of chat requests the
+	// backend rejects. They are removed from the body before sending it.
+	UnsupportedParams []string
+	// Deployment, when set, returns the name of the deployment serving a
+	// model. Requests are then sent to the deployment URL, as Azure expects.
+	Deployment func(model string) string
+}
+
+// quirksForAPIType returns the quirks of the given API type. Azure API
+// versions before 2024-09-01-preview reject stream_options, so it is never
+// sent to Azure.
+func quirksForAPIType(apiType APIType) Quirks {
+	identity := func(model string) string { return model }
+	switch apiType {
+	case APITypeAzure:
+		return Quirks{Auth: AuthAPIKey, Deployment: identity, UnsupportedParams: []string{"stream_options"}}
+	case APITypeAzureAD:
+		return Quirks{Auth: AuthBearer, Deployment: identity, UnsupportedParams: []string{"stream_options"}}
+	case APITypeOpenAI:
+	}
+	return Quirks{Auth: AuthBearer}
+}
+
 // Client is a client for the OpenAI API.
 type Client struct {
 	token        string
@@ -31,6 +76,7 @@ type Client struct {
 	baseURL      string
 	organization string
 	apiType      APIType
+	quirks       Quirks
 	httpClient   Doer
 
 	// required when APIType is APITypeAzure or APITypeAzureAD
@@ -41,6 +87,15 @@ type Client struct {
 // Option is an option for the OpenAI client.
 type Option func(*Client) error
 
+// WithQuirks sets the quirks of the backend, replacing those implied by the
+// API type of the client.
+func WithQuirks(quirks Quirks) Option {
+	return func(c *Client) error {
+		c.quirks = quirks
+		return nil
+	}
+}
+
 // Doer performs a HTTP request.
 type Doer interface {
 	Do(req *http.Request) (*http.Response, error)
@@ -58,6 +113,7 @@ func New(token string, model string, baseURL string, organization string,
 		baseURL:         strings.TrimSuffix(baseURL, "/"),
 		organization:    organization,
 		apiType:         apiType,
+		quirks:          quirksForAPIType(apiType),
 		apiVersion:      apiVersion,
 		httpClient:      httpClient,
 	}
@@ -124,6 +180,19 @@ func (c *Client) CreateEmbedding(ctx context.Context, r *EmbeddingRequest) ([][]
 
 // CreateChat creates chat request.
 func (c *Client) CreateChat(ctx context.Context, r *ChatRequest) (*ChatResponse, error) {
+	c.setChatDefaults(r)
+	resp, err := c.createChat(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, ErrEmptyResponse
+	}
+	return resp, nil
+}
+
+// setChatDefaults sets the fields of a chat request left to their defaults.
+func (c *Client) setChatDefaults(r *ChatRequest) {
 	if r.Model == "" {
 		if c.Model == "" {
 			r.Model = defaultChatModel
@@ -134,14 +203,6 @@ func (c *Client) CreateChat(ctx context.Context, r *ChatRequest) (*ChatResponse,
 	if r.FunctionCallBehavior == "" && len(r.Functions) > 0 {
 		r.FunctionCallBehavior = defaultFunctionCallBehavior
 	}
-	resp, err := c.createChat(ctx, r)
-	if err != nil {
-		return nil, err
-	}
-	if len(resp.Choices) == 0 {
-		return nil, ErrEmptyResponse
-	}
-	return resp, nil
 }
 
 func IsAzure(apiType APIType) bool {
@@ -150,32 +211,66 @@ func IsAzure(apiType APIType) bool {
 
 func (c *Client) setHeaders(req *http.Request) {
 	req.Header.Set("Content-Type", "application/json")
-	if c.apiType == APITypeOpenAI || c.apiType == APITypeAzureAD {
-		req.Header.Set("Authorization", "Bearer "+c.token)
-	} else {
+	switch c.quirks.Auth {
+	case AuthAPIKey:
 		req.Header.Set("api-key", c.token)
+	case AuthNone:
+	case AuthBearer:
+		fallthrough
+	default:
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
 	}
 	if c.organization != "" {
 		req.Header.Set("OpenAI-Organization", c.organization)
 	}
+	for k, v := range c.quirks.Headers {
+		req.Header.Set(k, v)
+	}
 }
 
 func (c *Client) buildURL(suffix string, model string) string {
-	if IsAzure(c.apiType) {
-		return c.buildAzureURL(suffix, model)
+	if c.quirks.Deployment != nil {
+		return c.buildAzureURL(suffix, c.quirks.Deployment(model))
 	}
 
 	// open ai implement:
 	return fmt.Sprintf("%s%s", c.baseURL, suffix)
 }
 
-func (c *Client) buildAzureURL(suffix string, model string) string {
+func (c *Client) buildAzureURL(suffix string, deployment string) string {
 	baseURL := c.baseURL
 	baseURL = strings.TrimRight(baseURL, "/")
 
 	// azure example url:
 	// /openai/deployments/{model}/chat/completions?api-version={api_version}
 	return fmt.Sprintf("%s/openai/deployments/%s%s?api-version=%s",
-		baseURL, model, suffix, c.apiVersion,
+		baseURL, deployment, suffix, c.apiVersion,
 	)
 }
+
+// marshalChatPayload marshals a chat request, removing the fields the backend
+// doesn't support and adding its extra fields.
+func (c *Client) marshalChatPayload(payload *ChatRequest) ([]byte, error) {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil || (len(c.quirks.UnsupportedParams) == 0 && len(c.quirks.ExtraBody) == 0) {
+		return payloadBytes, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payloadBytes, &fields); err != nil {
+		return nil, err
+	}
+	for _, name := range c.quirks.UnsupportedParams {
+		delete(fields, name)
+	}
+	for name, v := range c.quirks.ExtraBody {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("marshal extra field %s: %w", name, err)
+		}
+		fields[name] = raw
+	}
+	return json.Marshal(fields)
+}
@@ -2,6 +2,7 @@ package openai
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"os"
 
@@ -12,6 +13,7 @@ var (
 	ErrEmptyResponse              = errors.New("no response")
 	ErrMissingToken               = errors.New("missing the OpenAI API key, set it in the OPENAI_API_KEY environment variable") //nolint:lll
 	ErrMissingAzureEmbeddingModel = errors.New("embeddings model needs to be provided when using Azure API")
+	ErrToolsNotSupported          = errors.New("tools are not supported by the backend")
 
 	ErrUnexpectedResponseLength = errors.New("unexpected length of response")
 )
@@ -19,9 +21,6 @@ var (
 // newClient is wrapper for openaiclient internal package.
 func newClient(opts ...Option) (*options, *openaiclient.Client, error) {
 	options := &options{
-		token:        os.Getenv(tokenEnvVarName),
-		model:        os.Getenv(modelEnvVarName),
-		baseURL:      getEnvs(baseURLEnvVarName, baseAPIBaseEnvVarName),
 		organization: os.Getenv(organizationEnvVarName),
 		apiType:      APIType(openaiclient.APITypeOpenAI),
 		httpClient:   http.DefaultClient,
@@ -31,6 +30,20 @@ func newClient(opts ...Option) (*options, *openaiclient.Client, error) {
 		opt(options)
 	}
 
+	if options.profile != nil {
+		return newProfileClient(options)
+	}
+
+	if options.token == "" {
+		options.token = os.Getenv(tokenEnvVarName)
+	}
+	if options.model == "" {
+		options.model = os.Getenv(modelEnvVarName)
+	}
+	if options.baseURL == "" {
+		options.baseURL = getEnvs(baseURLEnvVarName, baseAPIBaseEnvVarName)
+	}
+
 	// set of options needed for Azure client
 	if openaiclient.IsAzure(openaiclient.APIType(options.apiType)) && options.apiVersion == "" {
 		options.apiVersion = DefaultAPIVersion
@@ -48,8 +61,41 @@ func newClient(opts ...Option) (*options, *openaiclient.Client, error) {
 	return options, cli, err
 }
 
+// newProfileClient returns a client for the backend of the profile of the
+// options. Settings not given as options are taken from the profile.
+func newProfileClient(options *options) (*options, *openaiclient.Client, error) {
+	p := options.profile
+	if options.apiType == APIType(openaiclient.APITypeOpenAI) && p.APIType != "" {
+		options.apiType = p.APIType
+	}
+	if options.token == "" {
+		options.token = getEnvs(p.TokenEnvVar, tokenEnvVarName)
+	}
+	if options.model == "" {
+		options.model = os.Getenv(modelEnvVarName)
+	}
+	if options.baseURL == "" {
+		options.baseURL = p.BaseURL
+	}
+	if options.apiVersion == "" {
+		options.apiVersion = p.APIVersion
+	}
+
+	if len(options.token) == 0 && p.Auth != AuthNone {
+		return options, nil, fmt.Errorf("%w (profile %s)", ErrMissingToken, p.Name)
+	}
+
+	cli, err := openaiclient.New(options.token, options.model, options.baseURL, options.organization,
+		openaiclient.APIType(options.apiType), options.apiVersion, options.httpClient, options.embeddingModel,
+		openaiclient.WithQuirks(p.quirks()))
+	return options, cli, err
+}
+
 func getEnvs(keys ...string) string {
 	for _, key := range keys {
+		if key == "" {
+			continue
+		}
 		val, ok := os.LookupEnv(key)
 		if ok {
 			return val
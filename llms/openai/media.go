@@ -0,0 +1,207 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/openai/internal/openaiclient"
+)
+
+var (
+	_ llms.ImageGenerator    = (*LLM)(nil)
+	_ llms.Transcriber       = (*LLM)(nil)
+	_ llms.SpeechSynthesizer = (*LLM)(nil)
+)
+
+// GenerateImages implements the llms.ImageGenerator interface. Images returned
+// as data are PNG images.
+func (o *LLM) GenerateImages(ctx context.Context, prompt string, options ...llms.ImageOption) ([]llms.GeneratedImage, error) { //nolint:lll
+	if o.CallbacksHandler != nil {
+		o.CallbacksHandler.HandleImageGenerationStart(ctx, prompt)
+	}
+
+	opts := llms.ImageOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	images, err := o.generateImages(ctx, prompt, opts)
+	if err != nil {
+		if o.CallbacksHandler != nil {
+			o.CallbacksHandler.HandleLLMError(ctx, err)
+		}
+		return nil, err
+	}
+
+	if o.CallbacksHandler != nil {
+		o.CallbacksHandler.HandleImageGenerationEnd(ctx, images)
+	}
+	return images, nil
+}
+
+// generateImages requests the images of GenerateImages and decodes them.
+func (o *LLM) generateImages(ctx context.Context, prompt string, opts llms.ImageOptions) ([]llms.GeneratedImage, error) { //nolint:lll
+	req := &openaiclient.ImageRequest{
+		Model:   opts.Model,
+		Prompt:  prompt,
+		N:       opts.N,
+		Size:    opts.Size,
+		Quality: opts.Quality,
+		Style:   opts.Style,
+	}
+	switch opts.Format {
+	case llms.ImageFormatData:
+		req.ResponseFormat = "b64_json"
+	case llms.ImageFormatURL:
+		req.ResponseFormat = "url"
+	}
+
+	resp, err := o.client.CreateImage(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	images := make([]llms.GeneratedImage, 0, len(resp.Data))
+	for _, d := range resp.Data {
+		image := llms.GeneratedImage{URL: d.URL, RevisedPrompt: d.RevisedPrompt}
+		if d.B64JSON != "" {
+			image.Data, err = base64.StdEncoding.DecodeString(d.B64JSON)
+			if err != nil {
+				return nil, fmt.Errorf("decode image: %w", err)
+			}
+			image.MIMEType = "image/png"
+		}
+		images = append(images, image)
+	}
+	return images, nil
+}
+
+// audioExtensions are the file extensions of the audio formats accepted for
+// transcription, by MIME type.
+var audioExtensions = map[string]string{ //nolint:gochecknoglobals
+	"audio/mpeg":   "mp3",
+	"audio/mp3":    "mp3",
+	"audio/mp4":    "m4a",
+	"audio/m4a":    "m4a",
+	"audio/x-m4a":  "m4a",
+	"audio/wav":    "wav",
+	"audio/x-wav":  "wav",
+	"audio/wave":   "wav",
+	"audio/webm":   "webm",
+	"audio/ogg":    "ogg",
+	"audio/flac":   "flac",
+	"audio/x-flac": "flac",
+	"video/mp4":    "mp4",
+	"video/webm":   "webm",
+}
+
+// speechMIMETypes are the MIME types of the formats of synthesized speech.
+var speechMIMETypes = map[string]string{ //nolint:gochecknoglobals
+	"mp3":  "audio/mpeg",
+	"opus": "audio/opus",
+	"aac":  "audio/aac",
+	"flac": "audio/flac",
+	"wav":  "audio/wav",
+	"pcm":  "audio/pcm",
+}
+
+// Transcribe implements the llms.Transcriber interface. The transcription
+// includes its segments.
+func (o *LLM) Transcribe(ctx context.Context, audio llms.BinaryContent, options ...llms.TranscriptionOption) (*llms.Transcription, error) { //nolint:lll
+	if o.CallbacksHandler != nil {
+		o.CallbacksHandler.HandleTranscriptionStart(ctx, audio)
+	}
+
+	opts := llms.TranscriptionOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	mimeType, _, _ := strings.Cut(audio.MIMEType, ";")
+	ext, ok := audioExtensions[mimeType]
+	if !ok {
+		// Let the backend tell whether it supports the format.
+		_, ext, _ = strings.Cut(mimeType, "/")
+	}
+
+	resp, err := o.client.CreateTranscription(ctx, &openaiclient.TranscriptionRequest{
+		Model:       opts.Model,
+		Filename:    "audio." + ext,
+		Audio:       audio.Data,
+		Language:    opts.Language,
+		Prompt:      opts.Prompt,
+		Temperature: opts.Temperature,
+	})
+	if err != nil {
+		if o.CallbacksHandler != nil {
+			o.CallbacksHandler.HandleLLMError(ctx, err)
+		}
+		return nil, err
+	}
+
+	transcription := &llms.Transcription{
+		Text:     resp.Text,
+		Language: resp.Language,
+		Duration: seconds(resp.Duration),
+	}
+	for _, s := range resp.Segments {
+		transcription.Segments = append(transcription.Segments, llms.TranscriptionSegment{
+			Start: seconds(s.Start),
+			End:   seconds(s.End),
+			Text:  s.Text,
+		})
+	}
+
+	if o.CallbacksHandler != nil {
+		o.CallbacksHandler.HandleTranscriptionEnd(ctx, transcription)
+	}
+	return transcription, nil
+}
+
+// SynthesizeSpeech implements the llms.SpeechSynthesizer interface.
+func (o *LLM) SynthesizeSpeech(ctx context.Context, text string, options ...llms.SpeechOption) (*llms.BinaryContent, error) { //nolint:lll
+	if o.CallbacksHandler != nil {
+		o.CallbacksHandler.HandleSpeechStart(ctx, text)
+	}
+
+	opts := llms.SpeechOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	audio, contentType, err := o.client.CreateSpeech(ctx, &openaiclient.SpeechRequest{
+		Model:          opts.Model,
+		Input:          text,
+		Voice:          opts.Voice,
+		ResponseFormat: opts.Format,
+		Speed:          opts.Speed,
+	})
+	if err != nil {
+		if o.CallbacksHandler != nil {
+			o.CallbacksHandler.HandleLLMError(ctx, err)
+		}
+		return nil, err
+	}
+
+	if contentType == "" || contentType == "application/octet-stream" {
+		format := opts.Format
+		if format == "" {
+			format = "mp3"
+		}
+		contentType = speechMIMETypes[format]
+	}
+	speech := &llms.BinaryContent{MIMEType: contentType, Data: audio}
+
+	if o.CallbacksHandler != nil {
+		o.CallbacksHandler.HandleSpeechEnd(ctx, speech)
+	}
+	return speech, nil
+}
+
+// seconds converts a number of seconds to a duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,194 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/llms"
+)
+
+type mediaHandler struct {
+	callbacks.SimpleHandler
+	events []string
+}
+
+func (h *mediaHandler) HandleImageGenerationStart(_ context.Context, prompt string) {
+	h.events = append(h.events, "image start: "+prompt)
+}
+
+func (h *mediaHandler) HandleImageGenerationEnd(context.Context, []llms.GeneratedImage) {
+	h.events = append(h.events, "image end")
+}
+
+func (h *mediaHandler) HandleLLMError(_ context.Context, err error) {
+	h.events = append(h.events, "error: "+err.Error())
+}
+
+func (h *mediaHandler) HandleTranscriptionStart(_ context.Context, audio llms.BinaryContent) {
+	h.events = append(h.events, "transcription start: "+audio.MIMEType)
+}
+
+func (h *mediaHandler) HandleTranscriptionEnd(_ context.Context, transcription *llms.Transcription) {
+	h.events = append(h.events, "transcription end: "+transcription.Text)
+}
+
+func (h *mediaHandler) HandleSpeechStart(_ context.Context, text string) {
+	h.events = append(h.events, "speech start: "+text)
+}
+
+func (h *mediaHandler) HandleSpeechEnd(_ context.Context, speech *llms.BinaryContent) {
+	h.events = append(h.events, "speech end: "+speech.MIMEType)
+}
+
+func TestGenerateImages(t *testing.T) {
+	t.Parallel()
+
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/images/generations", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte(`{"created":1,"data":[{"b64_json":"` +
+			base64.StdEncoding.EncodeToString([]byte("png")) + `","revised_prompt":"A red parrot"}]}`))
+	}))
+	defer srv.Close()
+
+	h := &mediaHandler{}
+	llm, err := New(WithToken("test"), WithBaseURL(srv.URL), WithCallback(h))
+	require.NoError(t, err)
+
+	images, err := llm.GenerateImages(context.Background(), "a parrot",
+		llms.WithImageSize("1024x1024"), llms.WithImageQuality("hd"), llms.WithImageCount(1),
+		llms.WithImageFormat(llms.ImageFormatData))
+	require.NoError(t, err)
+
+	assert.Equal(t, []llms.GeneratedImage{{
+		MIMEType:      "image/png",
+		Data:          []byte("png"),
+		RevisedPrompt: "A red parrot",
+	}}, images)
+	assert.Equal(t, map[string]any{
+		"model":           "dall-e-3",
+		"prompt":          "a parrot",
+		"n":               float64(1),
+		"size":            "1024x1024",
+		"quality":         "hd",
+		"response_format": "b64_json",
+	}, got)
+	assert.Equal(t, []string{"image start: a parrot", "image end"}, h.events)
+}
+
+func TestTranscribe(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/audio/transcriptions", r.URL.Path)
+		assert.Equal(t, "Bearer test", r.Header.Get("Authorization"))
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		assert.Equal(t, "whisper-1", r.FormValue("model"))
+		assert.Equal(t, "verbose_json", r.FormValue("response_format"))
+		assert.Equal(t, "en", r.FormValue("language"))
+		f, header, err := r.FormFile("file")
+		require.NoError(t, err)
+		defer f.Close()
+		assert.Equal(t, "audio.mp3", header.Filename)
+		data, err := io.ReadAll(f)
+		require.NoError(t, err)
+		assert.Equal(t, "mp3 data", string(data))
+
+		_, _ = w.Write([]byte(`{"text":"Hello there.","language":"english","duration":1.5,
+			"segments":[{"id":0,"start":0,"end":0.5,"text":"Hello"},{"id":1,"start":0.5,"end":1.5,"text":" there."}]}`))
+	}))
+	defer srv.Close()
+
+	h := &mediaHandler{}
+	llm, err := New(WithToken("test"), WithBaseURL(srv.URL), WithCallback(h))
+	require.NoError(t, err)
+
+	transcription, err := llm.Transcribe(context.Background(),
+		llms.BinaryContent{MIMEType: "audio/mpeg", Data: []byte("mp3 data")},
+		llms.WithTranscriptionLanguage("en"))
+	require.NoError(t, err)
+
+	assert.Equal(t, &llms.Transcription{
+		Text:     "Hello there.",
+		Language: "english",
+		Duration: 1500 * time.Millisecond,
+		Segments: []llms.TranscriptionSegment{
+			{Start: 0, End: 500 * time.Millisecond, Text: "Hello"},
+			{Start: 500 * time.Millisecond, End: 1500 * time.Millisecond, Text: " there."},
+		},
+	}, transcription)
+	assert.Equal(t, []string{"transcription start: audio/mpeg", "transcription end: Hello there."}, h.events)
+}
+
+func TestSynthesizeSpeech(t *testing.T) {
+	t.Parallel()
+
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/audio/speech", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write([]byte("wav data"))
+	}))
+	defer srv.Close()
+
+	h := &mediaHandler{}
+	llm, err := New(WithToken("test"), WithBaseURL(srv.URL), WithCallback(h))
+	require.NoError(t, err)
+
+	speech, err := llm.SynthesizeSpeech(context.Background(), "Hello",
+		llms.WithVoice("nova"), llms.WithSpeechFormat("wav"))
+	require.NoError(t, err)
+
+	assert.Equal(t, &llms.BinaryContent{MIMEType: "audio/wav", Data: []byte("wav data")}, speech)
+	assert.Equal(t, map[string]any{
+		"model":           "tts-1",
+		"input":           "Hello",
+		"voice":           "nova",
+		"response_format": "wav",
+	}, got)
+	assert.Equal(t, []string{"speech start: Hello", "speech end: audio/wav"}, h.events)
+}
+
+func TestMediaError(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":{"message":"bad prompt","type":"invalid_request_error"}}`))
+	}))
+	defer srv.Close()
+
+	llm, err := New(WithToken("test"), WithBaseURL(srv.URL))
+	require.NoError(t, err)
+
+	_, err = llm.GenerateImages(context.Background(), "a parrot")
+	require.ErrorContains(t, err, "bad prompt")
+}
+
+func TestGenerateImagesDecodeError(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"created":1,"data":[{"b64_json":"not base64!"}]}`))
+	}))
+	defer srv.Close()
+
+	h := &mediaHandler{}
+	llm, err := New(WithToken("test"), WithBaseURL(srv.URL), WithCallback(h))
+	require.NoError(t, err)
+
+	_, err = llm.GenerateImages(context.Background(), "a parrot", llms.WithImageFormat(llms.ImageFormatData))
+	require.ErrorContains(t, err, "decode image")
+	assert.Equal(t, []string{"image start: a parrot", "error: " + err.Error()}, h.events)
+}
@@ -2,6 +2,7 @@ package openai
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/tmc/langchaingo/callbacks"
@@ -15,6 +16,10 @@ type ChatMessage = openaiclient.ChatMessage
 type LLM struct {
 	CallbacksHandler callbacks.Handler
 	client           *openaiclient.Client
+	// capabilities are the features of the backend, from its profile.
+	capabilities llms.Capabilities
+	// batches reports whether the backend has the batch API.
+	batches bool
 }
 
 const (
@@ -22,9 +27,13 @@ const (
 	RoleAssistant = "assistant"
 	RoleUser      = "user"
 	RoleFunction  = "function"
+	RoleTool      = "tool"
 )
 
-var _ llms.Model = (*LLM)(nil)
+var (
+	_ llms.Model          = (*LLM)(nil)
+	_ llms.StreamingModel = (*LLM)(nil)
+)
 
 // New returns a new OpenAI LLM.
 func New(opts ...Option) (*LLM, error) {
@@ -32,9 +41,17 @@ func New(opts ...Option) (*LLM, error) {
 	if err != nil {
 		return nil, err
 	}
+	capabilities := allCapabilities
+	batches := opt.apiType == APIType(openaiclient.APITypeOpenAI)
+	if opt.profile != nil {
+		capabilities = opt.profile.Capabilities
+		batches = opt.profile.Batches
+	}
 	return &LLM{
 		client:           c,
 		CallbacksHandler: opt.callbackHandler,
+		capabilities:     capabilities,
+		batches:          batches,
 	}, err
 }
 
@@ -44,9 +61,7 @@ func (o *LLM) Call(ctx context.Context, prompt string, options ...llms.CallOptio
 }
 
 // GenerateContent implements the Model interface.
-//
-//nolint:goerr113
-func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) { //nolint: lll, cyclop
+func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) { //nolint: lll
 	if o.CallbacksHandler != nil {
 		o.CallbacksHandler.HandleLLMGenerateContentStart(ctx, messages)
 	}
@@ -56,25 +71,48 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 		opt(&opts)
 	}
 
+	req, err := o.chatRequest(messages, opts)
+	if err != nil {
+		return nil, err
+	}
+	result, err := o.client.CreateChat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Choices) == 0 {
+		return nil, ErrEmptyResponse
+	}
+	response := contentResponse(result)
+
+	if o.CallbacksHandler != nil {
+		o.CallbacksHandler.HandleLLMGenerateContentEnd(ctx, response)
+	}
+
+	return response, nil
+}
+
+// chatRequest returns the chat request sending messages with opts.
+//
+//nolint:goerr113
+func (o *LLM) chatRequest(messages []llms.MessageContent, opts llms.CallOptions) (*openaiclient.ChatRequest, error) { //nolint:cyclop,lll
+	if !o.capabilities.Tools && (len(opts.Tools) > 0 || len(opts.Functions) > 0) {
+		return nil, ErrToolsNotSupported
+	}
+
 	chatMsgs := make([]*ChatMessage, 0, len(messages))
 	for _, mc := range messages {
-		msg := &ChatMessage{MultiContent: mc.Parts}
-		switch mc.Role {
-		case schema.ChatMessageTypeSystem:
-			msg.Role = RoleSystem
-		case schema.ChatMessageTypeAI:
-			msg.Role = RoleAssistant
-		case schema.ChatMessageTypeHuman:
-			msg.Role = RoleUser
-		case schema.ChatMessageTypeGeneric:
-			msg.Role = RoleUser
-		case schema.ChatMessageTypeFunction:
-			fallthrough
-		default:
-			return nil, fmt.Errorf("role %v not supported", mc.Role)
+		msgs, err := convertMessageContent(mc)
+		if err != nil {
+			return nil, err
+		}
+		chatMsgs = append(chatMsgs, msgs...)
+	}
+	if !o.capabilities.SystemRole {
+		for _, msg := range chatMsgs {
+			if msg.Role == RoleSystem {
+				msg.Role = RoleUser
+			}
 		}
-
-		chatMsgs = append(chatMsgs, msg)
 	}
 
 	req := &openaiclient.ChatRequest{
@@ -82,6 +120,7 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 		StopWords:            opts.StopWords,
 		Messages:             chatMsgs,
 		StreamingFunc:        opts.StreamingFunc,
+		StreamingEventFunc:   opts.StreamingEventFunc,
 		Temperature:          opts.Temperature,
 		MaxTokens:            opts.MaxTokens,
 		N:                    opts.N,
@@ -89,6 +128,9 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 		PresencePenalty:      opts.PresencePenalty,
 		FunctionCallBehavior: openaiclient.FunctionCallBehavior(opts.FunctionCallBehavior),
 	}
+	if opts.JSONMode && o.capabilities.JSONMode {
+		req.ResponseFormat = &openaiclient.ResponseFormat{Type: "json_object"}
+	}
 
 	for _, fn := range opts.Functions {
 		req.Functions = append(req.Functions, openaiclient.FunctionDefinition{
@@ -97,14 +139,19 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 			Parameters:  fn.Parameters,
 		})
 	}
-	result, err := o.client.CreateChat(ctx, req)
-	if err != nil {
-		return nil, err
-	}
-	if len(result.Choices) == 0 {
-		return nil, ErrEmptyResponse
+	for _, tool := range opts.Tools {
+		t, err := toolFromTool(tool)
+		if err != nil {
+			return nil, err
+		}
+		req.Tools = append(req.Tools, t)
 	}
+	req.ToolChoice = toolChoiceFromChoice(opts.ToolChoice)
+	return req, nil
+}
 
+// contentResponse converts a chat response to a content response.
+func contentResponse(result *openaiclient.ChatResponse) *llms.ContentResponse {
 	choices := make([]*llms.ContentChoice, len(result.Choices))
 	for i, c := range result.Choices {
 		choices[i] = &llms.ContentChoice{
@@ -117,21 +164,164 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 			},
 		}
 
-		if c.FinishReason == "function_call" {
+		if c.Message.FunctionCall != nil {
 			choices[i].FuncCall = &schema.FunctionCall{
 				Name:      c.Message.FunctionCall.Name,
 				Arguments: c.Message.FunctionCall.Arguments,
 			}
 		}
+		for _, tc := range c.Message.ToolCalls {
+			choices[i].ToolCalls = append(choices[i].ToolCalls, llms.ToolCall{
+				ID:   tc.ID,
+				Type: string(tc.Type),
+				FunctionCall: &schema.FunctionCall{
+					Name:      tc.Function.Name,
+					Arguments: tc.Function.Arguments,
+				},
+			})
+		}
+		// Keep FuncCall populated for callers that predate tool calls.
+		if choices[i].FuncCall == nil && len(choices[i].ToolCalls) > 0 {
+			choices[i].FuncCall = choices[i].ToolCalls[0].FunctionCall
+		}
 	}
 
-	response := &llms.ContentResponse{Choices: choices}
+	response := &llms.ContentResponse{Choices: choices, Model: result.Model}
+	// Streamed responses only carry usage when it was requested.
+	if result.Usage.TotalTokens > 0 {
+		response.Usage = &llms.Usage{
+			PromptTokens:     int(result.Usage.PromptTokens),
+			CompletionTokens: int(result.Usage.CompletionTokens),
+			TotalTokens:      int(result.Usage.TotalTokens),
+		}
+	}
+	return response
+}
 
-	if o.CallbacksHandler != nil {
-		o.CallbacksHandler.HandleLLMGenerateContentEnd(ctx, response)
+// GenerateContentStream implements the StreamingModel interface.
+func (o *LLM) GenerateContentStream(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentStream, error) { //nolint:lll
+	return llms.GenerateContentEventStream(ctx, o, messages, options...)
+}
+
+// convertMessageContent converts a MessageContent to the chat messages sent to
+// OpenAI. Tool results are sent as one message per tool call, so a single
+// MessageContent may produce several chat messages.
+//
+// Tool calls without an ID are sent as a legacy function call, and results in
+// function messages as the result of such a call.
+func convertMessageContent(mc llms.MessageContent) ([]*ChatMessage, error) { //nolint:cyclop
+	msg := &ChatMessage{}
+	switch mc.Role {
+	case schema.ChatMessageTypeSystem:
+		msg.Role = RoleSystem
+	case schema.ChatMessageTypeAI:
+		msg.Role = RoleAssistant
+	case schema.ChatMessageTypeHuman:
+		msg.Role = RoleUser
+	case schema.ChatMessageTypeGeneric:
+		msg.Role = RoleUser
+	case schema.ChatMessageTypeTool:
+		msg.Role = RoleTool
+	case schema.ChatMessageTypeFunction:
+		msg.Role = RoleFunction
+	default:
+		return nil, fmt.Errorf("role %v not supported", mc.Role) //nolint:goerr113
 	}
 
-	return response, nil
+	var toolResults []*ChatMessage
+	for _, part := range mc.Parts {
+		switch p := part.(type) {
+		case llms.ToolCall:
+			if p.FunctionCall == nil {
+				return nil, fmt.Errorf("tool call %v has no function call", p.ID) //nolint:goerr113
+			}
+			if p.ID == "" {
+				if msg.FunctionCall != nil {
+					return nil, errors.New("a message can carry a single function call") //nolint:goerr113
+				}
+				msg.FunctionCall = &openaiclient.FunctionCall{
+					Name:      p.FunctionCall.Name,
+					Arguments: p.FunctionCall.Arguments,
+				}
+				continue
+			}
+			msg.ToolCalls = append(msg.ToolCalls, openaiclient.ToolCall{
+				ID:   p.ID,
+				Type: openaiclient.ToolType(p.Type),
+				Function: openaiclient.FunctionCall{
+					Name:      p.FunctionCall.Name,
+					Arguments: p.FunctionCall.Arguments,
+				},
+			})
+		case llms.ToolCallResponse:
+			result := &ChatMessage{
+				Role:       RoleTool,
+				Content:    p.Content,
+				ToolCallID: p.ToolCallID,
+			}
+			if mc.Role == schema.ChatMessageTypeFunction {
+				result = &ChatMessage{
+					Role:    RoleFunction,
+					Content: p.Content,
+					Name:    p.Name,
+				}
+			}
+			toolResults = append(toolResults, result)
+		default:
+			msg.MultiContent = append(msg.MultiContent, part)
+		}
+	}
+
+	if len(toolResults) > 0 {
+		if len(msg.MultiContent) > 0 || len(msg.ToolCalls) > 0 || msg.FunctionCall != nil {
+			return nil, errors.New("tool results can't be mixed with other parts") //nolint:goerr113
+		}
+		return toolResults, nil
+	}
+	if msg.Role == RoleFunction {
+		return nil, errors.New("function messages must carry a tool call response with the function name") //nolint:goerr113,lll
+	}
+	return []*ChatMessage{msg}, nil
+}
+
+// toolFromTool converts an llms.Tool to a Tool.
+func toolFromTool(t llms.Tool) (openaiclient.Tool, error) {
+	tool := openaiclient.Tool{
+		Type: openaiclient.ToolType(t.Type),
+	}
+	switch t.Type {
+	case string(openaiclient.ToolTypeFunction):
+		if t.Function == nil {
+			return tool, errors.New("function tool has no definition") //nolint:goerr113
+		}
+		tool.Function = openaiclient.FunctionDefinition{
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+			Parameters:  t.Function.Parameters,
+		}
+	default:
+		return tool, fmt.Errorf("tool type %v not supported", t.Type) //nolint:goerr113
+	}
+	return tool, nil
+}
+
+// toolChoiceFromChoice converts the llms tool choice to the one sent to OpenAI.
+func toolChoiceFromChoice(choice any) any {
+	switch c := choice.(type) {
+	case llms.ToolChoice:
+		tc := openaiclient.ToolChoice{Type: openaiclient.ToolType(c.Type)}
+		if c.Function != nil {
+			tc.Function.Name = c.Function.Name
+		}
+		return tc
+	case *llms.ToolChoice:
+		if c == nil {
+			return nil
+		}
+		return toolChoiceFromChoice(*c)
+	default:
+		return c
+	}
 }
 
 // CreateEmbedding creates embeddings for the given input texts.
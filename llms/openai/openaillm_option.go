@@ -31,6 +31,7 @@ type options struct {
 	baseURL      string
 	organization string
 	apiType      APIType
+	profile      *Profile
 	httpClient   openaiclient.Doer
 
 	// required when APIType is APITypeAzure or APITypeAzureAD
@@ -112,3 +113,12 @@ func WithCallback(callbackHandler callbacks.Handler) Option {
 		opts.callbackHandler = callbackHandler
 	}
 }
+
+// WithProfile sets the profile of the backend the client talks to, for
+// servers speaking the OpenAI wire format with their own quirks. It takes
+// precedence over WithAPIType.
+func WithProfile(profile Profile) Option {
+	return func(opts *options) {
+		opts.profile = &profile
+	}
+}
@@ -0,0 +1,176 @@
+package openai
+
+import (
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/openai/internal/openaiclient"
+)
+
+// AuthScheme is how the API token is sent to a backend.
+type AuthScheme openaiclient.AuthScheme
+
+const (
+	// AuthBearer sends the token in an "Authorization: Bearer" header.
+	AuthBearer AuthScheme = AuthScheme(openaiclient.AuthBearer)
+	// AuthAPIKey sends the token in an "api-key" header, as Azure does.
+	AuthAPIKey AuthScheme = AuthScheme(openaiclient.AuthAPIKey)
+	// AuthNone doesn't send a token, for local servers. No token is required.
+	AuthNone AuthScheme = AuthScheme(openaiclient.AuthNone)
+)
+
+// Profile describes a backend speaking the OpenAI wire format and how it
+// departs from OpenAI, so that a single client can talk to it. Pass it to New
+// with WithProfile.
+type Profile struct {
+	// Name is the name of the backend.
+	Name string
+	// APIType is the API type of the backend. It is used unless WithAPIType
+	// gives a type other than the default, APITypeOpenAI. If empty,
+	// APITypeOpenAI is used.
+	APIType APIType
+	// BaseURL is the default base URL of the backend, used unless WithBaseURL
+	// is given.
+	BaseURL string
+	// TokenEnvVar is the environment variable the token is read from unless
+	// WithToken is given. OPENAI_API_KEY is used if empty.
+	TokenEnvVar string
+	// Auth is how the token is sent. AuthBearer is used if empty.
+	Auth AuthScheme
+	// Headers are extra headers sent with every request.
+	Headers map[string]string
+	// ExtraBody are extra fields added to the body of chat requests, e.g.
+	// sampling parameters specific to the backend. They take precedence over
+	// the fields set from call options.
+	ExtraBody map[string]any
+	// UnsupportedParams are the JSON names of the chat request fields the
+	// backend rejects, e.g. "n" or "stream_options". They are never sent.
+	UnsupportedParams []string
+	// Deployment, when set, returns the name of the deployment serving a
+	// model. Requests are then sent to
+	// {BaseURL}/openai/deployments/{deployment}/..., as Azure expects.
+	Deployment func(model string) string
+	// APIVersion is the API version sent to deployments.
+	APIVersion string
+	// Batches reports whether the backend has the batch API of OpenAI. Jobs
+	// run with llms/batch are sent as live calls otherwise.
+	Batches bool
+	// Capabilities are the features of the backend. Calls with tools fail
+	// when Tools is false, JSON mode is ignored when JSONMode is false, and
+	// system messages are sent as user messages when SystemRole is false.
+	Capabilities llms.Capabilities
+}
+
+// quirks returns the client quirks of the profile.
+func (p Profile) quirks() openaiclient.Quirks {
+	auth := openaiclient.AuthScheme(p.Auth)
+	if auth == "" {
+		auth = openaiclient.AuthBearer
+	}
+	return openaiclient.Quirks{
+		Auth:              auth,
+		Headers:           p.Headers,
+		ExtraBody:         p.ExtraBody,
+		UnsupportedParams: p.UnsupportedParams,
+		Deployment:        p.Deployment,
+	}
+}
+
+// allCapabilities are the capabilities of OpenAI itself.
+var allCapabilities = llms.Capabilities{Vision: true, Tools: true, JSONMode: true, SystemRole: true} //nolint:gochecknoglobals,lll
+
+// OpenAIProfile returns the profile of the OpenAI API.
+func OpenAIProfile() Profile {
+	return Profile{
+		Name:         "openai",
+		BaseURL:      "https://api.openai.com/v1",
+		Auth:         AuthBearer,
+		Batches:      true,
+		Capabilities: allCapabilities,
+	}
+}
+
+// AzureProfile returns the profile of Azure OpenAI deployments. Models are
+// served by deployments of the same name; set Deployment to map them
+// otherwise. BaseURL must be set to the endpoint of the resource with
+// WithBaseURL. stream_options isn't sent, as older API versions reject it.
+func AzureProfile() Profile {
+	return Profile{
+		Name:              "azure",
+		APIType:           APITypeAzure,
+		TokenEnvVar:       "AZURE_OPENAI_API_KEY",
+		Auth:              AuthAPIKey,
+		UnsupportedParams: []string{"stream_options"},
+		Deployment:        func(model string) string { return model },
+		APIVersion:        DefaultAPIVersion,
+		Capabilities:      allCapabilities,
+	}
+}
+
+// VLLMProfile returns the profile of a local vLLM server.
+func VLLMProfile() Profile {
+	return Profile{
+		Name:         "vllm",
+		BaseURL:      "http://localhost:8000/v1",
+		Auth:         AuthNone,
+		Capabilities: llms.Capabilities{Tools: true, JSONMode: true, SystemRole: true},
+	}
+}
+
+// LlamaCppProfile returns the profile of a local llama.cpp server. It serves
+// a single model, whatever the model name.
+func LlamaCppProfile() Profile {
+	return Profile{
+		Name:              "llama.cpp",
+		BaseURL:           "http://localhost:8080/v1",
+		Auth:              AuthNone,
+		UnsupportedParams: []string{"n", "stream_options", "tools", "tool_choice", "functions", "function_call"},
+		Capabilities:      llms.Capabilities{JSONMode: true, SystemRole: true},
+	}
+}
+
+// LMStudioProfile returns the profile of a local LM Studio server.
+func LMStudioProfile() Profile {
+	return Profile{
+		Name:              "lmstudio",
+		BaseURL:           "http://localhost:1234/v1",
+		Auth:              AuthNone,
+		UnsupportedParams: []string{"n", "stream_options", "response_format"},
+		Capabilities:      llms.Capabilities{SystemRole: true},
+	}
+}
+
+// TogetherProfile returns the profile of the Together API.
+func TogetherProfile() Profile {
+	return Profile{
+		Name:         "together",
+		BaseURL:      "https://api.together.xyz/v1",
+		TokenEnvVar:  "TOGETHER_API_KEY",
+		Auth:         AuthBearer,
+		Capabilities: llms.Capabilities{Tools: true, JSONMode: true, SystemRole: true},
+	}
+}
+
+// GroqProfile returns the profile of the Groq API.
+func GroqProfile() Profile {
+	return Profile{
+		Name:              "groq",
+		BaseURL:           "https://api.groq.com/openai/v1",
+		TokenEnvVar:       "GROQ_API_KEY",
+		Auth:              AuthBearer,
+		UnsupportedParams: []string{"n", "stream_options", "functions", "function_call"},
+		Capabilities:      llms.Capabilities{Tools: true, JSONMode: true, SystemRole: true},
+	}
+}
+
+// MistralProfile returns the profile of the Mistral API.
+func MistralProfile() Profile {
+	return Profile{
+		Name:        "mistral",
+		BaseURL:     "https://api.mistral.ai/v1",
+		TokenEnvVar: "MISTRAL_API_KEY",
+		Auth:        AuthBearer,
+		UnsupportedParams: []string{
+			"n", "frequency_penalty", "presence_penalty", "stream_options", "functions", "function_call",
+		},
+		Capabilities: llms.Capabilities{Tools: true, JSONMode: true, SystemRole: true},
+	}
+}
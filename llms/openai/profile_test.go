@@ -0,0 +1,145 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// profileServer is an httptest stand-in for an OpenAI compatible backend. It
+// records the last request it received.
+type profileServer struct {
+	*httptest.Server
+	req  *http.Request
+	body map[string]any
+}
+
+func newProfileServer(t *testing.T) *profileServer {
+	t.Helper()
+	s := &profileServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.req = r
+		s.body = nil
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&s.body))
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}],
+			"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestProfileQuirks(t *testing.T) {
+	t.Parallel()
+	srv := newProfileServer(t)
+
+	profile := GroqProfile()
+	profile.Headers = map[string]string{"X-Tenant": "acme"}
+	profile.ExtraBody = map[string]any{"top_k": 40, "temperature": 0.5}
+	llm, err := New(WithProfile(profile), WithBaseURL(srv.URL), WithToken("groq-token"), WithModel("llama3-8b-8192"))
+	require.NoError(t, err)
+
+	_, err = llm.GenerateContent(context.Background(),
+		[]llms.MessageContent{llms.TextParts(schema.ChatMessageTypeHuman, "Hi")},
+		llms.WithN(2), llms.WithTemperature(0.9), llms.WithMaxTokens(10))
+	require.NoError(t, err)
+
+	assert.Equal(t, "/chat/completions", srv.req.URL.Path)
+	assert.Equal(t, "Bearer groq-token", srv.req.Header.Get("Authorization"))
+	assert.Equal(t, "acme", srv.req.Header.Get("X-Tenant"))
+	assert.Equal(t, "llama3-8b-8192", srv.body["model"])
+	assert.NotContains(t, srv.body, "n")
+	assert.EqualValues(t, 10, srv.body["max_tokens"])
+	assert.EqualValues(t, 40, srv.body["top_k"])
+	assert.InDelta(t, 0.5, srv.body["temperature"], 1e-9)
+}
+
+func TestProfileDeployment(t *testing.T) {
+	t.Parallel()
+	srv := newProfileServer(t)
+
+	profile := AzureProfile()
+	profile.Deployment = func(model string) string { return "prod-" + model }
+	llm, err := New(WithProfile(profile), WithBaseURL(srv.URL), WithToken("azure-key"), WithModel("gpt-4"))
+	require.NoError(t, err)
+
+	_, err = llm.GenerateContent(context.Background(),
+		[]llms.MessageContent{llms.TextParts(schema.ChatMessageTypeHuman, "Hi")},
+		llms.WithModel("gpt-4o"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "/openai/deployments/prod-gpt-4o/chat/completions", srv.req.URL.Path)
+	assert.Equal(t, DefaultAPIVersion, srv.req.URL.Query().Get("api-version"))
+	assert.Equal(t, "azure-key", srv.req.Header.Get("api-key"))
+	assert.Empty(t, srv.req.Header.Get("Authorization"))
+
+	// Embeddings are sent to the deployment of the embeddings model, or of
+	// the model without one. The server answers with a chat completion, so
+	// only the requests are checked.
+	_, _ = llm.CreateEmbedding(context.Background(), []string{"Hi"})
+	assert.Equal(t, "/openai/deployments/prod-gpt-4/embeddings", srv.req.URL.Path)
+	llm, err = New(WithProfile(profile), WithBaseURL(srv.URL), WithToken("azure-key"),
+		WithEmbeddingModel("text-embedding-3-small"))
+	require.NoError(t, err)
+	_, _ = llm.CreateEmbedding(context.Background(), []string{"Hi"})
+	assert.Equal(t, "/openai/deployments/prod-text-embedding-3-small/embeddings", srv.req.URL.Path)
+}
+
+func TestProfileAPIType(t *testing.T) {
+	t.Parallel()
+
+	opts, _, err := newClient(WithProfile(AzureProfile()), WithToken("azure-key"), WithBaseURL("https://example.com"))
+	require.NoError(t, err)
+	assert.Equal(t, APITypeAzure, opts.apiType)
+
+	// An API type given with WithAPIType takes precedence.
+	opts, _, err = newClient(WithAPIType(APITypeAzureAD), WithProfile(AzureProfile()),
+		WithToken("azure-key"), WithBaseURL("https://example.com"))
+	require.NoError(t, err)
+	assert.Equal(t, APITypeAzureAD, opts.apiType)
+
+	opts, _, err = newClient(WithProfile(VLLMProfile()))
+	require.NoError(t, err)
+	assert.Equal(t, APITypeOpenAI, opts.apiType)
+}
+
+func TestProfileCapabilities(t *testing.T) {
+	t.Parallel()
+	srv := newProfileServer(t)
+
+	// Local servers don't need a token.
+	llm, err := New(WithProfile(LlamaCppProfile()), WithBaseURL(srv.URL))
+	require.NoError(t, err)
+
+	tools := []llms.Tool{{Type: "function", Function: &llms.FunctionDefinition{Name: "f"}}}
+	_, err = llm.GenerateContent(context.Background(),
+		[]llms.MessageContent{llms.TextParts(schema.ChatMessageTypeHuman, "Hi")}, llms.WithTools(tools))
+	require.ErrorIs(t, err, ErrToolsNotSupported)
+
+	_, err = llm.GenerateContent(context.Background(),
+		[]llms.MessageContent{llms.TextParts(schema.ChatMessageTypeHuman, "Hi")}, llms.WithJSONMode())
+	require.NoError(t, err)
+	assert.Empty(t, srv.req.Header.Get("Authorization"))
+	assert.Equal(t, map[string]any{"type": "json_object"}, srv.body["response_format"])
+
+	profile := LMStudioProfile()
+	profile.Capabilities.SystemRole = false
+	llm, err = New(WithProfile(profile), WithBaseURL(srv.URL))
+	require.NoError(t, err)
+
+	_, err = llm.GenerateContent(context.Background(), []llms.MessageContent{
+		llms.TextParts(schema.ChatMessageTypeSystem, "Be brief."),
+		llms.TextParts(schema.ChatMessageTypeHuman, "Hi"),
+	}, llms.WithJSONMode())
+	require.NoError(t, err)
+	assert.NotContains(t, srv.body, "response_format")
+	messages, ok := srv.body["messages"].([]any)
+	require.True(t, ok)
+	assert.Equal(t, "user", messages[0].(map[string]any)["role"])
+}
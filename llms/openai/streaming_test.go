@@ -0,0 +1,72 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+func TestGenerateContentStream(t *testing.T) {
+	t.Parallel()
+
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(`data: {"choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}
+
+data: {"choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"stop"}]}
+
+data: {"choices":[],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}
+
+data: [DONE]
+`))
+	}))
+	defer srv.Close()
+
+	llm, err := New(WithToken("test"), WithBaseURL(srv.URL))
+	require.NoError(t, err)
+
+	stream, err := llm.GenerateContentStream(context.Background(),
+		[]llms.MessageContent{llms.TextParts(schema.ChatMessageTypeHuman, "Say hello")})
+	require.NoError(t, err)
+
+	var text string
+	for stream.Next() {
+		if e := stream.Event(); e.Type == llms.StreamEventText {
+			text += e.Text
+		}
+	}
+	require.NoError(t, stream.Err())
+
+	assert.Equal(t, "Hello", text)
+	assert.Equal(t, "Hello", stream.Response().Choices[0].Content)
+	assert.Equal(t, "stop", stream.Response().Choices[0].StopReason)
+	assert.Equal(t, &llms.Usage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5}, stream.Usage())
+	assert.Equal(t, true, got["stream"])
+	assert.Equal(t, map[string]any{"include_usage": true}, got["stream_options"])
+
+	// Azure streams without usage, as older API versions reject stream_options.
+	for _, opt := range []Option{
+		WithAPIType(APITypeAzure),
+		WithProfile(AzureProfile()),
+	} {
+		llm, err := New(opt, WithToken("test"), WithBaseURL(srv.URL), WithAPIVersion(DefaultAPIVersion))
+		require.NoError(t, err)
+		got = nil
+		stream, err := llm.GenerateContentStream(context.Background(),
+			[]llms.MessageContent{llms.TextParts(schema.ChatMessageTypeHuman, "Say hello")})
+		require.NoError(t, err)
+		for stream.Next() {
+		}
+		require.NoError(t, stream.Err())
+		assert.NotContains(t, got, "stream_options")
+	}
+}
@@ -0,0 +1,174 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+func TestGenerateContentWithParallelToolCalls(t *testing.T) {
+	t.Parallel()
+
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"index":0,"finish_reason":"tool_calls","message":{
+			"role":"assistant","content":"",
+			"tool_calls":[
+				{"id":"call_1","type":"function","function":{"name":"weather","arguments":"{\"city\":\"Paris\"}"}},
+				{"id":"call_2","type":"function","function":{"name":"weather","arguments":"{\"city\":\"Rome\"}"}}
+			]}}],
+			"usage":{"prompt_tokens":12,"completion_tokens":8,"total_tokens":20}}`))
+	}))
+	defer srv.Close()
+
+	llm, err := New(WithToken("test"), WithBaseURL(srv.URL))
+	require.NoError(t, err)
+
+	messages := []llms.MessageContent{
+		llms.TextParts(schema.ChatMessageTypeHuman, "Weather in Paris and Rome?"),
+		{
+			Role: schema.ChatMessageTypeAI,
+			Parts: []llms.ContentPart{llms.ToolCall{
+				ID: "call_0", Type: "function",
+				FunctionCall: &schema.FunctionCall{Name: "weather", Arguments: `{"city":"Oslo"}`},
+			}},
+		},
+		{
+			Role:  schema.ChatMessageTypeTool,
+			Parts: []llms.ContentPart{llms.ToolCallResponse{ToolCallID: "call_0", Name: "weather", Content: "cold"}},
+		},
+	}
+	tools := []llms.Tool{{
+		Type: "function",
+		Function: &llms.FunctionDefinition{
+			Name:        "weather",
+			Description: "Get the weather in a city",
+			Parameters:  map[string]any{"type": "object"},
+		},
+	}}
+
+	resp, err := llm.GenerateContent(context.Background(), messages,
+		llms.WithTools(tools), llms.WithFunctionToolChoice("weather"))
+	require.NoError(t, err)
+
+	require.Len(t, resp.Choices, 1)
+	calls := resp.Choices[0].ToolCalls
+	require.Len(t, calls, 2)
+	assert.Equal(t, "call_1", calls[0].ID)
+	assert.Equal(t, "call_2", calls[1].ID)
+	assert.Equal(t, `{"city":"Rome"}`, calls[1].FunctionCall.Arguments)
+	assert.Equal(t, calls[0].FunctionCall, resp.Choices[0].FuncCall)
+	assert.Equal(t, &llms.Usage{PromptTokens: 12, CompletionTokens: 8, TotalTokens: 20}, resp.Usage)
+
+	sent, ok := got["messages"].([]any)
+	require.True(t, ok)
+	require.Len(t, sent, 3)
+	assert.Equal(t, "call_0", sent[1].(map[string]any)["tool_calls"].([]any)[0].(map[string]any)["id"])
+	assert.Equal(t, "tool", sent[2].(map[string]any)["role"])
+	assert.Equal(t, "call_0", sent[2].(map[string]any)["tool_call_id"])
+	assert.Equal(t, map[string]any{"type": "function", "function": map[string]any{"name": "weather"}}, got["tool_choice"])
+	assert.Len(t, got["tools"], 1)
+}
+
+func TestGenerateStructuredJSONMode(t *testing.T) {
+	t.Parallel()
+
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"index":0,"finish_reason":"stop","message":{
+			"role":"assistant","content":"{\"city\":\"Paris\",\"temperature\":21}"}}]}`))
+	}))
+	defer srv.Close()
+
+	llm, err := New(WithToken("test"), WithBaseURL(srv.URL))
+	require.NoError(t, err)
+
+	type weather struct {
+		City        string  `json:"city"`
+		Temperature float64 `json:"temperature"`
+	}
+	w, err := llms.GenerateStructured[weather](context.Background(), llm,
+		[]llms.MessageContent{llms.TextParts(schema.ChatMessageTypeHuman, "Weather in Paris?")})
+	require.NoError(t, err)
+	assert.Equal(t, weather{City: "Paris", Temperature: 21}, w)
+	assert.Equal(t, map[string]any{"type": "json_object"}, got["response_format"])
+}
+
+func TestGenerateContentFunctionRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(`data: {"choices":[{"index":0,"delta":{"role":"assistant","function_call":{"name":"weather","arguments":""}}}]}
+
+data: {"choices":[{"index":0,"delta":{"function_call":{"arguments":"{\"city\":"}}}]}
+
+data: {"choices":[{"index":0,"delta":{"function_call":{"arguments":"\"Rome\"}"}},"finish_reason":"function_call"}]}
+
+data: [DONE]
+`))
+	}))
+	defer srv.Close()
+
+	llm, err := New(WithToken("test"), WithBaseURL(srv.URL))
+	require.NoError(t, err)
+
+	messages := []llms.MessageContent{
+		llms.TextParts(schema.ChatMessageTypeHuman, "Weather in Oslo, then Rome?"),
+		{
+			Role: schema.ChatMessageTypeAI,
+			Parts: []llms.ContentPart{llms.ToolCall{
+				Type:         "function",
+				FunctionCall: &schema.FunctionCall{Name: "weather", Arguments: `{"city":"Oslo"}`},
+			}},
+		},
+		{
+			Role:  schema.ChatMessageTypeFunction,
+			Parts: []llms.ContentPart{llms.ToolCallResponse{Name: "weather", Content: "cold"}},
+		},
+	}
+	functions := []llms.FunctionDefinition{{Name: "weather", Parameters: map[string]any{"type": "object"}}}
+
+	var names, args string
+	stream, err := llm.GenerateContentStream(context.Background(), messages, llms.WithFunctions(functions))
+	require.NoError(t, err)
+	for stream.Next() {
+		if e := stream.Event(); e.Type == llms.StreamEventToolCall {
+			names += e.ToolCall.Name
+			args += e.ToolCall.Arguments
+		}
+	}
+	require.NoError(t, stream.Err())
+
+	assert.Equal(t, "weather", names)
+	assert.Equal(t, `{"city":"Rome"}`, args)
+	c := stream.Response().Choices[0]
+	assert.Equal(t, "function_call", c.StopReason)
+	assert.Equal(t, &schema.FunctionCall{Name: "weather", Arguments: `{"city":"Rome"}`}, c.FuncCall)
+
+	sent, ok := got["messages"].([]any)
+	require.True(t, ok)
+	require.Len(t, sent, 3)
+	assert.Equal(t, map[string]any{"name": "weather", "arguments": `{"city":"Oslo"}`},
+		sent[1].(map[string]any)["function_call"])
+	assert.NotContains(t, sent[1], "tool_calls")
+	assert.Equal(t, map[string]any{"role": "function", "name": "weather", "content": "cold"}, sent[2])
+
+	_, err = llm.GenerateContent(context.Background(), []llms.MessageContent{
+		llms.TextParts(schema.ChatMessageTypeFunction, "cold"),
+	})
+	require.Error(t, err)
+}
@@ -1,6 +1,10 @@
 package llms
 
-import "context"
+import (
+	"context"
+
+	"github.com/tmc/langchaingo/schema"
+)
 
 // CallOption is a function that configures a CallOptions.
 type CallOption func(*CallOptions)
@@ -20,7 +24,11 @@ type CallOptions struct {
 	StopWords []string `json:"stop_words"`
 	// StreamingFunc is a function to be called for each chunk of a streaming response.
 	// Return an error to stop streaming early.
-	StreamingFunc func(ctx context.Context, chunk []byte) error
+	StreamingFunc func(ctx context.Context, chunk []byte) error `json:"-"`
+	// StreamingEventFunc is a function to be called for each typed event of a
+	// streaming response. It is set by GenerateContentStream and honored by
+	// providers implementing StreamingModel.
+	StreamingEventFunc StreamEventFunc `json:"-"`
 	// TopK is the number of tokens to consider for top-k sampling.
 	TopK int `json:"top_k"`
 	// TopP is the cumulative probability for top-p sampling.
@@ -39,6 +47,12 @@ type CallOptions struct {
 	FrequencyPenalty float64 `json:"frequency_penalty"`
 	// PresencePenalty is the presence penalty for sampling.
 	PresencePenalty float64 `json:"presence_penalty"`
+	// JSONMode asks the model to reply with a JSON object, for models that
+	// support it.
+	JSONMode bool `json:"json_mode,omitempty"`
+	// Documents are documents to ground the generation on, for models that
+	// support it.
+	Documents []schema.Document `json:"documents,omitempty"`
 
 	// Function defitions to include in the request.
 	Functions []FunctionDefinition `json:"functions"`
@@ -47,8 +61,56 @@ type CallOptions struct {
 	// If a specific function should be invoked, use the format:
 	// `{"name": "my_function"}`
 	FunctionCallBehavior FunctionCallBehavior `json:"function_call"`
+
+	// Tools is a list of tools the model may call.
+	Tools []Tool `json:"tools,omitempty"`
+	// ToolChoice controls which (if any) tool is called by the model. It can
+	// be one of the ToolChoice* string constants, or a ToolChoice value to
+	// force a specific tool.
+	ToolChoice any `json:"tool_choice,omitempty"`
+
+	// Metadata holds provider-specific options, keyed by provider, for
+	// settings without a counterpart in CallOptions. They let providers offer
+	// per-call settings as llms.CallOption values, which wrappers such as
+	// llms/cache and llms/router pass through unchanged. Values must marshal
+	// to JSON, since call options are marshaled e.g. for cache keys.
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// Tool is a tool that can be used by the model.
+type Tool struct {
+	// Type is the type of the tool. Only "function" is currently supported.
+	Type string `json:"type"`
+	// Function is the definition of the function, when Type is "function".
+	Function *FunctionDefinition `json:"function,omitempty"`
+}
+
+// ToolChoice forces the model to call a specific tool.
+type ToolChoice struct {
+	// Type is the type of the tool.
+	Type string `json:"type"`
+	// Function is the function to call, when Type is "function".
+	Function *FunctionReference `json:"function,omitempty"`
+}
+
+// FunctionReference is a reference to a function by name.
+type FunctionReference struct {
+	// Name is the name of the function.
+	Name string `json:"name"`
 }
 
+const (
+	// ToolTypeFunction is the type of tools that are functions.
+	ToolTypeFunction = "function"
+
+	// ToolChoiceNone will not call any tools.
+	ToolChoiceNone = "none"
+	// ToolChoiceAuto lets the model decide whether to call tools.
+	ToolChoiceAuto = "auto"
+	// ToolChoiceRequired forces the model to call at least one tool.
+	ToolChoiceRequired = "required"
+)
+
 // FunctionDefinition is a definition of a function that can be called by the model.
 type FunctionDefinition struct {
 	// Name is the name of the function.
@@ -112,6 +174,19 @@ func WithOptions(options CallOptions) CallOption {
 	}
 }
 
+// WithMetadata sets a provider-specific option. Providers document the keys
+// they read, and usually provide their own call options setting them.
+func WithMetadata(key string, value any) CallOption {
+	return func(o *CallOptions) {
+		metadata := make(map[string]any, len(o.Metadata)+1)
+		for k, v := range o.Metadata {
+			metadata[k] = v
+		}
+		metadata[key] = value
+		o.Metadata = metadata
+	}
+}
+
 // WithStreamingFunc specifies the streaming function to use.
 func WithStreamingFunc(streamingFunc func(ctx context.Context, chunk []byte) error) CallOption {
 	return func(o *CallOptions) {
@@ -119,6 +194,14 @@ func WithStreamingFunc(streamingFunc func(ctx context.Context, chunk []byte) err
 	}
 }
 
+// WithStreamingEventFunc specifies the function called for each typed event of
+// a streaming response.
+func WithStreamingEventFunc(fn StreamEventFunc) CallOption {
+	return func(o *CallOptions) {
+		o.StreamingEventFunc = fn
+	}
+}
+
 // WithTopK will add an option to use top-k sampling.
 func WithTopK(topK int) CallOption {
 	return func(o *CallOptions) {
@@ -182,6 +265,23 @@ func WithPresencePenalty(presencePenalty float64) CallOption {
 	}
 }
 
+// WithJSONMode will add an option to ask the model to reply with a JSON
+// object. Models that don't support it ignore the option, so the prompt should
+// still ask for JSON.
+func WithJSONMode() CallOption {
+	return func(o *CallOptions) {
+		o.JSONMode = true
+	}
+}
+
+// WithDocuments will add an option to set the documents to ground the
+// generation on. Models that don't support it ignore the option.
+func WithDocuments(docs ...schema.Document) CallOption {
+	return func(o *CallOptions) {
+		o.Documents = docs
+	}
+}
+
 // WithFunctionCallBehavior will add an option to set the behavior to use when calling functions.
 func WithFunctionCallBehavior(behavior FunctionCallBehavior) CallOption {
 	return func(o *CallOptions) {
@@ -195,3 +295,29 @@ func WithFunctions(functions []FunctionDefinition) CallOption {
 		o.Functions = functions
 	}
 }
+
+// WithTools will add an option to set the tools the model may call.
+func WithTools(tools []Tool) CallOption {
+	return func(o *CallOptions) {
+		o.Tools = tools
+	}
+}
+
+// WithToolChoice will add an option to set which tool the model calls. The
+// choice is either one of the ToolChoice* string constants or a ToolChoice.
+func WithToolChoice(choice any) CallOption {
+	return func(o *CallOptions) {
+		o.ToolChoice = choice
+	}
+}
+
+// WithFunctionToolChoice will add an option that forces the model to call the
+// function with the given name.
+func WithFunctionToolChoice(name string) CallOption {
+	return func(o *CallOptions) {
+		o.ToolChoice = ToolChoice{
+			Type:     ToolTypeFunction,
+			Function: &FunctionReference{Name: name},
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package llms
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMetadata(t *testing.T) {
+	t.Parallel()
+
+	first := CallOptions{}
+	WithMetadata("a", 1)(&first)
+	second := first
+	WithMetadata("b", "x")(&second)
+	WithMetadata("a", 2)(&second)
+
+	// Options sharing metadata aren't changed by later options.
+	assert.Equal(t, map[string]any{"a": 1}, first.Metadata)
+	assert.Equal(t, map[string]any{"a": 2, "b": "x"}, second.Metadata)
+
+	b, err := json.Marshal(second)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"metadata":{"a":2,"b":"x"}`)
+}
@@ -0,0 +1,177 @@
+package llms
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ModelInfo describes a model: how much text it handles, what it costs and
+// what it supports.
+type ModelInfo struct {
+	// Provider is the name of the provider serving the model, e.g. "openai".
+	Provider string `json:"provider" yaml:"provider"`
+	// Name is the name of the model, as passed to the provider.
+	Name string `json:"name" yaml:"name"`
+	// ContextWindow is the maximum number of tokens of a prompt and its
+	// completion.
+	ContextWindow int `json:"context_window" yaml:"context_window"`
+	// MaxOutputTokens is the maximum number of tokens of a completion, or 0
+	// if only limited by the context window.
+	MaxOutputTokens int `json:"max_output_tokens,omitempty" yaml:"max_output_tokens,omitempty"`
+	// Price is the price of the tokens of the model.
+	Price Price `json:"price" yaml:"price"`
+	// Encoding is the name of the tiktoken encoding of the model, if any.
+	Encoding string `json:"encoding,omitempty" yaml:"encoding,omitempty"`
+	// TokenizerFile is the path of the HuggingFace tokenizer.json file of the
+	// model, if any.
+	TokenizerFile string `json:"tokenizer_file,omitempty" yaml:"tokenizer_file,omitempty"`
+	// Capabilities are the features the model supports.
+	Capabilities Capabilities `json:"capabilities" yaml:"capabilities"`
+}
+
+// Capabilities are the features a model supports.
+type Capabilities struct {
+	// Vision is whether the model accepts images.
+	Vision bool `json:"vision,omitempty" yaml:"vision,omitempty"`
+	// Tools is whether the model can call tools.
+	Tools bool `json:"tools,omitempty" yaml:"tools,omitempty"`
+	// JSONMode is whether the model can be constrained to reply with JSON.
+	JSONMode bool `json:"json_mode,omitempty" yaml:"json_mode,omitempty"`
+	// SystemRole is whether the model accepts system messages.
+	SystemRole bool `json:"system_role,omitempty" yaml:"system_role,omitempty"`
+}
+
+// ModelRegistry records the ModelInfo of models. It is safe for concurrent
+// use.
+type ModelRegistry struct {
+	mu     sync.RWMutex
+	models []ModelInfo
+}
+
+// DefaultModelRegistry is the registry consulted by GetModelContextSize and
+// CalculateMaxTokens. It knows the models of the providers of this module,
+// and can be extended with RegisterModels.
+var DefaultModelRegistry = NewModelRegistry(defaultModels...) //nolint:gochecknoglobals
+
+// NewModelRegistry returns a registry of the given models.
+func NewModelRegistry(models ...ModelInfo) *ModelRegistry {
+	r := &ModelRegistry{}
+	r.Register(models...)
+	return r
+}
+
+// Register adds models to the registry, replacing those with the same
+// provider and name.
+func (r *ModelRegistry) Register(models ...ModelInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+next:
+	for _, m := range models {
+		for i, old := range r.models {
+			if old.Provider == m.Provider && old.Name == m.Name {
+				r.models[i] = m
+				continue next
+			}
+		}
+		r.models = append(r.models, m)
+	}
+}
+
+// Lookup returns the info of the model with the given name, served by the
+// given provider or by any provider if provider is empty. Names with a
+// version or tag suffix, e.g. "gpt-4-0613" or "llama3:8b", match the model
+// with the longest name they start with.
+func (r *ModelRegistry) Lookup(provider, name string) (ModelInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	best := -1
+	// Later registrations take precedence over earlier ones.
+	for i := len(r.models) - 1; i >= 0; i-- {
+		m := r.models[i]
+		if provider != "" && m.Provider != provider {
+			continue
+		}
+		if m.Name == name {
+			return m, true
+		}
+		if isModelVariant(name, m.Name) && (best < 0 || len(m.Name) > len(r.models[best].Name)) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return ModelInfo{}, false
+	}
+	return r.models[best], true
+}
+
+// isModelVariant reports whether name is a version or tag of the model
+// named base.
+func isModelVariant(name, base string) bool {
+	if base == "" || !strings.HasPrefix(name, base) || len(name) == len(base) {
+		return false
+	}
+	sep := name[len(base)]
+	return sep == '-' || sep == ':' || sep == '@'
+}
+
+// Models returns the info of all the models of the registry.
+func (r *ModelRegistry) Models() []ModelInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]ModelInfo(nil), r.models...)
+}
+
+// modelsFile is the format of files loaded by Load.
+type modelsFile struct {
+	Models []ModelInfo `json:"models" yaml:"models"`
+}
+
+// Load registers the models of a YAML or JSON document of the form:
+//
+//	models:
+//	  - provider: openai
+//	    name: gpt-4o
+//	    context_window: 128000
+//	    max_output_tokens: 4096
+//	    price: {prompt: 5, completion: 15}
+//	    capabilities: {vision: true, tools: true, json_mode: true, system_role: true}
+func (r *ModelRegistry) Load(data []byte) error {
+	var f modelsFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("parse models: %w", err)
+	}
+	for i, m := range f.Models {
+		if m.Name == "" {
+			return fmt.Errorf("model %d has no name", i) //nolint:goerr113
+		}
+	}
+	r.Register(f.Models...)
+	return nil
+}
+
+// LoadFile registers the models of a YAML or JSON file, as described by
+// Load.
+func (r *ModelRegistry) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return r.Load(data)
+}
+
+// RegisterModels adds models to the DefaultModelRegistry.
+func RegisterModels(models ...ModelInfo) {
+	DefaultModelRegistry.Register(models...)
+}
+
+// LookupModel returns the info of a model of the DefaultModelRegistry, as
+// described by ModelRegistry.Lookup.
+func LookupModel(provider, name string) (ModelInfo, bool) {
+	return DefaultModelRegistry.Lookup(provider, name)
+}
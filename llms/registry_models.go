@@ -0,0 +1,74 @@
+package llms
+
+// Capabilities shared by families of models.
+var (
+	_chatCapabilities   = Capabilities{SystemRole: true}                                            //nolint:gochecknoglobals
+	_toolCapabilities   = Capabilities{Tools: true, SystemRole: true}                               //nolint:gochecknoglobals
+	_openaiCapabilities = Capabilities{Tools: true, JSONMode: true, SystemRole: true}               //nolint:gochecknoglobals
+	_visionCapabilities = Capabilities{Vision: true, Tools: true, SystemRole: true}                 //nolint:gochecknoglobals
+	_allCapabilities    = Capabilities{Vision: true, Tools: true, JSONMode: true, SystemRole: true} //nolint:gochecknoglobals,lll
+)
+
+// defaultModels are the models of the DefaultModelRegistry. Prices are in US
+// dollars per million tokens.
+//
+//nolint:gochecknoglobals,gomnd,lll
+var defaultModels = []ModelInfo{
+	// OpenAI.
+	// The gpt-3.5-turbo alias keeps the context window GetModelContextSize
+	// always reported for it; the versions with a larger one are listed by
+	// name.
+	{Provider: "openai", Name: "gpt-3.5-turbo", ContextWindow: 4096, MaxOutputTokens: 4096, Price: Price{0.5, 1.5}, Encoding: "cl100k_base", Capabilities: _openaiCapabilities},
+	{Provider: "openai", Name: "gpt-3.5-turbo-16k", ContextWindow: 16385, MaxOutputTokens: 4096, Price: Price{3, 4}, Encoding: "cl100k_base", Capabilities: _openaiCapabilities},
+	{Provider: "openai", Name: "gpt-3.5-turbo-1106", ContextWindow: 16385, MaxOutputTokens: 4096, Price: Price{1, 2}, Encoding: "cl100k_base", Capabilities: _openaiCapabilities},
+	{Provider: "openai", Name: "gpt-3.5-turbo-0125", ContextWindow: 16385, MaxOutputTokens: 4096, Price: Price{0.5, 1.5}, Encoding: "cl100k_base", Capabilities: _openaiCapabilities},
+	{Provider: "openai", Name: "gpt-3.5-turbo-instruct", ContextWindow: 4096, Price: Price{1.5, 2}, Encoding: "cl100k_base"},
+	{Provider: "openai", Name: "gpt-4", ContextWindow: 8192, MaxOutputTokens: 8192, Price: Price{30, 60}, Encoding: "cl100k_base", Capabilities: _toolCapabilities},
+	{Provider: "openai", Name: "gpt-4-32k", ContextWindow: 32768, MaxOutputTokens: 32768, Price: Price{60, 120}, Encoding: "cl100k_base", Capabilities: _toolCapabilities},
+	{Provider: "openai", Name: "gpt-4-turbo", ContextWindow: 128000, MaxOutputTokens: 4096, Price: Price{10, 30}, Encoding: "cl100k_base", Capabilities: _allCapabilities},
+	{Provider: "openai", Name: "gpt-4o", ContextWindow: 128000, MaxOutputTokens: 4096, Price: Price{5, 15}, Encoding: "o200k_base", Capabilities: _allCapabilities},
+	{Provider: "openai", Name: "gpt-4o-mini", ContextWindow: 128000, MaxOutputTokens: 16384, Price: Price{0.15, 0.6}, Encoding: "o200k_base", Capabilities: _allCapabilities},
+	{Provider: "openai", Name: "text-davinci-003", ContextWindow: 4097, Price: Price{20, 20}, Encoding: "p50k_base"},
+	{Provider: "openai", Name: "text-curie-001", ContextWindow: 2048, Price: Price{2, 2}, Encoding: "r50k_base"},
+	{Provider: "openai", Name: "text-babbage-001", ContextWindow: 2048, Price: Price{0.5, 0.5}, Encoding: "r50k_base"},
+	{Provider: "openai", Name: "text-ada-001", ContextWindow: 2048, Price: Price{0.4, 0.4}, Encoding: "r50k_base"},
+	{Provider: "openai", Name: "code-davinci-002", ContextWindow: 8000, Encoding: "p50k_base"},
+	{Provider: "openai", Name: "code-cushman-001", ContextWindow: 2048, Encoding: "p50k_base"},
+	{Provider: "openai", Name: "text-embedding-ada-002", ContextWindow: 8191, Price: Price{0.1, 0}, Encoding: "cl100k_base"},
+	{Provider: "openai", Name: "text-embedding-3-small", ContextWindow: 8191, Price: Price{0.02, 0}, Encoding: "cl100k_base"},
+	{Provider: "openai", Name: "text-embedding-3-large", ContextWindow: 8191, Price: Price{0.13, 0}, Encoding: "cl100k_base"},
+
+	// Anthropic.
+	{Provider: "anthropic", Name: "claude-instant-1.2", ContextWindow: 100000, MaxOutputTokens: 4096, Price: Price{0.8, 2.4}, Capabilities: _chatCapabilities},
+	{Provider: "anthropic", Name: "claude-2.0", ContextWindow: 100000, MaxOutputTokens: 4096, Price: Price{8, 24}, Capabilities: _chatCapabilities},
+	{Provider: "anthropic", Name: "claude-2.1", ContextWindow: 200000, MaxOutputTokens: 4096, Price: Price{8, 24}, Capabilities: _chatCapabilities},
+	{Provider: "anthropic", Name: "claude-3-haiku", ContextWindow: 200000, MaxOutputTokens: 4096, Price: Price{0.25, 1.25}, Capabilities: _visionCapabilities},
+	{Provider: "anthropic", Name: "claude-3-sonnet", ContextWindow: 200000, MaxOutputTokens: 4096, Price: Price{3, 15}, Capabilities: _visionCapabilities},
+	{Provider: "anthropic", Name: "claude-3-opus", ContextWindow: 200000, MaxOutputTokens: 4096, Price: Price{15, 75}, Capabilities: _visionCapabilities},
+	{Provider: "anthropic", Name: "claude-3-5-sonnet", ContextWindow: 200000, MaxOutputTokens: 8192, Price: Price{3, 15}, Capabilities: _visionCapabilities},
+
+	// Google AI and Vertex AI.
+	{Provider: "googleai", Name: "gemini-pro", ContextWindow: 32760, MaxOutputTokens: 8192, Price: Price{0.5, 1.5}, Capabilities: Capabilities{Tools: true}},
+	{Provider: "googleai", Name: "gemini-pro-vision", ContextWindow: 16384, MaxOutputTokens: 2048, Price: Price{0.5, 1.5}, Capabilities: Capabilities{Vision: true}},
+	{Provider: "googleai", Name: "gemini-1.5-pro", ContextWindow: 1048576, MaxOutputTokens: 8192, Price: Price{3.5, 10.5}, Capabilities: _allCapabilities},
+	{Provider: "googleai", Name: "gemini-1.5-flash", ContextWindow: 1048576, MaxOutputTokens: 8192, Price: Price{0.35, 1.05}, Capabilities: _allCapabilities},
+	{Provider: "googleai", Name: "embedding-001", ContextWindow: 2048},
+	{Provider: "vertex", Name: "text-bison", ContextWindow: 8192, MaxOutputTokens: 1024},
+	{Provider: "vertex", Name: "chat-bison", ContextWindow: 8192, MaxOutputTokens: 1024, Capabilities: _chatCapabilities},
+	{Provider: "vertex", Name: "textembedding-gecko", ContextWindow: 3072},
+
+	// Cohere.
+	{Provider: "cohere", Name: "command", ContextWindow: 4096, MaxOutputTokens: 4096, Price: Price{1, 2}},
+	{Provider: "cohere", Name: "command-light", ContextWindow: 4096, MaxOutputTokens: 4096, Price: Price{0.3, 0.6}},
+	{Provider: "cohere", Name: "command-r", ContextWindow: 128000, MaxOutputTokens: 4000, Price: Price{0.5, 1.5}, Capabilities: _toolCapabilities},
+	{Provider: "cohere", Name: "command-r-plus", ContextWindow: 128000, MaxOutputTokens: 4000, Price: Price{3, 15}, Capabilities: _toolCapabilities},
+
+	// Ollama. Models run locally, so they are free.
+	{Provider: "ollama", Name: "llama2", ContextWindow: 4096, Capabilities: Capabilities{JSONMode: true, SystemRole: true}},
+	{Provider: "ollama", Name: "llama3", ContextWindow: 8192, Capabilities: Capabilities{JSONMode: true, SystemRole: true}},
+	{Provider: "ollama", Name: "llama3.1", ContextWindow: 131072, Capabilities: _openaiCapabilities},
+	{Provider: "ollama", Name: "mistral", ContextWindow: 32768, Capabilities: _openaiCapabilities},
+	{Provider: "ollama", Name: "mixtral", ContextWindow: 32768, Capabilities: Capabilities{JSONMode: true, SystemRole: true}},
+	{Provider: "ollama", Name: "gemma", ContextWindow: 8192, Capabilities: Capabilities{JSONMode: true}},
+	{Provider: "ollama", Name: "llava", ContextWindow: 4096, Capabilities: Capabilities{Vision: true, JSONMode: true, SystemRole: true}},
+}
@@ -0,0 +1,103 @@
+package llms
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModelRegistryLookup(t *testing.T) {
+	t.Parallel()
+
+	r := NewModelRegistry(
+		ModelInfo{Provider: "openai", Name: "gpt-4", ContextWindow: 8192},
+		ModelInfo{Provider: "openai", Name: "gpt-4-32k", ContextWindow: 32768},
+		ModelInfo{Provider: "ollama", Name: "llama3", ContextWindow: 8192},
+		ModelInfo{Provider: "groq", Name: "llama3", ContextWindow: 4096},
+	)
+
+	tests := []struct {
+		provider, name string
+		want           int
+	}{
+		{"", "gpt-4", 8192},
+		{"openai", "gpt-4-0613", 8192},
+		{"", "gpt-4-32k-0613", 32768},
+		{"", "llama3:8b", 4096},
+		{"ollama", "llama3:8b", 8192},
+	}
+	for _, tt := range tests {
+		info, ok := r.Lookup(tt.provider, tt.name)
+		require.True(t, ok, tt.name)
+		assert.Equal(t, tt.want, info.ContextWindow, tt.name)
+	}
+
+	for _, name := range []string{"gpt-4o", "gpt", "llama3.1"} {
+		_, ok := r.Lookup("", name)
+		assert.False(t, ok, name)
+	}
+	_, ok := r.Lookup("anthropic", "gpt-4")
+	assert.False(t, ok)
+
+	// Registering a model again replaces it.
+	r.Register(ModelInfo{Provider: "openai", Name: "gpt-4", ContextWindow: 1})
+	info, _ := r.Lookup("openai", "gpt-4")
+	assert.Equal(t, 1, info.ContextWindow)
+	assert.Len(t, r.Models(), 4)
+}
+
+func TestModelRegistryLoad(t *testing.T) {
+	t.Parallel()
+
+	yamlPath := filepath.Join(t.TempDir(), "models.yaml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte(`
+models:
+  - provider: acme
+    name: acme-large
+    context_window: 65536
+    max_output_tokens: 8192
+    price: {prompt: 2, completion: 6}
+    capabilities: {tools: true, json_mode: true}
+`), 0o600))
+
+	r := NewModelRegistry()
+	require.NoError(t, r.LoadFile(yamlPath))
+	require.NoError(t, r.Load([]byte(`{"models": [{"provider": "acme", "name": "acme-small", "context_window": 4096}]}`)))
+
+	info, ok := r.Lookup("acme", "acme-large-v2")
+	require.True(t, ok)
+	assert.Equal(t, ModelInfo{
+		Provider:        "acme",
+		Name:            "acme-large",
+		ContextWindow:   65536,
+		MaxOutputTokens: 8192,
+		Price:           Price{Prompt: 2, Completion: 6},
+		Capabilities:    Capabilities{Tools: true, JSONMode: true},
+	}, info)
+	info, ok = r.Lookup("", "acme-small")
+	require.True(t, ok)
+	assert.Equal(t, 4096, info.ContextWindow)
+
+	require.Error(t, r.Load([]byte(`models: [{provider: acme}]`)))
+	require.Error(t, r.Load([]byte(`models: {`)))
+}
+
+func TestGetModelContextSize(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 128000, GetModelContextSize("gpt-4o-2024-05-13"))
+	assert.Equal(t, 4096, GetModelContextSize("gpt-3.5-turbo"))
+	assert.Equal(t, 4096, GetModelContextSize("gpt-3.5-turbo-0613"))
+	assert.Equal(t, 16385, GetModelContextSize("gpt-3.5-turbo-0125"))
+	assert.Equal(t, 16385, GetModelContextSize("gpt-3.5-turbo-16k-0613"))
+	assert.Equal(t, 200000, GetModelContextSize("claude-3-haiku-20240307"))
+	assert.Equal(t, _defaultContextSize, GetModelContextSize("unknown"))
+
+	info, ok := LookupModel("openai", "gpt-4o-mini")
+	require.True(t, ok)
+	assert.True(t, info.Capabilities.Vision)
+	assert.Equal(t, 0.15, info.Price.Prompt)
+}
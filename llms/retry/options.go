@@ -0,0 +1,84 @@
+package retry
+
+import "time"
+
+const (
+	defaultMaxRetries   = 3
+	defaultInitialDelay = 500 * time.Millisecond
+	defaultMaxDelay     = 30 * time.Second
+	defaultMultiplier   = 2
+)
+
+type options struct {
+	maxRetries        int
+	initialDelay      time.Duration
+	maxDelay          time.Duration
+	multiplier        float64
+	retryable         func(error) bool
+	requestsPerMinute int
+	tokensPerMinute   int
+	callTimeout       time.Duration
+}
+
+// Option is a function that configures the retrying model.
+type Option func(*options)
+
+// WithMaxRetries sets the maximum number of times a failed call is retried.
+// Zero disables retries. The default is 3.
+func WithMaxRetries(n int) Option {
+	return func(o *options) {
+		o.maxRetries = n
+	}
+}
+
+// WithBackoff sets the delay before the first retry, and the maximum delay
+// between retries, which also bounds the delays requested by servers with a
+// Retry-After header. The defaults are 500ms and 30s.
+func WithBackoff(initial, maximum time.Duration) Option {
+	return func(o *options) {
+		o.initialDelay = initial
+		o.maxDelay = maximum
+	}
+}
+
+// WithMultiplier sets the factor by which the delay grows after each retry.
+// The default is 2.
+func WithMultiplier(m float64) Option {
+	return func(o *options) {
+		o.multiplier = m
+	}
+}
+
+// WithRetryable sets the function that decides whether a failed call should
+// be retried. The default is IsRetryable.
+func WithRetryable(fn func(error) bool) Option {
+	return func(o *options) {
+		o.retryable = fn
+	}
+}
+
+// WithRequestsPerMinute limits the rate of requests sent to the model,
+// including retries. Zero, the default, means no limit.
+func WithRequestsPerMinute(n int) Option {
+	return func(o *options) {
+		o.requestsPerMinute = n
+	}
+}
+
+// WithTokensPerMinute limits the rate of tokens used by the model. Prompt
+// tokens are estimated before each request, and the limit is charged for the
+// remaining tokens once the model reports its usage. Zero, the default, means
+// no limit.
+func WithTokensPerMinute(n int) Option {
+	return func(o *options) {
+		o.tokensPerMinute = n
+	}
+}
+
+// WithCallTimeout sets a deadline for each call to the model. A call that
+// times out may be retried. Zero, the default, means no deadline.
+func WithCallTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.callTimeout = d
+	}
+}
@@ -0,0 +1,188 @@
+// Package retry provides a model that wraps another llms.Model, retrying
+// failed calls with exponential backoff and limiting the rate of requests and
+// tokens sent to it. It works with any provider:
+//
+//	llm, err := openai.New()
+//	...
+//	model := retry.New(llm, retry.WithMaxRetries(5), retry.WithRequestsPerMinute(60))
+package retry
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/internal/streaming"
+	"golang.org/x/time/rate"
+)
+
+// LLM is a model that retries the failed calls of the model it wraps.
+type LLM struct {
+	model    llms.Model
+	opts     options
+	requests *rate.Limiter
+	tokens   *rate.Limiter
+}
+
+var _ llms.Model = (*LLM)(nil)
+
+// New returns a model that retries the failed calls of model.
+func New(model llms.Model, opts ...Option) *LLM {
+	o := options{
+		maxRetries:   defaultMaxRetries,
+		initialDelay: defaultInitialDelay,
+		maxDelay:     defaultMaxDelay,
+		multiplier:   defaultMultiplier,
+		retryable:    IsRetryable,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	l := &LLM{model: model, opts: o}
+	if o.requestsPerMinute > 0 {
+		l.requests = rate.NewLimiter(perMinute(o.requestsPerMinute), o.requestsPerMinute)
+	}
+	if o.tokensPerMinute > 0 {
+		l.tokens = rate.NewLimiter(perMinute(o.tokensPerMinute), o.tokensPerMinute)
+	}
+	return l
+}
+
+// Call implements the llms.Model interface.
+func (l *LLM) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, l, prompt, options...)
+}
+
+// GenerateContent implements the llms.Model interface. Failed calls are
+// retried unless they already streamed part of their response.
+func (l *LLM) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) { //nolint:lll
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	var streamed atomic.Bool
+	options = streaming.Track(options, &streamed)
+
+	for attempt := 0; ; attempt++ {
+		resp, err := l.generate(ctx, messages, opts, options)
+		if err == nil {
+			return resp, nil
+		}
+		if attempt >= l.opts.maxRetries || streamed.Load() || ctx.Err() != nil || !l.opts.retryable(err) {
+			return nil, err
+		}
+
+		timer := time.NewTimer(l.delay(attempt, err))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// generate makes a single call to the wrapped model, within the rate limits.
+func (l *LLM) generate(ctx context.Context, messages []llms.MessageContent, opts llms.CallOptions, options []llms.CallOption) (*llms.ContentResponse, error) { //nolint:lll
+	if l.requests != nil {
+		if err := l.requests.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	var estimate int
+	if l.tokens != nil {
+		estimate = l.clampTokens(estimatePromptTokens(opts.Model, messages))
+		if err := l.tokens.WaitN(ctx, estimate); err != nil {
+			return nil, err
+		}
+	}
+
+	if l.opts.callTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.opts.callTimeout)
+		defer cancel()
+	}
+	resp, err := l.model.GenerateContent(ctx, messages, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Charge the tokens that weren't accounted for by the estimate.
+	if l.tokens != nil && resp.Usage != nil {
+		if extra := resp.Usage.TotalTokens - estimate; extra > 0 {
+			l.tokens.ReserveN(time.Now(), l.clampTokens(extra))
+		}
+	}
+	return resp, nil
+}
+
+// delay returns how long to wait before retrying a call that failed with err
+// after the given number of retries. A delay requested by the server takes
+// precedence over the backoff, but neither exceeds the maximum delay.
+func (l *LLM) delay(attempt int, err error) time.Duration {
+	maxDelay := l.opts.maxDelay
+	var httpErr *llms.HTTPError
+	if errors.As(err, &httpErr) && httpErr.RetryAfter > 0 {
+		if maxDelay > 0 && httpErr.RetryAfter > maxDelay {
+			return maxDelay
+		}
+		return httpErr.RetryAfter
+	}
+
+	d := float64(l.opts.initialDelay) * math.Pow(l.opts.multiplier, float64(attempt))
+	if maxDelay > 0 && d > float64(maxDelay) {
+		d = float64(maxDelay)
+	}
+	// Spread out the retries of concurrent calls over the second half of the
+	// delay.
+	return time.Duration(d/2 + rand.Float64()*d/2) //nolint:gosec,gomnd
+}
+
+// clampTokens bounds n to what the token limiter can grant at once.
+func (l *LLM) clampTokens(n int) int {
+	if burst := l.tokens.Burst(); n > burst {
+		return burst
+	}
+	return n
+}
+
+// IsRetryable reports whether a call that failed with err may succeed if
+// retried: the provider was throttling requests or failed temporarily, or the
+// request timed out.
+func IsRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var httpErr *llms.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Temporary()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// estimatePromptTokens estimates the number of tokens of the text in messages.
+func estimatePromptTokens(model string, messages []llms.MessageContent) int {
+	n := 0
+	for _, m := range messages {
+		for _, p := range m.Parts {
+			if t, ok := p.(llms.TextContent); ok {
+				n += llms.CountTokens(model, t.Text)
+			}
+		}
+	}
+	return n
+}
+
+func perMinute(n int) rate.Limit {
+	return rate.Limit(float64(n) / time.Minute.Seconds())
+}
@@ -0,0 +1,211 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/openai"
+	"github.com/tmc/langchaingo/llms/retry"
+)
+
+const okResponse = `{"choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"hi"}}],
+	"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`
+
+// newThrottlingServer returns a server that fails the first failures requests
+// with status, then answers.
+func newThrottlingServer(t *testing.T, failures int32, status int, header http.Header) (*httptest.Server, *atomic.Int32) { //nolint:lll
+	t.Helper()
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if requests.Add(1) <= failures {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"error":{"message":"slow down","type":"rate_limit"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(okResponse))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func newOpenAI(t *testing.T, url string) llms.Model {
+	t.Helper()
+
+	llm, err := openai.New(openai.WithToken("test"), openai.WithBaseURL(url))
+	require.NoError(t, err)
+	return llm
+}
+
+func TestRetriesThrottledCalls(t *testing.T) {
+	t.Parallel()
+
+	srv, requests := newThrottlingServer(t, 2, http.StatusTooManyRequests, nil)
+	model := retry.New(newOpenAI(t, srv.URL), retry.WithBackoff(time.Millisecond, 10*time.Millisecond))
+
+	out, err := llms.GenerateFromSinglePrompt(context.Background(), model, "hello")
+	require.NoError(t, err)
+	assert.Equal(t, "hi", out)
+	assert.Equal(t, int32(3), requests.Load())
+}
+
+func TestGivesUpAfterMaxRetries(t *testing.T) {
+	t.Parallel()
+
+	srv, requests := newThrottlingServer(t, 10, http.StatusServiceUnavailable, nil)
+	model := retry.New(newOpenAI(t, srv.URL),
+		retry.WithMaxRetries(2), retry.WithBackoff(time.Millisecond, time.Millisecond))
+
+	_, err := llms.GenerateFromSinglePrompt(context.Background(), model, "hello")
+	var httpErr *llms.HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusServiceUnavailable, httpErr.StatusCode)
+	assert.Equal(t, "slow down", httpErr.Message)
+	assert.Equal(t, int32(3), requests.Load())
+}
+
+func TestDoesNotRetryClientErrors(t *testing.T) {
+	t.Parallel()
+
+	srv, requests := newThrottlingServer(t, 1, http.StatusBadRequest, nil)
+	model := retry.New(newOpenAI(t, srv.URL), retry.WithBackoff(time.Millisecond, time.Millisecond))
+
+	_, err := llms.GenerateFromSinglePrompt(context.Background(), model, "hello")
+	require.Error(t, err)
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestRespectsRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	srv, _ := newThrottlingServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"1"}})
+	model := retry.New(newOpenAI(t, srv.URL), retry.WithBackoff(time.Millisecond, 2*time.Second))
+
+	start := time.Now()
+	_, err := llms.GenerateFromSinglePrompt(context.Background(), model, "hello")
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+}
+
+func TestClampsRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	srv, _ := newThrottlingServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"3600"}})
+	model := retry.New(newOpenAI(t, srv.URL), retry.WithBackoff(time.Millisecond, 10*time.Millisecond))
+
+	start := time.Now()
+	_, err := llms.GenerateFromSinglePrompt(context.Background(), model, "hello")
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+// flakyModel fails its first calls, optionally after streaming a chunk or
+// hanging until its context is done.
+type flakyModel struct {
+	failures int
+	stream   bool
+	hang     bool
+	usage    *llms.Usage
+	calls    int
+}
+
+func (m *flakyModel) GenerateContent(ctx context.Context, _ []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) { //nolint:lll
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	m.calls++
+	if m.calls > m.failures {
+		return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: "ok"}}, Usage: m.usage}, nil
+	}
+	if m.stream && opts.StreamingFunc != nil {
+		if err := opts.StreamingFunc(ctx, []byte("partial")); err != nil {
+			return nil, err
+		}
+	}
+	if m.hang {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return nil, &llms.HTTPError{StatusCode: http.StatusBadGateway}
+}
+
+func (m *flakyModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+func TestDoesNotRetryStartedStreams(t *testing.T) {
+	t.Parallel()
+
+	inner := &flakyModel{failures: 1, stream: true}
+	model := retry.New(inner, retry.WithBackoff(time.Millisecond, time.Millisecond))
+
+	_, err := llms.GenerateFromSinglePrompt(context.Background(), model, "hello",
+		llms.WithStreamingFunc(func(context.Context, []byte) error { return nil }))
+	require.Error(t, err)
+	assert.Equal(t, 1, inner.calls)
+}
+
+func TestCallTimeout(t *testing.T) {
+	t.Parallel()
+
+	inner := &flakyModel{failures: 1, hang: true}
+	model := retry.New(inner,
+		retry.WithCallTimeout(10*time.Millisecond), retry.WithBackoff(time.Millisecond, time.Millisecond))
+
+	out, err := llms.GenerateFromSinglePrompt(context.Background(), model, "hello")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", out)
+	assert.Equal(t, 2, inner.calls)
+}
+
+func TestRequestsPerMinute(t *testing.T) {
+	t.Parallel()
+
+	// The limiter allows a single request in the first minute.
+	model := retry.New(&flakyModel{}, retry.WithRequestsPerMinute(1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := llms.GenerateFromSinglePrompt(ctx, model, "hello")
+	require.NoError(t, err)
+	_, err = llms.GenerateFromSinglePrompt(ctx, model, "hello")
+	require.Error(t, err)
+}
+
+func TestTokensPerMinute(t *testing.T) {
+	t.Parallel()
+
+	// The reported usage of the first call exhausts the limit.
+	model := retry.New(&flakyModel{usage: llms.NewUsage(60, 40)}, retry.WithTokensPerMinute(100))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := llms.GenerateFromSinglePrompt(ctx, model, "hello")
+	require.NoError(t, err)
+	_, err = llms.GenerateFromSinglePrompt(ctx, model, "hello")
+	require.Error(t, err)
+}
+
+func TestIsRetryable(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, retry.IsRetryable(&llms.HTTPError{StatusCode: http.StatusTooManyRequests}))
+	assert.True(t, retry.IsRetryable(&llms.HTTPError{StatusCode: http.StatusInternalServerError}))
+	assert.False(t, retry.IsRetryable(&llms.HTTPError{StatusCode: http.StatusUnauthorized}))
+	assert.True(t, retry.IsRetryable(context.DeadlineExceeded))
+	assert.False(t, retry.IsRetryable(context.Canceled))
+	assert.False(t, retry.IsRetryable(errors.New("boom")))
+}
@@ -0,0 +1,40 @@
+package router
+
+import (
+	"math/rand"
+
+	"github.com/tmc/langchaingo/callbacks"
+)
+
+type options struct {
+	strategy         Strategy
+	callbacksHandler callbacks.Handler
+	source           rand.Source
+}
+
+// Option is a function that configures the router.
+type Option func(*options)
+
+// WithStrategy sets the way the first backend of each call is chosen. The
+// default is StrategyPriority.
+func WithStrategy(s Strategy) Option {
+	return func(o *options) {
+		o.strategy = s
+	}
+}
+
+// WithCallback sets the callbacks handler, which is told which backend served
+// each call through HandleLLMRoute, and about each failed backend through
+// HandleLLMError.
+func WithCallback(h callbacks.Handler) Option {
+	return func(o *options) {
+		o.callbacksHandler = h
+	}
+}
+
+// WithRandSource sets the source of randomness of StrategyWeighted.
+func WithRandSource(src rand.Source) Option {
+	return func(o *options) {
+		o.source = src
+	}
+}
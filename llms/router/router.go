@@ -0,0 +1,204 @@
+// Package router provides a model that sends calls to one of several backend
+// models, falling back to the next backend when a call fails and optionally
+// balancing the load between the backends. It can be used anywhere a
+// llms.Model is accepted:
+//
+//	model, err := router.New([]router.Backend{
+//		{Name: "openai", Model: retry.New(gpt, retry.WithCallTimeout(30*time.Second))},
+//		{Name: "anthropic", Model: claude},
+//		{Name: "ollama", Model: llama},
+//	})
+//	...
+//	chain := chains.NewLLMChain(model, prompt)
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/internal/streaming"
+)
+
+// ErrNoBackends is returned when a router is created without backends.
+var ErrNoBackends = errors.New("no backends")
+
+// Strategy is the way a router chooses the first backend of each call.
+type Strategy string
+
+const (
+	// StrategyPriority sends each call to the backends in the order they were
+	// given, so later backends only serve calls the earlier ones failed.
+	StrategyPriority Strategy = "priority"
+	// StrategyRoundRobin rotates the first backend of each call through the
+	// backends.
+	StrategyRoundRobin Strategy = "round_robin"
+	// StrategyWeighted chooses the first backend of each call at random, in
+	// proportion to the weights of the backends.
+	StrategyWeighted Strategy = "weighted"
+)
+
+// Backend is a model calls can be routed to.
+type Backend struct {
+	// Name identifies the backend in callbacks and errors.
+	Name string
+	// Model is the model that serves the calls.
+	Model llms.Model
+	// Weight is the relative share of calls first sent to the backend with
+	// StrategyWeighted. Weights less than 1 count as 1.
+	Weight int
+	// ShouldFallback reports whether a call that failed on this backend with
+	// err should be sent to the next backend. If nil, all errors but the
+	// cancellation of the call fall back.
+	ShouldFallback func(err error) bool
+}
+
+// LLM is a model that routes calls to backend models.
+type LLM struct {
+	CallbacksHandler callbacks.Handler
+
+	backends []Backend
+	strategy Strategy
+	next     atomic.Uint64
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+var _ llms.Model = (*LLM)(nil)
+
+// New returns a model that routes calls to the given backends.
+func New(backends []Backend, opts ...Option) (*LLM, error) {
+	if len(backends) == 0 {
+		return nil, ErrNoBackends
+	}
+
+	o := options{
+		strategy: StrategyPriority,
+		source:   rand.NewSource(rand.Int63()), //nolint:gosec
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	switch o.strategy {
+	case StrategyPriority, StrategyRoundRobin, StrategyWeighted:
+	default:
+		return nil, fmt.Errorf("unknown strategy %q", o.strategy) //nolint:goerr113
+	}
+
+	backends = append([]Backend(nil), backends...)
+	for i := range backends {
+		if backends[i].Model == nil {
+			return nil, fmt.Errorf("backend %d has no model", i) //nolint:goerr113
+		}
+		if backends[i].Name == "" {
+			backends[i].Name = fmt.Sprintf("backend-%d", i)
+		}
+	}
+
+	return &LLM{
+		CallbacksHandler: o.callbacksHandler,
+		backends:         backends,
+		strategy:         o.strategy,
+		rnd:              rand.New(o.source), //nolint:gosec
+	}, nil
+}
+
+// Call implements the llms.Model interface.
+func (l *LLM) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, l, prompt, options...)
+}
+
+// GenerateContent implements the llms.Model interface. The call is sent to
+// the backends in turn until one of them succeeds, or fails with an error
+// that shouldn't fall back. A call that already streamed part of its response
+// doesn't fall back either. The start and end of the call are reported by the
+// backend that serves it, not by the router, so handlers shared with the
+// backends see each call once.
+func (l *LLM) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) { //nolint:lll
+	var streamed atomic.Bool
+	options = streaming.Track(options, &streamed)
+
+	var errs []error
+	for _, i := range l.order() {
+		b := l.backends[i]
+		resp, err := b.Model.GenerateContent(ctx, messages, options...)
+		if err == nil {
+			if l.CallbacksHandler != nil {
+				l.CallbacksHandler.HandleLLMRoute(ctx, b.Name)
+			}
+			return resp, nil
+		}
+
+		err = fmt.Errorf("backend %s: %w", b.Name, err)
+		if l.CallbacksHandler != nil {
+			l.CallbacksHandler.HandleLLMError(ctx, err)
+		}
+		errs = append(errs, err)
+		if streamed.Load() || ctx.Err() != nil || !b.shouldFallback(err) {
+			break
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// order returns the indexes of the backends in the order a call should try
+// them.
+func (l *LLM) order() []int {
+	n := len(l.backends)
+	first := 0
+	switch l.strategy {
+	case StrategyRoundRobin:
+		first = int((l.next.Add(1) - 1) % uint64(n))
+	case StrategyWeighted:
+		first = l.pickWeighted()
+	case StrategyPriority:
+	}
+
+	order := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		order = append(order, (first+i)%n)
+	}
+	return order
+}
+
+// pickWeighted returns the index of a backend chosen at random in proportion
+// to the weights of the backends.
+func (l *LLM) pickWeighted() int {
+	total := 0
+	for _, b := range l.backends {
+		total += b.weight()
+	}
+
+	l.mu.Lock()
+	r := l.rnd.Intn(total)
+	l.mu.Unlock()
+
+	for i, b := range l.backends {
+		if r < b.weight() {
+			return i
+		}
+		r -= b.weight()
+	}
+	return 0
+}
+
+func (b Backend) weight() int {
+	if b.Weight < 1 {
+		return 1
+	}
+	return b.Weight
+}
+
+func (b Backend) shouldFallback(err error) bool {
+	if b.ShouldFallback != nil {
+		return b.ShouldFallback(err)
+	}
+	return !errors.Is(err, context.Canceled)
+}
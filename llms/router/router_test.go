@@ -0,0 +1,218 @@
+package router_test
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/chains"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/router"
+	"github.com/tmc/langchaingo/prompts"
+)
+
+var errTimeout = errors.New("timeout")
+
+// fakeModel answers with its name, or fails with err. It reports its
+// responses to handler, if set.
+type fakeModel struct {
+	name    string
+	err     error
+	stream  bool
+	calls   int
+	handler callbacks.Handler
+}
+
+func (m *fakeModel) GenerateContent(ctx context.Context, _ []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) { //nolint:lll
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	m.calls++
+	if m.stream && opts.StreamingFunc != nil {
+		if err := opts.StreamingFunc(ctx, []byte(m.name)); err != nil {
+			return nil, err
+		}
+	}
+	if m.err != nil {
+		return nil, m.err
+	}
+	resp := &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: m.name}}, Usage: llms.NewUsage(10, 5)}
+	if m.handler != nil {
+		m.handler.HandleLLMGenerateContentEnd(ctx, resp)
+	}
+	return resp, nil
+}
+
+func (m *fakeModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+type routeRecorder struct {
+	callbacks.SimpleHandler
+	routes []string
+	errs   []error
+}
+
+func (r *routeRecorder) HandleLLMRoute(_ context.Context, backend string) {
+	r.routes = append(r.routes, backend)
+}
+
+func (r *routeRecorder) HandleLLMError(_ context.Context, err error) {
+	r.errs = append(r.errs, err)
+}
+
+func TestFallback(t *testing.T) {
+	t.Parallel()
+
+	openai := &fakeModel{name: "openai", err: errTimeout}
+	anthropic := &fakeModel{name: "anthropic", err: errTimeout}
+	ollama := &fakeModel{name: "ollama"}
+	handler := &routeRecorder{}
+	model, err := router.New([]router.Backend{
+		{Name: "openai", Model: openai},
+		{Name: "anthropic", Model: anthropic},
+		{Name: "ollama", Model: ollama},
+	}, router.WithCallback(handler))
+	require.NoError(t, err)
+
+	// The router can be used in place of any model.
+	chain := chains.NewLLMChain(model, prompts.NewPromptTemplate("{{.q}}", []string{"q"}))
+	out, err := chains.Run(context.Background(), chain, "hello")
+	require.NoError(t, err)
+
+	assert.Equal(t, "ollama", out)
+	assert.Equal(t, []string{"ollama"}, handler.routes)
+	require.Len(t, handler.errs, 2)
+	require.ErrorIs(t, handler.errs[0], errTimeout)
+	assert.Contains(t, handler.errs[0].Error(), "openai")
+}
+
+func TestSharedHandler(t *testing.T) {
+	t.Parallel()
+
+	// The usage of a call is counted once when the router and its backends
+	// share a handler.
+	usage := callbacks.NewUsageHandler(llms.Price{})
+	model, err := router.New([]router.Backend{
+		{Name: "openai", Model: &fakeModel{name: "openai", err: errTimeout, handler: usage}},
+		{Name: "ollama", Model: &fakeModel{name: "ollama", handler: usage}},
+	}, router.WithCallback(usage))
+	require.NoError(t, err)
+
+	_, err = model.Call(context.Background(), "hello")
+	require.NoError(t, err)
+	assert.Equal(t, 1, usage.Total().Calls)
+	assert.Equal(t, 15, usage.Total().TotalTokens)
+}
+
+func TestFallbackPredicate(t *testing.T) {
+	t.Parallel()
+
+	errBadRequest := errors.New("bad request")
+	second := &fakeModel{name: "second"}
+	model, err := router.New([]router.Backend{
+		{
+			Model:          &fakeModel{name: "first", err: errBadRequest},
+			ShouldFallback: func(err error) bool { return errors.Is(err, errTimeout) },
+		},
+		{Model: second},
+	})
+	require.NoError(t, err)
+
+	_, err = llms.GenerateFromSinglePrompt(context.Background(), model, "hello")
+	require.ErrorIs(t, err, errBadRequest)
+	assert.Contains(t, err.Error(), "backend-0")
+	assert.Equal(t, 0, second.calls)
+}
+
+func TestAllBackendsFail(t *testing.T) {
+	t.Parallel()
+
+	errOther := errors.New("other")
+	model, err := router.New([]router.Backend{
+		{Model: &fakeModel{err: errTimeout}},
+		{Model: &fakeModel{err: errOther}},
+	})
+	require.NoError(t, err)
+
+	_, err = llms.GenerateFromSinglePrompt(context.Background(), model, "hello")
+	require.ErrorIs(t, err, errTimeout)
+	require.ErrorIs(t, err, errOther)
+}
+
+func TestNoFallbackAfterStreaming(t *testing.T) {
+	t.Parallel()
+
+	second := &fakeModel{name: "second"}
+	model, err := router.New([]router.Backend{
+		{Model: &fakeModel{name: "first", err: errTimeout, stream: true}},
+		{Model: second},
+	})
+	require.NoError(t, err)
+
+	var chunks []string
+	_, err = llms.GenerateFromSinglePrompt(context.Background(), model, "hello",
+		llms.WithStreamingFunc(func(_ context.Context, chunk []byte) error {
+			chunks = append(chunks, string(chunk))
+			return nil
+		}))
+	require.ErrorIs(t, err, errTimeout)
+	assert.Equal(t, []string{"first"}, chunks)
+	assert.Equal(t, 0, second.calls)
+}
+
+func TestRoundRobin(t *testing.T) {
+	t.Parallel()
+
+	handler := &routeRecorder{}
+	model, err := router.New([]router.Backend{
+		{Name: "a", Model: &fakeModel{name: "a"}},
+		{Name: "b", Model: &fakeModel{name: "b"}},
+		{Name: "c", Model: &fakeModel{name: "c", err: errTimeout}},
+	}, router.WithStrategy(router.StrategyRoundRobin), router.WithCallback(handler))
+	require.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		_, err := llms.GenerateFromSinglePrompt(context.Background(), model, "hello")
+		require.NoError(t, err)
+	}
+	// The failing backend falls back to the next one.
+	assert.Equal(t, []string{"a", "b", "a", "a"}, handler.routes)
+}
+
+func TestWeighted(t *testing.T) {
+	t.Parallel()
+
+	heavy := &fakeModel{name: "heavy"}
+	light := &fakeModel{name: "light"}
+	model, err := router.New([]router.Backend{
+		{Model: heavy, Weight: 3},
+		{Model: light, Weight: 1},
+	}, router.WithStrategy(router.StrategyWeighted), router.WithRandSource(rand.NewSource(1)))
+	require.NoError(t, err)
+
+	for i := 0; i < 400; i++ {
+		_, err := llms.GenerateFromSinglePrompt(context.Background(), model, "hello")
+		require.NoError(t, err)
+	}
+	assert.InDelta(t, 300, heavy.calls, 40)
+	assert.Equal(t, 400, heavy.calls+light.calls)
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	_, err := router.New(nil)
+	require.ErrorIs(t, err, router.ErrNoBackends)
+
+	_, err = router.New([]router.Backend{{Model: &fakeModel{}}}, router.WithStrategy("random"))
+	require.Error(t, err)
+
+	_, err = router.New([]router.Backend{{Name: "empty"}})
+	require.Error(t, err)
+}
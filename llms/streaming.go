@@ -0,0 +1,272 @@
+package llms
+
+import (
+	"context"
+	"sync"
+
+	"github.com/tmc/langchaingo/schema"
+)
+
+// StreamEventType is the type of a StreamEvent.
+type StreamEventType string
+
+const (
+	// StreamEventText carries a delta of the textual content of a choice.
+	StreamEventText StreamEventType = "text"
+	// StreamEventToolCall carries a delta of a tool call of a choice.
+	StreamEventToolCall StreamEventType = "tool_call"
+	// StreamEventUsage carries the token usage of the call.
+	StreamEventUsage StreamEventType = "usage"
+	// StreamEventFinish marks the end of a choice and carries its stop reason.
+	StreamEventFinish StreamEventType = "finish"
+)
+
+// StreamEvent is a typed event emitted while a model streams its response.
+type StreamEvent struct {
+	// Type is the type of the event; it determines which other fields are set.
+	Type StreamEventType
+	// ChoiceIndex is the index of the choice the event belongs to.
+	ChoiceIndex int
+	// Text is the text delta, for StreamEventText events.
+	Text string
+	// ToolCall is the tool call delta, for StreamEventToolCall events.
+	ToolCall *ToolCallDelta
+	// Usage is the token usage, for StreamEventUsage events.
+	Usage *Usage
+	// StopReason is the reason the model stopped, for StreamEventFinish events.
+	StopReason string
+}
+
+// ToolCallDelta is a fragment of a tool call. Deltas with the same Index are
+// concatenated to form the complete tool call: ID, Type and Name are usually
+// only set in the first delta, while Arguments is split across deltas.
+type ToolCallDelta struct {
+	// Index is the position of the tool call within its choice.
+	Index int
+	// ID is the ID of the tool call.
+	ID string
+	// Type is the type of the tool call, typically "function".
+	Type string
+	// Name is the name of the function to call.
+	Name string
+	// Arguments is a fragment of the JSON arguments of the function call.
+	Arguments string
+}
+
+// StreamingModel is a Model that can stream its response as typed events.
+type StreamingModel interface {
+	Model
+
+	// GenerateContentStream is like GenerateContent, but returns a stream of
+	// the events of the response as they are produced.
+	GenerateContentStream(ctx context.Context, messages []MessageContent, options ...CallOption) (*ContentStream, error)
+}
+
+// StreamEventFunc is a function called for each typed event of a streaming
+// response. Return an error to stop streaming early.
+type StreamEventFunc func(ctx context.Context, event StreamEvent) error
+
+// GenerateContentStream asks the model to generate content and returns the
+// response as a stream of typed events. Models implementing StreamingModel
+// stream natively; for other models, text is streamed through
+// CallOptions.StreamingFunc and the remaining events are derived from the
+// final response. The text of models ignoring StreamingFunc is emitted in a
+// single event once the response is complete.
+func GenerateContentStream(ctx context.Context, model Model, messages []MessageContent, options ...CallOption) (*ContentStream, error) { //nolint:lll
+	if sm, ok := model.(StreamingModel); ok {
+		return sm.GenerateContentStream(ctx, messages, options...)
+	}
+
+	return NewContentStream(ctx, func(ctx context.Context, fn StreamEventFunc) error {
+		streamed := false
+		streamText := WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+			streamed = true
+			return fn(ctx, StreamEvent{Type: StreamEventText, Text: string(chunk)})
+		})
+		options := append(options[:len(options):len(options)], streamText)
+		resp, err := model.GenerateContent(ctx, messages, options...)
+		if err != nil {
+			return err
+		}
+		return emitResponseEvents(ctx, resp, streamed, fn)
+	}), nil
+}
+
+// GenerateContentEventStream returns the response of model as a stream of the
+// typed events its GenerateContent passes to CallOptions.StreamingEventFunc.
+// It is intended for implementations of StreamingModel whose GenerateContent
+// streams events.
+func GenerateContentEventStream(ctx context.Context, model Model, messages []MessageContent, options ...CallOption) (*ContentStream, error) { //nolint:lll
+	return NewContentStream(ctx, func(ctx context.Context, fn StreamEventFunc) error {
+		options := append(options[:len(options):len(options)], WithStreamingEventFunc(fn))
+		_, err := model.GenerateContent(ctx, messages, options...)
+		return err
+	}), nil
+}
+
+// emitResponseEvents emits the events of a complete response that are not
+// covered by streamed text. Text streamed through StreamingFunc belongs to the
+// first choice, so the text of the other choices, and of the first one if
+// nothing was streamed, is emitted from the response.
+func emitResponseEvents(ctx context.Context, resp *ContentResponse, streamed bool, fn StreamEventFunc) error {
+	for i, c := range resp.Choices {
+		if c.Content != "" && (i > 0 || !streamed) {
+			if err := fn(ctx, StreamEvent{Type: StreamEventText, ChoiceIndex: i, Text: c.Content}); err != nil {
+				return err
+			}
+		}
+		for j, tc := range c.ToolCalls {
+			delta := &ToolCallDelta{Index: j, ID: tc.ID, Type: tc.Type}
+			if tc.FunctionCall != nil {
+				delta.Name = tc.FunctionCall.Name
+				delta.Arguments = tc.FunctionCall.Arguments
+			}
+			if err := fn(ctx, StreamEvent{Type: StreamEventToolCall, ChoiceIndex: i, ToolCall: delta}); err != nil {
+				return err
+			}
+		}
+		if err := fn(ctx, StreamEvent{Type: StreamEventFinish, ChoiceIndex: i, StopReason: c.StopReason}); err != nil {
+			return err
+		}
+	}
+	if resp.Usage != nil {
+		return fn(ctx, StreamEvent{Type: StreamEventUsage, Usage: resp.Usage})
+	}
+	return nil
+}
+
+// ContentStream is a stream of the typed events of a response. Iterate over it
+// with Next and Event, in the manner of bufio.Scanner:
+//
+//	for stream.Next() {
+//		event := stream.Event()
+//		...
+//	}
+//	if err := stream.Err(); err != nil {
+//		...
+//	}
+//	resp := stream.Response()
+type ContentStream struct {
+	events chan StreamEvent
+	cancel context.CancelFunc
+	once   sync.Once
+
+	event StreamEvent
+	err   error
+	resp  *ContentResponse
+}
+
+// NewContentStream returns a stream of the events produced by run, which is
+// called in a separate goroutine and should call fn for each event. It is
+// intended for implementations of StreamingModel.
+func NewContentStream(ctx context.Context, run func(ctx context.Context, fn StreamEventFunc) error) *ContentStream {
+	ctx, cancel := context.WithCancel(ctx)
+	s := &ContentStream{
+		events: make(chan StreamEvent),
+		cancel: cancel,
+		resp:   &ContentResponse{},
+	}
+
+	go func() {
+		defer close(s.events)
+		err := run(ctx, func(ctx context.Context, event StreamEvent) error {
+			select {
+			case s.events <- event:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		s.err = err
+	}()
+
+	return s
+}
+
+// Next advances the stream to the next event, which is then available through
+// Event. It returns false when the stream ends, either because the response is
+// complete or because of an error reported by Err.
+func (s *ContentStream) Next() bool {
+	event, ok := <-s.events
+	if !ok {
+		s.Close()
+		return false
+	}
+	s.event = event
+	s.resp.apply(event)
+	return true
+}
+
+// Event returns the current event.
+func (s *ContentStream) Event() StreamEvent {
+	return s.event
+}
+
+// Err returns the error that ended the stream, if any. It should be called
+// after Next returns false.
+func (s *ContentStream) Err() error {
+	return s.err
+}
+
+// Response returns the response assembled from the events seen so far. Once
+// Next returns false, it is the complete response.
+func (s *ContentStream) Response() *ContentResponse {
+	return s.resp
+}
+
+// Usage returns the token usage reported by the stream, or nil if the model
+// didn't report any.
+func (s *ContentStream) Usage() *Usage {
+	return s.resp.Usage
+}
+
+// Close stops the stream and releases its resources. It is safe to call Close
+// multiple times, and it is called automatically when Next returns false.
+func (s *ContentStream) Close() {
+	s.once.Do(func() {
+		s.cancel()
+		// Drain the events so the producer can observe the cancellation.
+		for range s.events { //nolint:revive
+		}
+	})
+}
+
+// apply adds an event to the response.
+func (r *ContentResponse) apply(event StreamEvent) {
+	if event.Type == StreamEventUsage {
+		r.Usage = event.Usage
+		return
+	}
+
+	for len(r.Choices) <= event.ChoiceIndex {
+		r.Choices = append(r.Choices, &ContentChoice{})
+	}
+	choice := r.Choices[event.ChoiceIndex]
+
+	switch event.Type {
+	case StreamEventText:
+		choice.Content += event.Text
+	case StreamEventToolCall:
+		delta := event.ToolCall
+		if delta == nil {
+			return
+		}
+		for len(choice.ToolCalls) <= delta.Index {
+			choice.ToolCalls = append(choice.ToolCalls, ToolCall{FunctionCall: &schema.FunctionCall{}})
+		}
+		tc := &choice.ToolCalls[delta.Index]
+		if delta.ID != "" {
+			tc.ID = delta.ID
+		}
+		if delta.Type != "" {
+			tc.Type = delta.Type
+		}
+		tc.FunctionCall.Name += delta.Name
+		tc.FunctionCall.Arguments += delta.Arguments
+		if delta.Index == 0 {
+			choice.FuncCall = tc.FunctionCall
+		}
+	case StreamEventFinish:
+		choice.StopReason = event.StopReason
+	}
+}
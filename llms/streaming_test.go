@@ -0,0 +1,203 @@
+package llms
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// textOnlyModel is a model that doesn't implement StreamingModel.
+type textOnlyModel struct{}
+
+func (textOnlyModel) GenerateContent(ctx context.Context, _ []MessageContent, options ...CallOption) (*ContentResponse, error) { //nolint:lll
+	opts := CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	for _, chunk := range []string{"Hello", ", world"} {
+		if err := opts.StreamingFunc(ctx, []byte(chunk)); err != nil {
+			return nil, err
+		}
+	}
+	return &ContentResponse{Choices: []*ContentChoice{{
+		Content:    "Hello, world",
+		StopReason: "stop",
+		ToolCalls: []ToolCall{{
+			ID: "call_1", Type: "function",
+			FunctionCall: &schema.FunctionCall{Name: "greet", Arguments: `{}`},
+		}},
+	}}}, nil
+}
+
+func (m textOnlyModel) Call(ctx context.Context, prompt string, options ...CallOption) (string, error) {
+	return GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+func TestGenerateContentStreamFallback(t *testing.T) {
+	t.Parallel()
+
+	stream, err := GenerateContentStream(context.Background(), textOnlyModel{}, nil)
+	require.NoError(t, err)
+
+	var types []StreamEventType
+	for stream.Next() {
+		types = append(types, stream.Event().Type)
+	}
+	require.NoError(t, stream.Err())
+
+	assert.Equal(t, []StreamEventType{
+		StreamEventText, StreamEventText, StreamEventToolCall, StreamEventFinish,
+	}, types)
+
+	resp := stream.Response()
+	require.Len(t, resp.Choices, 1)
+	assert.Equal(t, "Hello, world", resp.Choices[0].Content)
+	assert.Equal(t, "stop", resp.Choices[0].StopReason)
+	require.Len(t, resp.Choices[0].ToolCalls, 1)
+	assert.Equal(t, "greet", resp.Choices[0].ToolCalls[0].FunctionCall.Name)
+	assert.Equal(t, resp.Choices[0].ToolCalls[0].FunctionCall, resp.Choices[0].FuncCall)
+}
+
+// nonStreamingModel is a model that ignores CallOptions.StreamingFunc.
+type nonStreamingModel struct{}
+
+func (nonStreamingModel) GenerateContent(context.Context, []MessageContent, ...CallOption) (*ContentResponse, error) {
+	return &ContentResponse{
+		Choices: []*ContentChoice{{Content: "Hello, world", StopReason: "stop"}},
+		Usage:   &Usage{PromptTokens: 1, CompletionTokens: 3, TotalTokens: 4},
+	}, nil
+}
+
+func (m nonStreamingModel) Call(ctx context.Context, prompt string, options ...CallOption) (string, error) {
+	return GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+func TestGenerateContentStreamFallbackWithoutStreaming(t *testing.T) {
+	t.Parallel()
+
+	stream, err := GenerateContentStream(context.Background(), nonStreamingModel{}, nil)
+	require.NoError(t, err)
+
+	var events []StreamEvent
+	for stream.Next() {
+		events = append(events, stream.Event())
+	}
+	require.NoError(t, stream.Err())
+
+	require.Len(t, events, 3)
+	assert.Equal(t, StreamEvent{Type: StreamEventText, Text: "Hello, world"}, events[0])
+	assert.Equal(t, StreamEventFinish, events[1].Type)
+	assert.Equal(t, StreamEventUsage, events[2].Type)
+	assert.Equal(t, "Hello, world", stream.Response().Choices[0].Content)
+}
+
+// eventModel is a model that streams events through
+// CallOptions.StreamingEventFunc.
+type eventModel struct{}
+
+func (eventModel) GenerateContent(ctx context.Context, _ []MessageContent, options ...CallOption) (*ContentResponse, error) { //nolint:lll
+	opts := CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	for _, event := range []StreamEvent{
+		{Type: StreamEventText, Text: "Hello"},
+		{Type: StreamEventFinish, StopReason: "stop"},
+	} {
+		if err := opts.StreamingEventFunc(ctx, event); err != nil {
+			return nil, err
+		}
+	}
+	return &ContentResponse{Choices: []*ContentChoice{{Content: "Hello", StopReason: "stop"}}}, nil
+}
+
+func (m eventModel) Call(ctx context.Context, prompt string, options ...CallOption) (string, error) {
+	return GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+func TestGenerateContentEventStream(t *testing.T) {
+	t.Parallel()
+
+	stream, err := GenerateContentEventStream(context.Background(), eventModel{}, nil)
+	require.NoError(t, err)
+
+	var events []StreamEvent
+	for stream.Next() {
+		events = append(events, stream.Event())
+	}
+	require.NoError(t, stream.Err())
+
+	assert.Equal(t, []StreamEvent{
+		{Type: StreamEventText, Text: "Hello"},
+		{Type: StreamEventFinish, StopReason: "stop"},
+	}, events)
+	assert.Equal(t, "Hello", stream.Response().Choices[0].Content)
+}
+
+func TestContentStreamAssemblesDeltas(t *testing.T) {
+	t.Parallel()
+
+	events := []StreamEvent{
+		{Type: StreamEventText, Text: "a"},
+		{Type: StreamEventText, ChoiceIndex: 1, Text: "b"},
+		{Type: StreamEventToolCall, ToolCall: &ToolCallDelta{Index: 0, ID: "c1", Type: "function", Name: "f"}},
+		{Type: StreamEventToolCall, ToolCall: &ToolCallDelta{Index: 1, ID: "c2", Type: "function", Name: "g"}},
+		{Type: StreamEventToolCall, ToolCall: &ToolCallDelta{Index: 0, Arguments: `{"x":`}},
+		{Type: StreamEventToolCall, ToolCall: &ToolCallDelta{Index: 0, Arguments: `1}`}},
+		{Type: StreamEventUsage, Usage: &Usage{PromptTokens: 1, CompletionTokens: 2, TotalTokens: 3}},
+		{Type: StreamEventFinish, StopReason: "tool_calls"},
+	}
+	stream := NewContentStream(context.Background(), func(ctx context.Context, fn StreamEventFunc) error {
+		for _, e := range events {
+			if err := fn(ctx, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	for stream.Next() { //nolint:revive
+	}
+	require.NoError(t, stream.Err())
+
+	resp := stream.Response()
+	require.Len(t, resp.Choices, 2)
+	assert.Equal(t, "a", resp.Choices[0].Content)
+	assert.Equal(t, "b", resp.Choices[1].Content)
+	assert.Equal(t, "tool_calls", resp.Choices[0].StopReason)
+	require.Len(t, resp.Choices[0].ToolCalls, 2)
+	assert.Equal(t, ToolCall{ID: "c1", Type: "function", FunctionCall: &schema.FunctionCall{Name: "f", Arguments: `{"x":1}`}},
+		resp.Choices[0].ToolCalls[0])
+	assert.Equal(t, "g", resp.Choices[0].ToolCalls[1].FunctionCall.Name)
+	assert.Equal(t, &Usage{PromptTokens: 1, CompletionTokens: 2, TotalTokens: 3}, stream.Usage())
+}
+
+func TestContentStreamErrorAndClose(t *testing.T) {
+	t.Parallel()
+
+	errBoom := errors.New("boom")
+	stream := NewContentStream(context.Background(), func(ctx context.Context, fn StreamEventFunc) error {
+		if err := fn(ctx, StreamEvent{Type: StreamEventText, Text: "a"}); err != nil {
+			return err
+		}
+		return errBoom
+	})
+	for stream.Next() { //nolint:revive
+	}
+	require.ErrorIs(t, stream.Err(), errBoom)
+
+	// Closing early stops the producer.
+	stream = NewContentStream(context.Background(), func(ctx context.Context, fn StreamEventFunc) error {
+		for {
+			if err := fn(ctx, StreamEvent{Type: StreamEventText, Text: "a"}); err != nil {
+				return err
+			}
+		}
+	})
+	require.True(t, stream.Next())
+	stream.Close()
+	require.ErrorIs(t, stream.Err(), context.Canceled)
+}
@@ -0,0 +1,295 @@
+package llms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tmc/langchaingo/jsonschema"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// ErrInvalidStructuredOutput is returned by GenerateStructured when the model
+// didn't reply with a value matching the schema within the allowed attempts.
+var ErrInvalidStructuredOutput = errors.New("invalid structured output")
+
+// StructuredMode is the way GenerateStructured asks a model for structured
+// output.
+type StructuredMode string
+
+const (
+	// StructuredModeAuto picks the mode from the capabilities of the model set
+	// with WithModel in the call options, as found in the
+	// DefaultModelRegistry: JSON mode for models that have one, then tool
+	// mode for models that support tools, and instructions otherwise. Models
+	// that aren't registered are asked with StructuredModeJSON. It is the
+	// default.
+	StructuredModeAuto StructuredMode = "auto"
+	// StructuredModeJSON asks for a JSON reply through format instructions,
+	// and through the JSON mode of models that have one when the output is a
+	// JSON object.
+	StructuredModeJSON StructuredMode = "json"
+	// StructuredModeTool forces the model to call a tool whose parameters are
+	// the schema of the output. It requires a model that supports tools.
+	StructuredModeTool StructuredMode = "tool"
+	// StructuredModeInstructions asks for a JSON reply through format
+	// instructions only.
+	StructuredModeInstructions StructuredMode = "instructions"
+)
+
+const (
+	_structuredToolName        = "structured_output"
+	_structuredToolDescription = "Responds to the user with structured output."
+
+	_structuredInstructionsTemplate = "Respond with a JSON value matching the following JSON schema, without any other text:\n```json\n%s\n```" //nolint:lll
+	_structuredRetryTemplate        = "The reply doesn't match the schema: %s. Respond again with the corrected value."
+)
+
+type structuredOptions struct {
+	mode        StructuredMode
+	maxRetries  int
+	name        string
+	description string
+	callOptions []CallOption
+}
+
+// StructuredOption is a function that configures GenerateStructured.
+type StructuredOption func(*structuredOptions)
+
+// WithStructuredMode sets the way the model is asked for structured output.
+// The default is StructuredModeAuto.
+func WithStructuredMode(mode StructuredMode) StructuredOption {
+	return func(o *structuredOptions) {
+		o.mode = mode
+	}
+}
+
+// WithStructuredMaxRetries sets how many times the model is asked again when
+// its reply doesn't match the schema. The default is 2.
+func WithStructuredMaxRetries(n int) StructuredOption {
+	return func(o *structuredOptions) {
+		o.maxRetries = n
+	}
+}
+
+// WithStructuredTool sets the name and description of the tool the model is
+// forced to call with StructuredModeTool.
+func WithStructuredTool(name, description string) StructuredOption {
+	return func(o *structuredOptions) {
+		o.name = name
+		o.description = description
+	}
+}
+
+// WithStructuredCallOptions sets the options of the calls to the model.
+func WithStructuredCallOptions(options ...CallOption) StructuredOption {
+	return func(o *structuredOptions) {
+		o.callOptions = append(o.callOptions, options...)
+	}
+}
+
+// GenerateStructured asks model for a value of type T in reply to messages.
+// The JSON schema of T is derived from its type with jsonschema.Reflect, so
+// struct tags describe the expected output:
+//
+//	type Weather struct {
+//		City        string  `json:"city"`
+//		Temperature float64 `json:"temperature" description:"In degrees Celsius"`
+//	}
+//
+//	weather, err := llms.GenerateStructured[Weather](ctx, model, messages)
+//
+// The reply is validated against the schema, and the model is asked again
+// with the validation errors when it doesn't match.
+func GenerateStructured[T any](ctx context.Context, model Model, messages []MessageContent, options ...StructuredOption) (T, error) { //nolint:lll
+	var value T
+
+	o := structuredOptions{
+		mode:        StructuredModeAuto,
+		maxRetries:  2,
+		name:        _structuredToolName,
+		description: _structuredToolDescription,
+	}
+	for _, opt := range options {
+		opt(&o)
+	}
+
+	def, err := jsonschema.Reflect(&value)
+	if err != nil {
+		return value, err
+	}
+
+	// JSON mode and tool parameters are constrained to objects.
+	object := def.Type == jsonschema.Object
+	if o.mode == StructuredModeAuto {
+		o.mode = autoStructuredMode(o.callOptions, object)
+	}
+
+	messages = append([]MessageContent(nil), messages...)
+	callOptions := append([]CallOption(nil), o.callOptions...)
+	switch o.mode {
+	case StructuredModeTool:
+		callOptions = append(callOptions,
+			WithTools([]Tool{{
+				Type: ToolTypeFunction,
+				Function: &FunctionDefinition{
+					Name:        o.name,
+					Description: o.description,
+					Parameters:  def,
+				},
+			}}),
+			WithFunctionToolChoice(o.name),
+		)
+	case StructuredModeJSON:
+		if object {
+			callOptions = append(callOptions, WithJSONMode())
+		}
+		fallthrough
+	case StructuredModeInstructions:
+		instructions, err := structuredInstructions(def)
+		if err != nil {
+			return value, err
+		}
+		messages = appendHumanText(messages, instructions)
+	default:
+		return value, fmt.Errorf("unknown structured mode %q", o.mode) //nolint:goerr113
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := model.GenerateContent(ctx, messages, callOptions...)
+		if err != nil {
+			return value, err
+		}
+		if len(resp.Choices) == 0 {
+			return value, fmt.Errorf("%w: no choices in response", ErrInvalidStructuredOutput)
+		}
+
+		choice := resp.Choices[0]
+		call := findToolCall(choice, o.name)
+		text := choice.Content
+		if call != nil {
+			text = call.FunctionCall.Arguments
+		}
+		value, err = parseStructured[T](text, def)
+		if err == nil {
+			return value, nil
+		}
+		if attempt >= o.maxRetries {
+			return value, fmt.Errorf("%w: %w", ErrInvalidStructuredOutput, err)
+		}
+
+		feedback := fmt.Sprintf(_structuredRetryTemplate, err)
+		if call != nil {
+			messages = append(messages,
+				MessageContent{Role: schema.ChatMessageTypeAI, Parts: []ContentPart{*call}},
+				MessageContent{Role: schema.ChatMessageTypeTool, Parts: []ContentPart{ToolCallResponse{
+					ToolCallID: call.ID,
+					Name:       call.FunctionCall.Name,
+					Content:    feedback,
+				}}},
+			)
+			continue
+		}
+		messages = append(messages, MessageContent{
+			Role:  schema.ChatMessageTypeAI,
+			Parts: []ContentPart{TextPart(choice.Content)},
+		})
+		messages = appendHumanText(messages, feedback)
+	}
+}
+
+// autoStructuredMode returns the mode of StructuredModeAuto for the model of
+// callOptions.
+func autoStructuredMode(callOptions []CallOption, object bool) StructuredMode {
+	opts := CallOptions{}
+	for _, opt := range callOptions {
+		opt(&opts)
+	}
+	info, ok := LookupModel("", opts.Model)
+	switch {
+	case opts.Model == "" || !ok:
+		return StructuredModeJSON
+	case object && info.Capabilities.JSONMode:
+		return StructuredModeJSON
+	case object && info.Capabilities.Tools:
+		return StructuredModeTool
+	default:
+		return StructuredModeInstructions
+	}
+}
+
+func structuredInstructions(def *jsonschema.Definition) (string, error) {
+	data, err := json.MarshalIndent(def, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(_structuredInstructionsTemplate, data), nil
+}
+
+// appendHumanText adds text to the last message if it is from a human, so
+// that models requiring alternating roles accept the messages, and as a new
+// human message otherwise.
+func appendHumanText(messages []MessageContent, text string) []MessageContent {
+	if n := len(messages); n > 0 && messages[n-1].Role == schema.ChatMessageTypeHuman {
+		last := messages[n-1]
+		last.Parts = append(last.Parts[:len(last.Parts):len(last.Parts)], TextPart(text))
+		messages[n-1] = last
+		return messages
+	}
+	return append(messages, MessageContent{
+		Role:  schema.ChatMessageTypeHuman,
+		Parts: []ContentPart{TextPart(text)},
+	})
+}
+
+// findToolCall returns the call to the tool with the given name in choice, or
+// nil if there is none.
+func findToolCall(choice *ContentChoice, name string) *ToolCall {
+	for i, tc := range choice.ToolCalls {
+		if tc.FunctionCall != nil && tc.FunctionCall.Name == name {
+			return &choice.ToolCalls[i]
+		}
+	}
+	return nil
+}
+
+// parseStructured validates the JSON value in text against def, and decodes
+// it.
+func parseStructured[T any](text string, def *jsonschema.Definition) (T, error) {
+	var value T
+	raw := json.RawMessage(extractJSON(text))
+	if err := def.Validate(raw); err != nil {
+		return value, err
+	}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		var zero T
+		return zero, err
+	}
+	return value, nil
+}
+
+// extractJSON returns the JSON value in text, which may be wrapped in a
+// markdown code block or surrounded by other text.
+func extractJSON(text string) string {
+	text = strings.TrimSpace(text)
+	if _, block, ok := strings.Cut(text, "```"); ok {
+		// Skip the language of the code block, if any.
+		if i := strings.IndexAny(block, "\n"); i >= 0 && !strings.ContainsAny(block[:i], "{[") {
+			block = block[i+1:]
+		}
+		block, _, _ = strings.Cut(block, "```")
+		return strings.TrimSpace(block)
+	}
+
+	start := strings.IndexAny(text, "{[")
+	if start < 0 {
+		return text
+	}
+	end := strings.LastIndexAny(text, "}]")
+	if end < start {
+		return text
+	}
+	return text[start : end+1]
+}
@@ -0,0 +1,167 @@
+package llms
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/schema"
+)
+
+type weather struct {
+	City        string  `json:"city"`
+	Temperature float64 `json:"temperature" description:"In degrees Celsius"`
+	Sky         string  `json:"sky,omitempty" enum:"clear,cloudy"`
+}
+
+// scriptedModel replies with the next of its choices, and records the
+// messages and options of each call.
+type scriptedModel struct {
+	replies  []*ContentChoice
+	messages [][]MessageContent
+	options  []CallOptions
+}
+
+func (m *scriptedModel) GenerateContent(_ context.Context, messages []MessageContent, options ...CallOption) (*ContentResponse, error) { //nolint:lll
+	opts := CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	m.messages = append(m.messages, messages)
+	m.options = append(m.options, opts)
+	reply := m.replies[0]
+	m.replies = m.replies[1:]
+	return &ContentResponse{Choices: []*ContentChoice{reply}}, nil
+}
+
+func (m *scriptedModel) Call(ctx context.Context, prompt string, options ...CallOption) (string, error) {
+	return GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+func humanMessage(text string) []MessageContent {
+	return []MessageContent{{Role: schema.ChatMessageTypeHuman, Parts: []ContentPart{TextPart(text)}}}
+}
+
+func TestGenerateStructured(t *testing.T) {
+	t.Parallel()
+
+	model := &scriptedModel{replies: []*ContentChoice{
+		{Content: "```json\n{\"city\": \"Paris\", \"temperature\": 21.5, \"sky\": \"clear\"}\n```"},
+	}}
+	w, err := GenerateStructured[weather](context.Background(), model, humanMessage("Weather in Paris?"))
+	require.NoError(t, err)
+	assert.Equal(t, weather{City: "Paris", Temperature: 21.5, Sky: "clear"}, w)
+
+	require.Len(t, model.messages, 1)
+	assert.True(t, model.options[0].JSONMode)
+	// The format instructions are added to the human message.
+	parts := model.messages[0][0].Parts
+	require.Len(t, parts, 2)
+	assert.Contains(t, parts[1].(TextContent).Text, `"temperature"`)
+}
+
+func TestGenerateStructuredRetry(t *testing.T) {
+	t.Parallel()
+
+	model := &scriptedModel{replies: []*ContentChoice{
+		{Content: `{"city": "Paris", "sky": "sunny"}`},
+		{Content: `Here it is: {"city": "Paris", "temperature": 21}`},
+	}}
+	w, err := GenerateStructured[*weather](context.Background(), model, humanMessage("Weather in Paris?"),
+		WithStructuredMode(StructuredModeInstructions))
+	require.NoError(t, err)
+	assert.Equal(t, &weather{City: "Paris", Temperature: 21}, w)
+
+	require.Len(t, model.messages, 2)
+	assert.False(t, model.options[0].JSONMode)
+	retry := model.messages[1]
+	require.Len(t, retry, 3)
+	assert.Equal(t, schema.ChatMessageTypeAI, retry[1].Role)
+	feedback := retry[2].Parts[0].(TextContent).Text
+	assert.Contains(t, feedback, `missing required property "temperature"`)
+	assert.Contains(t, feedback, `$.sky: must be one of "clear", "cloudy"`)
+}
+
+func TestGenerateStructuredTool(t *testing.T) {
+	t.Parallel()
+
+	call := func(args string) *ContentChoice {
+		return &ContentChoice{ToolCalls: []ToolCall{{
+			ID:           "call_1",
+			Type:         ToolTypeFunction,
+			FunctionCall: &schema.FunctionCall{Name: "report", Arguments: args},
+		}}}
+	}
+	model := &scriptedModel{replies: []*ContentChoice{
+		call(`{"city": "Paris", "temperature": "warm"}`),
+		call(`{"city": "Paris", "temperature": 21}`),
+	}}
+	w, err := GenerateStructured[weather](context.Background(), model, humanMessage("Weather in Paris?"),
+		WithStructuredMode(StructuredModeTool), WithStructuredTool("report", "Reports the weather."))
+	require.NoError(t, err)
+	assert.Equal(t, weather{City: "Paris", Temperature: 21}, w)
+
+	opts := model.options[0]
+	require.Len(t, opts.Tools, 1)
+	assert.Equal(t, "report", opts.Tools[0].Function.Name)
+	assert.Equal(t, ToolChoice{Type: ToolTypeFunction, Function: &FunctionReference{Name: "report"}}, opts.ToolChoice)
+
+	retry := model.messages[1]
+	require.Len(t, retry, 3)
+	resp := retry[2].Parts[0].(ToolCallResponse)
+	assert.Equal(t, "call_1", resp.ToolCallID)
+	assert.Contains(t, resp.Content, "$.temperature: expected number, got string")
+}
+
+func TestGenerateStructuredAuto(t *testing.T) {
+	t.Parallel()
+
+	RegisterModels(
+		ModelInfo{Name: "structured-tools", Capabilities: Capabilities{Tools: true}},
+		ModelInfo{Name: "structured-json", Capabilities: Capabilities{Tools: true, JSONMode: true}},
+	)
+
+	// Models without JSON mode are forced to call a tool.
+	model := &scriptedModel{replies: []*ContentChoice{{ToolCalls: []ToolCall{{
+		ID:           "call_1",
+		Type:         ToolTypeFunction,
+		FunctionCall: &schema.FunctionCall{Name: _structuredToolName, Arguments: `{"city": "Paris", "temperature": 21}`},
+	}}}}}
+	w, err := GenerateStructured[weather](context.Background(), model, humanMessage("Weather in Paris?"),
+		WithStructuredCallOptions(WithModel("structured-tools-v2")))
+	require.NoError(t, err)
+	assert.Equal(t, weather{City: "Paris", Temperature: 21}, w)
+	require.Len(t, model.options[0].Tools, 1)
+	assert.False(t, model.options[0].JSONMode)
+
+	// Models with JSON mode use it, text after the value is ignored.
+	model = &scriptedModel{replies: []*ContentChoice{{Content: `{"city": "Paris", "temperature": 21} Enjoy!`}}}
+	w, err = GenerateStructured[weather](context.Background(), model, humanMessage("Weather in Paris?"),
+		WithStructuredCallOptions(WithModel("structured-json")))
+	require.NoError(t, err)
+	assert.Equal(t, weather{City: "Paris", Temperature: 21}, w)
+	assert.True(t, model.options[0].JSONMode)
+	assert.Empty(t, model.options[0].Tools)
+
+	// JSON mode isn't used for values other than objects.
+	model = &scriptedModel{replies: []*ContentChoice{{Content: `["Paris", "Rome"]`}}}
+	cities, err := GenerateStructured[[]string](context.Background(), model, humanMessage("Capitals?"),
+		WithStructuredCallOptions(WithModel("structured-json")))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Paris", "Rome"}, cities)
+	assert.False(t, model.options[0].JSONMode)
+	assert.Empty(t, model.options[0].Tools)
+}
+
+func TestGenerateStructuredGivesUp(t *testing.T) {
+	t.Parallel()
+
+	model := &scriptedModel{replies: []*ContentChoice{
+		{Content: "sunny"}, {Content: "sunny"},
+	}}
+	_, err := GenerateStructured[weather](context.Background(), model, humanMessage("Weather in Paris?"),
+		WithStructuredMaxRetries(1))
+	require.ErrorIs(t, err, ErrInvalidStructuredOutput)
+	assert.Len(t, model.messages, 2)
+}
@@ -0,0 +1,158 @@
+package llms
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/pkoukk/tiktoken-go"
+)
+
+// Tokenizer splits text into the tokens of a model.
+type Tokenizer interface {
+	// Encode returns the IDs of the tokens of text.
+	Encode(text string) []int
+	// Decode returns the text of the tokens with the given IDs.
+	Decode(ids []int) string
+	// Count returns the number of tokens of text.
+	Count(text string) int
+}
+
+// TiktokenTokenizer is a Tokenizer using a tiktoken encoding, as used by
+// OpenAI models.
+type TiktokenTokenizer struct {
+	// AllowedSpecial lists the special tokens encoded as such, or "all".
+	AllowedSpecial []string
+	// DisallowedSpecial lists the special tokens that must not appear in
+	// encoded text, or "all". Encoding text containing them panics.
+	DisallowedSpecial []string
+
+	encoding *tiktoken.Tiktoken
+}
+
+var _ Tokenizer = (*TiktokenTokenizer)(nil)
+
+// NewTiktokenTokenizer returns a tokenizer for the tiktoken encoding with the
+// given name, e.g. "cl100k_base". Special tokens are encoded as text.
+func NewTiktokenTokenizer(encoding string) (*TiktokenTokenizer, error) {
+	e, err := tiktoken.GetEncoding(encoding)
+	if err != nil {
+		return nil, err
+	}
+	return &TiktokenTokenizer{encoding: e}, nil
+}
+
+// Encode implements the Tokenizer interface.
+func (t *TiktokenTokenizer) Encode(text string) []int {
+	return t.encoding.Encode(text, t.AllowedSpecial, t.DisallowedSpecial)
+}
+
+// Decode implements the Tokenizer interface.
+func (t *TiktokenTokenizer) Decode(ids []int) string {
+	return t.encoding.Decode(ids)
+}
+
+// Count implements the Tokenizer interface.
+func (t *TiktokenTokenizer) Count(text string) int {
+	return len(t.Encode(text))
+}
+
+// approximateTokenizer counts tokens as a fraction of the runes of text, for
+// models whose tokenizer isn't available. It can't encode text.
+type approximateTokenizer struct{}
+
+func (approximateTokenizer) Encode(string) []int   { return nil }
+func (approximateTokenizer) Decode([]int) string   { return "" }
+func (approximateTokenizer) Count(text string) int { return len([]rune(text)) / _tokenApproximation }
+
+// nolint:gochecknoglobals
+var (
+	tokenizersMu sync.Mutex
+	// tokenizers are the tokenizers registered with RegisterTokenizer, by
+	// model name.
+	tokenizers = map[string]Tokenizer{}
+	// loadedTokenizers are the tokenizers loaded from files, by path.
+	loadedTokenizers = map[string]Tokenizer{}
+)
+
+// RegisterTokenizer sets the tokenizer returned by TokenizerForModel for the
+// model with the given name, and its versions and tags.
+func RegisterTokenizer(model string, t Tokenizer) {
+	tokenizersMu.Lock()
+	defer tokenizersMu.Unlock()
+	tokenizers[model] = t
+}
+
+// TokenizerForModel returns the tokenizer of the model with the given name.
+// It is, in order of preference:
+//   - the tokenizer registered for the model with RegisterTokenizer;
+//   - the HuggingFace tokenizer of the TokenizerFile of the model in the
+//     DefaultModelRegistry;
+//   - the tiktoken encoding of the model in the DefaultModelRegistry, or
+//     known to tiktoken.
+//
+// An error is returned if none is available.
+//
+// Files and encodings are loaded without holding the lock of the registered
+// tokenizers, so that a slow load doesn't block other models.
+func TokenizerForModel(model string) (Tokenizer, error) {
+	if t, ok := registeredTokenizer(model); ok {
+		return t, nil
+	}
+
+	info, ok := LookupModel("", model)
+	if ok && info.TokenizerFile != "" {
+		return loadTokenizerFile(model, info.TokenizerFile)
+	}
+	if ok && info.Encoding != "" {
+		return NewTiktokenTokenizer(info.Encoding)
+	}
+
+	e, err := tiktoken.EncodingForModel(model)
+	if err != nil {
+		return nil, err
+	}
+	return &TiktokenTokenizer{encoding: e}, nil
+}
+
+// registeredTokenizer returns the tokenizer registered for model, or for the
+// model with the longest name it is a version or tag of.
+func registeredTokenizer(model string) (Tokenizer, bool) {
+	tokenizersMu.Lock()
+	defer tokenizersMu.Unlock()
+
+	best := ""
+	for name := range tokenizers {
+		if (name == model || isModelVariant(model, name)) && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return nil, false
+	}
+	return tokenizers[best], true
+}
+
+// loadTokenizerFile returns the HuggingFace tokenizer of model in path. It is
+// loaded without holding the lock, so concurrent first calls may load it
+// several times, but they all return the first tokenizer stored.
+func loadTokenizerFile(model, path string) (Tokenizer, error) {
+	tokenizersMu.Lock()
+	t, ok := loadedTokenizers[path]
+	tokenizersMu.Unlock()
+	if ok {
+		return t, nil
+	}
+
+	t, err := LoadHuggingFaceTokenizer(path)
+	if err != nil {
+		return nil, fmt.Errorf("load tokenizer of %s: %w", model, err)
+	}
+
+	tokenizersMu.Lock()
+	defer tokenizersMu.Unlock()
+	if loaded, ok := loadedTokenizers[path]; ok {
+		return loaded, nil
+	}
+	loadedTokenizers[path] = t
+	return t, nil
+}
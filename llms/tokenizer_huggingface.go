@@ -0,0 +1,614 @@
+package llms
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dlclark/regexp2"
+	"golang.org/x/text/unicode/norm"
+)
+
+// HuggingFaceTokenizer is a Tokenizer reading the tokenizer.json files of
+// HuggingFace models, such as Llama, Mistral or Command R. Only BPE models
+// are supported, with the normalizers, pre-tokenizers and decoders they
+// commonly use. Special tokens added by the post-processor, such as a
+// beginning of sequence token, aren't added by Encode.
+type HuggingFaceTokenizer struct {
+	vocab        map[string]int
+	tokens       map[int]string
+	ranks        map[[2]string]int
+	unknown      string
+	byteFallback bool
+	ignoreMerges bool
+
+	added     []string
+	normalize []func(string) string
+	preTok    []func([]string) []string
+	decode    []func([]string) []string
+}
+
+var _ Tokenizer = (*HuggingFaceTokenizer)(nil)
+
+// LoadHuggingFaceTokenizer returns the tokenizer of a tokenizer.json file.
+func LoadHuggingFaceTokenizer(path string) (*HuggingFaceTokenizer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewHuggingFaceTokenizer(data)
+}
+
+// hfConfig is the subset of tokenizer.json used by HuggingFaceTokenizer.
+type hfConfig struct {
+	AddedTokens []struct {
+		ID      int    `json:"id"`
+		Content string `json:"content"`
+	} `json:"added_tokens"`
+	Normalizer   *hfComponent `json:"normalizer"`
+	PreTokenizer *hfComponent `json:"pre_tokenizer"`
+	Decoder      *hfComponent `json:"decoder"`
+	Model        struct {
+		Type         string          `json:"type"`
+		Vocab        map[string]int  `json:"vocab"`
+		Merges       json.RawMessage `json:"merges"`
+		UnkToken     *string         `json:"unk_token"`
+		ByteFallback bool            `json:"byte_fallback"`
+		IgnoreMerges bool            `json:"ignore_merges"`
+	} `json:"model"`
+}
+
+// hfComponent is a normalizer, pre-tokenizer or decoder of tokenizer.json.
+type hfComponent struct {
+	Type          string         `json:"type"`
+	Normalizers   []*hfComponent `json:"normalizers"`
+	PreTokenizers []*hfComponent `json:"pretokenizers"`
+	Decoders      []*hfComponent `json:"decoders"`
+	Pattern       struct {
+		String *string `json:"String"`
+		Regex  *string `json:"Regex"`
+	} `json:"pattern"`
+	Content        string `json:"content"`
+	Prepend        string `json:"prepend"`
+	Behavior       string `json:"behavior"`
+	Invert         bool   `json:"invert"`
+	Replacement    string `json:"replacement"`
+	AddPrefixSpace *bool  `json:"add_prefix_space"`
+	PrependScheme  string `json:"prepend_scheme"`
+	Split          *bool  `json:"split"`
+	UseRegex       *bool  `json:"use_regex"`
+	Start          int    `json:"start"`
+	Stop           int    `json:"stop"`
+}
+
+// NewHuggingFaceTokenizer returns the tokenizer of the contents of a
+// tokenizer.json file.
+func NewHuggingFaceTokenizer(data []byte) (*HuggingFaceTokenizer, error) {
+	var c hfConfig
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parse tokenizer: %w", err)
+	}
+	if c.Model.Type != "BPE" {
+		return nil, fmt.Errorf("unsupported tokenizer model %q", c.Model.Type) //nolint:goerr113
+	}
+
+	t := &HuggingFaceTokenizer{
+		vocab:        c.Model.Vocab,
+		tokens:       make(map[int]string, len(c.Model.Vocab)),
+		byteFallback: c.Model.ByteFallback,
+		ignoreMerges: c.Model.IgnoreMerges,
+	}
+	if c.Model.UnkToken != nil {
+		t.unknown = *c.Model.UnkToken
+	}
+	for token, id := range c.Model.Vocab {
+		t.tokens[id] = token
+	}
+	for _, at := range c.AddedTokens {
+		t.vocab[at.Content] = at.ID
+		t.tokens[at.ID] = at.Content
+		t.added = append(t.added, at.Content)
+	}
+	// Longer added tokens are matched first.
+	sort.SliceStable(t.added, func(i, j int) bool { return len(t.added[i]) > len(t.added[j]) })
+
+	var err error
+	if t.ranks, err = parseMerges(c.Model.Merges); err != nil {
+		return nil, err
+	}
+	if err := t.addNormalizer(c.Normalizer); err != nil {
+		return nil, err
+	}
+	if err := t.addPreTokenizer(c.PreTokenizer); err != nil {
+		return nil, err
+	}
+	if err := t.addDecoder(c.Decoder); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// parseMerges parses merges given either as "a b" strings or ["a", "b"]
+// pairs, and returns their ranks.
+func parseMerges(data json.RawMessage) (map[[2]string]int, error) {
+	ranks := map[[2]string]int{}
+	if len(data) == 0 {
+		return ranks, nil
+	}
+	var merges []json.RawMessage
+	if err := json.Unmarshal(data, &merges); err != nil {
+		return nil, fmt.Errorf("parse merges: %w", err)
+	}
+	for i, m := range merges {
+		var pair [2]string
+		var s string
+		if err := json.Unmarshal(m, &s); err == nil {
+			a, b, ok := strings.Cut(s, " ")
+			if !ok {
+				return nil, fmt.Errorf("invalid merge %q", s) //nolint:goerr113
+			}
+			pair = [2]string{a, b}
+		} else if err := json.Unmarshal(m, &pair); err != nil {
+			return nil, fmt.Errorf("parse merges: %w", err)
+		}
+		ranks[pair] = i
+	}
+	return ranks, nil
+}
+
+func (t *HuggingFaceTokenizer) addNormalizer(c *hfComponent) error {
+	if c == nil {
+		return nil
+	}
+	switch c.Type {
+	case "Sequence":
+		for _, n := range c.Normalizers {
+			if err := t.addNormalizer(n); err != nil {
+				return err
+			}
+		}
+	case "Prepend":
+		t.normalize = append(t.normalize, func(s string) string {
+			if s == "" {
+				return s
+			}
+			return c.Prepend + s
+		})
+	case "Replace":
+		replace, err := c.replacer()
+		if err != nil {
+			return err
+		}
+		t.normalize = append(t.normalize, replace)
+	case "Lowercase":
+		t.normalize = append(t.normalize, strings.ToLower)
+	case "NFC":
+		t.normalize = append(t.normalize, norm.NFC.String)
+	case "NFD":
+		t.normalize = append(t.normalize, norm.NFD.String)
+	case "NFKC":
+		t.normalize = append(t.normalize, norm.NFKC.String)
+	case "NFKD":
+		t.normalize = append(t.normalize, norm.NFKD.String)
+	default:
+		return fmt.Errorf("unsupported normalizer %q", c.Type) //nolint:goerr113
+	}
+	return nil
+}
+
+func (t *HuggingFaceTokenizer) addPreTokenizer(c *hfComponent) error { //nolint:cyclop
+	if c == nil {
+		return nil
+	}
+	switch c.Type {
+	case "Sequence":
+		for _, p := range c.PreTokenizers {
+			if err := t.addPreTokenizer(p); err != nil {
+				return err
+			}
+		}
+	case "ByteLevel":
+		addPrefixSpace := c.AddPrefixSpace != nil && *c.AddPrefixSpace
+		useRegex := c.UseRegex == nil || *c.UseRegex
+		t.preTok = append(t.preTok, func(pieces []string) []string {
+			if addPrefixSpace && len(pieces) > 0 && !strings.HasPrefix(pieces[0], " ") {
+				pieces[0] = " " + pieces[0]
+			}
+			if useRegex {
+				pieces = splitPieces(pieces, _gpt2Pattern, "Isolated", false)
+			}
+			for i, p := range pieces {
+				pieces[i] = bytesToUnicode(p)
+			}
+			return pieces
+		})
+	case "Split":
+		re, err := c.regexp()
+		if err != nil {
+			return err
+		}
+		switch c.Behavior {
+		case "Isolated", "Removed", "MergedWithPrevious", "MergedWithNext":
+		default:
+			return fmt.Errorf("unsupported split behavior %q", c.Behavior) //nolint:goerr113
+		}
+		t.preTok = append(t.preTok, func(pieces []string) []string {
+			return splitPieces(pieces, re, c.Behavior, c.Invert)
+		})
+	case "Metaspace":
+		prepend := c.PrependScheme != "never" && (c.AddPrefixSpace == nil || *c.AddPrefixSpace)
+		split := c.Split == nil || *c.Split
+		re := regexp2.MustCompile(regexp2.Escape(c.Replacement), 0)
+		t.preTok = append(t.preTok, func(pieces []string) []string {
+			for i, p := range pieces {
+				p = strings.ReplaceAll(p, " ", c.Replacement)
+				if prepend && !strings.HasPrefix(p, c.Replacement) && (i == 0 || c.PrependScheme != "first") {
+					p = c.Replacement + p
+				}
+				pieces[i] = p
+			}
+			if split {
+				pieces = splitPieces(pieces, re, "MergedWithNext", false)
+			}
+			return pieces
+		})
+	case "Whitespace":
+		t.preTok = append(t.preTok, func(pieces []string) []string {
+			return splitPieces(pieces, _whitespacePattern, "Removed", true)
+		})
+	case "Digits":
+		t.preTok = append(t.preTok, func(pieces []string) []string {
+			return splitPieces(pieces, _digitsPattern, "Isolated", false)
+		})
+	default:
+		return fmt.Errorf("unsupported pre-tokenizer %q", c.Type) //nolint:goerr113
+	}
+	return nil
+}
+
+func (t *HuggingFaceTokenizer) addDecoder(c *hfComponent) error {
+	if c == nil {
+		return nil
+	}
+	switch c.Type {
+	case "Sequence":
+		for _, d := range c.Decoders {
+			if err := t.addDecoder(d); err != nil {
+				return err
+			}
+		}
+	case "ByteLevel":
+		t.decode = append(t.decode, func(tokens []string) []string {
+			return []string{unicodeToBytes(strings.Join(tokens, ""))}
+		})
+	case "Metaspace":
+		prepend := c.PrependScheme != "never" && (c.AddPrefixSpace == nil || *c.AddPrefixSpace)
+		t.decode = append(t.decode, func(tokens []string) []string {
+			for i, tok := range tokens {
+				tok = strings.ReplaceAll(tok, c.Replacement, " ")
+				if i == 0 && prepend {
+					tok = strings.TrimPrefix(tok, " ")
+				}
+				tokens[i] = tok
+			}
+			return tokens
+		})
+	case "Replace":
+		replace, err := c.replacer()
+		if err != nil {
+			return err
+		}
+		t.decode = append(t.decode, func(tokens []string) []string {
+			for i, tok := range tokens {
+				tokens[i] = replace(tok)
+			}
+			return tokens
+		})
+	case "ByteFallback":
+		t.decode = append(t.decode, decodeByteFallback)
+	case "Fuse":
+		t.decode = append(t.decode, func(tokens []string) []string {
+			return []string{strings.Join(tokens, "")}
+		})
+	case "Strip":
+		t.decode = append(t.decode, func(tokens []string) []string {
+			for i, tok := range tokens {
+				for n := 0; n < c.Start && strings.HasPrefix(tok, c.Content); n++ {
+					tok = strings.TrimPrefix(tok, c.Content)
+				}
+				for n := 0; n < c.Stop && strings.HasSuffix(tok, c.Content); n++ {
+					tok = strings.TrimSuffix(tok, c.Content)
+				}
+				tokens[i] = tok
+			}
+			return tokens
+		})
+	default:
+		return fmt.Errorf("unsupported decoder %q", c.Type) //nolint:goerr113
+	}
+	return nil
+}
+
+func (c *hfComponent) replacer() (func(string) string, error) {
+	if c.Pattern.String != nil {
+		old := *c.Pattern.String
+		return func(s string) string { return strings.ReplaceAll(s, old, c.Content) }, nil
+	}
+	re, err := c.regexp()
+	if err != nil {
+		return nil, err
+	}
+	return func(s string) string {
+		out, err := re.Replace(s, c.Content, -1, -1)
+		if err != nil {
+			return s
+		}
+		return out
+	}, nil
+}
+
+func (c *hfComponent) regexp() (*regexp2.Regexp, error) {
+	switch {
+	case c.Pattern.Regex != nil:
+		return regexp2.Compile(*c.Pattern.Regex, regexp2.Unicode)
+	case c.Pattern.String != nil:
+		return regexp2.Compile(regexp2.Escape(*c.Pattern.String), 0)
+	}
+	return nil, fmt.Errorf("%s has no pattern", c.Type) //nolint:goerr113
+}
+
+// Encode implements the Tokenizer interface.
+func (t *HuggingFaceTokenizer) Encode(text string) []int {
+	var ids []int
+	for _, segment := range t.splitAdded(text) {
+		if segment.added {
+			ids = append(ids, t.vocab[segment.text])
+			continue
+		}
+		s := segment.text
+		for _, n := range t.normalize {
+			s = n(s)
+		}
+		pieces := []string{s}
+		for _, p := range t.preTok {
+			pieces = p(pieces)
+		}
+		for _, p := range pieces {
+			ids = t.encodeWord(ids, p)
+		}
+	}
+	return ids
+}
+
+// Decode implements the Tokenizer interface.
+func (t *HuggingFaceTokenizer) Decode(ids []int) string {
+	tokens := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if tok, ok := t.tokens[id]; ok {
+			tokens = append(tokens, tok)
+		}
+	}
+	for _, d := range t.decode {
+		tokens = d(tokens)
+	}
+	return strings.Join(tokens, "")
+}
+
+// Count implements the Tokenizer interface.
+func (t *HuggingFaceTokenizer) Count(text string) int {
+	return len(t.Encode(text))
+}
+
+type hfSegment struct {
+	text  string
+	added bool
+}
+
+// splitAdded splits text around the added tokens it contains.
+func (t *HuggingFaceTokenizer) splitAdded(text string) []hfSegment {
+	var segments []hfSegment
+	start := 0
+	for i := 0; i < len(text); {
+		matched := ""
+		for _, a := range t.added {
+			if a != "" && strings.HasPrefix(text[i:], a) {
+				matched = a
+				break
+			}
+		}
+		if matched == "" {
+			i++
+			continue
+		}
+		if start < i {
+			segments = append(segments, hfSegment{text: text[start:i]})
+		}
+		segments = append(segments, hfSegment{text: matched, added: true})
+		i += len(matched)
+		start = i
+	}
+	if start < len(text) {
+		segments = append(segments, hfSegment{text: text[start:]})
+	}
+	return segments
+}
+
+// encodeWord appends the IDs of the tokens of a pre-tokenized word to ids.
+func (t *HuggingFaceTokenizer) encodeWord(ids []int, word string) []int {
+	if word == "" {
+		return ids
+	}
+	if id, ok := t.vocab[word]; ok && t.ignoreMerges {
+		return append(ids, id)
+	}
+
+	symbols := make([]string, 0, utf8.RuneCountInString(word))
+	for _, r := range word {
+		symbols = append(symbols, string(r))
+	}
+	for len(symbols) > 1 {
+		best, bestRank := -1, math.MaxInt
+		for i := 0; i < len(symbols)-1; i++ {
+			if rank, ok := t.ranks[[2]string{symbols[i], symbols[i+1]}]; ok && rank < bestRank {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		symbols[best] += symbols[best+1]
+		symbols = append(symbols[:best+1], symbols[best+2:]...)
+	}
+
+	for _, s := range symbols {
+		if id, ok := t.vocab[s]; ok {
+			ids = append(ids, id)
+			continue
+		}
+		if t.byteFallback {
+			for _, b := range []byte(s) {
+				if id, ok := t.vocab[fmt.Sprintf("<0x%02X>", b)]; ok {
+					ids = append(ids, id)
+				}
+			}
+			continue
+		}
+		if id, ok := t.vocab[t.unknown]; ok && t.unknown != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// nolint:gochecknoglobals
+var (
+	_gpt2Pattern = regexp2.MustCompile(
+		`'s|'t|'re|'ve|'m|'ll|'d| ?\p{L}+| ?\p{N}+| ?[^\s\p{L}\p{N}]+|\s+(?!\S)|\s+`, regexp2.Unicode)
+	_whitespacePattern = regexp2.MustCompile(`\w+|[^\w\s]+`, regexp2.Unicode)
+	_digitsPattern     = regexp2.MustCompile(`\p{N}+`, regexp2.Unicode)
+)
+
+// splitPieces splits each piece around the matches of re, keeping the
+// matches as pieces of their own or merged with their neighbors, or removing
+// them, according to behavior. If invert is set, the text between matches is
+// what is kept, merged or removed instead.
+func splitPieces(pieces []string, re *regexp2.Regexp, behavior string, invert bool) []string {
+	var out []string
+	for _, p := range pieces {
+		out = append(out, splitPiece(p, re, behavior, invert)...)
+	}
+	return out
+}
+
+func splitPiece(piece string, re *regexp2.Regexp, behavior string, invert bool) []string {
+	type span struct {
+		text    string
+		matched bool
+	}
+	var spans []span
+	runes := []rune(piece)
+	last := 0
+	m, _ := re.FindStringMatch(piece)
+	for m != nil {
+		if m.Index > last {
+			spans = append(spans, span{text: string(runes[last:m.Index])})
+		}
+		if m.Length > 0 {
+			spans = append(spans, span{text: m.String(), matched: true})
+		}
+		last = m.Index + m.Length
+		m, _ = re.FindNextMatch(m)
+	}
+	if last < len(runes) {
+		spans = append(spans, span{text: string(runes[last:])})
+	}
+
+	var out []string
+	for i, s := range spans {
+		isDelimiter := s.matched != invert
+		switch {
+		case !isDelimiter || behavior == "Isolated":
+			out = append(out, s.text)
+		case behavior == "Removed":
+		case behavior == "MergedWithPrevious" && len(out) > 0 && i > 0:
+			out[len(out)-1] += s.text
+		case behavior == "MergedWithNext" && i+1 < len(spans):
+			spans[i+1].text = s.text + spans[i+1].text
+		default:
+			out = append(out, s.text)
+		}
+	}
+	return out
+}
+
+// decodeByteFallback replaces the runs of byte tokens, such as "<0xE2>", with
+// the text they encode.
+func decodeByteFallback(tokens []string) []string {
+	var out []string
+	var bytes []byte
+	flush := func() {
+		if len(bytes) == 0 {
+			return
+		}
+		out = append(out, strings.ToValidUTF8(string(bytes), "�"))
+		bytes = nil
+	}
+	for _, tok := range tokens {
+		if len(tok) == 6 && strings.HasPrefix(tok, "<0x") && strings.HasSuffix(tok, ">") {
+			if b, err := strconv.ParseUint(tok[3:5], 16, 8); err == nil {
+				bytes = append(bytes, byte(b))
+				continue
+			}
+		}
+		flush()
+		out = append(out, tok)
+	}
+	flush()
+	return out
+}
+
+// The byte-level pre-tokenizer maps each byte to a printable rune, so that
+// vocabularies only hold printable text.
+//
+// nolint:gochecknoglobals
+var _byteToRune, _runeToByte = byteLevelAlphabet()
+
+func byteLevelAlphabet() ([256]rune, map[rune]byte) {
+	var byteToRune [256]rune
+	runeToByte := make(map[rune]byte, 256) //nolint:gomnd
+	n := 0
+	for b := 0; b < 256; b++ {
+		printable := (b >= '!' && b <= '~') || (b >= 0xA1 && b <= 0xAC) || (b >= 0xAE && b <= 0xFF)
+		r := rune(b)
+		if !printable {
+			r = rune(256 + n)
+			n++
+		}
+		byteToRune[b] = r
+		runeToByte[r] = byte(b)
+	}
+	return byteToRune, runeToByte
+}
+
+func bytesToUnicode(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		sb.WriteRune(_byteToRune[s[i]])
+	}
+	return sb.String()
+}
+
+func unicodeToBytes(s string) string {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if c, ok := _runeToByte[r]; ok {
+			b = append(b, c)
+		}
+	}
+	return strings.ToValidUTF8(string(b), "�")
+}
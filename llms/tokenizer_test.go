@@ -0,0 +1,102 @@
+package llms
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHuggingFaceTokenizerByteLevel(t *testing.T) {
+	t.Parallel()
+
+	tk, err := LoadHuggingFaceTokenizer(filepath.Join("testdata", "tokenizer_bytelevel.json"))
+	require.NoError(t, err)
+
+	ids := tk.Encode("hello world!<|endoftext|>")
+	assert.Equal(t, []int{12, 17, 0, 100}, ids)
+	assert.Equal(t, "hello world!<|endoftext|>", tk.Decode(ids))
+	assert.Equal(t, 4, tk.Count("hello world!<|endoftext|>"))
+
+	// Characters outside the vocabulary are dropped without an unknown token.
+	assert.Equal(t, []int{9}, tk.Encode("hez"))
+}
+
+func TestHuggingFaceTokenizerMetaspace(t *testing.T) {
+	t.Parallel()
+
+	tk, err := LoadHuggingFaceTokenizer(filepath.Join("testdata", "tokenizer_metaspace.json"))
+	require.NoError(t, err)
+
+	ids := tk.Encode("hi there")
+	assert.Equal(t, []int{12, 17}, ids)
+	assert.Equal(t, "hi there", tk.Decode(ids))
+
+	// Unknown characters fall back to their bytes.
+	ids = tk.Encode("hi é</s>")
+	assert.Equal(t, []int{12, 5, 3, 4, 2}, ids)
+	assert.Equal(t, "hi é</s>", tk.Decode(ids))
+}
+
+func TestHuggingFaceTokenizerErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewHuggingFaceTokenizer([]byte(`{"model": {"type": "Unigram"}}`))
+	require.ErrorContains(t, err, "Unigram")
+
+	_, err = NewHuggingFaceTokenizer([]byte(`{"model": {"type": "BPE"}, "pre_tokenizer": {"type": "Magic"}}`))
+	require.ErrorContains(t, err, "Magic")
+
+	_, err = LoadHuggingFaceTokenizer(filepath.Join("testdata", "missing.json"))
+	require.Error(t, err)
+}
+
+type wordTokenizer struct{}
+
+func (wordTokenizer) Encode(string) []int   { return nil }
+func (wordTokenizer) Decode([]int) string   { return "" }
+func (wordTokenizer) Count(text string) int { return len(text) }
+
+func TestTokenizerForModel(t *testing.T) {
+	t.Parallel()
+
+	RegisterTokenizer("test-words", wordTokenizer{})
+	tk, err := TokenizerForModel("test-words:latest")
+	require.NoError(t, err)
+	assert.Equal(t, wordTokenizer{}, tk)
+	assert.Equal(t, 5, CountTokens("test-words", "hello"))
+
+	RegisterModels(ModelInfo{
+		Provider:      "test",
+		Name:          "test-llama",
+		ContextWindow: 4096,
+		TokenizerFile: filepath.Join("testdata", "tokenizer_metaspace.json"),
+	})
+	tk, err = TokenizerForModel("test-llama-7b")
+	require.NoError(t, err)
+	require.IsType(t, &HuggingFaceTokenizer{}, tk)
+	assert.Equal(t, 2, CountTokens("test-llama", "hi there"))
+
+	// Concurrent calls share the tokenizer loaded from the file.
+	var wg sync.WaitGroup
+	loaded := make([]Tokenizer, 8)
+	for i := range loaded {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			loaded[i], _ = TokenizerForModel("test-llama")
+		}(i)
+	}
+	wg.Wait()
+	for _, l := range loaded {
+		assert.Same(t, tk, l)
+	}
+
+	// Known models without a tokenizer are counted approximately.
+	RegisterModels(ModelInfo{Provider: "test", Name: "test-opaque"})
+	_, err = TokenizerForModel("test-opaque")
+	require.Error(t, err)
+	assert.Equal(t, 2, CountTokens("test-opaque", "12345678"))
+}
@@ -0,0 +1,52 @@
+package llms
+
+// Usage is the number of tokens used by a call.
+type Usage struct {
+	// PromptTokens is the number of tokens in the input.
+	PromptTokens int `json:"prompt_tokens"`
+	// CompletionTokens is the number of tokens generated.
+	CompletionTokens int `json:"completion_tokens"`
+	// TotalTokens is the total number of tokens used.
+	TotalTokens int `json:"total_tokens"`
+}
+
+// NewUsage returns the usage of a call that consumed the given number of
+// prompt and completion tokens.
+func NewUsage(promptTokens, completionTokens int) *Usage {
+	return &Usage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      promptTokens + completionTokens,
+	}
+}
+
+// EstimateUsage returns the usage of a call estimated by counting the tokens
+// of its prompt and completion with CountTokens. It is meant for models that
+// don't report usage themselves.
+func EstimateUsage(model, prompt, completion string) *Usage {
+	return NewUsage(CountTokens(model, prompt), CountTokens(model, completion))
+}
+
+// Add adds other to u. A nil other is ignored.
+func (u *Usage) Add(other *Usage) {
+	if other == nil {
+		return
+	}
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+}
+
+// Price is the price of using a model, in an arbitrary currency per million
+// tokens.
+type Price struct {
+	// Prompt is the price of a million prompt tokens.
+	Prompt float64 `json:"prompt" yaml:"prompt"`
+	// Completion is the price of a million completion tokens.
+	Completion float64 `json:"completion" yaml:"completion"`
+}
+
+// Cost returns the estimated cost of the usage at the given price.
+func (u *Usage) Cost(p Price) float64 {
+	return (float64(u.PromptTokens)*p.Prompt + float64(u.CompletionTokens)*p.Completion) / 1e6 //nolint:gomnd
+}
@@ -12,6 +12,13 @@ type ConversationTokenBuffer struct {
 	ConversationBuffer
 	LLM           llms.Model
 	MaxTokenLimit int
+	// ModelName is the name of the model the buffer is used with. It is used
+	// to count tokens and, if MaxTokenLimit is 0, to look up the context size
+	// of the model in the model registry.
+	ModelName string
+	// Tokenizer counts the tokens of the buffer. If nil, the tokenizer of the
+	// model named ModelName is used.
+	Tokenizer llms.Tokenizer
 }
 
 // Statically assert that ConversationTokenBuffer implement the memory interface.
@@ -57,10 +64,11 @@ func (tb *ConversationTokenBuffer) SaveContext(
 		return err
 	}
 
-	if currBufferLength > tb.MaxTokenLimit {
-		// while currBufferLength is greater than MaxTokenLimit we keep removing messages from the memory
+	maxTokenLimit := tb.maxTokenLimit()
+	if currBufferLength > maxTokenLimit {
+		// while currBufferLength is greater than maxTokenLimit we keep removing messages from the memory
 		// from the oldest
-		for currBufferLength > tb.MaxTokenLimit {
+		for currBufferLength > maxTokenLimit {
 			messages, err := tb.ChatHistory.Messages(ctx)
 			if err != nil {
 				return err
@@ -105,5 +113,15 @@ func (tb *ConversationTokenBuffer) getNumTokensFromMessages(ctx context.Context)
 		return 0, err
 	}
 
-	return llms.CountTokens("", bufferString), nil
+	if tb.Tokenizer != nil {
+		return tb.Tokenizer.Count(bufferString), nil
+	}
+	return llms.CountTokens(tb.ModelName, bufferString), nil
+}
+
+func (tb *ConversationTokenBuffer) maxTokenLimit() int {
+	if tb.MaxTokenLimit > 0 {
+		return tb.MaxTokenLimit
+	}
+	return llms.GetModelContextSize(tb.ModelName)
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/llms/openai"
 	"github.com/tmc/langchaingo/schema"
 )
@@ -94,3 +95,16 @@ func TestTokenBufferMemoryWithPreLoadedHistory(t *testing.T) {
 	expected := map[string]any{"history": "Human: bar\nAI: foo"}
 	assert.Equal(t, expected, result)
 }
+
+func TestTokenBufferMemoryModelLimit(t *testing.T) {
+	t.Parallel()
+
+	m := NewConversationTokenBuffer(nil, 0)
+	assert.Equal(t, llms.GetModelContextSize(""), m.maxTokenLimit())
+
+	m.ModelName = "gpt-4-0613"
+	assert.Equal(t, 8192, m.maxTokenLimit())
+
+	m.MaxTokenLimit = 100
+	assert.Equal(t, 100, m.maxTokenLimit())
+}
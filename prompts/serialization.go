@@ -0,0 +1,295 @@
+package prompts
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The types of the prompts in a PromptConfig.
+const (
+	PromptTypeTemplate = "prompt"
+	PromptTypeChat     = "chat"
+	PromptTypeFewShot  = "few_shot"
+)
+
+// The types of the messages of a chat prompt in a MessageConfig.
+const (
+	MessageTypeSystem      = "system"
+	MessageTypeHuman       = "human"
+	MessageTypeAI          = "ai"
+	MessageTypeGeneric     = "generic"
+	MessageTypePlaceholder = "placeholder"
+)
+
+var (
+	// ErrPromptNotSerializable is returned when saving a prompt that has parts
+	// that can't be serialized, such as an output parser, an example selector
+	// or a partial variable that is a function.
+	ErrPromptNotSerializable = errors.New("prompt is not serializable")
+	// ErrUnknownPromptType is returned when loading a prompt or a message of an
+	// unknown type.
+	ErrUnknownPromptType = errors.New("unknown prompt type")
+)
+
+// PromptConfig is the serializable description of a PromptTemplate, a
+// ChatPromptTemplate or a FewShotPrompt. The fields used depend on the type of
+// the prompt.
+type PromptConfig struct {
+	// Type is the type of the prompt: "prompt", "chat" or "few_shot". It
+	// defaults to "prompt".
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+
+	Template       string         `json:"template,omitempty" yaml:"template,omitempty"`
+	InputVariables []string       `json:"input_variables,omitempty" yaml:"input_variables,omitempty"`
+	TemplateFormat TemplateFormat `json:"template_format,omitempty" yaml:"template_format,omitempty"`
+	// PartialVariables are the partial variables of the prompt. Only string
+	// values can be serialized.
+	PartialVariables map[string]string `json:"partial_variables,omitempty" yaml:"partial_variables,omitempty"`
+
+	// Messages are the messages of a chat prompt.
+	Messages []MessageConfig `json:"messages,omitempty" yaml:"messages,omitempty"`
+
+	// ExamplePrompt, Examples, Prefix, Suffix, ExampleSeparator and
+	// ValidateTemplate are the fields of a few-shot prompt.
+	ExamplePrompt    *PromptConfig       `json:"example_prompt,omitempty" yaml:"example_prompt,omitempty"`
+	Examples         []map[string]string `json:"examples,omitempty" yaml:"examples,omitempty"`
+	Prefix           string              `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+	Suffix           string              `json:"suffix,omitempty" yaml:"suffix,omitempty"`
+	ExampleSeparator string              `json:"example_separator,omitempty" yaml:"example_separator,omitempty"`
+	ValidateTemplate bool                `json:"validate_template,omitempty" yaml:"validate_template,omitempty"`
+}
+
+// MessageConfig is the serializable description of a message of a chat prompt.
+type MessageConfig struct {
+	// Type is the type of the message: "system", "human", "ai", "generic" or
+	// "placeholder".
+	Type string `json:"type" yaml:"type"`
+	// Role is the role of a generic message.
+	Role             string            `json:"role,omitempty" yaml:"role,omitempty"`
+	Template         string            `json:"template,omitempty" yaml:"template,omitempty"`
+	InputVariables   []string          `json:"input_variables,omitempty" yaml:"input_variables,omitempty"`
+	TemplateFormat   TemplateFormat    `json:"template_format,omitempty" yaml:"template_format,omitempty"`
+	PartialVariables map[string]string `json:"partial_variables,omitempty" yaml:"partial_variables,omitempty"`
+	// VariableName is the variable holding the messages of a placeholder.
+	VariableName string `json:"variable_name,omitempty" yaml:"variable_name,omitempty"`
+}
+
+// SavePrompt returns the config of a PromptTemplate, a ChatPromptTemplate or a
+// FewShotPrompt.
+func SavePrompt(prompt FormatPrompter) (*PromptConfig, error) {
+	switch p := prompt.(type) {
+	case PromptTemplate:
+		return saveTemplate(p)
+	case *PromptTemplate:
+		return saveTemplate(*p)
+	case ChatPromptTemplate:
+		return saveChatPrompt(p)
+	case *ChatPromptTemplate:
+		return saveChatPrompt(*p)
+	case *FewShotPrompt:
+		return saveFewShotPrompt(p)
+	default:
+		return nil, fmt.Errorf("%w: unsupported prompt type %T", ErrPromptNotSerializable, prompt)
+	}
+}
+
+func saveTemplate(p PromptTemplate) (*PromptConfig, error) {
+	if p.OutputParser != nil {
+		return nil, fmt.Errorf("%w: prompt has an output parser", ErrPromptNotSerializable)
+	}
+	partials, err := savePartialVariables(p.PartialVariables)
+	if err != nil {
+		return nil, err
+	}
+	return &PromptConfig{
+		Type:             PromptTypeTemplate,
+		Template:         p.Template,
+		InputVariables:   p.InputVariables,
+		TemplateFormat:   p.TemplateFormat,
+		PartialVariables: partials,
+	}, nil
+}
+
+func saveChatPrompt(p ChatPromptTemplate) (*PromptConfig, error) {
+	partials, err := savePartialVariables(p.PartialVariables)
+	if err != nil {
+		return nil, err
+	}
+	config := &PromptConfig{
+		Type:             PromptTypeChat,
+		PartialVariables: partials,
+		Messages:         make([]MessageConfig, 0, len(p.Messages)),
+	}
+	for _, m := range p.Messages {
+		message, err := saveMessage(m)
+		if err != nil {
+			return nil, err
+		}
+		config.Messages = append(config.Messages, message)
+	}
+	return config, nil
+}
+
+func saveMessage(m MessageFormatter) (MessageConfig, error) {
+	var (
+		message MessageConfig
+		prompt  PromptTemplate
+	)
+	switch m := m.(type) {
+	case SystemMessagePromptTemplate:
+		message.Type, prompt = MessageTypeSystem, m.Prompt
+	case HumanMessagePromptTemplate:
+		message.Type, prompt = MessageTypeHuman, m.Prompt
+	case AIMessagePromptTemplate:
+		message.Type, prompt = MessageTypeAI, m.Prompt
+	case GenericMessagePromptTemplate:
+		message.Type, message.Role, prompt = MessageTypeGeneric, m.Role, m.Prompt
+	case MessagesPlaceholder:
+		return MessageConfig{Type: MessageTypePlaceholder, VariableName: m.VariableName}, nil
+	default:
+		return message, fmt.Errorf("%w: unsupported message type %T", ErrPromptNotSerializable, m)
+	}
+
+	config, err := saveTemplate(prompt)
+	if err != nil {
+		return message, err
+	}
+	message.Template = config.Template
+	message.InputVariables = config.InputVariables
+	message.TemplateFormat = config.TemplateFormat
+	message.PartialVariables = config.PartialVariables
+	return message, nil
+}
+
+func saveFewShotPrompt(p *FewShotPrompt) (*PromptConfig, error) {
+	if p.ExampleSelector != nil {
+		return nil, fmt.Errorf("%w: prompt has an example selector", ErrPromptNotSerializable)
+	}
+	examplePrompt, err := saveTemplate(p.ExamplePrompt)
+	if err != nil {
+		return nil, err
+	}
+	partials, err := savePartialVariables(p.PartialVariables)
+	if err != nil {
+		return nil, err
+	}
+	return &PromptConfig{
+		Type:             PromptTypeFewShot,
+		InputVariables:   p.InputVariables,
+		TemplateFormat:   p.TemplateFormat,
+		PartialVariables: partials,
+		ExamplePrompt:    examplePrompt,
+		Examples:         p.Examples,
+		Prefix:           p.Prefix,
+		Suffix:           p.Suffix,
+		ExampleSeparator: p.ExampleSeparator,
+		ValidateTemplate: p.ValidateTemplate,
+	}, nil
+}
+
+func savePartialVariables(partials map[string]any) (map[string]string, error) {
+	if len(partials) == 0 {
+		return nil, nil
+	}
+	saved := make(map[string]string, len(partials))
+	for name, value := range partials {
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: partial variable %s is a %T", ErrPromptNotSerializable, name, value)
+		}
+		saved[name] = s
+	}
+	return saved, nil
+}
+
+// LoadPrompt returns the prompt described by a config.
+func LoadPrompt(config *PromptConfig) (FormatPrompter, error) { //nolint:ireturn
+	switch config.Type {
+	case PromptTypeTemplate, "":
+		return loadTemplate(config.Template, config.InputVariables, config.TemplateFormat, config.PartialVariables)
+	case PromptTypeChat:
+		return loadChatPrompt(config)
+	case PromptTypeFewShot:
+		return loadFewShotPrompt(config)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownPromptType, config.Type)
+	}
+}
+
+func loadTemplate(
+	template string, inputVariables []string, format TemplateFormat, partials map[string]string,
+) (PromptTemplate, error) {
+	prompt := NewPromptTemplate(template, inputVariables)
+	if format != "" {
+		if _, ok := defaultFormatterMapping[format]; !ok {
+			return prompt, newInvalidTemplateError(format)
+		}
+		prompt.TemplateFormat = format
+	}
+	prompt.PartialVariables = loadPartialVariables(partials)
+	return prompt, nil
+}
+
+func loadChatPrompt(config *PromptConfig) (ChatPromptTemplate, error) {
+	messages := make([]MessageFormatter, 0, len(config.Messages))
+	for _, m := range config.Messages {
+		if m.Type == MessageTypePlaceholder {
+			messages = append(messages, MessagesPlaceholder{VariableName: m.VariableName})
+			continue
+		}
+		prompt, err := loadTemplate(m.Template, m.InputVariables, m.TemplateFormat, m.PartialVariables)
+		if err != nil {
+			return ChatPromptTemplate{}, err
+		}
+		switch m.Type {
+		case MessageTypeSystem:
+			messages = append(messages, SystemMessagePromptTemplate{Prompt: prompt})
+		case MessageTypeHuman:
+			messages = append(messages, HumanMessagePromptTemplate{Prompt: prompt})
+		case MessageTypeAI:
+			messages = append(messages, AIMessagePromptTemplate{Prompt: prompt})
+		case MessageTypeGeneric:
+			messages = append(messages, GenericMessagePromptTemplate{Prompt: prompt, Role: m.Role})
+		default:
+			return ChatPromptTemplate{}, fmt.Errorf("%w: message type %q", ErrUnknownPromptType, m.Type)
+		}
+	}
+	prompt := NewChatPromptTemplate(messages)
+	prompt.PartialVariables = loadPartialVariables(config.PartialVariables)
+	return prompt, nil
+}
+
+func loadFewShotPrompt(config *PromptConfig) (*FewShotPrompt, error) {
+	if config.ExamplePrompt == nil {
+		return nil, fmt.Errorf("few-shot prompt has no example prompt") //nolint:goerr113
+	}
+	examplePrompt, err := loadTemplate(config.ExamplePrompt.Template, config.ExamplePrompt.InputVariables,
+		config.ExamplePrompt.TemplateFormat, config.ExamplePrompt.PartialVariables)
+	if err != nil {
+		return nil, err
+	}
+	format := config.TemplateFormat
+	if format == "" {
+		format = TemplateFormatGoTemplate
+	}
+	if _, ok := defaultFormatterMapping[format]; !ok {
+		return nil, newInvalidTemplateError(format)
+	}
+	examples := config.Examples
+	if examples == nil {
+		examples = []map[string]string{}
+	}
+	return NewFewShotPrompt(examplePrompt, examples, nil, config.Prefix, config.Suffix, config.InputVariables,
+		loadPartialVariables(config.PartialVariables), config.ExampleSeparator, format, config.ValidateTemplate)
+}
+
+func loadPartialVariables(partials map[string]string) map[string]any {
+	if len(partials) == 0 {
+		return nil
+	}
+	loaded := make(map[string]any, len(partials))
+	for name, value := range partials {
+		loaded[name] = value
+	}
+	return loaded
+}
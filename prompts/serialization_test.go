@@ -0,0 +1,87 @@
+package prompts
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/schema"
+)
+
+func TestSerializeChatPrompt(t *testing.T) {
+	t.Parallel()
+
+	prompt := NewChatPromptTemplate([]MessageFormatter{
+		NewSystemMessagePromptTemplate("You are {{.name}}.", []string{"name"}),
+		MessagesPlaceholder{VariableName: "history"},
+		NewGenericMessagePromptTemplate("tool", "{{.result}}", []string{"result"}),
+		NewAIMessagePromptTemplate("Thinking", nil),
+		NewHumanMessagePromptTemplate("{{.question}}", []string{"question"}),
+	})
+	prompt.PartialVariables = map[string]any{"name": "a helpful assistant"}
+
+	config, err := SavePrompt(prompt)
+	require.NoError(t, err)
+	assert.Equal(t, PromptTypeChat, config.Type)
+	require.Len(t, config.Messages, 5)
+	assert.Equal(t, MessageConfig{Type: MessageTypePlaceholder, VariableName: "history"}, config.Messages[1])
+	assert.Equal(t, "tool", config.Messages[2].Role)
+
+	loaded, err := LoadPrompt(config)
+	require.NoError(t, err)
+	assert.Equal(t, prompt, loaded)
+
+	value, err := loaded.FormatPrompt(map[string]any{
+		"history":  []schema.ChatMessage{schema.HumanChatMessage{Content: "Hi"}},
+		"result":   "42",
+		"question": "What?",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "System: You are a helpful assistant.\nHuman: Hi\ntool: 42\nAI: Thinking\nHuman: What?",
+		value.String())
+}
+
+func TestSerializeFewShotPrompt(t *testing.T) {
+	t.Parallel()
+
+	prompt, err := NewFewShotPrompt(
+		NewPromptTemplate("{{.word}}: {{.antonym}}", []string{"word", "antonym"}),
+		[]map[string]string{{"word": "happy", "antonym": "sad"}, {"word": "tall", "antonym": "short"}},
+		nil, "Give the antonym of every word.", "{{.input}}:", []string{"input"}, nil, "\n",
+		TemplateFormatGoTemplate, true)
+	require.NoError(t, err)
+
+	config, err := SavePrompt(prompt)
+	require.NoError(t, err)
+	assert.Equal(t, PromptTypeFewShot, config.Type)
+	assert.Equal(t, PromptTypeTemplate, config.ExamplePrompt.Type)
+
+	loaded, err := LoadPrompt(config)
+	require.NoError(t, err)
+	assert.Equal(t, prompt, loaded)
+
+	text, err := loaded.(*FewShotPrompt).Format(map[string]any{"input": "big"})
+	require.NoError(t, err)
+	assert.Equal(t, "Give the antonym of every word.\nhappy: sad\ntall: short\nbig:", text)
+}
+
+func TestSerializePromptErrors(t *testing.T) {
+	t.Parallel()
+
+	prompt := NewPromptTemplate("{{.date}}", nil)
+	prompt.PartialVariables = map[string]any{"date": func() string { return "today" }}
+	_, err := SavePrompt(prompt)
+	require.ErrorIs(t, err, ErrPromptNotSerializable)
+
+	_, err = SavePrompt(NewChatPromptTemplate([]MessageFormatter{NewChatPromptTemplate(nil)}))
+	require.ErrorIs(t, err, ErrPromptNotSerializable)
+
+	_, err = LoadPrompt(&PromptConfig{Type: "jinja"})
+	require.ErrorIs(t, err, ErrUnknownPromptType)
+
+	_, err = LoadPrompt(&PromptConfig{Type: PromptTypeChat, Messages: []MessageConfig{{Type: "user"}}})
+	require.ErrorIs(t, err, ErrUnknownPromptType)
+
+	_, err = LoadPrompt(&PromptConfig{Template: "{input}", TemplateFormat: "mustache"})
+	require.ErrorIs(t, err, ErrInvalidTemplateFormat)
+}
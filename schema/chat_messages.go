@@ -24,6 +24,8 @@ const (
 	ChatMessageTypeGeneric ChatMessageType = "generic"
 	// ChatMessageTypeFunction is a message sent by a function.
 	ChatMessageTypeFunction ChatMessageType = "function"
+	// ChatMessageTypeTool is a message sent by a tool.
+	ChatMessageTypeTool ChatMessageType = "tool"
 )
 
 // ChatMessage represents a message in a chat.
@@ -46,6 +48,7 @@ var (
 	_ ChatMessage = SystemChatMessage{}
 	_ ChatMessage = GenericChatMessage{}
 	_ ChatMessage = FunctionChatMessage{}
+	_ ChatMessage = ToolChatMessage{}
 )
 
 // AIChatMessage is a message sent by an AI.
@@ -55,11 +58,15 @@ type AIChatMessage struct {
 
 	// FunctionCall represents the model choosing to call a function.
 	FunctionCall *FunctionCall `json:"function_call,omitempty"`
+
+	// ToolCalls represents the model choosing to call tools.
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
 }
 
 func (m AIChatMessage) GetType() ChatMessageType       { return ChatMessageTypeAI }
 func (m AIChatMessage) GetContent() string             { return m.Content }
 func (m AIChatMessage) GetFunctionCall() *FunctionCall { return m.FunctionCall }
+func (m AIChatMessage) GetToolCalls() []ToolCall       { return m.ToolCalls }
 
 // HumanChatMessage is a message sent by a human.
 type HumanChatMessage struct {
@@ -104,6 +111,29 @@ func (m FunctionChatMessage) GetType() ChatMessageType { return ChatMessageTypeF
 func (m FunctionChatMessage) GetContent() string       { return m.Content }
 func (m FunctionChatMessage) GetName() string          { return m.Name }
 
+// ToolCall is a call to a tool requested by the model.
+type ToolCall struct {
+	// ID is the unique identifier of the tool call.
+	ID string `json:"id"`
+	// Type is the type of the tool call, typically "function".
+	Type string `json:"type"`
+	// FunctionCall is the function call to be executed.
+	FunctionCall *FunctionCall `json:"function,omitempty"`
+}
+
+// ToolChatMessage is a chat message representing the result of a tool call.
+type ToolChatMessage struct {
+	// ID is the ID of the tool call this message is a response to.
+	ID string `json:"tool_call_id"`
+	// Name is the name of the tool that was called.
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+func (m ToolChatMessage) GetType() ChatMessageType { return ChatMessageTypeTool }
+func (m ToolChatMessage) GetContent() string       { return m.Content }
+func (m ToolChatMessage) GetName() string          { return m.Name }
+
 // GetBufferString gets the buffer string of messages.
 func GetBufferString(messages []ChatMessage, humanPrefix string, aiPrefix string) (string, error) {
 	result := []string{}
@@ -120,6 +150,13 @@ func GetBufferString(messages []ChatMessage, humanPrefix string, aiPrefix string
 			}
 			msg = fmt.Sprintf("%s %s", msg, string(j))
 		}
+		if m, ok := m.(AIChatMessage); ok && len(m.ToolCalls) > 0 {
+			j, err := json.Marshal(m.ToolCalls)
+			if err != nil {
+				return "", err
+			}
+			msg = fmt.Sprintf("%s %s", msg, string(j))
+		}
 		result = append(result, msg)
 	}
 	return strings.Join(result, "\n"), nil
@@ -142,6 +179,8 @@ func getMessageRole(m ChatMessage, humanPrefix, aiPrefix string) (string, error)
 		role = cgm.Role
 	case ChatMessageTypeFunction:
 		role = "Function"
+	case ChatMessageTypeTool:
+		role = "Tool"
 	default:
 		return "", ErrUnexpectedChatMessageType
 	}
@@ -5,6 +5,9 @@ type AgentAction struct {
 	Tool      string
 	ToolInput string
 	Log       string
+	// ToolID is the ID of the tool call the action originates from, for
+	// agents driven by native tool calling.
+	ToolID string
 }
 
 // AgentStep is a step of the agent.
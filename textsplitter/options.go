@@ -1,5 +1,7 @@
 package textsplitter
 
+import "github.com/tmc/langchaingo/llms"
+
 // Options is a struct that contains options for a text splitter.
 type Options struct {
 	ChunkSize         int
@@ -9,6 +11,7 @@ type Options struct {
 	EncodingName      string
 	AllowedSpecial    []string
 	DisallowedSpecial []string
+	Tokenizer         llms.Tokenizer
 	SecondSplitter    TextSplitter
 	CodeBlocks        bool
 	ReferenceLinks    bool
@@ -52,7 +55,8 @@ func WithSeparators(separators []string) Option {
 	}
 }
 
-// WithModelName sets the model name for a text splitter.
+// WithModelName sets the model name for a text splitter. The token splitter
+// uses the tokenizer of the model when the encoding name is empty.
 func WithModelName(modelName string) Option {
 	return func(o *Options) {
 		o.ModelName = modelName
@@ -66,6 +70,14 @@ func WithEncodingName(encodingName string) Option {
 	}
 }
 
+// WithTokenizer sets the tokenizer of a token splitter, in place of the
+// tiktoken encoding given by the encoding or model name.
+func WithTokenizer(tokenizer llms.Tokenizer) Option {
+	return func(o *Options) {
+		o.Tokenizer = tokenizer
+	}
+}
+
 // WithAllowedSpecial sets the allowed special tokens for a text splitter.
 func WithAllowedSpecial(allowedSpecial []string) Option {
 	return func(o *Options) {
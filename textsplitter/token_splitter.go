@@ -3,7 +3,7 @@ package textsplitter
 import (
 	"fmt"
 
-	"github.com/pkoukk/tiktoken-go"
+	"github.com/tmc/langchaingo/llms"
 )
 
 const (
@@ -22,6 +22,10 @@ type TokenSplitter struct {
 	EncodingName      string
 	AllowedSpecial    []string
 	DisallowedSpecial []string
+	// Tokenizer is the tokenizer splitting texts. If nil, the tiktoken
+	// encoding named EncodingName, or else the tokenizer of the model named
+	// ModelName, is used.
+	Tokenizer llms.Tokenizer
 }
 
 func NewTokenSplitter(opts ...Option) TokenSplitter {
@@ -37,6 +41,7 @@ func NewTokenSplitter(opts ...Option) TokenSplitter {
 		EncodingName:      options.EncodingName,
 		AllowedSpecial:    options.AllowedSpecial,
 		DisallowedSpecial: options.DisallowedSpecial,
+		Tokenizer:         options.Tokenizer,
 	}
 
 	return s
@@ -45,24 +50,43 @@ func NewTokenSplitter(opts ...Option) TokenSplitter {
 // SplitText splits a text into multiple text.
 func (s TokenSplitter) SplitText(text string) ([]string, error) {
 	// Get the tokenizer
-	var tk *tiktoken.Tiktoken
+	tk := s.Tokenizer
+	if tk == nil {
+		var err error
+		if tk, err = s.tokenizer(); err != nil {
+			return nil, fmt.Errorf("get tokenizer: %w", err)
+		}
+	}
+	texts := s.splitText(text, tk)
+
+	return texts, nil
+}
+
+// tokenizer returns the tokenizer of the encoding or model of the splitter.
+func (s TokenSplitter) tokenizer() (llms.Tokenizer, error) {
+	var tk llms.Tokenizer
 	var err error
 	if s.EncodingName != "" {
-		tk, err = tiktoken.GetEncoding(s.EncodingName)
+		tk, err = llms.NewTiktokenTokenizer(s.EncodingName)
 	} else {
-		tk, err = tiktoken.EncodingForModel(s.ModelName)
+		tk, err = llms.TokenizerForModel(s.ModelName)
 	}
 	if err != nil {
-		return nil, fmt.Errorf("tiktoken.GetEncoding: %w", err)
+		return nil, err
 	}
-	texts := s.splitText(text, tk)
 
-	return texts, nil
+	if t, ok := tk.(*llms.TiktokenTokenizer); ok {
+		t := *t
+		t.AllowedSpecial = s.AllowedSpecial
+		t.DisallowedSpecial = s.DisallowedSpecial
+		return &t, nil
+	}
+	return tk, nil
 }
 
-func (s TokenSplitter) splitText(text string, tk *tiktoken.Tiktoken) []string {
+func (s TokenSplitter) splitText(text string, tk llms.Tokenizer) []string {
 	splits := make([]string, 0)
-	inputIds := tk.Encode(text, s.AllowedSpecial, s.DisallowedSpecial)
+	inputIds := tk.Encode(text)
 
 	startIdx := 0
 	curIdx := len(inputIds)
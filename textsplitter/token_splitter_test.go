@@ -1,6 +1,7 @@
 package textsplitter
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -86,3 +87,43 @@ Bye!
 		assert.Equal(t, tc.expectedDocs, docs)
 	}
 }
+
+// wordTokenizer is a tokenizer whose tokens are the words of a fixed
+// vocabulary.
+type wordTokenizer struct {
+	words []string
+}
+
+func (tk *wordTokenizer) Encode(text string) []int {
+	ids := make([]int, 0)
+	for _, w := range strings.Fields(text) {
+		ids = append(ids, len(tk.words))
+		tk.words = append(tk.words, w)
+	}
+	return ids
+}
+
+func (tk *wordTokenizer) Decode(ids []int) string {
+	words := make([]string, 0, len(ids))
+	for _, id := range ids {
+		words = append(words, tk.words[id])
+	}
+	return strings.Join(words, " ")
+}
+
+func (tk *wordTokenizer) Count(text string) int {
+	return len(strings.Fields(text))
+}
+
+func TestTokenSplitterWithTokenizer(t *testing.T) {
+	t.Parallel()
+
+	splitter := NewTokenSplitter(
+		WithTokenizer(&wordTokenizer{}),
+		WithChunkSize(3),
+		WithChunkOverlap(1),
+	)
+	texts, err := splitter.SplitText("one two three four five six")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"one two three", "three four five", "five six"}, texts)
+}
@@ -16,7 +16,7 @@ func init() {
 	sqldatabase.RegisterEngine(EngineName, NewMySQL)
 }
 
-var _ sqldatabase.Engine = MySQL{}
+var _ sqldatabase.ReadOnlyEngine = MySQL{}
 
 // MySQL is a MySQL engine.
 type MySQL struct {
@@ -74,6 +74,22 @@ func (m MySQL) Query(ctx context.Context, query string, args ...any) ([]string,
 	return cols, results, nil
 }
 
+// QueryReadOnly executes the query in a read-only transaction.
+func (m MySQL) QueryReadOnly(ctx context.Context, query string, args ...any) ([]string, [][]string, error) {
+	tx, err := m.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	return sqldatabase.ScanRows(rows)
+}
+
 func (m MySQL) TableNames(ctx context.Context) ([]string, error) {
 	_, result, err := m.Query(ctx, "SHOW TABLES")
 	if err != nil {
@@ -18,7 +18,7 @@ func init() {
 	sqldatabase.RegisterEngine(EngineName, NewPostgreSQL)
 }
 
-var _ sqldatabase.Engine = PostgreSQL{}
+var _ sqldatabase.ReadOnlyEngine = PostgreSQL{}
 
 // PostgreSQL represents the PostgreSQL engine.
 type PostgreSQL struct {
@@ -81,6 +81,23 @@ func (p PostgreSQL) Query(ctx context.Context, query string, args ...any) ([]str
 	return cols, results, nil
 }
 
+// QueryReadOnly executes a query on the PostgreSQL engine in a read-only
+// transaction.
+func (p PostgreSQL) QueryReadOnly(ctx context.Context, query string, args ...any) ([]string, [][]string, error) {
+	tx, err := p.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	return sqldatabase.ScanRows(rows)
+}
+
 // TableNames returns the names of all tables in the PostgreSQL database.
 // It takes a context.Context.
 // It returns a slice of table names and an error, if any.
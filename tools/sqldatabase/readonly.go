@@ -0,0 +1,249 @@
+package sqldatabase
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrUnsafeQuery is returned when a query isn't a single read-only SELECT
+	// statement.
+	ErrUnsafeQuery = fmt.Errorf("unsafe query")
+	// ErrInvalidQuery is returned when a query can't be parsed.
+	ErrInvalidQuery = fmt.Errorf("invalid query")
+)
+
+// unsafeKeywords are the keywords of statements and clauses writing to the
+// database, changing its settings or locking rows. They are allowed when
+// followed by a parenthesis, as names of functions such as REPLACE.
+//
+//nolint:gochecknoglobals
+var unsafeKeywords = map[string]struct{}{
+	"ALTER": {}, "ATTACH": {}, "BEGIN": {}, "CALL": {}, "COMMIT": {}, "COPY": {}, "CREATE": {},
+	"DELETE": {}, "DETACH": {}, "DO": {}, "DROP": {}, "EXEC": {}, "EXECUTE": {}, "GRANT": {},
+	"HANDLER": {}, "INSERT": {}, "INTO": {}, "LOAD": {}, "LOCK": {}, "MERGE": {}, "PRAGMA": {},
+	"REINDEX": {}, "RELEASE": {}, "RENAME": {}, "REPLACE": {}, "REVOKE": {}, "ROLLBACK": {},
+	"SAVEPOINT": {}, "SET": {}, "SHARE": {}, "TRUNCATE": {}, "UPDATE": {}, "UPSERT": {}, "VACUUM": {},
+}
+
+// unsafeFunctions are functions reading files, loading code or affecting the
+// server.
+//
+//nolint:gochecknoglobals
+var unsafeFunctions = map[string]struct{}{
+	"BENCHMARK": {}, "DBLINK": {}, "DBLINK_EXEC": {}, "LOAD_EXTENSION": {}, "LOAD_FILE": {},
+	"LO_EXPORT": {}, "LO_IMPORT": {}, "PG_CANCEL_BACKEND": {}, "PG_LS_DIR": {}, "PG_READ_BINARY_FILE": {},
+	"PG_READ_FILE": {}, "PG_SLEEP": {}, "PG_TERMINATE_BACKEND": {}, "SLEEP": {},
+}
+
+type sqlTokenKind int
+
+const (
+	sqlWord sqlTokenKind = iota
+	sqlNumber
+	sqlString
+	sqlQuotedIdentifier
+	sqlPunctuation
+)
+
+// sqlToken is a token of a query, with its position in the query and the
+// number of parentheses it is nested in.
+type sqlToken struct {
+	kind  sqlTokenKind
+	text  string
+	start int
+	end   int
+	depth int
+}
+
+// tokenizeSQL splits a query into tokens, skipping whitespace and comments.
+//
+// Dialects quote differently: MySQL and PostgreSQL E'...' strings escape quotes
+// with backslashes, SQL Server and SQLite quote identifiers in brackets, and
+// PostgreSQL has dollar-quoted strings. Queries whose tokens depend on the
+// dialect could hide statements from the checks, so they are rejected as
+// unsafe: quotes whose end depends on backslash escapes, quotes within
+// brackets, backslashes outside quotes and dollar quotes.
+func tokenizeSQL(query string) ([]sqlToken, error) { //nolint:cyclop,funlen,gocognit
+	var tokens []sqlToken
+	depth, brackets := 0, 0
+	for i := 0; i < len(query); {
+		c := query[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			i += end
+			continue
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated comment", ErrInvalidQuery)
+			}
+			i += end + 4
+			continue
+		case c == '\'' || c == '"' || c == '`':
+			if brackets > 0 {
+				return nil, fmt.Errorf("%w: quote within brackets at %d", ErrUnsafeQuery, start)
+			}
+			end, ok := scanQuote(query, start, false)
+			if !ok {
+				return nil, fmt.Errorf("%w: unterminated quote at %d", ErrInvalidQuery, start)
+			}
+			if c != '`' {
+				if escapedEnd, ok := scanQuote(query, start, true); !ok || escapedEnd != end {
+					return nil, fmt.Errorf("%w: ambiguous backslash in quote at %d", ErrUnsafeQuery, start)
+				}
+			}
+			i = end
+			kind := sqlQuotedIdentifier
+			if c == '\'' {
+				kind = sqlString
+			}
+			tokens = append(tokens, sqlToken{kind: kind, text: query[start:i], start: start, end: i, depth: depth})
+			continue
+		case isWordByte(c):
+			for i < len(query) && isWordByte(query[i]) {
+				i++
+			}
+			if c == '$' && strings.Contains(query[start+1:i], "$") {
+				return nil, fmt.Errorf("%w: dollar quote at %d", ErrUnsafeQuery, start)
+			}
+			kind := sqlWord
+			if _, err := strconv.Atoi(query[start:i]); err == nil {
+				kind = sqlNumber
+			}
+			tokens = append(tokens, sqlToken{kind: kind, text: query[start:i], start: start, end: i, depth: depth})
+			continue
+		case c == '\\':
+			return nil, fmt.Errorf("%w: backslash outside quotes at %d", ErrUnsafeQuery, start)
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth == 0 {
+				return nil, fmt.Errorf("%w: unbalanced parentheses", ErrInvalidQuery)
+			}
+			depth--
+		case c == '[':
+			brackets++
+		case c == ']':
+			if brackets == 0 {
+				return nil, fmt.Errorf("%w: unbalanced brackets", ErrInvalidQuery)
+			}
+			brackets--
+		}
+		i++
+		tokens = append(tokens, sqlToken{kind: sqlPunctuation, text: query[start:i], start: start, end: i, depth: depth})
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("%w: unbalanced parentheses", ErrInvalidQuery)
+	}
+	if brackets != 0 {
+		return nil, fmt.Errorf("%w: unbalanced brackets", ErrInvalidQuery)
+	}
+	return tokens, nil
+}
+
+// scanQuote returns the end of the quote starting at start. Quotes are
+// escaped by doubling them, and also with a backslash if backslash is true.
+func scanQuote(query string, start int, backslash bool) (int, bool) {
+	c := query[start]
+	for i := start + 1; i < len(query); i++ {
+		switch {
+		case backslash && query[i] == '\\':
+			i++
+		case query[i] == c && i+1 < len(query) && query[i+1] == c:
+			i++
+		case query[i] == c:
+			return i + 1, true
+		}
+	}
+	return 0, false
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// parseReadOnlyQuery parses a query, and checks that it is a single SELECT
+// statement that doesn't write to the database.
+func parseReadOnlyQuery(query string) ([]sqlToken, error) {
+	tokens, err := tokenizeSQL(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) > 0 && tokens[len(tokens)-1].text == ";" {
+		tokens = tokens[:len(tokens)-1]
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%w: empty query", ErrInvalidQuery)
+	}
+
+	first := strings.ToUpper(tokens[0].text)
+	if tokens[0].kind != sqlWord || (first != "SELECT" && first != "WITH") {
+		return nil, fmt.Errorf("%w: only SELECT statements are allowed", ErrUnsafeQuery)
+	}
+	for i, token := range tokens {
+		if token.text == ";" {
+			return nil, fmt.Errorf("%w: multiple statements", ErrUnsafeQuery)
+		}
+		if token.kind != sqlWord {
+			continue
+		}
+		word := strings.ToUpper(token.text)
+		isCall := i+1 < len(tokens) && tokens[i+1].text == "("
+		if _, ok := unsafeFunctions[word]; ok && isCall {
+			return nil, fmt.Errorf("%w: function %s is not allowed", ErrUnsafeQuery, token.text)
+		}
+		if _, ok := unsafeKeywords[word]; ok && !isCall {
+			return nil, fmt.Errorf("%w: %s is not allowed", ErrUnsafeQuery, token.text)
+		}
+	}
+	return tokens, nil
+}
+
+// CheckReadOnlyQuery checks that a query is a single SELECT statement that
+// doesn't write to the database. It returns an error wrapping ErrUnsafeQuery
+// if it isn't, or ErrInvalidQuery if the query can't be parsed.
+func CheckReadOnlyQuery(query string) error {
+	_, err := parseReadOnlyQuery(query)
+	return err
+}
+
+// LimitReadOnlyQuery checks that a query is read-only like CheckReadOnlyQuery,
+// and returns it with a LIMIT clause so that it returns at most limit rows.
+// The LIMIT clause of the query is kept when it is lower.
+func LimitReadOnlyQuery(query string, limit int) (string, error) {
+	tokens, err := parseReadOnlyQuery(query)
+	if err != nil {
+		return "", err
+	}
+
+	for i := len(tokens) - 1; i >= 0; i-- {
+		if tokens[i].depth != 0 || !strings.EqualFold(tokens[i].text, "LIMIT") {
+			continue
+		}
+		// The row count is the second number of the LIMIT offset, count form
+		// of MySQL and SQLite.
+		count := i + 1
+		if count+2 < len(tokens) && tokens[count+1].text == "," {
+			count += 2
+		}
+		if count >= len(tokens) || tokens[count].kind != sqlNumber {
+			return "", fmt.Errorf("%w: LIMIT must be a number", ErrInvalidQuery)
+		}
+		if n, _ := strconv.Atoi(tokens[count].text); n <= limit {
+			return query, nil
+		}
+		return query[:tokens[count].start] + strconv.Itoa(limit) + query[tokens[count].end:], nil
+	}
+
+	return query[:tokens[len(tokens)-1].end] + " LIMIT " + strconv.Itoa(limit), nil
+}
@@ -0,0 +1,85 @@
+package sqldatabase_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/tools/sqldatabase"
+)
+
+func TestCheckReadOnlyQuery(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		query string
+		err   error
+	}{
+		{query: "SELECT name FROM users"},
+		{query: "select count(*) from users;"},
+		{query: "WITH t AS (SELECT 1 AS x) SELECT x FROM t"},
+		{query: "SELECT REPLACE(name, 'a', 'b') FROM users"},
+		{query: "SELECT 'DELETE FROM users; --' AS s"},
+		{query: `SELECT "update" FROM users -- DROP TABLE users`},
+		{query: "", err: sqldatabase.ErrInvalidQuery},
+		{query: "SELECT 'unterminated", err: sqldatabase.ErrInvalidQuery},
+		{query: "SELECT (1", err: sqldatabase.ErrInvalidQuery},
+		{query: "DELETE FROM users", err: sqldatabase.ErrUnsafeQuery},
+		{query: "SELECT 1; DROP TABLE users", err: sqldatabase.ErrUnsafeQuery},
+		{query: "WITH d AS (DELETE FROM users RETURNING *) SELECT * FROM d", err: sqldatabase.ErrUnsafeQuery},
+		{query: "SELECT * INTO backup FROM users", err: sqldatabase.ErrUnsafeQuery},
+		{query: "SELECT * FROM users FOR UPDATE", err: sqldatabase.ErrUnsafeQuery},
+		{query: "SELECT load_extension('evil')", err: sqldatabase.ErrUnsafeQuery},
+		{query: "PRAGMA query_only = OFF", err: sqldatabase.ErrUnsafeQuery},
+		{query: "SELECT [name], tags[1] FROM [users]"},
+		{query: `SELECT 'C:\data', 'it''s', '\\' FROM users`},
+		{
+			query: "SELECT 1 AS [x']; PRAGMA query_only=OFF; COMMIT; DROP TABLE t; SELECT 1 AS [']",
+			err:   sqldatabase.ErrUnsafeQuery,
+		},
+		{query: `SELECT '\'; DROP TABLE users; SELECT \''`, err: sqldatabase.ErrUnsafeQuery},
+		{query: `SELECT E'\'; DROP TABLE users; --'`, err: sqldatabase.ErrUnsafeQuery},
+		{query: `SELECT "a\"; DROP TABLE users; --"`, err: sqldatabase.ErrUnsafeQuery},
+		{query: "SELECT $$'$$; DROP TABLE users; SELECT '$$", err: sqldatabase.ErrUnsafeQuery},
+		{query: `SELECT 1 \! rm -rf`, err: sqldatabase.ErrUnsafeQuery},
+		{query: "SELECT [name FROM users", err: sqldatabase.ErrInvalidQuery},
+	}
+
+	for _, tc := range testCases {
+		err := sqldatabase.CheckReadOnlyQuery(tc.query)
+		if tc.err == nil {
+			assert.NoError(t, err, tc.query)
+		} else {
+			assert.ErrorIs(t, err, tc.err, tc.query)
+		}
+	}
+}
+
+func TestLimitReadOnlyQuery(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		query string
+		want  string
+	}{
+		{query: "SELECT name FROM users", want: "SELECT name FROM users LIMIT 5"},
+		{query: "SELECT name FROM users;\n", want: "SELECT name FROM users LIMIT 5"},
+		{query: "SELECT name FROM users -- all of them", want: "SELECT name FROM users LIMIT 5"},
+		{query: "SELECT name FROM users LIMIT 3", want: "SELECT name FROM users LIMIT 3"},
+		{query: "SELECT name FROM users LIMIT 100 OFFSET 10", want: "SELECT name FROM users LIMIT 5 OFFSET 10"},
+		{query: "SELECT name FROM users LIMIT 10, 100", want: "SELECT name FROM users LIMIT 10, 5"},
+		{
+			query: "SELECT name FROM (SELECT name FROM users LIMIT 100) u",
+			want:  "SELECT name FROM (SELECT name FROM users LIMIT 100) u LIMIT 5",
+		},
+	}
+
+	for _, tc := range testCases {
+		got, err := sqldatabase.LimitReadOnlyQuery(tc.query, 5)
+		require.NoError(t, err, tc.query)
+		assert.Equal(t, tc.want, got)
+	}
+
+	_, err := sqldatabase.LimitReadOnlyQuery("SELECT name FROM users LIMIT ?", 5)
+	require.ErrorIs(t, err, sqldatabase.ErrInvalidQuery)
+}
@@ -2,6 +2,7 @@ package sqldatabase
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"strings"
 	"time"
@@ -36,9 +37,21 @@ type Engine interface {
 	Close() error
 }
 
+// ReadOnlyEngine is implemented by engines that can run queries in a
+// read-only transaction.
+type ReadOnlyEngine interface {
+	Engine
+
+	// QueryReadOnly executes the query in a read-only transaction and returns
+	// the columns and results.
+	QueryReadOnly(ctx context.Context, query string, args ...any) (cols []string, results [][]string, err error)
+}
+
 var (
 	ErrUnknownDialect = fmt.Errorf("unknown dialect")
 
+	ErrReadOnlyNotSupported = fmt.Errorf("engine does not support read-only queries")
+
 	ErrTableNotFound = fmt.Errorf("table not found")
 	ErrInvalidResult = fmt.Errorf("invalid result")
 )
@@ -129,12 +142,56 @@ func (sd *SQLDatabase) Query(ctx context.Context, query string) (string, error)
 	if err != nil {
 		return "", err
 	}
+	return formatResults(cols, results), nil
+}
 
+// QueryReadOnly executes the query in a read-only transaction and returns the
+// string that contains columns and results. The engine must implement
+// ReadOnlyEngine.
+func (sd *SQLDatabase) QueryReadOnly(ctx context.Context, query string) (string, error) {
+	engine, ok := sd.Engine.(ReadOnlyEngine)
+	if !ok {
+		return "", ErrReadOnlyNotSupported
+	}
+	cols, results, err := engine.QueryReadOnly(ctx, query)
+	if err != nil {
+		return "", err
+	}
+	return formatResults(cols, results), nil
+}
+
+func formatResults(cols []string, results [][]string) string {
 	str := strings.Join(cols, "\t") + "\n"
 	for _, row := range results {
 		str += strings.Join(row, "\t") + "\n"
 	}
-	return str, nil
+	return str
+}
+
+// ScanRows reads the columns and rows of the result of a query, with the values
+// as strings. NULL values are empty strings.
+func ScanRows(rows *sql.Rows) ([]string, [][]string, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+	results := make([][]string, 0)
+	for rows.Next() {
+		row := make([]string, len(cols))
+		rowNullable := make([]sql.NullString, len(cols))
+		rowPtrs := make([]interface{}, len(cols))
+		for i := range row {
+			rowPtrs[i] = &rowNullable[i]
+		}
+		if err := rows.Scan(rowPtrs...); err != nil {
+			return nil, nil, err
+		}
+		for i := range rowNullable {
+			row[i] = rowNullable[i].String
+		}
+		results = append(results, row)
+	}
+	return cols, results, rows.Err()
 }
 
 // Close closes the database.
@@ -15,7 +15,7 @@ func init() {
 	sqldatabase.RegisterEngine(EngineName, NewSQLite3)
 }
 
-var _ sqldatabase.Engine = SQLite3{}
+var _ sqldatabase.ReadOnlyEngine = SQLite3{}
 
 // SQLite3 is a SQLite3 engine.
 type SQLite3 struct {
@@ -73,6 +73,29 @@ func (m SQLite3) Query(ctx context.Context, query string, args ...any) ([]string
 	return cols, results, nil
 }
 
+// QueryReadOnly executes the query in a read-only transaction.
+func (m SQLite3) QueryReadOnly(ctx context.Context, query string, args ...any) ([]string, [][]string, error) {
+	tx, err := m.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	// The driver ignores the read-only option, so writes are rejected by the
+	// connection until the transaction ends.
+	if _, err := tx.ExecContext(ctx, "PRAGMA query_only = ON"); err != nil {
+		return nil, nil, err
+	}
+	defer tx.ExecContext(context.Background(), "PRAGMA query_only = OFF") //nolint:errcheck
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	return sqldatabase.ScanRows(rows)
+}
+
 func (m SQLite3) TableNames(ctx context.Context) ([]string, error) {
 	_, result, err := m.Query(ctx, "SELECT name FROM sqlite_master WHERE type='table';")
 	if err != nil {
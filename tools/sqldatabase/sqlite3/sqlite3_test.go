@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -59,3 +60,31 @@ func Test(t *testing.T) {
 		require.NoError(t, err)
 	}
 }
+
+func TestQueryReadOnly(t *testing.T) {
+	t.Parallel()
+
+	dsn := filepath.Join(t.TempDir(), "test.sqlite")
+	db, err := sqldatabase.NewSQLDatabaseWithDSN("sqlite3", dsn, nil)
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Query(context.Background(), "CREATE TABLE users (name text)")
+	require.NoError(t, err)
+	_, err = db.Query(context.Background(), "INSERT INTO users VALUES ('alice')")
+	require.NoError(t, err)
+
+	result, err := db.QueryReadOnly(context.Background(), "SELECT name FROM users")
+	require.NoError(t, err)
+	require.Equal(t, "name\nalice\n", result)
+
+	_, err = db.QueryReadOnly(context.Background(), "DELETE FROM users")
+	require.Error(t, err)
+
+	// Writes are allowed again outside of read-only queries.
+	_, err = db.Query(context.Background(), "INSERT INTO users VALUES ('bob')")
+	require.NoError(t, err)
+	result, err = db.Query(context.Background(), "SELECT count(*) FROM users")
+	require.NoError(t, err)
+	require.Equal(t, "count(*)\n2\n", result)
+}